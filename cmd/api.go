@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// apiClient sends JSON requests to the REST API of a code review platform.
+type apiClient struct {
+	baseURL string
+	// header is sent with every request, e.g. for authentication.
+	header http.Header
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	method, path string
+	status       string
+	code         int
+	message      string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.path, e.status, e.message)
+}
+
+// do sends a request with a JSON body, if any, and decodes the JSON response into result.
+func (c *apiClient) do(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &apiError{method: method, path: path, status: resp.Status, code: resp.StatusCode, message: strings.TrimSpace(string(msg))}
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// argoApplication is the subset of an ArgoCD Application manifest needed to validate its Helm values.
+type argoApplication struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Source      *argoSource  `json:"source"`
+		Sources     []argoSource `json:"sources"`
+		Destination struct {
+			Namespace string `json:"namespace"`
+		} `json:"destination"`
+	} `json:"spec"`
+}
+
+type argoSource struct {
+	RepoURL        string    `json:"repoURL"`
+	Chart          string    `json:"chart"`
+	Path           string    `json:"path"`
+	TargetRevision string    `json:"targetRevision"`
+	Ref            string    `json:"ref"`
+	Helm           *argoHelm `json:"helm"`
+}
+
+type argoHelm struct {
+	ValueFiles              []string                `json:"valueFiles"`
+	Values                  string                  `json:"values"`
+	ValuesObject            map[string]interface{}  `json:"valuesObject"`
+	Parameters              []argoHelmParameter     `json:"parameters"`
+	FileParameters          []argoHelmFileParameter `json:"fileParameters"`
+	IgnoreMissingValueFiles bool                    `json:"ignoreMissingValueFiles"`
+}
+
+type argoHelmParameter struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	ForceString bool   `json:"forceString"`
+}
+
+type argoHelmFileParameter struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// argoLoader resolves ArgoCD Applications against a local checkout of the repositories they
+// reference. Inline values are stored in documents under synthetic names, so they can be
+// read like any other values file.
+type argoLoader struct {
+	sourceDir string
+	documents map[string][]byte
+	cwd       string
+}
+
+// loadApplications reads every Application in the given manifest file.
+func (l *argoLoader) loadApplications(path string) ([]resolvedRelease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var releases []resolvedRelease
+	for _, part := range splitDocuments(data) {
+		var app argoApplication
+		if err := yaml.Unmarshal(part, &app); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if app.Kind != "Application" {
+			continue
+		}
+
+		sources := app.Spec.Sources
+		if app.Spec.Source != nil {
+			sources = []argoSource{*app.Spec.Source}
+		}
+		var helmSources []argoSource
+		for _, source := range sources {
+			// Sources that only provide a $ref for values files of other sources, or plain
+			// manifests and Kustomize directories, carry no chart.
+			if source.Chart != "" || l.isLocalChart(source) {
+				helmSources = append(helmSources, source)
+			}
+		}
+		for i, source := range helmSources {
+			name := app.Metadata.Name
+			if len(helmSources) > 1 {
+				name = fmt.Sprintf("%s[%d]", app.Metadata.Name, i)
+			}
+			release, err := l.resolveSource(path, name, source)
+			if err != nil {
+				return nil, fmt.Errorf("application %s: %w", name, err)
+			}
+			release.namespace = app.Spec.Destination.Namespace
+			release.labels = app.Metadata.Labels
+			releases = append(releases, release)
+		}
+	}
+	return releases, nil
+}
+
+// isLocalChart reports whether a Git source points at a chart in the local checkout.
+func (l *argoLoader) isLocalChart(source argoSource) bool {
+	if source.Path == "" || source.Ref != "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(l.sourceDir, source.Path, "Chart.yaml"))
+	return err == nil
+}
+
+// resolveSource resolves the chart reference and Helm values of a single Application source.
+func (l *argoLoader) resolveSource(path, name string, source argoSource) (resolvedRelease, error) {
+	r := resolvedRelease{name: name}
+	if source.TargetRevision != "*" {
+		r.version = source.TargetRevision
+	}
+
+	// Charts from Helm repositories and registries are located by name, charts from Git
+	// repositories are expected in the local checkout.
+	chartDir := ""
+	switch {
+	case source.Chart != "" && strings.HasPrefix(source.RepoURL, "oci://"):
+		r.chart = strings.TrimSuffix(source.RepoURL, "/") + "/" + source.Chart
+	case source.Chart != "" && !strings.Contains(source.RepoURL, "://"):
+		// ArgoCD accepts OCI registries without a scheme.
+		r.chart = "oci://" + strings.TrimSuffix(source.RepoURL, "/") + "/" + source.Chart
+	case source.Chart != "":
+		r.chart = source.Chart
+		r.repoURL = source.RepoURL
+	default:
+		chartDir = filepath.Join(l.sourceDir, source.Path)
+		r.chart = l.display(chartDir)
+		// Git revisions are not chart versions.
+		r.version = ""
+	}
+
+	helm := source.Helm
+	if helm == nil {
+		return r, nil
+	}
+	for _, file := range helm.ValueFiles {
+		resolved, err := l.resolveFile(file, chartDir)
+		if err != nil {
+			return r, err
+		}
+		if _, err := os.Stat(resolved); err != nil && !isChartURL(resolved) {
+			if helm.IgnoreMissingValueFiles {
+				r.missingFiles = append(r.missingFiles, resolved)
+				continue
+			}
+			return r, err
+		}
+		r.valuesFiles = append(r.valuesFiles, resolved)
+	}
+
+	// valuesObject takes precedence over values, like in ArgoCD.
+	inline := []byte(helm.Values)
+	if helm.ValuesObject != nil {
+		var err error
+		if inline, err = yaml.Marshal(helm.ValuesObject); err != nil {
+			return r, err
+		}
+	}
+	if len(strings.TrimSpace(string(inline))) > 0 {
+		doc := fmt.Sprintf("%s#%s.helm.values", l.display(path), name)
+		l.documents[doc] = inline
+		r.valuesFiles = append(r.valuesFiles, doc)
+	}
+
+	for _, p := range helm.Parameters {
+		value := p.Name + "=" + escapeSetValue(p.Value)
+		if p.ForceString {
+			r.stringValues = append(r.stringValues, value)
+		} else {
+			r.setValues = append(r.setValues, value)
+		}
+	}
+	for _, p := range helm.FileParameters {
+		resolved, err := l.resolveFile(p.Path, chartDir)
+		if err != nil {
+			return r, err
+		}
+		r.fileValues = append(r.fileValues, p.Name+"="+resolved)
+	}
+	return r, nil
+}
+
+// resolveFile resolves a values file of a source. "$ref/" paths point into another source of
+// the Application and URLs are kept; other paths are relative to the chart in the checkout.
+func (l *argoLoader) resolveFile(file, chartDir string) (string, error) {
+	if isChartURL(file) {
+		return file, nil
+	}
+	if strings.HasPrefix(file, "$") {
+		_, rest, _ := strings.Cut(file, "/")
+		return l.display(filepath.Join(l.sourceDir, rest)), nil
+	}
+	if chartDir == "" {
+		return "", fmt.Errorf("values file %s is inside a remote chart, which is not supported", file)
+	}
+	return l.display(filepath.Join(chartDir, file)), nil
+}
+
+// display returns path relative to the working directory, matching how values files given
+// with -f are reported.
+func (l *argoLoader) display(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return relPath(l.cwd, abs)
+}
+
+// argoOptions holds the flags of the argo command.
+type argoOptions struct {
+	validateOptions
+
+	sourceDir string
+}
+
+func newArgoCmd() *cobra.Command {
+	o := &argoOptions{}
+	cmd := &cobra.Command{
+		Use:   "argo <application.yaml>...",
+		Short: "Validate the Helm values of ArgoCD Applications",
+		Long: `Validate the Helm values of ArgoCD Application manifests against their charts.
+
+Charts from Helm repositories and OCI registries are located by spec.source.chart and
+targetRevision. Charts and values files from Git repositories, including "$ref" sources,
+are read from a local checkout given by --source-dir. helm.valueFiles, helm.values,
+helm.valuesObject, helm.parameters, and helm.fileParameters are applied in ArgoCD's order.`,
+		Example: `  helm kc argo apps/web.yaml
+  helm kc argo apps/*.yaml --source-dir ../gitops`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args)
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.sourceDir, "source-dir", ".", "Local checkout of the Git repositories referenced by the Applications")
+	return cmd
+}
+
+func (o *argoOptions) run(ctx context.Context, out io.Writer, paths []string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("determining current directory: %w", err)
+	}
+
+	loader := &argoLoader{sourceDir: o.sourceDir, documents: map[string][]byte{}, cwd: cwd}
+	var releases []resolvedRelease
+	for _, path := range paths {
+		apps, err := loader.loadApplications(path)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", path, err)
+		}
+		releases = append(releases, apps...)
+	}
+	if len(releases) == 0 {
+		return fmt.Errorf("no Application with a Helm source found in %s", strings.Join(paths, ", "))
+	}
+	// Inline values are read through the shared values reader.
+	o.reader.cache = loader.documents
+
+	issuesFound := o.validateReleases(ctx, out, "Application", releases, opts, reporter)
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadApplications verifies that Application sources are resolved to charts and layered
+// values, for Git path sources as well as multi-source Applications with a $ref values source.
+func TestLoadApplications(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"apps.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: web
+spec:
+  destination:
+    namespace: web
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: charts/web_service
+    targetRevision: main
+    helm:
+      valueFiles:
+        - values-production.yaml
+        - missing.yaml
+      ignoreMissingValueFiles: true
+      values: |
+        replicaCount: 2
+      parameters:
+        - name: image.tag
+          value: "1.0"
+          forceString: true
+        - name: ingress.enabled
+          value: "true"
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cache
+spec:
+  sources:
+    - repoURL: https://charts.bitnami.com/bitnami
+      chart: redis
+      targetRevision: 19.0.0
+      helm:
+        valueFiles:
+          - $values/env/redis.yaml
+        valuesObject:
+          architecture: standalone
+    - repoURL: https://github.com/example/gitops.git
+      targetRevision: main
+      ref: values
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+`,
+		"charts/web_service/Chart.yaml":             "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"charts/web_service/values-production.yaml": "resources: {}\n",
+		"env/redis.yaml":                            "auth:\n  enabled: false\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	loader := &argoLoader{sourceDir: ".", documents: map[string][]byte{}, cwd: dir}
+	releases, err := loader.loadApplications("apps.yaml")
+	if err != nil {
+		t.Fatalf("loadApplications() returned error: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 applications, got %d", len(releases))
+	}
+
+	web := releases[0]
+	if web.chart != filepath.Join("charts", "web_service") || web.version != "" || web.namespace != "web" {
+		t.Errorf("unexpected chart for web: %+v", web)
+	}
+	wantFiles := []string{filepath.Join("charts", "web_service", "values-production.yaml"), "apps.yaml#web.helm.values"}
+	if !reflect.DeepEqual(web.valuesFiles, wantFiles) {
+		t.Errorf("unexpected values files for web:\n got: %v\nwant: %v", web.valuesFiles, wantFiles)
+	}
+	if len(web.missingFiles) != 1 {
+		t.Errorf("expected the missing values file to be skipped, got %v", web.missingFiles)
+	}
+	if !reflect.DeepEqual(web.stringValues, []string{"image.tag=1.0"}) || !reflect.DeepEqual(web.setValues, []string{"ingress.enabled=true"}) {
+		t.Errorf("unexpected parameters for web: %v %v", web.stringValues, web.setValues)
+	}
+
+	cache := releases[1]
+	if cache.chart != "redis" || cache.repoURL != "https://charts.bitnami.com/bitnami" || cache.version != "19.0.0" {
+		t.Errorf("unexpected chart for cache: %+v", cache)
+	}
+	wantFiles = []string{filepath.Join("env", "redis.yaml"), "apps.yaml#cache.helm.values"}
+	if !reflect.DeepEqual(cache.valuesFiles, wantFiles) {
+		t.Errorf("unexpected values files for cache:\n got: %v\nwant: %v", cache.valuesFiles, wantFiles)
+	}
+	if got := string(loader.documents["apps.yaml#cache.helm.values"]); got != "architecture: standalone\n" {
+		t.Errorf("unexpected inline values: %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// baselineOrDefault returns the explicitly requested baseline path or the default one.
+func baselineOrDefault(path string) string {
+	if path != "" {
+		return path
+	}
+	return kc.DefaultBaselineFile
+}
+
+func newBaselineCmd() *cobra.Command {
+	o := &validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "baseline <chart> [-f <values-file> ...]",
+		Short: "Snapshot current findings into a baseline file",
+		Long: `Record every current finding into a baseline file.
+
+Subsequent runs load the baseline and only report findings that are not part of it,
+so the tool can be adopted in a repository with existing issues.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.runBaseline(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addJobsFlag(cmd.Flags())
+	return cmd
+}
+
+func (o *validateOptions) runBaseline(ctx context.Context, out io.Writer, chartPath string) error {
+	opts, err := o.options()
+	if err != nil {
+		return err
+	}
+
+	collector := &kc.Collector{}
+	if _, err := o.validate(ctx, out, chartPath, opts, collector, false); err != nil {
+		return err
+	}
+
+	path := baselineOrDefault(o.baselinePath)
+	if err := kc.NewBaseline(collector.Findings).Save(path); err != nil {
+		return fmt.Errorf("writing baseline: %w", err)
+	}
+	fmt.Fprintf(out, "Baseline with %d findings written to %s\n", len(collector.Findings), path)
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// buildkiteAgent is the command that creates annotations; tests replace it.
+var buildkiteAgent = "buildkite-agent"
+
+// buildkiteOptions holds the flags of the report buildkite command.
+type buildkiteOptions struct {
+	validateOptions
+	context string
+}
+
+func newBuildkiteCmd() *cobra.Command {
+	o := &buildkiteOptions{}
+	cmd := &cobra.Command{
+		Use:   "buildkite <chart> [-f <values-file> ...]",
+		Short: "Annotate the current Buildkite build with the findings",
+		Long: `Validate chart values like the validate command and annotate the Buildkite build with
+the findings, with a collapsible section per values set, using buildkite-agent annotate.
+The annotation of a previous run of the same context is replaced.
+
+To write the annotation to a file instead, use validate with -o buildkite.`,
+		Example: `  helm kc report buildkite ./mychart
+  helm kc report buildkite --charts charts/ --context values`,
+		Args:          chartArgs(&o.validateOptions),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args)
+		},
+	}
+	o.addReportFlags(cmd)
+	cmd.Flags().StringVar(&o.context, "context", "kaartcontrole", "Context of the annotation, to replace the one of a previous run")
+	return cmd
+}
+
+func (o *buildkiteOptions) run(ctx context.Context, out io.Writer, args []string) error {
+	var annotation bytes.Buffer
+	reporter := kc.NewBuildkiteReporter(&annotation)
+	issuesFound, err := o.runReport(ctx, reporter, args)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(buildkiteAgent, "annotate", "--style", reporter.Style(), "--context", o.context)
+	cmd.Stdin = &annotation
+	var stderr bytes.Buffer
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if issuesFound {
+		return errIssuesFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuildkiteRun verifies that the findings are passed to buildkite-agent annotate with the
+// style of the most severe finding.
+func TestBuildkiteRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\n",
+		"values.yaml":       "replicaCount: 1\n",
+		"buildkite-agent":   "#!/bin/sh\necho \"$@\" > \"$(dirname \"$0\")/args\"\ncat > \"$(dirname \"$0\")/annotation\"\n",
+	})
+	agent := filepath.Join(dir, "buildkite-agent")
+	if err := os.Chmod(agent, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(agent string) { buildkiteAgent = agent }(buildkiteAgent)
+	buildkiteAgent = agent
+
+	o := &buildkiteOptions{context: "values"}
+	o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+	o.jobs = 1
+	if err := o.run(context.Background(), &bytes.Buffer{}, []string{filepath.Join(dir, "chart")}); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "annotate --style warning --context values\n"; string(args) != want {
+		t.Errorf("expected arguments %q, got %q", want, args)
+	}
+	annotation, _ := os.ReadFile(filepath.Join(dir, "annotation"))
+	if !strings.HasPrefix(string(annotation), "KaartControle found 0 errors, 1 warnings, and 0 info in 1 values sets.\n\n<details>\n<summary><code>") ||
+		!strings.Contains(string(annotation), "| ⚠️ warning | KC001 | `replicaCount` |") {
+		t.Errorf("unexpected annotation:\n%s", annotation)
+	}
+}
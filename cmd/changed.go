@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// changedOptions holds the flags that restrict validation to values sets affected by changes
+// in the Git working tree.
+type changedOptions struct {
+	changedOnly bool
+	since       string
+	staged      bool
+	// paths, if set, are the changed files, such as the files pre-commit passes to its hooks.
+	paths []string
+}
+
+func (o *changedOptions) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.changedOnly, "changed-only", false, "Only validate values sets with files changed according to git")
+	fs.StringVar(&o.since, "since", "", "Git ref to compare against for --changed-only, e.g. origin/main (implies --changed-only; default HEAD)")
+	fs.BoolVar(&o.staged, "staged", false, "Only validate values sets with files staged in git, using the staged contents of those files")
+}
+
+// enabled reports whether validation is restricted to changed values sets.
+func (o *changedOptions) enabled() bool {
+	return o.changedOnly || o.since != "" || o.staged || o.paths != nil
+}
+
+// String describes the changes values sets are selected by.
+func (o *changedOptions) String() string {
+	switch {
+	case o.paths != nil:
+		return "the given files"
+	case o.staged:
+		return "staged changes"
+	}
+	return "changes since " + o.ref()
+}
+
+// files returns the absolute paths of the changed files, looking up the Git repository
+// containing dir.
+func (o *changedOptions) files(dir string) (map[string]bool, error) {
+	switch {
+	case o.paths != nil:
+		files := map[string]bool{}
+		for _, path := range o.paths {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, err
+			}
+			files[abs] = true
+		}
+		return files, nil
+	case o.staged:
+		return stagedFiles(dir)
+	}
+	return changedFiles(dir, o.ref())
+}
+
+// ref returns the Git ref changes are compared against.
+func (o *changedOptions) ref() string {
+	if o.since != "" {
+		return o.since
+	}
+	return "HEAD"
+}
+
+// changedFiles returns the absolute paths of the files changed since ref in the Git repository
+// containing dir: changes committed since the merge base with ref, uncommitted changes, and
+// untracked files.
+func changedFiles(dir, ref string) (map[string]bool, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	base, err := git(dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	changed, err := git(dir, "diff", "--name-only", "--no-renames", strings.TrimSpace(base))
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]bool{}
+	for _, name := range strings.Fields(changed + "\n" + untracked) {
+		files[filepath.Join(strings.TrimSpace(root), filepath.FromSlash(name))] = true
+	}
+	return files, nil
+}
+
+// stagedFiles returns the absolute paths of the files added, copied, modified, or renamed in
+// the index of the Git repository containing dir.
+func stagedFiles(dir string) (map[string]bool, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	staged, err := git(dir, "diff", "--cached", "--name-only", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, name := range strings.Split(staged, "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			files[filepath.Join(strings.TrimSpace(root), filepath.FromSlash(name))] = true
+		}
+	}
+	return files, nil
+}
+
+// readStaged caches the staged contents of the staged files of the sets, so they are
+// validated as they will be committed rather than as they are in the working tree.
+// SOPS-encrypted files are left to be read and decrypted from the working tree.
+func (o *validateOptions) readStaged(dir string, sets [][]string, staged map[string]bool) error {
+	for _, files := range sets {
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil || !staged[abs] {
+				continue
+			}
+			if _, ok := o.reader.cache[file]; ok {
+				continue
+			}
+			rel, err := filepath.Rel(dir, abs)
+			if err != nil {
+				return err
+			}
+			data, err := git(dir, "cat-file", "blob", ":./"+filepath.ToSlash(rel))
+			if err != nil {
+				return fmt.Errorf("reading staged %s: %w", file, err)
+			}
+			if isSOPSEncrypted([]byte(data)) {
+				continue
+			}
+			if o.reader.cache == nil {
+				o.reader.cache = map[string][]byte{}
+			}
+			o.reader.cache[file] = []byte(data)
+		}
+	}
+	return nil
+}
+
+// git runs a git command in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// affectedSets returns the values sets with at least one changed file. If the chart itself
+// changed, every set is affected.
+func affectedSets(c *loadedChart, sets [][]string, changed map[string]bool) [][]string {
+	if chartDir, err := filepath.Abs(c.path); err == nil {
+		for file := range changed {
+			if file == chartDir || strings.HasPrefix(file, chartDir+string(filepath.Separator)) {
+				return sets
+			}
+		}
+	}
+	var affected [][]string
+	for _, files := range sets {
+		for _, file := range files {
+			if abs, err := filepath.Abs(file); err == nil && changed[abs] {
+				affected = append(affected, files)
+				break
+			}
+		}
+	}
+	return affected
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// TestAffectedSets verifies that only values sets with files changed since a ref are selected,
+// counting committed, uncommitted, and untracked changes.
+func TestAffectedSets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":      "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"overrides.yaml":              "replicaCount: 1\n",
+		"dev/web_service.yaml":        "replicaCount: 1\n",
+		"staging/web_service.yaml":    "replicaCount: 2\n",
+		"production/web_service.yaml": "replicaCount: 3\n",
+	})
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	run("checkout", "-q", "-b", "change")
+	writeFiles(t, dir, map[string]string{"staging/web_service.yaml": "replicaCount: 4\n"})
+	run("commit", "-q", "-am", "scale staging")
+	writeFiles(t, dir, map[string]string{
+		"production/web_service.yaml": "replicaCount: 5\n",
+		"preview/web_service.yaml":    "replicaCount: 1\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	c := &loadedChart{path: "web_service", name: "web_service"}
+	sets := [][]string{
+		{"overrides.yaml", "dev/web_service.yaml"},
+		{"overrides.yaml", "preview/web_service.yaml"},
+		{"overrides.yaml", "production/web_service.yaml"},
+		{"overrides.yaml", "staging/web_service.yaml"},
+	}
+	tests := []struct {
+		ref  string
+		want [][]string
+	}{
+		{"HEAD", [][]string{sets[1], sets[2]}},
+		{"main", [][]string{sets[1], sets[2], sets[3]}},
+	}
+	for _, tt := range tests {
+		changed, err := changedFiles(".", tt.ref)
+		if err != nil {
+			t.Fatalf("changedFiles(%s) returned error: %v", tt.ref, err)
+		}
+		if got := affectedSets(c, sets, changed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("affectedSets() since %s = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"web_service/values.yaml": "replicaCount: 1\n"})
+	changed, err := changedFiles(".", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := affectedSets(c, sets, changed); !reflect.DeepEqual(got, sets) {
+		t.Errorf("affectedSets() with a changed chart = %v, want every set", got)
+	}
+}
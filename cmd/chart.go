@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// chartOptions holds the flags used to resolve a chart reference.
+type chartOptions struct {
+	action.ChartPathOptions
+
+	// updateDeps builds missing chart dependencies before validation.
+	updateDeps bool
+}
+
+func (o *chartOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Version, "version", "", "Chart version constraint for charts resolved from a repository (default latest)")
+	fs.StringVar(&o.RepoURL, "repo", "", "Chart repository URL where to locate the requested chart")
+	fs.StringVar(&o.Username, "username", "", "Chart repository username")
+	fs.StringVar(&o.Password, "password", "", "Chart repository password")
+	fs.StringVar(&o.CertFile, "cert-file", "", "Identify HTTPS client using this SSL certificate file")
+	fs.StringVar(&o.KeyFile, "key-file", "", "Identify HTTPS client using this SSL key file")
+	fs.StringVar(&o.CaFile, "ca-file", "", "Verify certificates of HTTPS-enabled servers using this CA bundle")
+	fs.BoolVar(&o.InsecureSkipTLSverify, "insecure-skip-tls-verify", false, "Skip TLS certificate checks for the chart download")
+	fs.BoolVar(&o.PlainHTTP, "plain-http", false, "Use insecure HTTP connections for the chart download")
+	fs.BoolVar(&o.updateDeps, "update-deps", false, "Build missing chart dependencies so subchart defaults are available")
+}
+
+// loadedChart is a chart together with the location it was loaded from.
+type loadedChart struct {
+	// path is the local directory or archive the chart was loaded from.
+	path string
+	// name is used to find service files during auto-detection.
+	name  string
+	chart *chart.Chart
+	// defaults memoizes the chart defaults across values sets.
+	defaults *kc.DefaultsCache
+}
+
+func newLoadedChart(path, name string, c *chart.Chart) *loadedChart {
+	return &loadedChart{path: path, name: name, chart: c, defaults: kc.NewDefaultsCache(c)}
+}
+
+// loadChart locates and loads the chart referenced by chartPath. Local directories and the
+// legacy ~/.helm cache are tried first, then OCI registries and configured Helm repositories.
+// Helm's downloaders take no context, so a download still running when ctx is done is
+// abandoned.
+func (o *chartOptions) loadChart(ctx context.Context, chartPath string) (*loadedChart, error) {
+	if ctx.Err() != nil {
+		return nil, &loadError{fmt.Errorf("loading chart %s: %w", chartPath, context.Cause(ctx))}
+	}
+	type loaded struct {
+		chart *loadedChart
+		err   error
+	}
+	done := make(chan loaded, 1)
+	go func() {
+		c, err := o.locateChart(chartPath)
+		done <- loaded{c, err}
+	}()
+	select {
+	case l := <-done:
+		if l.err != nil {
+			return nil, &loadError{l.err}
+		}
+		return l.chart, nil
+	case <-ctx.Done():
+		return nil, &loadError{fmt.Errorf("loading chart %s: %w", chartPath, context.Cause(ctx))}
+	}
+}
+
+// locateChart implements loadChart.
+func (o *chartOptions) locateChart(chartPath string) (*loadedChart, error) {
+	settings := cli.New()
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize Helm configuration: %w", err)
+	}
+
+	registryClient, err := o.newRegistryClient(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	actionConfig.RegistryClient = registryClient
+
+	if isChartURL(chartPath) {
+		return o.loadChartURL(chartPath, settings)
+	}
+
+	chartDir, err := findChart(chartPath)
+	if err != nil || o.RepoURL != "" {
+		// ChartPathOptions only accepts a registry client through an action.
+		client := action.NewInstall(actionConfig)
+		client.ChartPathOptions = o.ChartPathOptions
+		client.SetRegistryClient(registryClient)
+		chartDir, err = client.LocateChart(chartPath, settings)
+		if err != nil {
+			return nil, fmt.Errorf("locating chart: %w", err)
+		}
+	}
+
+	info, err := os.Stat(chartDir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("chart directory does not exist: %s", chartDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	c, err := loader.Load(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	// Service files are named after the chart directory; archives only carry the chart name.
+	name := c.Name()
+	if info.IsDir() {
+		name = filepath.Base(chartDir)
+		if c, err = o.ensureDependencies(c, chartDir, settings, registryClient); err != nil {
+			return nil, err
+		}
+	}
+	return newLoadedChart(chartDir, name, c), nil
+}
+
+// ensureDependencies checks that every dependency declared in Chart.yaml is present in charts/.
+// Missing dependencies are built when --update-deps is set; otherwise a warning is printed,
+// since subchart defaults will be absent from the comparison.
+func (o *chartOptions) ensureDependencies(c *chart.Chart, chartDir string, settings *cli.EnvSettings, registryClient *registry.Client) (*chart.Chart, error) {
+	if c.Metadata == nil || len(c.Metadata.Dependencies) == 0 {
+		return c, nil
+	}
+	err := action.CheckDependencies(c, c.Metadata.Dependencies)
+	if err == nil {
+		return c, nil
+	}
+	if !o.updateDeps {
+		logger.Warn("subchart defaults will not be validated; use --update-deps to build them", "err", err)
+		return c, nil
+	}
+
+	man := &downloader.Manager{
+		Out:              os.Stderr,
+		ChartPath:        chartDir,
+		Keyring:          o.Keyring,
+		Getters:          getter.All(settings),
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+		Debug:            settings.Debug,
+		RegistryClient:   registryClient,
+	}
+	if err := man.Build(); err != nil {
+		return nil, fmt.Errorf("building chart dependencies: %w", err)
+	}
+	c, err = loader.Load(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed reloading chart after dependency build: %w", err)
+	}
+	return c, nil
+}
+
+// isChartURL reports whether ref points to a chart archive over HTTP(S).
+func isChartURL(ref string) bool {
+	u, err := url.Parse(ref)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// loadChartURL downloads a chart archive into a temporary directory and loads it.
+func (o *chartOptions) loadChartURL(chartURL string, settings *cli.EnvSettings) (*loadedChart, error) {
+	tmpDir, err := os.MkdirTemp("", "kc-chart-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dl := downloader.ChartDownloader{
+		Out:     os.Stderr,
+		Getters: getter.All(settings),
+		Options: []getter.Option{
+			getter.WithPassCredentialsAll(o.PassCredentialsAll),
+			getter.WithTLSClientConfig(o.CertFile, o.KeyFile, o.CaFile),
+			getter.WithInsecureSkipVerifyTLS(o.InsecureSkipTLSverify),
+			getter.WithBasicAuth(o.Username, o.Password),
+		},
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	archive, _, err := dl.DownloadTo(chartURL, o.Version, tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("downloading chart: %w", err)
+	}
+
+	c, err := loader.Load(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+	return newLoadedChart(chartURL, c.Name(), c), nil
+}
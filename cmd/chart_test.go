@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// packageTestChart writes a minimal chart archive into dir and returns its path.
+func packageTestChart(t *testing.T, dir string) string {
+	t.Helper()
+	chartDir := filepath.Join(t.TempDir(), "web_service")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: web_service\nversion: 1.2.3\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("replicaCount: 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write values.yaml: %v", err)
+	}
+	c, err := loader.LoadDir(chartDir)
+	if err != nil {
+		t.Fatalf("failed to load chart: %v", err)
+	}
+	archive, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatalf("failed to package chart: %v", err)
+	}
+	return archive
+}
+
+// TestLoadChartArchive verifies that packaged charts are loaded both from a local path
+// and from a URL.
+func TestLoadChartArchive(t *testing.T) {
+	t.Setenv("HELM_CACHE_HOME", t.TempDir())
+	t.Setenv("HELM_CONFIG_HOME", t.TempDir())
+	archive := packageTestChart(t, t.TempDir())
+
+	o := &chartOptions{}
+	c, err := o.loadChart(context.Background(), archive)
+	if err != nil {
+		t.Fatalf("loadChart(%s) returned error: %v", archive, err)
+	}
+	if c.name != "web_service" || c.chart.Values["replicaCount"] == nil {
+		t.Errorf("unexpected chart loaded from archive: name=%q values=%v", c.name, c.chart.Values)
+	}
+
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	chartURL := srv.URL + "/" + filepath.Base(archive)
+	c, err = o.loadChart(context.Background(), chartURL)
+	if err != nil {
+		t.Fatalf("loadChart(%s) returned error: %v", chartURL, err)
+	}
+	if c.name != "web_service" || c.path != chartURL {
+		t.Errorf("unexpected chart loaded from URL: name=%q path=%q", c.name, c.path)
+	}
+}
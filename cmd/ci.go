@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ciOptions holds the flags of the ci command.
+type ciOptions struct {
+	validateOptions
+
+	provider string
+}
+
+// ciProvider is a CI service kc ci detects from the environment of its jobs.
+type ciProvider struct {
+	// env is set in every job of the provider.
+	env string
+	// output is the format of the findings on stdout.
+	output string
+	// reports returns the additional reports of the provider, as --output-file values.
+	reports func() []string
+	// base returns the Git ref the changes of the job are compared against, or "" to
+	// validate every values set.
+	base func() string
+}
+
+// ciProviders holds the CI services kc ci detects, by name.
+var ciProviders = map[string]ciProvider{
+	"github": {
+		env:    "GITHUB_ACTIONS",
+		output: "github",
+		reports: func() []string {
+			if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+				return []string{"markdown=" + path}
+			}
+			return nil
+		},
+		base: gitHubBase,
+	},
+	"gitlab": {
+		env:    "GITLAB_CI",
+		output: "text",
+		reports: func() []string {
+			// Collected with artifacts:reports:codequality.
+			return []string{"codequality=gl-code-quality-report.json"}
+		},
+		base: func() string {
+			if sha := os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"); sha != "" {
+				return sha
+			}
+			return pushedBase(os.Getenv("CI_COMMIT_BEFORE_SHA"))
+		},
+	},
+	"teamcity": {
+		env:    "TEAMCITY_VERSION",
+		output: "teamcity",
+	},
+	"azure": {
+		env:    "TF_BUILD",
+		output: "azure",
+		base: func() string {
+			return branchBase(strings.TrimPrefix(os.Getenv("SYSTEM_PULLREQUEST_TARGETBRANCH"), "refs/heads/"))
+		},
+	},
+}
+
+func newCICmd() *cobra.Command {
+	o := &ciOptions{}
+	cmd := &cobra.Command{
+		Use:   "ci <chart>",
+		Short: "Validate chart values with the output and defaults of the detected CI service",
+		Long: `Detect the CI service from its environment variables, and validate the values sets with
+its output format, restricted to the values sets changed by the pull request or push:
+
+  GitHub Actions  -o github annotations, a Markdown table in $GITHUB_STEP_SUMMARY, and
+                  changes since the base branch of the pull request or the pushed commits
+  GitLab CI       text output, a Code Quality report in gl-code-quality-report.json, and
+                  changes since the base of the merge request or the pushed commits
+  TeamCity        -o teamcity inspections of every values set
+  Azure Pipelines -o azure logging commands, and changes since the target branch of the
+                  pull request
+
+Flags given explicitly, such as -o or --since, take precedence. If the base of the changes
+is not fetched, as in shallow clones, every values set is validated.`,
+		Example: `  helm kc ci ./charts/web_service
+  helm kc ci ./charts/web_service --provider gitlab --fail-on error`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			if err := o.configure(cmd.Flags()); err != nil {
+				return err
+			}
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addCIFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *ciOptions) addCIFlags(fs *pflag.FlagSet) {
+	o.addFlags(fs)
+	o.addJobsFlag(fs)
+	o.changed.addFlags(fs)
+	o.addOutputFlags(fs)
+	fs.StringVar(&o.provider, "provider", "", "CI service to assume instead of detecting it: "+strings.Join(ciProviderNames(), ", "))
+}
+
+// ciProviderNames returns the names of the CI services kc ci detects, sorted.
+func ciProviderNames() []string {
+	names := make([]string, 0, len(ciProviders))
+	for name := range ciProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// detectCI returns the name of the CI service kc runs on, or "".
+func detectCI() string {
+	for _, name := range ciProviderNames() {
+		if os.Getenv(ciProviders[name].env) != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// configure applies the defaults of the CI service to the flags that were not given.
+func (o *ciOptions) configure(fs *pflag.FlagSet) error {
+	name := o.provider
+	if name == "" {
+		if name = detectCI(); name == "" {
+			logger.Warn("no CI service detected; validating every values set with the given flags")
+			return nil
+		}
+	}
+	provider, ok := ciProviders[name]
+	if !ok {
+		return fmt.Errorf("unknown CI provider: %q (expected one of %s)", name, strings.Join(ciProviderNames(), ", "))
+	}
+	if !fs.Changed("output") {
+		o.output = provider.output
+	}
+	if !fs.Changed("output-file") && provider.reports != nil {
+		o.outputFiles = append(o.outputFiles, provider.reports()...)
+	}
+	base := ""
+	if !o.changed.enabled() && provider.base != nil {
+		base = provider.base()
+	}
+	if base != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("determining current directory: %w", err)
+		}
+		if _, err := git(cwd, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+			logger.Warn("base of the changes not found, e.g. in a shallow clone; validating every values set", "ref", base)
+			base = ""
+		} else {
+			o.changed.since = base
+		}
+	}
+	logger.Info("detected CI service", "provider", name, "output", o.output, "since", base)
+	return nil
+}
+
+// gitHubBase returns the base of the changes of a GitHub Actions job: the base branch of a
+// pull request, or the commit before a push.
+func gitHubBase() string {
+	if base := os.Getenv("GITHUB_BASE_REF"); base != "" {
+		return branchBase(base)
+	}
+	if os.Getenv("GITHUB_EVENT_NAME") != "push" {
+		return ""
+	}
+	data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		Before string `json:"before"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return ""
+	}
+	return pushedBase(event.Before)
+}
+
+// branchBase returns the remote-tracking ref of a base branch, or "".
+func branchBase(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	return "origin/" + branch
+}
+
+// pushedBase returns the commit before a push, or "" for pushes of new branches, which CI
+// services report as a zero commit.
+func pushedBase(sha string) string {
+	if strings.Trim(sha, "0") == "" {
+		return ""
+	}
+	return sha
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// TestCIConfigure verifies that the output and the base of the changes follow the detected CI
+// service, unless flags are given, and that a base that is not fetched validates every set.
+func TestCIConfigure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"overrides.yaml": "a: 1\n"})
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "."}, {"commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	head, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head = strings.TrimSpace(head)
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	// Tests may run on a CI service themselves.
+	for _, name := range ciProviderNames() {
+		t.Setenv(ciProviders[name].env, "")
+	}
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		args        []string
+		output      string
+		outputFiles []string
+		since       string
+	}{
+		{"none", nil, nil, "text", nil, ""},
+		{"gitlab", map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_DIFF_BASE_SHA": head}, nil, "text", []string{"codequality=gl-code-quality-report.json"}, head},
+		{"gitlab push of a new branch", map[string]string{"GITLAB_CI": "true", "CI_COMMIT_BEFORE_SHA": "0000000000000000000000000000000000000000"}, nil, "text", []string{"codequality=gl-code-quality-report.json"}, ""},
+		{"github without the base branch", map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_BASE_REF": "main", "GITHUB_STEP_SUMMARY": "summary.md"}, nil, "github", []string{"markdown=summary.md"}, ""},
+		{"explicit flags", map[string]string{"TF_BUILD": "True", "SYSTEM_PULLREQUEST_TARGETBRANCH": "refs/heads/main"}, []string{"-o", "json", "--since", "HEAD"}, "json", nil, "HEAD"},
+		{"provider flag", nil, []string{"--provider", "teamcity"}, "teamcity", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			o := &ciOptions{}
+			fs := pflag.NewFlagSet("ci", pflag.ContinueOnError)
+			o.addCIFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.configure(fs); err != nil {
+				t.Fatal(err)
+			}
+			if o.output != tt.output || !reflect.DeepEqual(o.outputFiles, tt.outputFiles) || o.changed.since != tt.since {
+				t.Errorf("expected -o %s, --output-file %v, and --since %q; got -o %s, --output-file %v, and --since %q",
+					tt.output, tt.outputFiles, tt.since, o.output, o.outputFiles, o.changed.since)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+// newActionConfig initializes a Helm action configuration for the given namespace, or the
+// namespace of the current kube context if it is empty.
+func newActionConfig(namespace string) (*action.Configuration, error) {
+	settings := cli.New()
+	if namespace != "" {
+		settings.SetNamespace(namespace)
+	}
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize Helm configuration: %w", err)
+	}
+	return actionConfig, nil
+}
+
+// clusterOptions holds the flags of the cluster command.
+type clusterOptions struct {
+	validateOptions
+
+	namespace     string
+	allNamespaces bool
+	selector      string
+	filter        string
+}
+
+func newClusterCmd() *cobra.Command {
+	o := &clusterOptions{}
+	cmd := &cobra.Command{
+		Use:   "cluster [-n <namespace> | -A]",
+		Short: "Validate the values of every release deployed in a cluster",
+		Long: `Validate the user-supplied values of every deployed Helm release against its chart.
+
+Releases are listed like with "helm list", and each release is validated with the chart
+and values stored in the cluster. Findings refer to the release as "release:<namespace>/<name>".`,
+		Example: `  helm kc cluster -n production
+  helm kc cluster -A -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "", "Namespace to validate releases in (default $HELM_NAMESPACE, set by helm -n, or the kube context)")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Validate releases in all namespaces")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Only validate releases matching the label selector, like helm list --selector")
+	cmd.Flags().StringVar(&o.filter, "filter", "", "Only validate releases whose name matches the regular expression")
+	return cmd
+}
+
+func (o *clusterOptions) run(ctx context.Context, out io.Writer) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	actionConfig, err := newActionConfig(o.namespace)
+	if err != nil {
+		return err
+	}
+	issuesFound, err := o.validateDeployed(ctx, out, actionConfig, opts, reporter)
+	if err != nil {
+		return err
+	}
+
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
+
+// validateDeployed validates every release listed from the cluster with the chart and values
+// it was deployed with. It returns true if any failing issues were found.
+func (o *clusterOptions) validateDeployed(ctx context.Context, out io.Writer, actionConfig *action.Configuration, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	list := action.NewList(actionConfig)
+	list.AllNamespaces = o.allNamespaces
+	list.Selector = o.selector
+	list.Filter = o.filter
+	list.SetStateMask()
+	releases, err := list.Run()
+	if err != nil {
+		return false, fmt.Errorf("listing releases: %w", err)
+	}
+
+	verbose := o.banners()
+	issuesFound := false
+	for _, rel := range releases {
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("validating release %s/%s: %w", rel.Namespace, rel.Name, context.Cause(ctx))
+		}
+		if rel.Chart == nil {
+			continue
+		}
+		// The deployed values are validated as if they came from a values file named after the release.
+		name := fmt.Sprintf("release:%s/%s", rel.Namespace, rel.Name)
+		data, err := yaml.Marshal(rel.Config)
+		if err != nil {
+			return false, err
+		}
+		if o.reader.cache == nil {
+			o.reader.cache = map[string][]byte{}
+		}
+		o.reader.cache[name] = data
+
+		if verbose {
+			fmt.Fprintf(out, "\nRelease: %s/%s (%s-%s)\n", rel.Namespace, rel.Name, rel.Chart.Name(), rel.Chart.Metadata.Version)
+		}
+		c := newLoadedChart(name, rel.Chart.Name(), rel.Chart)
+		found, err := o.validateValues(ctx, c, []string{name}, rel.Config, opts, reporter)
+		if err != nil {
+			logger.Error("failed to validate release", "namespace", rel.Namespace, "name", rel.Name, "err", err)
+			o.loadFailures++
+			continue
+		}
+		issuesFound = issuesFound || found
+		if o.stopFast() {
+			logger.Info("stopping at the first error (--fail-fast)", "namespace", rel.Namespace, "name", rel.Name)
+			break
+		}
+	}
+	return issuesFound, nil
+}
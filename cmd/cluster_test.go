@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// newTestActionConfig returns an action configuration backed by in-memory release storage.
+func newTestActionConfig(t *testing.T, releases ...*release.Release) *action.Configuration {
+	t.Helper()
+	store := storage.Init(driver.NewMemory())
+	for _, rel := range releases {
+		if err := store.Create(rel); err != nil {
+			t.Fatalf("failed to store release: %v", err)
+		}
+	}
+	return &action.Configuration{
+		Releases:     store,
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(string, ...interface{}) {},
+	}
+}
+
+// TestValidateDeployed verifies that deployed releases are validated with the chart and
+// values stored for them.
+func TestValidateDeployed(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "1.0.0"},
+		Values:   map[string]interface{}{"replicaCount": 1, "image": map[string]interface{}{"tag": "latest"}},
+	}
+	rel := &release.Release{
+		Name:      "web",
+		Namespace: "default",
+		Version:   1,
+		Chart:     c,
+		Config:    map[string]interface{}{"replicaCount": 1, "image": map[string]interface{}{"tag": "1.25"}},
+		Info:      &release.Info{Status: release.StatusDeployed},
+	}
+	actionConfig := newTestActionConfig(t, rel)
+
+	o := &clusterOptions{}
+	o.begin(nil)
+	collector := &kc.Collector{}
+	issuesFound, err := o.validateDeployed(context.Background(), io.Discard, actionConfig, kc.Options{}, collector)
+	if err != nil {
+		t.Fatalf("validateDeployed() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Errorf("expected the redundant value to be reported as an issue")
+	}
+	if len(collector.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %v", collector.Findings)
+	}
+	f := collector.Findings[0]
+	if f.RuleID != kc.RuleRedundant || f.Path != "replicaCount" || len(f.Files) != 1 || f.Files[0] != "release:default/web" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
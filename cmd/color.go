@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// ANSI escape sequences used to color output.
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiGray   = "\033[90m"
+)
+
+// Values of --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var (
+	// colorMode selects when output is colored.
+	colorMode = colorAuto
+	// asciiOutput replaces emoji with plain text markers.
+	asciiOutput bool
+)
+
+// styleFlags holds the persistent flags selecting how text output is styled.
+type styleFlags struct {
+	color string
+	ascii bool
+}
+
+func (f *styleFlags) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&f.color, "color", colorAuto, "Color output: auto (on terminals, unless NO_COLOR is set), always, or never")
+	cmd.PersistentFlags().BoolVar(&f.ascii, "ascii", false, "Print plain text severity markers instead of emoji")
+}
+
+// apply sets the style of the run.
+func (f *styleFlags) apply() error {
+	switch f.color {
+	case colorAuto, colorAlways, colorNever:
+	default:
+		return fmt.Errorf("unknown --color mode: %q (expected auto, always, or never)", f.color)
+	}
+	colorMode, asciiOutput = f.color, f.ascii
+	return nil
+}
+
+// useColor reports whether out should receive colored output: always with --color always,
+// otherwise only terminals, unless NO_COLOR is set or --color never.
+func useColor(out io.Writer) bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestUseColor verifies that --color overrides the detection of terminals and NO_COLOR.
+func TestUseColor(t *testing.T) {
+	defer func(mode string) { colorMode = mode }(colorMode)
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	t.Setenv("NO_COLOR", "1")
+
+	for mode, want := range map[string]bool{colorAuto: false, colorAlways: true, colorNever: false} {
+		if err := (&styleFlags{color: mode}).apply(); err != nil {
+			t.Fatal(err)
+		}
+		if got := useColor(file); got != want {
+			t.Errorf("--color %s: expected %v, got %v", mode, want, got)
+		}
+	}
+	if err := (&styleFlags{color: "sometimes"}).apply(); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// completionFunc completes the value of a flag or argument.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// flagCompletions completes the values of flags by name, wherever the flags are defined.
+var flagCompletions = map[string]completionFunc{
+	"enable":       completeRules,
+	"disable":      completeRules,
+	"output":       completeOutputFormats,
+	"fail-on":      fixedCompletions(string(kc.SeverityError), string(kc.SeverityWarning), string(kc.SeverityInfo)),
+	"min-severity": fixedCompletions(string(kc.SeverityError), string(kc.SeverityWarning), string(kc.SeverityInfo)),
+	"group-by":     fixedCompletions(string(kc.GroupByEnvironment), string(kc.GroupByFile), string(kc.GroupByRule), "none"),
+	"color":        fixedCompletions(colorAuto, colorAlways, colorNever),
+	"log-format":   fixedCompletions(logFormatText, logFormatJSON),
+	"provider":     fixedCompletions(ciProviderNames()...),
+	"values": func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+}
+
+// addCompletions registers the completions of flags and chart arguments on cmd and its
+// subcommands. Commands whose first argument is a <chart> complete it with directories.
+func addCompletions(cmd *cobra.Command) {
+	flags := cmd.LocalNonPersistentFlags()
+	if !cmd.HasParent() {
+		flags.AddFlagSet(cmd.PersistentFlags())
+	}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if complete, ok := flagCompletions[f.Name]; ok {
+			_ = cmd.RegisterFlagCompletionFunc(f.Name, complete)
+		}
+	})
+	if use := strings.Fields(cmd.Use); len(use) > 1 && use[1] == "<chart>" && cmd.ValidArgsFunction == nil {
+		cmd.ValidArgsFunction = completeChart
+	}
+	for _, sub := range cmd.Commands() {
+		addCompletions(sub)
+	}
+}
+
+// completeChart completes the chart argument with directories; charts from repositories
+// are not listed.
+func completeChart(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeRules completes rule IDs, described by the names of the rules.
+func completeRules(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	ids := make([]string, len(kc.Rules))
+	for i, rule := range kc.Rules {
+		ids[i] = rule.ID + "\t" + rule.Name
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOutputFormats completes the output formats of reports.
+func completeOutputFormats(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	formats := make([]string, 0, len(reporters)+1)
+	for format := range reporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return append(formats, "go-template="), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// fixedCompletions completes one of values.
+func fixedCompletions(values ...string) completionFunc {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
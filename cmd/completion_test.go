@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestCompletion verifies the completions of flag values and chart arguments.
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		args      []string
+		want      string
+		directive cobra.ShellCompDirective
+	}{
+		{[]string{"validate", "--disable", ""}, "KC001\tredundant-value", cobra.ShellCompDirectiveNoFileComp},
+		{[]string{"-o", "s"}, "sarif", cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace},
+		{[]string{"diff", "--color", ""}, "always", cobra.ShellCompDirectiveNoFileComp},
+		{[]string{"validate", "-f", ""}, "yaml", cobra.ShellCompDirectiveFilterFileExt},
+		{[]string{""}, "validate\t", cobra.ShellCompDirectiveFilterDirs},
+		{[]string{"explain", ""}, "", cobra.ShellCompDirectiveFilterDirs},
+		{[]string{"explain", "./chart", ""}, "", cobra.ShellCompDirectiveNoFileComp},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newRootCmd()
+			cmd.SetOut(&out)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, tt.args...))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if got := lines[len(lines)-1]; got != fmt.Sprintf(":%d", tt.directive) {
+				t.Errorf("expected the directive :%d, got %s", tt.directive, got)
+			}
+			if tt.want != "" && !strings.Contains(out.String(), tt.want) {
+				t.Errorf("expected %q among the completions, got:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Labels of the Secrets that Helm stores releases in, and the labels Flux's helm-controller
+// adds to the releases of HelmRelease objects.
+const (
+	helmOwnerLabel     = "owner"
+	helmNameLabel      = "name"
+	helmStatusLabel    = "status"
+	fluxNameLabel      = "helm.toolkit.fluxcd.io/name"
+	fluxNamespaceLabel = "helm.toolkit.fluxcd.io/namespace"
+)
+
+const (
+	// controllerComponent is the source of the events and the field manager of the conditions.
+	controllerComponent = "kaartcontrole"
+	// valuesConditionType is the type of the condition set on HelmRelease objects.
+	valuesConditionType = "ValuesValid"
+	// maxFindingsInEvent limits the failing findings named in an event.
+	maxFindingsInEvent = 5
+)
+
+// helmReleaseResource is the Flux HelmRelease resource, which gets the ValuesValid condition.
+var helmReleaseResource = schema.GroupVersionResource{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"}
+
+// controllerOptions holds the flags of the controller command.
+type controllerOptions struct {
+	validateOptions
+
+	namespace     string
+	allNamespaces bool
+	selector      string
+}
+
+func newControllerCmd() *cobra.Command {
+	o := &controllerOptions{}
+	cmd := &cobra.Command{
+		Use:   "controller [-n <namespace> | -A]",
+		Short: "Continuously validate the values of releases deployed in a cluster",
+		Long: `Watch the Helm releases deployed in a cluster and validate the values of every new
+revision against its chart, like "kc cluster" does once.
+
+The result of each revision is recorded as a Kubernetes Event on the Secret Helm stores
+the release in: ValuesValid without failing findings, ValuesInvalid with a summary of
+them otherwise. Releases of Flux HelmRelease objects also get a ValuesValid status
+condition on the HelmRelease.
+
+The controller runs with the kube context, or the service account when deployed in the
+cluster, which needs to get, list, and watch Secrets, create Events, and get and update
+the status of helmreleases.helm.toolkit.fluxcd.io. Only the default Secret storage of
+Helm is watched.`,
+		Example: `  helm kc controller -A --config /etc/kaartcontrole/.kaartcontrole.yaml
+  helm kc controller -n production -l team=payments`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return o.run(ctx)
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "", "Namespace to watch releases in (default $HELM_NAMESPACE, set by helm -n, or the kube context)")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Watch releases in all namespaces")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Only watch releases matching the label selector, like helm list --selector")
+	return cmd
+}
+
+func (o *controllerOptions) run(ctx context.Context) error {
+	// Findings are published as events, so the reporter of the output format is not used.
+	o.begin(&kc.Collector{})
+	_, opts, err := o.prepare(io.Discard)
+	if err != nil {
+		return err
+	}
+	settings := cli.New()
+	if o.namespace != "" {
+		settings.SetNamespace(o.namespace)
+	}
+	config, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the kube config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	namespace := settings.Namespace()
+	if o.allNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(metav1.NamespaceAll)})
+	defer broadcaster.Shutdown()
+	c := newReleaseController(o, opts, client, dynamicClient, broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerComponent}))
+	return c.run(ctx, namespace)
+}
+
+// releaseController validates the values of every deployed revision of the releases in the
+// Secrets it is notified of.
+type releaseController struct {
+	o        *controllerOptions
+	opts     kc.Options
+	client   kubernetes.Interface
+	dynamic  dynamic.Interface
+	recorder record.EventRecorder
+	queue    workqueue.TypedRateLimitingInterface[string]
+	// validated holds the last validated revision of every release, by namespace/name.
+	validated map[string]int
+}
+
+func newReleaseController(o *controllerOptions, opts kc.Options, client kubernetes.Interface, dynamicClient dynamic.Interface, recorder record.EventRecorder) *releaseController {
+	return &releaseController{
+		o:         o,
+		opts:      opts,
+		client:    client,
+		dynamic:   dynamicClient,
+		recorder:  recorder,
+		queue:     workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]()),
+		validated: map[string]int{},
+	}
+}
+
+// run watches the release Secrets of namespace, or of every namespace if it is empty, and
+// validates releases one at a time until ctx is done.
+func (c *releaseController) run(ctx context.Context, namespace string) error {
+	selector := helmOwnerLabel + "=helm"
+	if c.o.selector != "" {
+		selector += "," + c.o.selector
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(c.client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(lo *metav1.ListOptions) { lo.LabelSelector = selector }))
+	informer := factory.Core().V1().Secrets().Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}); err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("watching releases: %w", context.Cause(ctx))
+	}
+	logger.Info("watching releases", "namespace", namespace, "selector", selector)
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	for c.processNext(ctx) {
+	}
+	return nil
+}
+
+// enqueue queues the release stored in a Secret, if it is the deployed revision.
+func (c *releaseController) enqueue(obj interface{}) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || secret.Labels[helmStatusLabel] != release.StatusDeployed.String() {
+		return
+	}
+	c.queue.Add(secret.Namespace + "/" + secret.Labels[helmNameLabel])
+}
+
+// processNext validates the next queued release. It returns false once the queue is shut down.
+func (c *releaseController) processNext(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+	if err := c.reconcile(ctx, key); err != nil {
+		logger.Error("failed to validate release", "release", key, "err", err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// reconcile validates the deployed revision of the release namespace/name, unless it was
+// already validated, and records the result.
+func (c *releaseController) reconcile(ctx context.Context, key string) error {
+	namespace, name, _ := strings.Cut(key, "/")
+	secrets := c.client.CoreV1().Secrets(namespace)
+	rel, err := storage.Init(driver.NewSecrets(secrets)).Deployed(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) || errors.Is(err, driver.ErrNoDeployedReleases) {
+		// The release was uninstalled or is being upgraded; a new revision is queued again.
+		delete(c.validated, key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if c.validated[key] == rel.Version || rel.Chart == nil {
+		return nil
+	}
+	secret, err := secrets.Get(ctx, fmt.Sprintf("sh.helm.release.v1.%s.v%d", rel.Name, rel.Version), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	findings, err := c.validate(ctx, rel)
+	if err != nil {
+		c.recorder.Eventf(secret, corev1.EventTypeWarning, "ValidationFailed", "Failed to validate the values of revision %d: %v", rel.Version, err)
+		c.validated[key] = rel.Version
+		return nil
+	}
+	passed, message := c.summarize(rel.Version, findings)
+	if passed {
+		c.recorder.Event(secret, corev1.EventTypeNormal, "ValuesValid", message)
+	} else {
+		c.recorder.Event(secret, corev1.EventTypeWarning, "ValuesInvalid", message)
+	}
+	logger.Info("validated release", "release", key, "revision", rel.Version, "findings", len(findings), "passed", passed)
+
+	if fluxName := secret.Labels[fluxNameLabel]; fluxName != "" {
+		fluxNamespace := secret.Labels[fluxNamespaceLabel]
+		if fluxNamespace == "" {
+			fluxNamespace = namespace
+		}
+		if err := c.setCondition(ctx, fluxNamespace, fluxName, passed, message); err != nil {
+			return fmt.Errorf("updating HelmRelease %s/%s: %w", fluxNamespace, fluxName, err)
+		}
+	}
+	c.validated[key] = rel.Version
+	return nil
+}
+
+// validate validates the user-supplied values of rel against its chart, like the cluster
+// command.
+func (c *releaseController) validate(ctx context.Context, rel *release.Release) ([]kc.Finding, error) {
+	name := fmt.Sprintf("release:%s/%s", rel.Namespace, rel.Name)
+	data, err := yaml.Marshal(rel.Config)
+	if err != nil {
+		return nil, err
+	}
+	// Every release is only validated once per revision, so documents are not kept.
+	c.o.begin(nil)
+	c.o.reader.cache = map[string][]byte{name: data}
+	collector := &kc.Collector{}
+	if _, err := c.o.validateValues(ctx, newLoadedChart(name, rel.Chart.Name(), rel.Chart), []string{name}, rel.Config, c.opts, collector); err != nil {
+		return nil, err
+	}
+	return collector.Findings, nil
+}
+
+// summarize reports whether findings pass the run, with a message naming the first failing
+// findings.
+func (c *releaseController) summarize(revision int, findings []kc.Finding) (bool, string) {
+	var failing []string
+	for _, f := range findings {
+		if f.Severity.AtLeast(c.opts.FailOn) {
+			failing = append(failing, f.RuleID+" "+f.Path)
+		}
+	}
+	if len(failing) == 0 {
+		return true, fmt.Sprintf("Revision %d has no failing findings in its values (%d findings in total)", revision, len(findings))
+	}
+	shown := failing[:min(len(failing), maxFindingsInEvent)]
+	message := fmt.Sprintf("Revision %d has %d failing findings in its values: %s", revision, len(failing), strings.Join(shown, ", "))
+	if len(failing) > len(shown) {
+		message += fmt.Sprintf(", and %d more", len(failing)-len(shown))
+	}
+	return false, message
+}
+
+// setCondition sets the ValuesValid condition of the HelmRelease namespace/name. The
+// condition is kept by helm-controller, which only updates the conditions it owns.
+func (c *releaseController) setCondition(ctx context.Context, namespace, name string, passed bool, message string) error {
+	client := c.dynamic.Resource(helmReleaseResource).Namespace(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		raw, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if err != nil {
+			return err
+		}
+		conditions := make([]metav1.Condition, len(raw))
+		for i, item := range raw {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid status condition: %v", item)
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &conditions[i]); err != nil {
+				return err
+			}
+		}
+		condition := metav1.Condition{
+			Type:               valuesConditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             "NoFailingFindings",
+			Message:            message,
+			ObservedGeneration: obj.GetGeneration(),
+		}
+		if !passed {
+			condition.Status, condition.Reason = metav1.ConditionFalse, "FailingFindings"
+		}
+		meta.SetStatusCondition(&conditions, condition)
+		raw = make([]interface{}, len(conditions))
+		for i := range conditions {
+			if raw[i], err = runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i]); err != nil {
+				return err
+			}
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, raw, "status", "conditions"); err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(ctx, obj, metav1.UpdateOptions{FieldManager: controllerComponent})
+		return err
+	})
+}
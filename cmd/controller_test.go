@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// TestReleaseController verifies that every deployed revision is validated once, with the
+// result recorded as an event and as a condition of the Flux HelmRelease.
+func TestReleaseController(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	helmRelease := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "helm.toolkit.fluxcd.io/v2",
+		"kind":       "HelmRelease",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "flux-system", "generation": int64(3)},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True", "reason": "InstallSucceeded", "message": "", "lastTransitionTime": "2025-01-01T00:00:00Z"},
+		}},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{helmReleaseResource: "HelmReleaseList"}, helmRelease)
+	recorder := record.NewFakeRecorder(10)
+	c := newReleaseController(&controllerOptions{}, kc.Options{FailOn: kc.SeverityWarning}, client, dynamicClient, recorder)
+
+	releases := driver.NewSecrets(client.CoreV1().Secrets("default"))
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "1.0.0"},
+		Values:   map[string]interface{}{"replicaCount": 1},
+	}
+	deploy := func(version int, config map[string]interface{}) {
+		rel := &release.Release{
+			Name: "web", Namespace: "default", Version: version, Chart: chrt, Config: config,
+			Info:   &release.Info{Status: release.StatusDeployed},
+			Labels: map[string]string{fluxNameLabel: "web", fluxNamespaceLabel: "flux-system"},
+		}
+		if err := releases.Create(fmt.Sprintf("sh.helm.release.v1.web.v%d", version), rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	condition := func() map[string]interface{} {
+		obj, err := dynamicClient.Resource(helmReleaseResource).Namespace("flux-system").Get(ctx, "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if len(conditions) != 2 {
+			t.Fatalf("expected the Ready and ValuesValid conditions, got %v", conditions)
+		}
+		return conditions[1].(map[string]interface{})
+	}
+
+	deploy(1, map[string]interface{}{"replicaCount": 1})
+	if err := c.reconcile(ctx, "default/web"); err != nil {
+		t.Fatal(err)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ValuesInvalid Revision 1 has 1 failing findings in its values: KC001 replicaCount") {
+		t.Errorf("unexpected event: %s", event)
+	}
+	if cond := condition(); cond["type"] != valuesConditionType || cond["status"] != "False" || cond["observedGeneration"] != int64(3) {
+		t.Errorf("unexpected condition: %v", cond)
+	}
+
+	// A revision is only validated once.
+	if err := c.reconcile(ctx, "default/web"); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no event for a validated revision, got %s", <-recorder.Events)
+	}
+
+	deploy(2, map[string]interface{}{"replicaCount": 3})
+	if err := c.reconcile(ctx, "default/web"); err != nil {
+		t.Fatal(err)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal ValuesValid Revision 2 has no failing findings") {
+		t.Errorf("unexpected event: %s", event)
+	}
+	if cond := condition(); cond["status"] != "True" || cond["reason"] != "NoFailingFindings" {
+		t.Errorf("unexpected condition: %v", cond)
+	}
+
+	if err := c.reconcile(ctx, "default/missing"); err != nil {
+		t.Errorf("expected releases that are not deployed to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// coverageOptions holds the flags of the coverage command.
+type coverageOptions struct {
+	validateOptions
+}
+
+// coverageReport is the output of the coverage command.
+type coverageReport struct {
+	Environments []kc.EnvironmentCoverage `json:"environments"`
+	Keys         []kc.KeyCoverage         `json:"keys"`
+	// Missing lists the environment directories without a service file of the chart.
+	Missing []string `json:"missing"`
+}
+
+func newCoverageCmd() *cobra.Command {
+	o := &coverageOptions{}
+	cmd := &cobra.Command{
+		Use:   "coverage <chart>",
+		Short: "Show how much of the chart defaults every environment overrides",
+		Long: `Show, for every auto-detected values set, the share of the chart defaults it overrides with
+another value, which keys are customized in which environments, and which environments
+have no service file of the chart at all.
+
+Environments are the directories of the service files. A directory next to them, holding
+YAML files but no service file of the chart, is listed as an environment without one.`,
+		Example: `  helm kc coverage ./charts/web_service
+  helm kc coverage ./charts/web_service -o json > coverage.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.chartOptions.addFlags(cmd.Flags())
+	o.addDiscoveryFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.configPath, "config", "", "Path to the configuration file (default "+kc.DefaultConfigFile+" if present)")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+func (o *coverageOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	report, err := o.coverage(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENVIRONMENT\tOVERRIDDEN\tCOVERAGE\tCUSTOMIZED KEYS")
+	for _, env := range report.Environments {
+		fmt.Fprintf(w, "%s\t%d/%d\t%.1f%%\t%d\n", env.Environment, env.Overridden, env.Defaults, env.Percent, len(env.Customized))
+	}
+	if len(report.Keys) > 0 {
+		fmt.Fprintln(w, "\nKEY\tENVIRONMENTS")
+		for _, key := range report.Keys {
+			fmt.Fprintf(w, "%s\t%s\n", key.Path, strings.Join(key.Environments, ", "))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(out, "\nEnvironments without a service file: %s\n", strings.Join(report.Missing, ", "))
+	}
+	return nil
+}
+
+// coverage compares every auto-detected values set of the chart with its defaults.
+func (o *coverageOptions) coverage(ctx context.Context, chartPath string) (coverageReport, error) {
+	o.begin(nil)
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return coverageReport{}, err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return coverageReport{}, err
+	}
+
+	report := coverageReport{Environments: make([]kc.EnvironmentCoverage, 0, len(sets))}
+	for _, files := range sets {
+		provided, err := o.mergeValues(files)
+		if err != nil {
+			return coverageReport{}, &loadError{fmt.Errorf("failed to load values: %w", err)}
+		}
+		defaults, err := c.defaults.Get(provided)
+		if err != nil {
+			return coverageReport{}, err
+		}
+		report.Environments = append(report.Environments, kc.ComputeCoverage(defaults, provided, files))
+	}
+	report.Keys = kc.CustomizedKeys(report.Environments)
+	if report.Missing, err = missingEnvironments(sets); err != nil {
+		return coverageReport{}, err
+	}
+	return report, nil
+}
+
+// missingEnvironments returns the directories next to the directories of the service files
+// that hold YAML files, but no service file of the values sets.
+func missingEnvironments(sets [][]string) ([]string, error) {
+	covered := map[string]bool{}
+	parents := map[string]bool{}
+	for _, files := range sets {
+		dir := filepath.Dir(files[len(files)-1])
+		covered[dir] = true
+		if dir != "." {
+			parents[filepath.Dir(dir)] = true
+		}
+	}
+	missing := []string{}
+	for parent := range parents {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			dir := filepath.Join(parent, entry.Name())
+			if !entry.IsDir() || covered[dir] || strings.HasPrefix(entry.Name(), ".") || isDefaultExcluded(entry.Name()) {
+				continue
+			}
+			if hasYAML(dir) {
+				missing = append(missing, filepath.ToSlash(dir))
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// hasYAML reports whether dir holds a YAML file.
+func hasYAML(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml":
+			if !entry.IsDir() {
+				return true
+			}
+		}
+	}
+	return false
+}
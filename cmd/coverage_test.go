@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestCoverageRun verifies that every environment is compared with the chart defaults, and
+// that environment directories without a service file are listed.
+func TestCoverageRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"charts/web/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml": "replicaCount: 1\nimage:\n  repository: web\n  tag: latest\nfoo: 1\n",
+		"envs/overrides.yaml":    "foo: 2\n",
+		"envs/prod/web.yaml":     "replicaCount: 3\nimage:\n  tag: \"1.2\"\n",
+		"envs/staging/web.yaml":  "replicaCount: 1\nextra: true\n",
+		"envs/dev/api.yaml":      "a: 1\n",
+		"envs/.git/config.yaml":  "a: 1\n",
+		"envs/docs/README.md":    "docs",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &coverageOptions{}
+	o.output = "text"
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, "./charts/web"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+	want := "ENVIRONMENT   OVERRIDDEN  COVERAGE  CUSTOMIZED KEYS\n" +
+		"envs/prod     3/4         75.0%     3\n" +
+		"envs/staging  1/4         25.0%     2\n" +
+		"\n" +
+		"KEY           ENVIRONMENTS\n" +
+		"extra         envs/staging\n" +
+		"foo           envs/prod, envs/staging\n" +
+		"image.tag     envs/prod\n" +
+		"replicaCount  envs/prod\n" +
+		"\nEnvironments without a service file: envs/dev\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	o.output = "json"
+	out.Reset()
+	if err := o.run(context.Background(), &out, "./charts/web"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+	var report coverageReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	wantEnv := kc.EnvironmentCoverage{
+		Environment: "envs/prod",
+		Files:       []string{"envs/overrides.yaml", "envs/prod/web.yaml"},
+		Defaults:    4,
+		Overridden:  3,
+		Percent:     75,
+		Customized:  []string{"foo", "image.tag", "replicaCount"},
+	}
+	if len(report.Environments) != 2 || !reflect.DeepEqual(report.Environments[0], wantEnv) {
+		t.Errorf("expected %+v, got %+v", wantEnv, report.Environments)
+	}
+}
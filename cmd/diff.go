@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// diffOptions holds the flags of the diff command.
+type diffOptions struct {
+	validateOptions
+
+	noColor bool
+}
+
+func newDiffCmd() *cobra.Command {
+	o := &diffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff <chart> [-f <values-file> ...]",
+		Short: "Show what the values customize compared to the chart defaults",
+		Long: `Print a structured diff between the chart defaults and the merged provided values.
+
+Keys are grouped into added (not defined by the chart), changed, redundant (same as the
+default), and removed (set to null) values. If no -f is provided, a diff is printed for
+every auto-detected values set.`,
+		Example: `  helm kc diff ./mychart -f overrides.yaml -f production/web_service.yaml`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&o.noColor, "no-color", false, "Disable colored output")
+	cmd.Flags().MarkDeprecated("no-color", "use --color never instead")
+	return cmd
+}
+
+func (o *diffOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	o.begin(nil)
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	opts, err := o.options()
+	if err != nil {
+		return err
+	}
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return err
+	}
+
+	type setDiff struct {
+		Files []string `json:"files"`
+		kc.ValuesDiff
+	}
+	var diffs []setDiff
+	for _, files := range sets {
+		provided, err := o.mergeValues(files)
+		if err != nil {
+			return fmt.Errorf("failed to load values (%s): %w", strings.Join(files, ", "), err)
+		}
+		defaults, err := c.defaults.Get(provided)
+		if err != nil {
+			return fmt.Errorf("computing chart defaults: %w", err)
+		}
+		diffs = append(diffs, setDiff{Files: files, ValuesDiff: kc.DiffDefaults(defaults, provided, opts)})
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+	color := !o.noColor && useColor(out)
+	for _, d := range diffs {
+		fmt.Fprintf(out, "\n==> %s\n", strings.Join(d.Files, ", "))
+		printDiffGroup(out, "Added", "+", ansiGreen, d.Added, color, func(c kc.ValueChange) string { return formatValue(c.New) })
+		printDiffGroup(out, "Changed", "~", ansiYellow, d.Changed, color, func(c kc.ValueChange) string {
+			return formatValue(c.Old) + " -> " + formatValue(c.New)
+		})
+		printDiffGroup(out, "Redundant", "=", ansiGray, d.Redundant, color, func(c kc.ValueChange) string { return formatValue(c.New) })
+		printDiffGroup(out, "Removed", "-", ansiRed, d.Removed, color, func(c kc.ValueChange) string { return formatValue(c.Old) })
+	}
+	return nil
+}
+
+func printDiffGroup(out io.Writer, title, marker, ansi string, changes []kc.ValueChange, color bool, value func(kc.ValueChange) string) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s (%d):\n", title, len(changes))
+	for _, change := range changes {
+		line := fmt.Sprintf("  %s %s: %s", marker, change.Path, value(change))
+		if color {
+			line = ansi + line + ansiReset
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+// formatValue prints scalars as they are and maps and lists as compact JSON.
+func formatValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiffRun verifies that the diff groups provided values by how they relate to the defaults.
+func TestDiffRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"values.yaml":             "replicaCount: 1\nimage:\n  tag: \"1.25\"\ningress:\n  enabled: true\n",
+	})
+
+	o := &diffOptions{}
+	o.output = "text"
+	o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, filepath.Join(dir, "web_service")); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	want := "Added (1):\n  + ingress.enabled: true\nChanged (1):\n  ~ image.tag: latest -> 1.25\nRedundant (1):\n  = replicaCount: 1\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("unexpected diff:\n%s\nwant suffix:\n%s", out.String(), want)
+	}
+}
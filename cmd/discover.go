@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// discoverOptions holds the flags of the discover command.
+type discoverOptions struct {
+	validateOptions
+}
+
+func newDiscoverCmd() *cobra.Command {
+	o := &discoverOptions{}
+	cmd := &cobra.Command{
+		Use:   "discover <chart>",
+		Short: "List the auto-detected values sets without validating them",
+		Long: `List every values set that would be validated without -f: each service file with the
+overrides files and values.d fragments merged with it. Service files that are skipped
+because no overrides file was found above them are listed as well.`,
+		Example: `  helm kc discover ./mychart
+  helm kc discover ./mychart --base-dir environments/prod -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.chartOptions.addFlags(cmd.Flags())
+	o.addDiscoveryFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.configPath, "config", "", "Path to the configuration file (default "+kc.DefaultConfigFile+" if present)")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+// discoveredSet is a service file found by discovery, with the files merged with it.
+type discoveredSet struct {
+	Service   string   `json:"service"`
+	Overrides []string `json:"overrides"`
+	Fragments []string `json:"fragments,omitempty"`
+	// Skipped is set for service files without an overrides file, which are not validated.
+	Skipped bool `json:"skipped"`
+}
+
+func (o *discoverOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	sets, discoveryOpts, err := o.discoverSets(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sets)
+	}
+	if len(sets) == 0 {
+		fmt.Fprintf(out, "No service files (%s) found.\n", discoveryOpts.naming.pattern)
+		return nil
+	}
+	skipped := 0
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tOVERRIDES\tFRAGMENTS")
+	for _, set := range sets {
+		overrides := strings.Join(set.Overrides, ", ")
+		if set.Skipped {
+			overrides = "(skipped: no " + strings.Join(discoveryOpts.naming.layers, " or ") + " found)"
+			skipped++
+		}
+		fragments := "-"
+		if len(set.Fragments) > 0 {
+			fragments = strings.Join(set.Fragments, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", set.Service, overrides, fragments)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n%d values sets found, %d service files skipped.\n", len(sets)-skipped, skipped)
+	return nil
+}
+
+// discoverSets returns the values sets auto-detected for the chart, with the options they
+// were detected with.
+func (o *validateOptions) discoverSets(ctx context.Context, chartPath string) ([]discoveredSet, discoveryOptions, error) {
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return nil, discoveryOptions{}, err
+	}
+	envDir, discoveryOpts, scopes, err := o.discoveryScope(c.name)
+	if err != nil {
+		return nil, discoveryOptions{}, err
+	}
+	pairs, err := findServiceFiles(ctx, envDir, discoveryOpts, scopes...)
+	if err != nil {
+		return nil, discoveryOptions{}, fmt.Errorf("auto-detecting values: %w", err)
+	}
+
+	sets := make([]discoveredSet, 0, len(pairs))
+	for _, pair := range pairs {
+		set := discoveredSet{Service: relPath(envDir, pair.service), Overrides: []string{}, Skipped: len(pair.overrides) == 0}
+		for _, file := range pair.overrides {
+			set.Overrides = append(set.Overrides, relPath(envDir, file))
+		}
+		for _, file := range pair.fragments {
+			set.Fragments = append(set.Fragments, relPath(envDir, file))
+		}
+		sets = append(sets, set)
+	}
+	return sets, discoveryOpts, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestDiscoverRun verifies that every service file is listed with the files merged with it,
+// including service files skipped for lack of an overrides file.
+func TestDiscoverRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"charts/web/Chart.yaml":          "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml":         "replicaCount: 1\n",
+		"envs/overrides.yaml":            "a: 1",
+		"envs/prod/web.yaml":             "a: 2",
+		"envs/prod/values.d/10-a.yaml":   "a: 3",
+		"envs/staging/web.yaml":          "a: 4",
+		"sandbox/web.yaml":               "a: 5",
+		"node_modules/pkg/envs/web.yaml": "a: 6",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &discoverOptions{}
+	o.output = "text"
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, "./charts/web"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+	want := "SERVICE                OVERRIDES                           FRAGMENTS\n" +
+		"envs/prod/web.yaml     envs/overrides.yaml                 envs/prod/values.d/10-a.yaml\n" +
+		"envs/staging/web.yaml  envs/overrides.yaml                 -\n" +
+		"sandbox/web.yaml       (skipped: no overrides.yaml found)  -\n" +
+		"\n2 values sets found, 1 service files skipped.\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	o.output = "json"
+	o.baseDirs = []string{"envs/prod"}
+	out.Reset()
+	if err := o.run(context.Background(), &out, "./charts/web"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+	var sets []discoveredSet
+	if err := json.Unmarshal(out.Bytes(), &sets); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	wantSets := []discoveredSet{{
+		Service:   "envs/prod/web.yaml",
+		Overrides: []string{"envs/overrides.yaml"},
+		Fragments: []string{"envs/prod/values.d/10-a.yaml"},
+	}}
+	if !reflect.DeepEqual(sets, wantSets) {
+		t.Errorf("expected %+v, got %+v", wantSets, sets)
+	}
+}
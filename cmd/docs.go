@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// docsOptions holds the flags of the docs command.
+type docsOptions struct {
+	validateOptions
+}
+
+func newDocsCmd() *cobra.Command {
+	o := &docsOptions{}
+	cmd := &cobra.Command{
+		Use:   "docs <chart> [-f <values-file> ...]",
+		Short: "Generate a Markdown table of the effective values",
+		Long: `Print a Markdown table of every effective value: its key, chart default, override, final
+value, and the file and line the final value comes from.
+
+A table is printed for every auto-detected values set, i.e. for every environment, unless
+values files are given with -f.`,
+		Example: `  helm kc docs ./mychart > VALUES.md
+  helm kc docs ./mychart -f overrides.yaml -f production/web_service.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *docsOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	o.begin(nil)
+	opts, err := o.options()
+	if err != nil {
+		return err
+	}
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "# Values of %s\n", c.chart.Name())
+	for _, files := range sets {
+		provided, err := o.mergeValues(files)
+		if err != nil {
+			return fmt.Errorf("failed to load values (%s): %w", strings.Join(files, ", "), err)
+		}
+		defaults, err := c.defaults.Get(provided)
+		if err != nil {
+			return fmt.Errorf("computing chart defaults: %w", err)
+		}
+		effective, err := kc.EffectiveValues(c.chart, provided)
+		if err != nil {
+			return fmt.Errorf("computing effective values: %w", err)
+		}
+		layers, err := o.valuesLayers(c.chart, files)
+		if err != nil {
+			return err
+		}
+		docs, err := kc.DocumentValues(defaults, provided, effective, layers, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\n## %s\n\n", strings.Join(files, ", "))
+		fmt.Fprintln(out, "| Key | Default | Override | Value | Source |")
+		fmt.Fprintln(out, "|-----|---------|----------|-------|--------|")
+		for _, doc := range docs {
+			override := ""
+			if doc.Overridden {
+				override = markdownValue(doc.Override)
+			}
+			source := doc.Source
+			if doc.Line > 0 {
+				source = fmt.Sprintf("%s:%d", doc.Source, doc.Line)
+			}
+			fmt.Fprintf(out, "| `%s` | %s | %s | %s | %s |\n",
+				doc.Path, markdownValue(doc.Default), override, markdownValue(doc.Value), markdownEscape(source))
+		}
+	}
+	return nil
+}
+
+// markdownValue formats a value as inline code for a Markdown table cell.
+func markdownValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return "`" + markdownEscape(formatValue(v)) + "`"
+}
+
+// markdownEscape escapes the characters that would break a Markdown table row.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDocsRun verifies that the table lists every effective value with its source.
+func TestDocsRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"values.yaml":             "image:\n  tag: \"1.25|beta\"\n",
+	})
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &docsOptions{}
+	o.valuesFiles = []string{"values.yaml"}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, "web_service"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	want := "## values.yaml\n\n" +
+		"| Key | Default | Override | Value | Source |\n" +
+		"|-----|---------|----------|-------|--------|\n" +
+		"| `image.tag` | `latest` | `1.25\\|beta` | `1.25\\|beta` | values.yaml:2 |\n" +
+		"| `replicaCount` | `1` |  | `1` | web_service/values.yaml:1 |\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("unexpected docs:\n%s\nwant suffix:\n%s", out.String(), want)
+	}
+}
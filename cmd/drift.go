@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// driftOptions holds the flags of the drift command.
+type driftOptions struct {
+	validateOptions
+
+	namespace string
+	revision  int
+}
+
+func newDriftCmd() *cobra.Command {
+	o := &driftOptions{}
+	cmd := &cobra.Command{
+		Use:   "drift <release> [-f <values-file> ...]",
+		Short: "Compare a deployed release with the values files",
+		Long: `Compare the values a release was deployed with against the values files in the repository.
+
+The release's chart and user-supplied values are fetched from the cluster. Both sides are
+coalesced with the deployed chart's defaults, and every key with a different effective value
+is reported. If no -f is provided, the values files are auto-detected like for validation.`,
+		Example: `  helm kc drift web -n production -f overrides.yaml -f production/web_service.yaml`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the release (default $HELM_NAMESPACE, set by helm -n, or the kube context)")
+	cmd.Flags().IntVar(&o.revision, "revision", 0, "Release revision to compare against (default latest)")
+	return cmd
+}
+
+// getRelease fetches a release and the values it was deployed with from the cluster.
+func (o *driftOptions) getRelease(name string) (*release.Release, error) {
+	actionConfig, err := newActionConfig(o.namespace)
+	if err != nil {
+		return nil, err
+	}
+	get := action.NewGet(actionConfig)
+	get.Version = o.revision
+	rel, err := get.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("fetching release %s: %w", name, err)
+	}
+	return rel, nil
+}
+
+func (o *driftOptions) run(ctx context.Context, out io.Writer, name string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	rel, err := o.getRelease(name)
+	if err != nil {
+		return err
+	}
+
+	sets, err := o.valuesSets(ctx, rel.Chart.Name())
+	if err != nil {
+		return err
+	}
+	if len(sets) > 1 {
+		return fmt.Errorf("found %d values sets for %s; select one with -f", len(sets), rel.Chart.Name())
+	}
+	files := sets[0]
+
+	if o.banners() {
+		fmt.Fprintf(out, "\nRelease: %s (%s-%s, revision %d)\n", rel.Name, rel.Chart.Name(), rel.Chart.Metadata.Version, rel.Version)
+		fmt.Fprintf(out, "Values files: %s\n\n", strings.Join(files, ","))
+	}
+
+	expected, err := o.mergeValues(files)
+	if err != nil {
+		return fmt.Errorf("failed to load values: %w", err)
+	}
+	opts.Files = files
+	opts.Suppressions, err = o.suppressions(files)
+	if err != nil {
+		return fmt.Errorf("reading inline suppressions: %w", err)
+	}
+	issuesFound, err := kc.CheckDrift(rel.Chart, expected, rel.Config, opts, reporter)
+	if err != nil {
+		return err
+	}
+
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// Exit codes, so CI can tell values problems from a misconfigured run.
+const (
+	// exitOK is returned when no failing issues were found.
+	exitOK = 0
+	// exitIssues is returned when findings fail the run.
+	exitIssues = 1
+	// exitUsage is returned for invalid flags, arguments, or configuration.
+	exitUsage = 2
+	// exitLoad is returned when a chart, values file, or release cannot be loaded, or when
+	// --timeout expired.
+	exitLoad = 3
+)
+
+// loadError marks a failure to load a chart, values, or releases, as opposed to a
+// misconfigured run.
+type loadError struct {
+	err error
+}
+
+func (e *loadError) Error() string { return e.err.Error() }
+
+func (e *loadError) Unwrap() error { return e.err }
+
+// exitCode returns the exit code of a run that returned err.
+func exitCode(err error) int {
+	var le *loadError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errIssuesFound):
+		return exitIssues
+	case errors.As(err, &le), errors.Is(err, context.DeadlineExceeded):
+		return exitLoad
+	}
+	return exitUsage
+}
+
+func (o *validateOptions) addThresholdFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.maxIssues, "max-issues", 0, "Number of failing findings (see --fail-on) tolerated before the run fails")
+	fs.IntVar(&o.maxWarnings, "max-warnings", -1, "Number of warnings tolerated before the run fails, whatever --fail-on is; warnings no longer count towards --max-issues (default no limit)")
+	fs.BoolVar(&o.failFast, "fail-fast", false, "Stop at the first error-severity finding instead of validating every values set")
+}
+
+// stopFast reports whether --fail-fast stops the run, because an error was reported.
+func (o *validateOptions) stopFast() bool {
+	if o.failFast && o.counter != nil && o.counter.counts[kc.SeverityError] > 0 {
+		o.failedFast = true
+	}
+	return o.failedFast
+}
+
+// issueCounter counts the findings of every severity on their way to the reporter.
+type issueCounter struct {
+	kc.Reporter
+	counts map[kc.Severity]int
+}
+
+func (c *issueCounter) Report(f kc.Finding) {
+	c.counts[f.Severity]++
+	c.Reporter.Report(f)
+}
+
+// result returns the error that ends a run, once every finding was reported: a loadError if
+// anything failed to load, errIssuesFound if the findings exceed the thresholds, nil otherwise.
+// issuesFound tells whether any failing findings were reported.
+func (o *validateOptions) result(issuesFound bool, failOn kc.Severity) error {
+	if o.loadFailures > 0 {
+		return &loadError{fmt.Errorf("%d charts, values sets, or releases failed to load", o.loadFailures)}
+	}
+	if o.counter == nil || o.maxIssues <= 0 && o.maxWarnings < 0 {
+		if issuesFound {
+			return errIssuesFound
+		}
+		return nil
+	}
+	failing := 0
+	for sev, n := range o.counter.counts {
+		if sev.AtLeast(failOn) && (sev != kc.SeverityWarning || o.maxWarnings < 0) {
+			failing += n
+		}
+	}
+	if failing > max(o.maxIssues, 0) || o.maxWarnings >= 0 && o.counter.counts[kc.SeverityWarning] > o.maxWarnings {
+		return errIssuesFound
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestExitCode verifies that findings, load failures, and misconfigured runs exit with
+// distinct codes.
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errIssuesFound, exitIssues},
+		{fmt.Errorf("unknown rule: KC999"), exitUsage},
+		{fmt.Errorf("chart web: %w", &loadError{errors.New("no such file")}), exitLoad},
+	} {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v): expected %d, got %d", tc.err, tc.want, got)
+		}
+	}
+}
+
+// TestThresholds verifies --max-issues and --max-warnings, and that a values file that fails
+// to load is a load error.
+func TestThresholds(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\nport: 80\n",
+		"values.yaml":       "replicaCount: 1\nport: http\n",
+	})
+	chart := filepath.Join(dir, "chart")
+
+	for _, tc := range []struct {
+		failOn                 string
+		maxIssues, maxWarnings int
+		want                   error
+	}{
+		{"", 0, -1, errIssuesFound},
+		{"", 2, -1, nil},
+		{"", 1, 0, errIssuesFound},
+		{"error", 1, 0, errIssuesFound},
+		{"error", 1, 1, nil},
+	} {
+		o := &validateOptions{output: "json", jobs: 1, failOn: tc.failOn, maxIssues: tc.maxIssues, maxWarnings: tc.maxWarnings}
+		o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+		if err := o.run(context.Background(), io.Discard, chart); !errors.Is(err, tc.want) {
+			t.Errorf("--fail-on %q --max-issues %d --max-warnings %d: expected %v, got %v", tc.failOn, tc.maxIssues, tc.maxWarnings, tc.want, err)
+		}
+	}
+
+	o := &validateOptions{output: "json", jobs: 1}
+	o.valuesFiles = []string{filepath.Join(dir, "missing.yaml")}
+	if err := o.run(context.Background(), io.Discard, chart); exitCode(err) != exitLoad {
+		t.Errorf("expected a load error for a missing values file, got %v", err)
+	}
+}
+
+// TestFailFast verifies that --fail-fast stops at the first error, without validating the
+// remaining values sets.
+func TestFailFast(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web/Chart.yaml":      "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"web/values.yaml":     "replicaCount: 1\nport: 80\nimage:\n  tag: v1\n",
+		"envs/overrides.yaml": "replicaCount: 2\n",
+		"envs/a/web.yaml":     "image:\n  tag: v1\nport: http\n",
+		"envs/b/web.yaml":     "port: https\n",
+	})
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "envs")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	for failFast, want := range map[bool]int{false: 3, true: 2} {
+		collector := &kc.Collector{}
+		o := &validateOptions{jobs: 2, failFast: failFast, maxWarnings: -1}
+		o.begin(collector)
+		if err := o.run(context.Background(), io.Discard, filepath.Join(dir, "web")); !errors.Is(err, errIssuesFound) {
+			t.Fatalf("--fail-fast=%v: expected issues to be found, got %v", failFast, err)
+		}
+		if len(collector.Findings) != want {
+			t.Errorf("--fail-fast=%v: expected %d findings, got %v", failFast, want, collector.Findings)
+		}
+	}
+}
+
+// TestTimeout verifies that an expired context stops discovery and validation with a load
+// error.
+func TestTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web/Chart.yaml":      "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"web/values.yaml":     "replicaCount: 1\n",
+		"envs/overrides.yaml": "replicaCount: 2\n",
+		"envs/a/web.yaml":     "replicaCount: 3\n",
+	})
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 0, fmt.Errorf("--timeout of 0s exceeded: %w", context.DeadlineExceeded))
+	defer cancel()
+
+	if _, err := detectPairs(ctx, filepath.Join(dir, "envs"), discoveryOptions{naming: defaultNaming(t, "web")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected discovery to time out, got %v", err)
+	}
+
+	o := &validateOptions{output: "json", jobs: 1}
+	o.valuesFiles = []string{filepath.Join(dir, "envs", "a", "web.yaml")}
+	err := o.run(ctx, io.Discard, filepath.Join(dir, "web"))
+	if exitCode(err) != exitLoad {
+		t.Errorf("expected a timed out run to exit with %d, got %v", exitLoad, err)
+	}
+}
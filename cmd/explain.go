@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// explainOptions holds the flags of the explain command.
+type explainOptions struct {
+	validateOptions
+}
+
+func newExplainCmd() *cobra.Command {
+	o := &explainOptions{}
+	cmd := &cobra.Command{
+		Use:   "explain <chart> <key.path> [-f <values-file> ...]",
+		Short: "Show where the effective value of a key comes from",
+		Long: `Print every source that sets a key, from the chart defaults through the values files
+to the --set flags, with the file and line, and which of them provides the effective value.
+
+Keys are dotted paths and may index into lists, e.g. "ingress.hosts[0]". If no -f is
+provided, the key is explained for every auto-detected values set.`,
+		Example: `  helm kc explain ./mychart image.tag -f overrides.yaml -f production/web_service.yaml
+  helm kc explain ./mychart redis.auth.enabled --set redis.auth.enabled=true`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+// defaultLayers returns the values.yaml of c and its subcharts as layers, subcharts first,
+// since the values of a parent chart override those of its subcharts.
+func defaultLayers(c *chart.Chart, dir, prefix string) []kc.ValuesLayer {
+	var layers []kc.ValuesLayer
+	for _, dep := range c.Dependencies() {
+		depPrefix := dep.Name()
+		if prefix != "" {
+			depPrefix = prefix + "." + dep.Name()
+		}
+		layers = append(layers, defaultLayers(dep, path.Join(dir, "charts", dep.Name()), depPrefix)...)
+	}
+	for _, f := range c.Raw {
+		if f.Name == chartutil.ValuesfileName {
+			layers = append(layers, kc.ValuesLayer{Source: path.Join(dir, f.Name), Prefix: prefix, Data: f.Data})
+		}
+	}
+	return layers
+}
+
+// valuesLayers returns every source of values for the given files in order of precedence.
+func (o *validateOptions) valuesLayers(c *chart.Chart, files []string) ([]kc.ValuesLayer, error) {
+	layers := defaultLayers(c, c.Name(), "")
+	for _, file := range files {
+		data, err := o.reader.read(file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, kc.ValuesLayer{Source: file, Data: data})
+	}
+	for _, flag := range o.setFlags() {
+		values := map[string]interface{}{}
+		if err := o.parseSetFlag(flag, values); err != nil {
+			return nil, err
+		}
+		layers = append(layers, kc.ValuesLayer{Source: flag.String(), Values: values})
+	}
+	return layers, nil
+}
+
+func (o *explainOptions) run(ctx context.Context, out io.Writer, chartPath, key string) error {
+	o.begin(nil)
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return err
+	}
+
+	type setExplanation struct {
+		Files   []string        `json:"files"`
+		Key     string          `json:"key"`
+		Sources []kc.Provenance `json:"sources"`
+	}
+	var explanations []setExplanation
+	for _, files := range sets {
+		layers, err := o.valuesLayers(c.chart, files)
+		if err != nil {
+			return fmt.Errorf("failed to load values (%s): %w", strings.Join(files, ", "), err)
+		}
+		sources, err := kc.Explain(layers, key)
+		if err != nil {
+			return err
+		}
+		explanations = append(explanations, setExplanation{Files: files, Key: key, Sources: sources})
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(explanations)
+	}
+	for _, e := range explanations {
+		fmt.Fprintf(out, "\n==> %s\n", strings.Join(e.Files, ", "))
+		if len(e.Sources) == 0 {
+			fmt.Fprintf(out, "%s is not set by the chart defaults, values files, or --set flags\n", key)
+			continue
+		}
+		effective := e.Sources[len(e.Sources)-1]
+		fmt.Fprintf(out, "%s: %s (from %s)\n", key, explainValue(effective.Value), sourceLocation(effective))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, source := range e.Sources {
+			marker := "overridden"
+			if source.Effective {
+				marker = "effective"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", sourceLocation(source), explainValue(source.Value), marker)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// sourceLocation returns the source of a value with its line, if known.
+func sourceLocation(p kc.Provenance) string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d", p.Source, p.Line)
+	}
+	return p.Source
+}
+
+// explainValue formats a value like formatValue, showing null for keys that remove a default.
+func explainValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return formatValue(v)
+}
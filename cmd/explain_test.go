@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestExplainRun verifies that every layer setting a key is listed, up to the --set flags.
+func TestExplainRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"overrides.yaml":          "image:\n  tag: \"1.24\"\n",
+		"web_service.yaml":        "replicaCount: 3\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &explainOptions{}
+	o.output = "text"
+	o.valuesFiles = []string{"overrides.yaml", "web_service.yaml"}
+	o.setValues.Values = []string{"image.tag=1.25"}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, "web_service", "image.tag"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	want := "image.tag: 1.25 (from --set image.tag=1.25)\n" +
+		"  web_service/values.yaml:3  latest  overridden\n" +
+		"  overrides.yaml:2           1.24    overridden\n" +
+		"  --set image.tag=1.25       1.25    effective\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("unexpected explanation:\n%s\nwant suffix:\n%s", out.String(), want)
+	}
+}
+
+// TestExplainFragments verifies that the fragments of an auto-detected values.d directory are
+// merged after the service file, in lexicographic order, and listed as sources of their own.
+func TestExplainFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":                "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml":               "replicaCount: 1\nimage:\n  tag: latest\n",
+		"overrides.yaml":                        "image:\n  tag: \"1.24\"\n",
+		"prod/web_service.yaml":                 "replicaCount: 3\n",
+		"prod/values.d/20-image.yaml":           "image:\n  tag: \"1.26\"\n",
+		"prod/values.d/10-image.yaml":           "image:\n  tag: \"1.25\"\n",
+		"prod/values.d/README.md":               "Fragments of the production values.\n",
+		"prod/values.d/web_service.yaml":        "replicaCount: 5\n",
+		"prod/values.d/nested/web_service.yaml": "replicaCount: 7\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &explainOptions{}
+	o.output = "text"
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, "web_service", "image.tag"); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	want := "\n==> overrides.yaml, prod/web_service.yaml, prod/values.d/10-image.yaml, prod/values.d/20-image.yaml, prod/values.d/web_service.yaml\n" +
+		"image.tag: 1.26 (from prod/values.d/20-image.yaml:2)\n" +
+		"  web_service/values.yaml:3      latest  overridden\n" +
+		"  overrides.yaml:2               1.24    overridden\n" +
+		"  prod/values.d/10-image.yaml:2  1.25    overridden\n" +
+		"  prod/values.d/20-image.yaml:2  1.26    effective\n"
+	if out.String() != want {
+		t.Errorf("unexpected explanation:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
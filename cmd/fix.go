@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// chartRenames returns the configured renames followed by the ones published by the chart.
+func chartRenames(c *loadedChart, opts kc.Options) ([]kc.Rename, error) {
+	renames, err := kc.ChartRenames(c.chart)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]kc.Rename{}, opts.Renames...), renames...), nil
+}
+
+// isLocalFile reports whether a values file is a file on disk that can be edited in place,
+// as opposed to stdin, a remote file, or a generated document.
+func (o *validateOptions) isLocalFile(file string) bool {
+	_, generated := o.reader.cache[file]
+	return !generated && file != stdinPath && !isChartURL(file)
+}
+
+// fixFiles applies the known renames to every local values file in place and lists the
+// changes on stderr, so machine-readable output stays intact. Stdin, remote, and generated
+// documents are left alone.
+func (o *validateOptions) fixFiles(c *loadedChart, files []string, opts kc.Options) error {
+	renames, err := chartRenames(c, opts)
+	if err != nil {
+		return err
+	}
+	if len(renames) == 0 {
+		return nil
+	}
+	for _, file := range files {
+		if !o.isLocalFile(file) {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fixed, applied, err := kc.ApplyRenames(data, renames)
+		if err != nil {
+			return fmt.Errorf("fixing %s: %w", file, err)
+		}
+		if len(applied) == 0 {
+			continue
+		}
+		if err := os.WriteFile(file, fixed, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("fixing %s: %w", file, err)
+		}
+		for _, rename := range applied {
+			logger.Info("renamed key", "file", file, "from", rename.From, "to", rename.To)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"sigs.k8s.io/yaml"
+)
+
+// getOptions holds the flags of the get command.
+type getOptions struct {
+	validateOptions
+}
+
+func newGetCmd() *cobra.Command {
+	o := &getOptions{}
+	cmd := &cobra.Command{
+		Use:   "get <chart> <key.path> [-f <values-file> ...]",
+		Short: "Print the effective value of a key",
+		Long: `Print the value of a key in the merged values, coalesced with the chart defaults like
+Helm does at install time.
+
+Keys are dotted paths and may index into lists ("ingress.hosts[0]"). "*" and "?" match
+within a single key, "[*]" matches every list item, and "**" matches any number of keys.
+Queries with wildcards print a map of every matching path to its value. String values of a
+single key are printed as they are, so they can be used in scripts directly.`,
+		Example: `  helm kc get ./mychart image.tag -f overrides.yaml -f production/web_service.yaml
+  helm kc get ./mychart 'ingress.hosts[*].host' -f values.yaml -o json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.output, "output", "o", "yaml", "Output format: yaml or json")
+	return cmd
+}
+
+func (o *getOptions) run(ctx context.Context, out io.Writer, chartPath, query string) error {
+	o.begin(nil)
+	if o.output != "yaml" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return err
+	}
+	if len(sets) > 1 {
+		return fmt.Errorf("found %d values sets for %s; select one with -f", len(sets), c.name)
+	}
+
+	provided, err := o.mergeValues(sets[0])
+	if err != nil {
+		return fmt.Errorf("failed to load values: %w", err)
+	}
+	effective, err := kc.EffectiveValues(c.chart, provided)
+	if err != nil {
+		return fmt.Errorf("computing effective values: %w", err)
+	}
+	matches, err := kc.Query(effective, query)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	if kc.IsWildcardQuery(query) {
+		if len(matches) == 0 {
+			return fmt.Errorf("no values match %s", query)
+		}
+		found := make(map[string]interface{}, len(matches))
+		for _, match := range matches {
+			found[match.Path] = match.Value
+		}
+		result = found
+	} else {
+		if len(matches) == 0 {
+			return fmt.Errorf("key %s not found", query)
+		}
+		result = matches[0].Value
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if s, ok := result.(string); ok {
+		_, err := fmt.Fprintln(out, s)
+		return err
+	}
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
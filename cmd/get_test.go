@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestGetRun verifies that keys are looked up in the values coalesced with the chart defaults.
+func TestGetRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "image:\n  repository: nginx\n  tag: latest\n",
+		"values.yaml":             "image:\n  tag: \"1.25\"\n",
+	})
+
+	tests := []struct {
+		query  string
+		output string
+		want   string
+	}{
+		{"image.tag", "yaml", "1.25\n"},
+		{"image", "yaml", "repository: nginx\ntag: \"1.25\"\n"},
+		{"image.*", "json", "{\n  \"image.repository\": \"nginx\",\n  \"image.tag\": \"1.25\"\n}\n"},
+	}
+	for _, tt := range tests {
+		o := &getOptions{}
+		o.output = tt.output
+		o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+		var out bytes.Buffer
+		if err := o.run(context.Background(), &out, filepath.Join(dir, "web_service"), tt.query); err != nil {
+			t.Fatalf("run(%q) returned error: %v", tt.query, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("run(%q) printed %q, want %q", tt.query, out.String(), tt.want)
+		}
+	}
+
+	o := &getOptions{}
+	o.output = "yaml"
+	o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+	if err := o.run(context.Background(), &bytes.Buffer{}, filepath.Join(dir, "web_service"), "image.pullPolicy"); err == nil {
+		t.Error("run() succeeded for a missing key")
+	}
+}
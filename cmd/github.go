@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// reportMarker identifies the comments posted by kc, so later runs update them instead of
+// adding new ones.
+const reportMarker = "<!-- kaartcontrole report -->"
+
+// gitHubPROptions holds the flags of the report github-pr command.
+type gitHubPROptions struct {
+	validateOptions
+	repository string
+	pr         int
+}
+
+func newGitHubPRCmd() *cobra.Command {
+	o := &gitHubPROptions{}
+	cmd := &cobra.Command{
+		Use:   "github-pr <chart> [-f <values-file> ...]",
+		Short: "Post the findings as a comment on the current GitHub pull request",
+		Long: `Validate chart values like the validate command and post the findings as a Markdown
+comment on a GitHub pull request. The comment of a previous run is updated instead of
+adding a new one.
+
+The token is read from GITHUB_TOKEN. The repository and pull request are detected from the
+GitHub Actions environment (GITHUB_REPOSITORY, GITHUB_EVENT_PATH, and GITHUB_REF) unless
+--repository and --pr are given. GITHUB_API_URL selects a GitHub Enterprise server.`,
+		Example: `  helm kc report github-pr ./mychart
+  helm kc report github-pr --charts charts/ --repository acme/deploy --pr 42`,
+		Args:          chartArgs(&o.validateOptions),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args)
+		},
+	}
+	o.addReportFlags(cmd)
+	cmd.Flags().StringVar(&o.repository, "repository", "", "GitHub repository as owner/name (default $GITHUB_REPOSITORY)")
+	cmd.Flags().IntVar(&o.pr, "pr", 0, "Pull request number (default detected from the GitHub Actions event)")
+	return cmd
+}
+
+func (o *gitHubPROptions) run(ctx context.Context, out io.Writer, args []string) error {
+	client, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+	repo, pr, err := o.pullRequest()
+	if err != nil {
+		return err
+	}
+	var report bytes.Buffer
+	issuesFound, err := o.runReport(ctx, kc.NewMarkdownReporter(&report), args)
+	if err != nil {
+		return err
+	}
+	url, err := client.upsertComment(repo, pr, reportMarker+"\n"+report.String())
+	if err != nil {
+		return fmt.Errorf("posting comment on %s#%d: %w", repo, pr, err)
+	}
+	fmt.Fprintf(out, "Findings posted to %s\n", url)
+	if issuesFound {
+		return errIssuesFound
+	}
+	return nil
+}
+
+// refPattern matches the ref GitHub Actions checks out for pull request events.
+var refPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// pullRequest resolves the repository and pull request from the flags or the GitHub Actions
+// environment.
+func (o *gitHubPROptions) pullRequest() (string, int, error) {
+	repo := o.repository
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if repo == "" {
+		return "", 0, errors.New("no repository found: set --repository or GITHUB_REPOSITORY")
+	}
+	pr := o.pr
+	if pr == 0 {
+		if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", 0, fmt.Errorf("reading GitHub event: %w", err)
+			}
+			var event struct {
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+			if err := json.Unmarshal(data, &event); err != nil {
+				return "", 0, fmt.Errorf("parsing GitHub event %s: %w", path, err)
+			}
+			pr = event.PullRequest.Number
+		}
+	}
+	if pr == 0 {
+		if m := refPattern.FindStringSubmatch(os.Getenv("GITHUB_REF")); m != nil {
+			pr, _ = strconv.Atoi(m[1])
+		}
+	}
+	if pr == 0 {
+		return "", 0, errors.New("no pull request found: set --pr or run on a pull_request event")
+	}
+	return repo, pr, nil
+}
+
+// gitHubClient is a minimal client of the GitHub REST API.
+type gitHubClient struct {
+	apiClient
+}
+
+func newGitHubClient() (*gitHubClient, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, errors.New("GITHUB_TOKEN is not set")
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return &gitHubClient{apiClient{
+		baseURL: strings.TrimSuffix(apiURL, "/"),
+		header: http.Header{
+			"Accept":               {"application/vnd.github+json"},
+			"Authorization":        {"Bearer " + token},
+			"X-Github-Api-Version": {"2022-11-28"},
+		},
+	}}, nil
+}
+
+// gitHubComment is an issue comment of the GitHub API.
+type gitHubComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// upsertComment updates the comment of pull request pr carrying reportMarker, or creates one,
+// and returns its URL.
+func (c *gitHubClient) upsertComment(repo string, pr int, body string) (string, error) {
+	for page := 1; ; page++ {
+		var comments []gitHubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", repo, pr, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return "", err
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, reportMarker) {
+				var updated gitHubComment
+				path := fmt.Sprintf("/repos/%s/issues/comments/%d", repo, comment.ID)
+				if err := c.do(http.MethodPatch, path, map[string]string{"body": body}, &updated); err != nil {
+					return "", err
+				}
+				return updated.HTMLURL, nil
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	var created gitHubComment
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr)
+	if err := c.do(http.MethodPost, path, map[string]string{"body": body}, &created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestGitHubPRRun verifies that the findings are posted as a pull request comment on the
+// first run and that the same comment is updated on later runs.
+func TestGitHubPRRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\n",
+		"values.yaml":       "replicaCount: 1\n",
+		"event.json":        `{"pull_request": {"number": 7}}`,
+	})
+
+	var mu sync.Mutex
+	var comments []gitHubComment
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Body string `json:"body"`
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/deploy/issues/7/comments":
+			json.NewEncoder(w).Encode(append([]gitHubComment{{ID: 1, Body: "LGTM"}}, comments...))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/deploy/issues/7/comments":
+			json.NewDecoder(r.Body).Decode(&body)
+			comment := gitHubComment{ID: 2, Body: body.Body, HTMLURL: "https://github.test/acme/deploy/pull/7#issuecomment-2"}
+			comments = append(comments, comment)
+			json.NewEncoder(w).Encode(comment)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/acme/deploy/issues/comments/2":
+			json.NewDecoder(r.Body).Decode(&body)
+			comments[0].Body = body.Body
+			json.NewEncoder(w).Encode(comments[0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "secret")
+	t.Setenv("GITHUB_REPOSITORY", "acme/deploy")
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(dir, "event.json"))
+
+	for run := 0; run < 2; run++ {
+		o := &gitHubPROptions{}
+		o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+		o.jobs = 1
+		var out bytes.Buffer
+		if err := o.run(context.Background(), &out, []string{filepath.Join(dir, "chart")}); !errors.Is(err, errIssuesFound) {
+			t.Fatalf("run %d: expected issues to be found, got %v", run, err)
+		}
+		if want := "Findings posted to https://github.test/acme/deploy/pull/7#issuecomment-2\n"; out.String() != want {
+			t.Errorf("run %d: expected %q, got %q", run, want, out.String())
+		}
+	}
+
+	if len(comments) != 1 {
+		t.Fatalf("expected a single comment, got %d", len(comments))
+	}
+	if !strings.HasPrefix(comments[0].Body, reportMarker+"\n## KaartControle report") || !strings.Contains(comments[0].Body, "replicaCount") {
+		t.Errorf("unexpected comment body:\n%s", comments[0].Body)
+	}
+	want := []string{
+		"GET /repos/acme/deploy/issues/7/comments",
+		"POST /repos/acme/deploy/issues/7/comments",
+		"GET /repos/acme/deploy/issues/7/comments",
+		"PATCH /repos/acme/deploy/issues/comments/2",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
+
+// TestGitHubPullRequest verifies the detection of the pull request from the GitHub Actions
+// environment.
+func TestGitHubPullRequest(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "acme/deploy")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_REF", "refs/pull/12/merge")
+	repo, pr, err := (&gitHubPROptions{}).pullRequest()
+	if err != nil || repo != "acme/deploy" || pr != 12 {
+		t.Errorf("expected acme/deploy#12, got %s#%d (%v)", repo, pr, err)
+	}
+
+	t.Setenv("GITHUB_REF", "refs/heads/main")
+	if _, _, err := (&gitHubPROptions{}).pullRequest(); err == nil {
+		t.Error("expected an error outside of a pull request")
+	}
+	t.Setenv("GITHUB_REPOSITORY", "")
+	if _, _, err := (&gitHubPROptions{}).pullRequest(); err == nil {
+		t.Error("expected an error without a repository")
+	}
+}
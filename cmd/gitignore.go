@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignoreFiles are the files whose patterns exclude paths from values discovery. .kcignore uses
+// the .gitignore syntax for exclusions that only apply to kc.
+var ignoreFiles = []string{".gitignore", ".kcignore"}
+
+// defaultExcludeDirs are never searched for values files.
+var defaultExcludeDirs = []string{".git", "node_modules", "vendor"}
+
+// ignoreRule is a single pattern of an ignore file, relative to the file's directory.
+type ignoreRule struct {
+	base    string
+	re      *regexp.Regexp
+	dirOnly bool
+	negate  bool
+}
+
+// ignoreMatcher decides which paths values discovery skips: directories matching an exclude
+// pattern, and paths ignored by .gitignore or .kcignore files in their directory or above.
+// It is safe for concurrent use.
+type ignoreMatcher struct {
+	root     string
+	excludes []string
+
+	mu    sync.RWMutex
+	rules map[string][]ignoreRule
+}
+
+func newIgnoreMatcher(root string, excludes []string) *ignoreMatcher {
+	return &ignoreMatcher{
+		root:     root,
+		excludes: append(append([]string{}, defaultExcludeDirs...), excludes...),
+		rules:    map[string][]ignoreRule{},
+	}
+}
+
+// loadDir reads the ignore files of dir. It must be called for a directory before its entries
+// are matched.
+func (m *ignoreMatcher) loadDir(dir string) error {
+	var rules []ignoreRule
+	for _, name := range ignoreFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		rules = append(rules, parseIgnoreRules(dir, data)...)
+	}
+	if len(rules) > 0 {
+		m.mu.Lock()
+		m.rules[dir] = rules
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// ignored reports whether path, below the matcher's root, is skipped.
+func (m *ignoreMatcher) ignored(path string, isDir bool) bool {
+	if path == m.root {
+		return false
+	}
+	if isDir {
+		rel := filepath.ToSlash(relPath(m.root, path))
+		for _, exclude := range m.excludes {
+			exclude = strings.Trim(filepath.ToSlash(exclude), "/")
+			if ok, _ := filepath.Match(exclude, filepath.Base(path)); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(exclude, rel); ok {
+				return true
+			}
+		}
+	}
+
+	// Rules of outer directories come first; the last matching rule decides.
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == m.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		for _, rule := range m.rules[dirs[i]] {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(filepath.ToSlash(relPath(rule.base, path))) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreRules parses the patterns of an ignore file in dir.
+func parseIgnoreRules(dir string, data []byte) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// Patterns with a slash are relative to the ignore file, others match at any depth.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		prefix := "(.*/)?"
+		if anchored {
+			prefix = ""
+		}
+		re, err := regexp.Compile("^" + prefix + ignoreGlobToRegexp(line) + "$")
+		if err != nil {
+			// Git skips patterns it cannot parse as well.
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignoreGlobToRegexp converts a .gitignore glob into a regular expression over slash-separated
+// paths.
+func ignoreGlobToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				class := pattern[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+			} else {
+				b.WriteString(regexp.QuoteMeta("["))
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
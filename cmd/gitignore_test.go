@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestDetectPairsExcludes verifies that discovery skips the default excluded directories,
+// directories given with --exclude-dir, and paths ignored by .gitignore and .kcignore files.
+func TestDetectPairsExcludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"overrides.yaml":                             "a: 1",
+		".gitignore":                                 "build/\n*.tmp.yaml\n",
+		"prod/web.yaml":                              "a: 2",
+		"staging/web.yaml":                           "a: 3",
+		"node_modules/pkg/web.yaml":                  "a: 4",
+		"vendor/charts/web.yaml":                     "a: 5",
+		"build/web.yaml":                             "a: 6",
+		"legacy/web.yaml":                            "a: 7",
+		"archive/.kcignore":                          "*\n!keep/\n!keep/web.yaml\n",
+		"archive/old/web.yaml":                       "a: 8",
+		"archive/keep/web.yaml":                      "a: 9",
+		"staging/sub/.kcignore":                      "/web.yaml\n",
+		"staging/sub/web.yaml":                       "a: 10",
+		"staging/sub/nested/web.yaml":                "a: 11",
+		"staging/.kcignore":                          "# comment\n\n",
+		"staging/tmp/web.tmp.yaml/placeholder.yaml":  "a: 12",
+		"staging/tmp/web.tmp.yaml/web.yaml":          "a: 13",
+		"staging/tmp/other/web.yaml":                 "a: 14",
+		"staging/tmp/other/nested.tmp.yaml/web.yaml": "a: 15",
+	})
+
+	pairs, err := detectPairs(context.Background(), dir, discoveryOptions{naming: defaultNaming(t, "web"), excludeDirs: []string{"legacy"}})
+	if err != nil {
+		t.Fatalf("detectPairs returned an error: %v", err)
+	}
+	var found []string
+	for _, pair := range pairs {
+		rel, _ := filepath.Rel(dir, pair.service)
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+	want := []string{
+		"archive/keep/web.yaml",
+		"prod/web.yaml",
+		"staging/sub/nested/web.yaml",
+		"staging/tmp/other/web.yaml",
+		"staging/web.yaml",
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("expected %v, got %v", want, found)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// gitLabMROptions holds the flags of the report gitlab-mr command.
+type gitLabMROptions struct {
+	validateOptions
+	project string
+	mr      int
+}
+
+func newGitLabMRCmd() *cobra.Command {
+	o := &gitLabMROptions{}
+	cmd := &cobra.Command{
+		Use:   "gitlab-mr <chart> [-f <values-file> ...]",
+		Short: "Post the findings as discussions on the current GitLab merge request",
+		Long: `Validate chart values like the validate command and post every finding as a discussion
+on a GitLab merge request, anchored to the line of the values file that sets the key, so it
+shows up inline in the diff. Findings on lines outside of the diff are posted together in a
+single discussion on the merge request. Discussions posted by a previous run are not repeated.
+
+The token is read from GITLAB_TOKEN. The API, project, and merge request are detected from
+the GitLab CI environment (CI_API_V4_URL, CI_PROJECT_ID, and CI_MERGE_REQUEST_IID) unless
+--project and --mr are given. File paths are resolved against CI_PROJECT_DIR, or the root of
+the Git repository.`,
+		Example: `  helm kc report gitlab-mr ./mychart
+  helm kc report gitlab-mr --charts charts/ --project acme/deploy --mr 42`,
+		Args:          chartArgs(&o.validateOptions),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args)
+		},
+	}
+	o.addReportFlags(cmd)
+	cmd.Flags().StringVar(&o.project, "project", "", "Project ID or path (default $CI_PROJECT_ID)")
+	cmd.Flags().IntVar(&o.mr, "mr", 0, "Merge request IID (default $CI_MERGE_REQUEST_IID)")
+	return cmd
+}
+
+func (o *gitLabMROptions) run(ctx context.Context, out io.Writer, args []string) error {
+	client, err := newGitLabClient()
+	if err != nil {
+		return err
+	}
+	project, mr, err := o.mergeRequest()
+	if err != nil {
+		return err
+	}
+	root, err := projectDir()
+	if err != nil {
+		return err
+	}
+	collector := &kc.Collector{}
+	issuesFound, err := o.runReport(ctx, collector, args)
+	if err != nil {
+		return err
+	}
+
+	posted, err := client.postFindings(project, mr, root, collector.Findings)
+	if err != nil {
+		return fmt.Errorf("posting discussions on merge request !%d: %w", mr, err)
+	}
+	fmt.Fprintf(out, "%d findings posted on merge request !%d, %d already posted by a previous run.\n", posted, mr, len(collector.Findings)-posted)
+	if issuesFound {
+		return errIssuesFound
+	}
+	return nil
+}
+
+// mergeRequest resolves the project and merge request from the flags or the GitLab CI
+// environment.
+func (o *gitLabMROptions) mergeRequest() (string, int, error) {
+	project := o.project
+	if project == "" {
+		project = os.Getenv("CI_PROJECT_ID")
+	}
+	if project == "" {
+		return "", 0, errors.New("no project found: set --project or CI_PROJECT_ID")
+	}
+	mr := o.mr
+	if mr == 0 {
+		mr, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	}
+	if mr == 0 {
+		return "", 0, errors.New("no merge request found: set --mr or run in a merge request pipeline")
+	}
+	return project, mr, nil
+}
+
+// projectDir returns the directory that file paths in the merge request are relative to.
+func projectDir() (string, error) {
+	if dir := os.Getenv("CI_PROJECT_DIR"); dir != "" {
+		return filepath.Abs(dir)
+	}
+	root, err := git(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(root), nil
+}
+
+// gitLabClient is a minimal client of the GitLab REST API.
+type gitLabClient struct {
+	apiClient
+}
+
+func newGitLabClient() (*gitLabClient, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return nil, errors.New("GITLAB_TOKEN is not set")
+	}
+	apiURL := os.Getenv("CI_API_V4_URL")
+	if apiURL == "" {
+		apiURL = "https://gitlab.com/api/v4"
+	}
+	return &gitLabClient{apiClient{
+		baseURL: strings.TrimSuffix(apiURL, "/"),
+		header:  http.Header{"Private-Token": {token}},
+	}}, nil
+}
+
+// gitLabPosition anchors a discussion to a line of a merge request diff.
+type gitLabPosition struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line"`
+}
+
+// gitLabDiscussion is a discussion of the GitLab API.
+type gitLabDiscussion struct {
+	Notes []struct {
+		Body string `json:"body"`
+	} `json:"notes"`
+}
+
+// postFindings posts a discussion for every finding that was not posted before, anchored to
+// the line that sets its key if that line is part of the diff, and returns the number of
+// findings posted.
+func (c *gitLabClient) postFindings(project string, mr int, root string, findings []kc.Finding) (int, error) {
+	base := fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(project), mr)
+	var request struct {
+		DiffRefs struct {
+			BaseSHA  string `json:"base_sha"`
+			StartSHA string `json:"start_sha"`
+			HeadSHA  string `json:"head_sha"`
+		} `json:"diff_refs"`
+	}
+	if err := c.do(http.MethodGet, base, nil, &request); err != nil {
+		return 0, err
+	}
+	posted, err := c.postedNotes(base)
+	if err != nil {
+		return 0, err
+	}
+
+	locator := kc.NewLocator()
+	count := 0
+	var general []string
+	for _, f := range findings {
+		note := gitLabNote(f)
+		if posted[note] {
+			continue
+		}
+		count++
+		if loc, ok := locator.Locate(f); ok {
+			if path, ok := repoPath(root, loc.File); ok {
+				position := gitLabPosition{
+					PositionType: "text",
+					BaseSHA:      request.DiffRefs.BaseSHA,
+					StartSHA:     request.DiffRefs.StartSHA,
+					HeadSHA:      request.DiffRefs.HeadSHA,
+					NewPath:      path,
+					NewLine:      loc.Line,
+				}
+				body := map[string]interface{}{"body": reportMarker + "\n" + note, "position": position}
+				err := c.do(http.MethodPost, base+"/discussions", body, &gitLabDiscussion{})
+				if err == nil {
+					continue
+				}
+				// GitLab rejects positions on lines outside of the diff.
+				var apiErr *apiError
+				if !errors.As(err, &apiErr) || apiErr.code != http.StatusBadRequest {
+					return 0, err
+				}
+			}
+		}
+		general = append(general, "- "+note)
+	}
+	if len(general) > 0 {
+		body := reportMarker + "\n" + strings.Join(general, "\n")
+		if err := c.do(http.MethodPost, base+"/discussions", map[string]string{"body": body}, &gitLabDiscussion{}); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// postedNotes returns the notes of the findings posted by previous runs on the merge request
+// at base.
+func (c *gitLabClient) postedNotes(base string) (map[string]bool, error) {
+	posted := map[string]bool{}
+	for page := 1; ; page++ {
+		var discussions []gitLabDiscussion
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s/discussions?per_page=100&page=%d", base, page), nil, &discussions); err != nil {
+			return nil, err
+		}
+		for _, discussion := range discussions {
+			if len(discussion.Notes) == 0 || !strings.HasPrefix(discussion.Notes[0].Body, reportMarker+"\n") {
+				continue
+			}
+			for _, line := range strings.Split(discussion.Notes[0].Body, "\n")[1:] {
+				posted[strings.TrimPrefix(line, "- ")] = true
+			}
+		}
+		if len(discussions) < 100 {
+			return posted, nil
+		}
+	}
+}
+
+// gitLabNote describes f in a single line of Markdown.
+func gitLabNote(f kc.Finding) string {
+	note := fmt.Sprintf("**%s** (%s) `%s`: %s", f.RuleID, f.Severity, f.Path, strings.ReplaceAll(f.Message, "\n", " "))
+	if len(f.Files) > 0 {
+		note += " in `" + strings.Join(f.Files, "` + `") + "`"
+	}
+	return note
+}
+
+// repoPath returns file relative to root with forward slashes, if it is inside of root.
+func repoPath(root, file string) (string, bool) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestGitLabMRRun verifies that findings are posted as discussions anchored to the lines that
+// set their keys, that findings outside of the diff are posted together, and that a later run
+// does not repeat them.
+func TestGitLabMRRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\nport: 80\n",
+		"envs/values.yaml":  "replicaCount: 1\nport: https\n",
+	})
+
+	var mu sync.Mutex
+	var discussions []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Private-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/acme/deploy/merge_requests/3":
+			w.Write([]byte(`{"diff_refs": {"base_sha": "a", "start_sha": "b", "head_sha": "c"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/acme/deploy/merge_requests/3/discussions":
+			var list []gitLabDiscussion
+			for _, d := range discussions {
+				var discussion gitLabDiscussion
+				discussion.Notes = append(discussion.Notes, struct {
+					Body string `json:"body"`
+				}{d["body"].(string)})
+				list = append(list, discussion)
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && r.URL.Path == "/projects/acme/deploy/merge_requests/3/discussions":
+			var d map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&d)
+			if position, ok := d["position"].(map[string]interface{}); ok && position["new_line"] != 1.0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message": "line_code can't be blank"}`))
+				return
+			}
+			discussions = append(discussions, d)
+			w.Write([]byte(`{"notes": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CI_API_V4_URL", server.URL)
+	t.Setenv("GITLAB_TOKEN", "secret")
+	t.Setenv("CI_PROJECT_ID", "acme/deploy")
+	t.Setenv("CI_MERGE_REQUEST_IID", "3")
+	t.Setenv("CI_PROJECT_DIR", dir)
+
+	for run, want := range []string{
+		"2 findings posted on merge request !3, 0 already posted by a previous run.\n",
+		"0 findings posted on merge request !3, 2 already posted by a previous run.\n",
+	} {
+		o := &gitLabMROptions{}
+		o.valuesFiles = []string{filepath.Join(dir, "envs", "values.yaml")}
+		o.jobs = 1
+		var out bytes.Buffer
+		if err := o.run(context.Background(), &out, []string{filepath.Join(dir, "chart")}); !errors.Is(err, errIssuesFound) {
+			t.Fatalf("run %d: expected issues to be found, got %v", run, err)
+		}
+		if out.String() != want {
+			t.Errorf("run %d: expected %q, got %q", run, want, out.String())
+		}
+	}
+
+	if len(discussions) != 2 {
+		t.Fatalf("expected 2 discussions, got %v", discussions)
+	}
+	position, ok := discussions[0]["position"].(map[string]interface{})
+	if !ok || position["new_path"] != "envs/values.yaml" || position["head_sha"] != "c" {
+		t.Errorf("expected the first discussion on envs/values.yaml, got %v", discussions[0])
+	}
+	if body := discussions[0]["body"].(string); !strings.Contains(body, "**KC001** (warning) `replicaCount`") {
+		t.Errorf("unexpected body of the anchored discussion:\n%s", body)
+	}
+	if _, ok := discussions[1]["position"]; ok {
+		t.Errorf("expected the second discussion on the merge request, got %v", discussions[1])
+	}
+	if body := discussions[1]["body"].(string); !strings.HasPrefix(body, reportMarker+"\n- **KC002** (error) `port`") {
+		t.Errorf("unexpected body of the general discussion:\n%s", body)
+	}
+}
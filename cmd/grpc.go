@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kaartcontrolev1 "github.com/tiulpin/kaartcontrole/api/kaartcontrole/v1"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcServer returns a gRPC server of the ValidatorService, with the health and reflection
+// services.
+func (o *serveOptions) grpcServer() *grpc.Server {
+	o.initMetrics()
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(maxRequestSize))
+	kaartcontrolev1.RegisterValidatorServiceServer(server, &grpcValidator{o: o})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	return server
+}
+
+// grpcValidator implements the ValidatorService with the validation of the HTTP API.
+type grpcValidator struct {
+	kaartcontrolev1.UnimplementedValidatorServiceServer
+
+	o *serveOptions
+}
+
+func (s *grpcValidator) ValidateChart(ctx context.Context, req *kaartcontrolev1.ValidateChartRequest) (*kaartcontrolev1.ValidateChartResponse, error) {
+	start := time.Now()
+	collector := &kc.Collector{}
+	passed, err := s.o.validateRequest(ctx, validateRequestFromProto(req), collector)
+	if err != nil {
+		s.o.metrics.observe(start, nil)
+		return nil, grpcError(err)
+	}
+	s.o.metrics.observe(start, &validateResponse{Findings: collector.Findings, Passed: passed})
+	resp := &kaartcontrolev1.ValidateChartResponse{Passed: passed}
+	for _, f := range collector.Findings {
+		resp.Findings = append(resp.Findings, findingToProto(f))
+	}
+	return resp, nil
+}
+
+func (s *grpcValidator) StreamFindings(req *kaartcontrolev1.ValidateChartRequest, stream grpc.ServerStreamingServer[kaartcontrolev1.Finding]) error {
+	start := time.Now()
+	reporter := &streamReporter{stream: stream}
+	passed, err := s.o.validateRequest(stream.Context(), validateRequestFromProto(req), reporter)
+	if err == nil {
+		err = reporter.err
+	}
+	if err != nil {
+		s.o.metrics.observe(start, nil)
+		return grpcError(err)
+	}
+	s.o.metrics.observe(start, &validateResponse{Findings: reporter.Findings, Passed: passed})
+	return nil
+}
+
+func (s *grpcValidator) DiscoverPairs(ctx context.Context, req *kaartcontrolev1.DiscoverPairsRequest) (*kaartcontrolev1.DiscoverPairsResponse, error) {
+	if req.GetChart() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing chart")
+	}
+	if err := s.o.checkChart(req.GetChart(), ""); err != nil {
+		return nil, grpcError(err)
+	}
+	if len(req.GetBaseDirs()) > 0 && !s.o.allowLocalCharts {
+		return nil, grpcError(fmt.Errorf("%w: base directories are on the file system of the server, see --allow-local-charts", errChartNotAllowed))
+	}
+	v := s.o.validateOptions
+	if len(req.GetBaseDirs()) > 0 {
+		v.baseDirs = req.GetBaseDirs()
+	}
+	sets, _, err := v.discoverSets(ctx, req.GetChart())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &kaartcontrolev1.DiscoverPairsResponse{}
+	for _, set := range sets {
+		resp.Sets = append(resp.Sets, &kaartcontrolev1.ValuesSet{
+			Service:   set.Service,
+			Overrides: set.Overrides,
+			Fragments: set.Fragments,
+			Skipped:   set.Skipped,
+		})
+	}
+	return resp, nil
+}
+
+// streamReporter sends findings on a stream as they are reported. Once sending fails, the
+// remaining findings are dropped and the error is kept.
+type streamReporter struct {
+	kc.Collector
+
+	stream grpc.ServerStreamingServer[kaartcontrolev1.Finding]
+	err    error
+}
+
+func (r *streamReporter) Report(f kc.Finding) {
+	r.Collector.Report(f)
+	if r.err == nil {
+		r.err = r.stream.Send(findingToProto(f))
+	}
+}
+
+// validateRequestFromProto converts a request of the gRPC API to one of the HTTP API.
+func validateRequestFromProto(req *kaartcontrolev1.ValidateChartRequest) validateRequest {
+	r := validateRequest{
+		Chart:   req.GetChart(),
+		Version: req.GetVersion(),
+		Repo:    req.GetRepo(),
+		Set:     req.GetSet(),
+		Ignore:  req.GetIgnore(),
+		Enable:  req.GetEnable(),
+		Disable: req.GetDisable(),
+	}
+	for _, file := range req.GetValues() {
+		r.Values = append(r.Values, valuesPayload{Name: file.GetName(), Content: string(file.GetContent())})
+	}
+	if req.GetFailOn() != kaartcontrolev1.Severity_SEVERITY_UNSPECIFIED {
+		r.FailOn = strings.ToLower(strings.TrimPrefix(req.GetFailOn().String(), "SEVERITY_"))
+	}
+	return r
+}
+
+// findingToProto converts a finding to its message in the gRPC API.
+func findingToProto(f kc.Finding) *kaartcontrolev1.Finding {
+	severity := kaartcontrolev1.Severity_value["SEVERITY_"+strings.ToUpper(string(f.Severity))]
+	return &kaartcontrolev1.Finding{
+		RuleId:       f.RuleID,
+		Severity:     kaartcontrolev1.Severity(severity),
+		Path:         f.Path,
+		Message:      f.Message,
+		DefaultValue: valueToProto(f.Default),
+		Value:        valueToProto(f.Value),
+		Files:        f.Files,
+	}
+}
+
+// valueToProto converts a values value to a protobuf Value, or nil if it is not set. Values
+// that have no JSON representation are converted to their string form.
+func valueToProto(v interface{}) *structpb.Value {
+	if v == nil {
+		return nil
+	}
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+	return value
+}
+
+// grpcError returns the status of an error of a request, with the codes matching the
+// statuses of the HTTP API.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, errChartNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, new(*loadError)):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
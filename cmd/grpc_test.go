@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	kaartcontrolev1 "github.com/tiulpin/kaartcontrole/api/kaartcontrole/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestGRPC verifies the gRPC API against a server on an in-memory connection.
+func TestGRPC(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":                "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml":               "replicaCount: 1\nimage:\n  tag: latest\n",
+		"envs/overrides.yaml":                   "image:\n  tag: \"1.25\"\n",
+		"envs/production/web_service.yaml":      "replicaCount: 3\n",
+		"envs/staging/nested/web_service.yaml":  "replicaCount: 2\n",
+		"unrelated/production/web_service.yaml": "replicaCount: 2\n",
+	})
+	chart := filepath.Join(dir, "web_service")
+	o := &serveOptions{allowLocalCharts: true}
+	o.jobs = 1
+	server := o.grpcServer()
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := kaartcontrolev1.NewValidatorServiceClient(conn)
+	ctx := context.Background()
+
+	req := &kaartcontrolev1.ValidateChartRequest{
+		Chart:  chart,
+		Values: []*kaartcontrolev1.ValuesFile{{Name: "prod.yaml", Content: []byte("replicaCount: 1\nimage:\n  tag: v2\n")}},
+	}
+	resp, err := client.ValidateChart(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetPassed() || len(resp.GetFindings()) != 1 {
+		t.Fatalf("expected a failing finding, got %v", resp)
+	}
+	f := resp.GetFindings()[0]
+	if f.GetRuleId() != "KC001" || f.GetSeverity() != kaartcontrolev1.Severity_SEVERITY_WARNING || f.GetPath() != "replicaCount" ||
+		f.GetValue().GetNumberValue() != 1 || len(f.GetFiles()) != 1 || f.GetFiles()[0] != "prod.yaml" {
+		t.Errorf("unexpected finding: %v", f)
+	}
+
+	req.FailOn = kaartcontrolev1.Severity_SEVERITY_ERROR
+	stream, err := client.StreamFindings(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed []*kaartcontrolev1.Finding
+	for {
+		f, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed = append(streamed, f)
+	}
+	if len(streamed) != 1 || streamed[0].GetPath() != "replicaCount" {
+		t.Errorf("expected the finding to be streamed, got %v", streamed)
+	}
+
+	_, err = client.ValidateChart(ctx, &kaartcontrolev1.ValidateChartRequest{Chart: filepath.Join(dir, "missing"), Values: req.Values})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected a missing chart to fail its precondition, got %v", err)
+	}
+	_, err = client.ValidateChart(ctx, &kaartcontrolev1.ValidateChartRequest{Chart: chart})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected a request without values to be invalid, got %v", err)
+	}
+
+	pairs, err := client.DiscoverPairs(ctx, &kaartcontrolev1.DiscoverPairsRequest{Chart: chart, BaseDirs: []string{filepath.Join(dir, "envs")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs.GetSets()) != 2 {
+		t.Errorf("expected the 2 service files below envs, got %v", pairs.GetSets())
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultHelmfiles are the state files looked up when no path is given, in helmfile's order.
+var defaultHelmfiles = []string{"helmfile.yaml", "helmfile.yaml.gotmpl", "helmfile.d"}
+
+// helmfileState is the subset of a helmfile state file needed to validate releases.
+type helmfileState struct {
+	Bases        []string                       `json:"bases"`
+	Helmfiles    []interface{}                  `json:"helmfiles"`
+	Environments map[string]helmfileEnvironment `json:"environments"`
+	Repositories []helmfileRepository           `json:"repositories"`
+	Releases     []helmfileRelease              `json:"releases"`
+}
+
+type helmfileEnvironment struct {
+	Values  []interface{} `json:"values"`
+	Secrets []interface{} `json:"secrets"`
+}
+
+type helmfileRepository struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	OCI      bool   `json:"oci"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type helmfileRelease struct {
+	Name               string             `json:"name"`
+	Namespace          string             `json:"namespace"`
+	Chart              string             `json:"chart"`
+	Version            string             `json:"version"`
+	Labels             map[string]string  `json:"labels"`
+	Installed          *bool              `json:"installed"`
+	Values             []interface{}      `json:"values"`
+	Secrets            []interface{}      `json:"secrets"`
+	Set                []helmfileSetValue `json:"set"`
+	MissingFileHandler string             `json:"missingFileHandler"`
+}
+
+type helmfileSetValue struct {
+	Name   string        `json:"name"`
+	Value  interface{}   `json:"value"`
+	Values []interface{} `json:"values"`
+	File   string        `json:"file"`
+}
+
+// helmfileLoader reads helmfile state files for a single environment. Rendered and inline
+// values documents are stored in documents under synthetic names, so they can be read like
+// any other values file. Environment values files are read with reader, which decrypts
+// environment secrets.
+type helmfileLoader struct {
+	environment string
+	documents   map[string][]byte
+	cwd         string
+	reader      *valuesReader
+}
+
+// loadHelmfile reads the state file or helmfile.d directory at path and returns its releases.
+func (l *helmfileLoader) loadHelmfile(path string) ([]resolvedRelease, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return l.loadStateFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var releases []resolvedRelease
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yaml.gotmpl")) {
+			continue
+		}
+		fileReleases, err := l.loadStateFile(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, fileReleases...)
+	}
+	return releases, nil
+}
+
+// loadStateFile renders every "---" separated part of a state file in turn. Environment
+// values declared in one part are available to the templates of the following parts.
+func (l *helmfileLoader) loadStateFile(path string) ([]resolvedRelease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+
+	envValues := map[string]interface{}{}
+	repositories := map[string]helmfileRepository{}
+	var releases []resolvedRelease
+	for i, part := range splitDocuments(data) {
+		rendered, err := l.render(fmt.Sprintf("%s#%d", path, i), part, dir, l.templateData(envValues, nil))
+		if err != nil {
+			return nil, err
+		}
+		var state helmfileState
+		if err := yaml.Unmarshal(rendered, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		for _, base := range state.Bases {
+			baseReleases, err := l.loadStateFile(filepath.Join(dir, base))
+			if err != nil {
+				return nil, fmt.Errorf("loading base %s: %w", base, err)
+			}
+			releases = append(releases, baseReleases...)
+		}
+		if env, ok := state.Environments[l.environment]; ok {
+			// Secrets are layered over the values, like helmfile does.
+			for _, field := range []helmfileValuesField{{"values", env.Values}, {"secrets", env.Secrets}} {
+				for j, entry := range field.entries {
+					values, err := l.environmentValues(fmt.Sprintf("%s#environments.%s.%s[%d]", l.display(path), l.environment, field.name, j), entry, dir, envValues)
+					if err != nil {
+						return nil, err
+					}
+					envValues = mergeMaps(envValues, values)
+				}
+			}
+		}
+		for _, repo := range state.Repositories {
+			repositories[repo.Name] = repo
+		}
+		for _, release := range state.Releases {
+			if release.Installed != nil && !*release.Installed {
+				continue
+			}
+			resolved, err := l.resolveRelease(path, dir, release, repositories, envValues)
+			if err != nil {
+				return nil, fmt.Errorf("release %s: %w", release.Name, err)
+			}
+			releases = append(releases, resolved)
+		}
+		for _, nested := range state.Helmfiles {
+			nestedPath, ok := nestedHelmfilePath(nested)
+			if !ok {
+				continue
+			}
+			nestedReleases, err := l.loadHelmfile(filepath.Join(dir, nestedPath))
+			if err != nil {
+				return nil, fmt.Errorf("loading helmfile %s: %w", nestedPath, err)
+			}
+			releases = append(releases, nestedReleases...)
+		}
+	}
+	return releases, nil
+}
+
+// helmfileValuesField is a list of values entries of an environment or a release, either
+// its values or its secrets.
+type helmfileValuesField struct {
+	name    string
+	entries []interface{}
+}
+
+// nestedHelmfilePath returns the path of an entry under "helmfiles", which is either
+// a plain path or a map with a "path" key.
+func nestedHelmfilePath(entry interface{}) (string, bool) {
+	switch e := entry.(type) {
+	case string:
+		return e, true
+	case map[string]interface{}:
+		path, ok := e["path"].(string)
+		return path, ok
+	}
+	return "", false
+}
+
+// environmentValues loads one entry of an environment's values: a file, a template, or an inline map.
+func (l *helmfileLoader) environmentValues(name string, entry interface{}, dir string, envValues map[string]interface{}) (map[string]interface{}, error) {
+	file, err := l.valuesDocument(name, entry, dir, l.templateData(envValues, nil))
+	if err != nil {
+		return nil, err
+	}
+	data, ok := l.documents[file]
+	if !ok {
+		if data, err = l.reader.read(file); err != nil {
+			return nil, err
+		}
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return values, nil
+}
+
+// resolveRelease resolves the chart reference and values of a release.
+func (l *helmfileLoader) resolveRelease(path, dir string, release helmfileRelease, repositories map[string]helmfileRepository, envValues map[string]interface{}) (resolvedRelease, error) {
+	r := resolvedRelease{
+		name:      release.Name,
+		namespace: release.Namespace,
+		labels:    release.Labels,
+		chart:     release.Chart,
+		version:   release.Version,
+	}
+
+	if filepath.IsAbs(release.Chart) {
+		r.chart = release.Chart
+	} else if isLocalChart(dir, release.Chart) {
+		r.chart = filepath.Join(dir, release.Chart)
+	} else if repoName, chartName, ok := strings.Cut(release.Chart, "/"); ok {
+		if repo, ok := repositories[repoName]; ok {
+			if repo.OCI {
+				r.chart = "oci://" + strings.TrimSuffix(repo.URL, "/") + "/" + chartName
+			} else {
+				r.chart = chartName
+				r.repoURL = repo.URL
+				r.username = repo.Username
+				r.password = repo.Password
+			}
+		}
+	}
+
+	data := l.templateData(envValues, &release)
+	// Secrets are layered over the values, like helmfile does, and read like any other values
+	// file, so the values reader decrypts them.
+	for _, field := range []helmfileValuesField{{"values", release.Values}, {"secrets", release.Secrets}} {
+		for i, entry := range field.entries {
+			name := fmt.Sprintf("%s#releases.%s.%s[%d]", l.display(path), release.Name, field.name, i)
+			file, err := l.valuesDocument(name, entry, dir, data)
+			if err != nil {
+				return r, err
+			}
+			if _, ok := l.documents[file]; !ok {
+				if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) && release.MissingFileHandler != "" && release.MissingFileHandler != "Error" {
+					r.missingFiles = append(r.missingFiles, file)
+					continue
+				}
+			}
+			r.valuesFiles = append(r.valuesFiles, file)
+		}
+	}
+
+	for _, set := range release.Set {
+		switch {
+		case set.File != "":
+			r.fileValues = append(r.fileValues, set.Name+"="+l.display(filepath.Join(dir, set.File)))
+		case set.Values != nil:
+			items := make([]string, 0, len(set.Values))
+			for _, v := range set.Values {
+				items = append(items, escapeSetValue(fmt.Sprint(v)))
+			}
+			r.setValues = append(r.setValues, set.Name+"={"+strings.Join(items, ",")+"}")
+		default:
+			r.setValues = append(r.setValues, set.Name+"="+escapeSetValue(fmt.Sprint(set.Value)))
+		}
+	}
+	return r, nil
+}
+
+// valuesDocument returns the name under which a values entry can be read. Templates are
+// rendered and inline maps are marshaled into l.documents; plain files are returned as paths.
+func (l *helmfileLoader) valuesDocument(name string, entry interface{}, dir string, data map[string]interface{}) (string, error) {
+	switch e := entry.(type) {
+	case string:
+		if isChartURL(e) {
+			return e, nil
+		}
+		file := e
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		file = l.display(file)
+		if !strings.HasSuffix(file, ".gotmpl") {
+			return file, nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		rendered, err := l.render(file, content, dir, data)
+		if err != nil {
+			return "", err
+		}
+		l.documents[file] = rendered
+		return file, nil
+	case map[string]interface{}:
+		content, err := yaml.Marshal(e)
+		if err != nil {
+			return "", err
+		}
+		l.documents[name] = content
+		return name, nil
+	default:
+		return "", fmt.Errorf("unsupported values entry in %s: %v", name, entry)
+	}
+}
+
+// render executes a helmfile template with sprig and helmfile's environment functions.
+func (l *helmfileLoader) render(name string, content []byte, dir string, data map[string]interface{}) ([]byte, error) {
+	if !bytes.Contains(content, []byte("{{")) {
+		return content, nil
+	}
+	funcs := sprig.TxtFuncMap()
+	funcs["requiredEnv"] = func(name string) (string, error) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("required env var `%s` is not set", name)
+	}
+	funcs["readFile"] = func(file string) (string, error) {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		b, err := os.ReadFile(file)
+		return string(b), err
+	}
+	funcs["toYaml"] = func(v interface{}) (string, error) {
+		b, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(b), "\n"), err
+	}
+	funcs["fromYaml"] = func(s string) (map[string]interface{}, error) {
+		m := map[string]interface{}{}
+		err := yaml.Unmarshal([]byte(s), &m)
+		return m, err
+	}
+
+	tmpl, err := template.New(filepath.Base(name)).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateData returns the data available to helmfile templates. Release is only set for
+// release values templates.
+func (l *helmfileLoader) templateData(envValues map[string]interface{}, release *helmfileRelease) map[string]interface{} {
+	data := map[string]interface{}{
+		"Environment": map[string]interface{}{
+			"Name":   l.environment,
+			"Values": envValues,
+		},
+		"Values":      envValues,
+		"StateValues": envValues,
+	}
+	if release != nil {
+		data["Release"] = map[string]interface{}{
+			"Name":      release.Name,
+			"Namespace": release.Namespace,
+			"Chart":     release.Chart,
+			"Labels":    release.Labels,
+		}
+	}
+	return data
+}
+
+// display returns path relative to the working directory, matching how values files given
+// with -f are reported.
+func (l *helmfileLoader) display(path string) string {
+	if isChartURL(path) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return relPath(l.cwd, abs)
+}
+
+// isLocalChart reports whether a release chart refers to a directory or archive on disk.
+func isLocalChart(dir, chart string) bool {
+	if strings.HasPrefix(chart, "./") || strings.HasPrefix(chart, "../") || filepath.IsAbs(chart) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, chart))
+	return err == nil
+}
+
+// escapeSetValue escapes the characters --set treats as separators.
+func escapeSetValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(value)
+}
+
+// splitDocuments splits a state file into its "---" separated parts. Templates may not be
+// valid YAML before rendering, so the split is done on the raw text.
+func splitDocuments(data []byte) [][]byte {
+	var parts [][]byte
+	var current []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimRight(line, " \t\r") == "---" {
+			parts = append(parts, []byte(strings.Join(current, "\n")))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	parts = append(parts, []byte(strings.Join(current, "\n")))
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		var node yamlv3.Node
+		if len(bytes.TrimSpace(part)) == 0 || (yamlv3.Unmarshal(part, &node) == nil && len(node.Content) == 0) {
+			continue
+		}
+		nonEmpty = append(nonEmpty, part)
+	}
+	return nonEmpty
+}
+
+// matchesSelectors reports whether a release matches any of the helmfile-style selectors.
+// Each selector is a comma-separated list of key=value or key!=value terms that must all
+// hold; name and namespace are matched like labels.
+func matchesSelectors(r resolvedRelease, selectors []string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+	labels := map[string]string{"name": r.name, "namespace": r.namespace}
+	for k, v := range r.labels {
+		labels[k] = v
+	}
+	for _, selector := range selectors {
+		matched := true
+		for _, term := range strings.Split(selector, ",") {
+			if key, value, ok := strings.Cut(term, "!="); ok {
+				matched = matched && labels[strings.TrimSpace(key)] != strings.TrimSpace(value)
+			} else if key, value, ok := strings.Cut(term, "="); ok {
+				matched = matched && labels[strings.TrimSpace(key)] == strings.TrimSpace(value)
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// helmfileOptions holds the flags of the helmfile command.
+type helmfileOptions struct {
+	validateOptions
+
+	environment string
+	selectors   []string
+}
+
+func newHelmfileCmd() *cobra.Command {
+	o := &helmfileOptions{}
+	cmd := &cobra.Command{
+		Use:   "helmfile [path]",
+		Short: "Validate the values of every release in a helmfile",
+		Long: `Validate the layered values of every release in a helmfile against its chart.
+
+The path may be a state file or a helmfile.d directory and defaults to helmfile.yaml,
+helmfile.yaml.gotmpl, or helmfile.d in the working directory. Environment values, bases,
+nested helmfiles, and .gotmpl templates are resolved for the selected environment.
+Values files given with -f are layered on top of every release's values.`,
+		Example: `  helm kc helmfile
+  helm kc helmfile deploy/helmfile.yaml -e production -l name=web`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return o.run(cmd.Context(), cmd.OutOrStdout(), path)
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.environment, "environment", "e", "default", "helmfile environment whose values are used")
+	cmd.Flags().StringArrayVarP(&o.selectors, "selector", "l", nil, "Only validate releases matching the selector, e.g. name=web or tier=frontend,env!=dev (can be specified multiple times)")
+	return cmd
+}
+
+// findHelmfile returns the explicitly requested state file or the first default one that exists.
+func findHelmfile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	for _, candidate := range defaultHelmfiles {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no state file found (tried %s)", strings.Join(defaultHelmfiles, ", "))
+}
+
+func (o *helmfileOptions) run(ctx context.Context, out io.Writer, path string) error {
+	path, err := findHelmfile(path)
+	if err != nil {
+		return err
+	}
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("determining current directory: %w", err)
+	}
+
+	// Rendered templates and inline values are read through the shared values reader.
+	if o.reader.cache == nil {
+		o.reader.cache = map[string][]byte{}
+	}
+	loader := &helmfileLoader{environment: o.environment, documents: o.reader.cache, cwd: cwd, reader: &o.reader}
+	releases, err := loader.loadHelmfile(path)
+	if err != nil {
+		return fmt.Errorf("loading helmfile: %w", err)
+	}
+
+	var selected []resolvedRelease
+	for _, release := range releases {
+		if matchesSelectors(release, o.selectors) {
+			selected = append(selected, release)
+		}
+	}
+	issuesFound := o.validateReleases(ctx, out, "Release", selected, opts, reporter)
+
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates the given files, relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// TestLoadHelmfile verifies that releases are resolved with their charts, environment-specific
+// values files, rendered templates, inline values, and set entries.
+func TestLoadHelmfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"helmfile.yaml": `environments:
+  production:
+    values:
+      - env/production.yaml
+---
+repositories:
+  - name: bitnami
+    url: https://charts.bitnami.com/bitnami
+  - name: internal
+    url: registry.example.com/charts
+    oci: true
+releases:
+  - name: web
+    chart: ./charts/web_service
+    labels:
+      tier: frontend
+    values:
+      - values/common.yaml
+      - values/{{ .Environment.Name }}.yaml
+      - values/web.yaml.gotmpl
+      - replicaCount: {{ .Values.replicas }}
+    set:
+      - name: image.tag
+        value: 1.0,beta
+  - name: cache
+    chart: bitnami/redis
+    version: 19.0.0
+    missingFileHandler: Warn
+    values:
+      - values/redis.yaml
+  - name: api
+    chart: internal/api
+  - name: legacy
+    chart: bitnami/nginx
+    installed: false
+`,
+		"env/production.yaml":    "replicas: 3\ndomain: example.com\n",
+		"values/common.yaml":     "resources: {}\n",
+		"values/production.yaml": "ingress:\n  enabled: true\n",
+		"values/web.yaml.gotmpl": "ingress:\n  host: web.{{ .Values.domain }}\n  release: {{ .Release.Name }}\n",
+	})
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	loader := &helmfileLoader{environment: "production", documents: map[string][]byte{}, cwd: dir, reader: &valuesReader{}}
+	releases, err := loader.loadHelmfile("helmfile.yaml")
+	if err != nil {
+		t.Fatalf("loadHelmfile() returned error: %v", err)
+	}
+	if len(releases) != 3 {
+		t.Fatalf("expected 3 installed releases, got %d", len(releases))
+	}
+
+	web := releases[0]
+	if web.chart != filepath.Join("charts", "web_service") {
+		t.Errorf("unexpected chart for web: %s", web.chart)
+	}
+	wantFiles := []string{
+		filepath.Join("values", "common.yaml"),
+		filepath.Join("values", "production.yaml"),
+		filepath.Join("values", "web.yaml.gotmpl"),
+		"helmfile.yaml#releases.web.values[3]",
+	}
+	if !reflect.DeepEqual(web.valuesFiles, wantFiles) {
+		t.Errorf("unexpected values files for web:\n got: %v\nwant: %v", web.valuesFiles, wantFiles)
+	}
+	if got := string(loader.documents[wantFiles[2]]); got != "ingress:\n  host: web.example.com\n  release: web\n" {
+		t.Errorf("unexpected rendered template: %q", got)
+	}
+	if got := string(loader.documents[wantFiles[3]]); got != "replicaCount: 3\n" {
+		t.Errorf("unexpected inline values: %q", got)
+	}
+	if !reflect.DeepEqual(web.setValues, []string{`image.tag=1.0\,beta`}) {
+		t.Errorf("unexpected set values for web: %v", web.setValues)
+	}
+
+	cache := releases[1]
+	if cache.chart != "redis" || cache.version != "19.0.0" || cache.repoURL != "https://charts.bitnami.com/bitnami" {
+		t.Errorf("unexpected chart for cache: %+v", cache)
+	}
+	if len(cache.valuesFiles) != 0 || len(cache.missingFiles) != 1 {
+		t.Errorf("expected the missing values file to be skipped, got %+v", cache)
+	}
+
+	if api := releases[2]; api.chart != "oci://registry.example.com/charts/api" {
+		t.Errorf("unexpected chart for api: %s", api.chart)
+	}
+
+	if !matchesSelectors(web, []string{"tier=frontend"}) || matchesSelectors(cache, []string{"tier=frontend"}) {
+		t.Errorf("selector tier=frontend should only match web")
+	}
+	if !matchesSelectors(cache, []string{"name=web", "name=cache"}) {
+		t.Errorf("selectors should be ORed")
+	}
+}
+
+// TestLoadHelmfileSecrets verifies that the secrets of environments and releases are layered
+// over their values, and that SOPS-encrypted ones are decrypted.
+func TestLoadHelmfileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"helmfile.yaml": `environments:
+  production:
+    values:
+      - env/production.yaml
+    secrets:
+      - env/production.secrets.yaml
+---
+releases:
+  - name: web
+    chart: ./charts/web_service
+    values:
+      - values/web.yaml
+      - dbHost: {{ .Values.db.host }}
+    secrets:
+      - secrets/web.yaml
+`,
+		"env/production.yaml":         "db:\n  host: db.internal\n",
+		"env/production.secrets.yaml": "db:\n  host: ENC[AES256_GCM,data:abc,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:def,type:str]\n  version: 3.9.0\n",
+		"values/web.yaml":             "replicaCount: 2\n",
+		"secrets/web.yaml":            "password: hunter2\n",
+	})
+	// A fake sops binary that prints the decrypted environment secrets.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "sops"), []byte("#!/bin/sh\necho 'db: {host: db.example.com}'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	loader := &helmfileLoader{environment: "production", documents: map[string][]byte{}, cwd: dir, reader: &valuesReader{}}
+	releases, err := loader.loadHelmfile("helmfile.yaml")
+	if err != nil {
+		t.Fatalf("loadHelmfile() returned error: %v", err)
+	}
+	if len(releases) != 1 {
+		t.Fatalf("expected 1 release, got %d", len(releases))
+	}
+	wantFiles := []string{
+		filepath.Join("values", "web.yaml"),
+		"helmfile.yaml#releases.web.values[1]",
+		filepath.Join("secrets", "web.yaml"),
+	}
+	if !reflect.DeepEqual(releases[0].valuesFiles, wantFiles) {
+		t.Errorf("unexpected values files for web:\n got: %v\nwant: %v", releases[0].valuesFiles, wantFiles)
+	}
+	if got := string(loader.documents[wantFiles[1]]); got != "dbHost: db.example.com\n" {
+		t.Errorf("expected the decrypted environment secrets to override the values, got %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+// historyOptions holds the flags of the history command.
+type historyOptions struct {
+	validateOptions
+
+	namespace string
+	max       int
+}
+
+func newHistoryCmd() *cobra.Command {
+	o := &historyOptions{}
+	cmd := &cobra.Command{
+		Use:   "history <release>",
+		Short: "Compare user-supplied values across release revisions",
+		Long: `Show how the user-supplied values of a release changed between its revisions.
+
+Values that went back to the chart default, or changed type, from one revision to the
+next are reported as findings.`,
+		Example: `  helm kc history web -n production
+  helm kc history web --max 5`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the release (default $HELM_NAMESPACE, set by helm -n, or the kube context)")
+	cmd.Flags().IntVar(&o.max, "max", 256, "Maximum number of revisions to compare")
+	return cmd
+}
+
+func (o *historyOptions) run(ctx context.Context, out io.Writer, name string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	actionConfig, err := newActionConfig(o.namespace)
+	if err != nil {
+		return err
+	}
+	issuesFound, err := o.compareHistory(out, actionConfig, name, opts, reporter)
+	if err != nil {
+		return err
+	}
+
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
+
+// compareHistory prints the values changes between the revisions of a release and reports
+// findings for them. It returns true if any failing issues were found.
+func (o *historyOptions) compareHistory(out io.Writer, actionConfig *action.Configuration, name string, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	history := action.NewHistory(actionConfig)
+	history.Max = o.max
+	releases, err := history.Run(name)
+	if err != nil {
+		return false, fmt.Errorf("fetching history of %s: %w", name, err)
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Version < releases[j].Version })
+
+	revisions := make([]kc.Revision, 0, len(releases))
+	for _, rel := range releases {
+		revisions = append(revisions, kc.Revision{Number: rel.Version, Chart: rel.Chart, Values: rel.Config})
+	}
+
+	if o.output == "text" {
+		for i, rel := range releases {
+			fmt.Fprintf(out, "\nRevision %d (%s-%s, %s)\n", rel.Version, rel.Chart.Name(), rel.Chart.Metadata.Version, rel.Info.Status)
+			if i == 0 {
+				continue
+			}
+			for _, change := range kc.DiffValues(releases[i-1].Config, rel.Config) {
+				switch {
+				case change.Added:
+					fmt.Fprintf(out, "  + %s: %v\n", change.Path, change.New)
+				case change.Removed:
+					fmt.Fprintf(out, "  - %s: %v\n", change.Path, change.Old)
+				default:
+					fmt.Fprintf(out, "  ~ %s: %v -> %v\n", change.Path, change.Old, change.New)
+				}
+			}
+		}
+		fmt.Fprintln(out)
+	}
+
+	if len(releases) > 0 {
+		opts.Files = []string{fmt.Sprintf("release:%s/%s", releases[0].Namespace, releases[0].Name)}
+	}
+	return kc.CheckHistory(revisions, opts, reporter)
+}
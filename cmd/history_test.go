@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// TestCompareHistory verifies that the values changes between revisions are printed and
+// that values reverted to their defaults are reported.
+func TestCompareHistory(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "1.0.0"},
+		Values:   map[string]interface{}{"replicaCount": 1},
+	}
+	newRelease := func(version int, config map[string]interface{}, status release.Status) *release.Release {
+		return &release.Release{Name: "web", Namespace: "default", Version: version, Chart: c, Config: config, Info: &release.Info{Status: status}}
+	}
+	actionConfig := newTestActionConfig(t,
+		newRelease(1, map[string]interface{}{"replicaCount": 3}, release.StatusSuperseded),
+		newRelease(2, map[string]interface{}{"replicaCount": 1, "debug": true}, release.StatusDeployed),
+	)
+
+	o := &historyOptions{max: 256}
+	o.output = "text"
+	var out bytes.Buffer
+	collector := &kc.Collector{}
+	if _, err := o.compareHistory(&out, actionConfig, "web", kc.Options{}, collector); err != nil {
+		t.Fatalf("compareHistory() returned error: %v", err)
+	}
+
+	for _, want := range []string{"Revision 2 (web_service-1.0.0, deployed)", "  + debug: true", "  ~ replicaCount: 3 -> 1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if len(collector.Findings) != 1 || collector.Findings[0].RuleID != kc.RuleRevertedToDefault {
+		t.Errorf("expected replicaCount to be reported as reverted to default, got %+v", collector.Findings)
+	}
+}
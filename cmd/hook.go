@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// hookMarker identifies the pre-commit hooks written by kc hook install.
+const hookMarker = "# Installed by kc hook install."
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Validate staged values files in a Git pre-commit hook",
+	}
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookRunCmd())
+	return cmd
+}
+
+func newHookInstallCmd() *cobra.Command {
+	force := false
+	cmd := &cobra.Command{
+		Use:   "install <chart> [-- <hook run flags>...]",
+		Short: "Install a Git pre-commit hook that runs kc hook run",
+		Long: `Write the pre-commit hook of the Git repository in the working directory, so every commit
+validates the values sets with staged files first. Arguments after the chart are passed
+on to kc hook run. An existing hook is only replaced with --force, unless it was written
+by kc hook install.`,
+		Example: `  helm kc hook install ./charts/web_service
+  helm kc hook install ./charts/web_service -- --config envs/.kaartcontrole.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return installHook(cmd.OutOrStdout(), args, force)
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing pre-commit hook")
+	return cmd
+}
+
+func newHookRunCmd() *cobra.Command {
+	o := &validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "run <chart> [<file>...]",
+		Short: "Validate the values sets with staged files, with terse output",
+		Long: `Validate the values sets with a file staged in git, using the staged contents of the files,
+and print one line per finding on the key that causes it. Without staged values files or
+chart files, kc exits right away; by default, it stops at the first error.
+
+This is the entry point of the pre-commit hook of kc hook install, and of the kc hook of
+.pre-commit-hooks.yaml: files given after the chart are taken as the changed files
+instead of the staged ones, as pre-commit stashes the unstaged changes itself.`,
+		Example: `  helm kc hook run ./charts/web_service
+  helm kc hook run ./charts/web_service envs/prod/web_service.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("fail-fast") {
+				o.failFast = true
+			}
+			o.changed.staged = len(args) == 1
+			if len(args) > 1 {
+				o.changed.paths = args[1:]
+			}
+			return o.runHook(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addJobsFlag(cmd.Flags())
+	return cmd
+}
+
+// runHook validates the values sets with changed files, unless no values or chart file
+// changed at all, and prints the findings with hookReporter.
+func (o *validateOptions) runHook(ctx context.Context, out io.Writer, chartPath string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("determining current directory: %w", err)
+	}
+	changed, err := o.changed.files(cwd)
+	if err != nil {
+		return fmt.Errorf("finding changed files: %w", err)
+	}
+	if !hookRelevant(chartPath, changed) {
+		return nil
+	}
+	o.begin(&hookReporter{w: out, locator: kc.NewLocator()})
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	issuesFound, err := o.validate(ctx, out, chartPath, opts, reporter, false)
+	if err != nil {
+		return err
+	}
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
+
+// hookRelevant reports whether any of the changed files is a YAML file or a file of the
+// chart, which could change the findings.
+func hookRelevant(chartPath string, changed map[string]bool) bool {
+	chartDir := ""
+	if info, err := os.Stat(chartPath); err == nil && info.IsDir() {
+		chartDir, _ = filepath.Abs(chartPath)
+	}
+	for file := range changed {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml":
+			return true
+		}
+		if chartDir != "" && isWithin(chartDir, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// hookReporter prints each finding on one line, prefixed with the file and line of the key
+// that causes it, like compiler errors.
+type hookReporter struct {
+	w       io.Writer
+	locator *kc.Locator
+	count   int
+}
+
+func (r *hookReporter) Report(f kc.Finding) {
+	r.count++
+	where := ""
+	if loc, ok := r.locator.Locate(f); ok {
+		where = fmt.Sprintf("%s:%d: ", loc.File, loc.Line)
+	} else if len(f.Files) > 0 {
+		where = f.Files[len(f.Files)-1] + ": "
+	}
+	fmt.Fprintf(r.w, "%s%s: %s [%s]\n", where, f.Severity, f.Message, f.RuleID)
+}
+
+func (r *hookReporter) Summary() {
+	if r.count > 0 {
+		fmt.Fprintf(r.w, "kc: %d findings in staged values files\n", r.count)
+	}
+}
+
+// installHook writes the pre-commit hook of the repository in the working directory, running
+// kc hook run with args.
+func installHook(out io.Writer, args []string, force bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("determining current directory: %w", err)
+	}
+	// The hooks directory respects core.hooksPath and linked worktrees.
+	hooksPath, err := git(cwd, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	dir := strings.TrimSpace(hooksPath)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+	}
+	path := filepath.Join(dir, "pre-commit")
+	if existing, err := os.ReadFile(path); err == nil && !force && !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s already exists; use --force to replace it", path)
+	}
+
+	// As a Helm plugin, the hook goes through helm, so it keeps working after updates.
+	command := []string{"helm", "kc"}
+	if os.Getenv("HELM_PLUGIN_DIR") == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		command = []string{exe}
+	}
+	command = append(command, "hook", "run")
+	command = append(command, args...)
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	script := "#!/bin/sh\n" + hookMarker + "\nexec " + strings.Join(quoted, " ") + "\n"
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(path, 0o755); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Installed the pre-commit hook in %s\n", path)
+	return nil
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
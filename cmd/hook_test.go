@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHookRun verifies that only values sets with staged files are validated, with the staged
+// contents of the files.
+func TestHookRun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":      "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml":     "replicaCount: 1\nimage:\n  tag: latest\n",
+		"overrides.yaml":              "image:\n  tag: v1\n",
+		"staging/web_service.yaml":    "replicaCount: 2\n",
+		"production/web_service.yaml": "replicaCount: 3\n",
+	})
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	hookRun := func(files ...string) (string, error) {
+		o := &validateOptions{}
+		o.jobs, o.failFast = 1, true
+		o.changed.staged = len(files) == 0
+		o.changed.paths = files
+		var out bytes.Buffer
+		err := o.runHook(context.Background(), &out, "./web_service")
+		return out.String(), err
+	}
+	if out, err := hookRun(); err != nil || out != "" {
+		t.Fatalf("expected no output without staged files, got %q (%v)", out, err)
+	}
+
+	// The staged file sets a default; the unstaged change that removes it is not validated.
+	writeFiles(t, dir, map[string]string{"staging/web_service.yaml": "replicaCount: 1\n"})
+	run("add", "staging/web_service.yaml")
+	writeFiles(t, dir, map[string]string{"staging/web_service.yaml": "replicaCount: 2\n"})
+	out, err := hookRun()
+	if !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues, got %v", err)
+	}
+	want := "staging/web_service.yaml:1: warning: "
+	if !strings.HasPrefix(out, want) || !strings.Contains(out, "[KC001]") || strings.Contains(out, "production") {
+		t.Errorf("unexpected output:\n%s\nwant a finding on %s", out, want)
+	}
+
+	// Files passed by pre-commit replace the staged files.
+	if out, err := hookRun("production/web_service.yaml"); err != nil || out != "" {
+		t.Errorf("expected no findings for production, got %q (%v)", out, err)
+	}
+}
+
+// TestInstallHook verifies that the pre-commit hook runs kc hook run with the arguments, and
+// that hooks of other tools are only replaced with --force.
+func TestInstallHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	t.Setenv("HELM_PLUGIN_DIR", "/plugins/kc")
+
+	var out bytes.Buffer
+	if err := installHook(&out, []string{"./charts/web", "--config", "it's.yaml"}, false); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	script, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `exec 'helm' 'kc' 'hook' 'run' './charts/web' '--config' 'it'\''s.yaml'`
+	if !strings.Contains(string(script), want) {
+		t.Errorf("expected %s in the hook:\n%s", want, script)
+	}
+	// Reinstalling replaces the hook of kc.
+	if err := installHook(&out, []string{"./charts/web"}, false); err != nil {
+		t.Errorf("reinstalling the hook: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nlint\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := installHook(&out, []string{"./charts/web"}, false); err == nil {
+		t.Error("expected an existing hook not to be replaced")
+	}
+	if err := installHook(&out, []string{"./charts/web"}, true); err != nil {
+		t.Errorf("replacing the hook with --force: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Verbosity levels of diagnostics, selected with -q and -v.
+const (
+	levelQuiet = iota - 1
+	levelNormal
+	// levelVerbose logs discovery steps and merge decisions.
+	levelVerbose
+	// levelDebug also logs every compared key.
+	levelDebug
+)
+
+// levelTrace is the slog level of the diagnostics of -vv, below the slog.LevelDebug of -v.
+const levelTrace = slog.LevelDebug - 4
+
+// Values of --log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+var (
+	// verbosity is the verbosity of the current run.
+	verbosity = levelNormal
+	// logOutput receives diagnostics, separate from the report on stdout.
+	logOutput io.Writer = os.Stderr
+	// logger writes the diagnostics of the current run.
+	logger = newLogger(logOutput, logFormatText, levelNormal)
+)
+
+// logFlags holds the persistent flags selecting the verbosity and format of diagnostics.
+type logFlags struct {
+	quiet   bool
+	verbose int
+	format  string
+}
+
+func (f *logFlags) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&f.quiet, "quiet", "q", false, "Only print the summary of text output, and no diagnostics but errors")
+	cmd.PersistentFlags().CountVarP(&f.verbose, "verbose", "v", "Log discovery steps and merge decisions to stderr; repeat (-vv) to log every compared key")
+	cmd.PersistentFlags().StringVar(&f.format, "log-format", logFormatText, "Format of the diagnostics on stderr: text or json")
+}
+
+// apply sets the verbosity of the run and creates its logger.
+func (f *logFlags) apply() error {
+	if f.quiet && f.verbose > 0 {
+		return errors.New("-q and -v cannot be combined")
+	}
+	format := f.format
+	if format == "" {
+		format = logFormatText
+	}
+	if format != logFormatText && format != logFormatJSON {
+		return fmt.Errorf("unknown --log-format: %q (expected text or json)", format)
+	}
+	switch {
+	case f.quiet:
+		verbosity = levelQuiet
+	case f.verbose > 0:
+		verbosity = min(levelNormal+f.verbose, levelDebug)
+	default:
+		verbosity = levelNormal
+	}
+	logger = newLogger(logOutput, format, verbosity)
+	log.SetFlags(0)
+	log.SetOutput(helmLog{})
+	return nil
+}
+
+// helmLog receives what the Helm libraries write to the standard logger, such as warnings about
+// coalescing a map with a scalar, which findings already report, and logs it with -v.
+type helmLog struct{}
+
+func (helmLog) Write(p []byte) (int, error) {
+	logger.Debug(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// newLogger returns a logger writing records of the given verbosity to w.
+func newLogger(w io.Writer, format string, verbosity int) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level: slogLevel(verbosity),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) > 0:
+			case a.Key == slog.TimeKey && format == logFormatText:
+				// Timestamps are left to the terminal or CI log.
+				return slog.Attr{}
+			case a.Key == slog.LevelKey && a.Value.Any() == levelTrace:
+				return slog.String(slog.LevelKey, "TRACE")
+			}
+			return a
+		},
+	}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// slogLevel returns the lowest level logged at verbosity.
+func slogLevel(verbosity int) slog.Level {
+	switch verbosity {
+	case levelQuiet:
+		return slog.LevelError
+	case levelVerbose:
+		return slog.LevelDebug
+	case levelDebug:
+		return levelTrace
+	}
+	return slog.LevelInfo
+}
+
+// logOverrides logs the values that every layer overrides from lower layers. names are the
+// sources of the layers.
+func logOverrides(names []string, layers []map[string]interface{}) {
+	if verbosity < levelDebug {
+		return
+	}
+	setBy := map[string]string{}
+	for i, layer := range layers {
+		leaves := map[string]bool{}
+		collectLeaves(layer, "", leaves)
+		paths := make([]string, 0, len(leaves))
+		for path := range leaves {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if previous, ok := setBy[path]; ok {
+				logger.Log(context.Background(), levelTrace, "value overridden", "key", path, "source", names[i], "overrides", previous)
+			}
+			setBy[path] = names[i]
+		}
+	}
+}
+
+// collectLeaves adds the key paths of the values that are not maps below prefix to leaves.
+func collectLeaves(values map[string]interface{}, prefix string, leaves map[string]bool) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+			collectLeaves(m, path, leaves)
+			continue
+		}
+		leaves[path] = true
+	}
+}
+
+// setSources names the sources of the layers of fileLayers.
+func (o *validateOptions) setSources(files []string) []string {
+	names := append([]string{}, files...)
+	if flags := o.setFlags(); len(flags) > 0 {
+		values := make([]string, len(flags))
+		for i, flag := range flags {
+			values[i] = flag.String()
+		}
+		names = append(names, strings.Join(values, " "))
+	}
+	return names
+}
+
+// banners reports whether progress banners are printed around the findings of text output,
+// which a template of --output-template-file replaces.
+func (o *validateOptions) banners() bool {
+	return o.output == "text" && o.templateFile == "" && verbosity > levelQuiet
+}
+
+// traceHandler logs the debug records of the library at levelTrace, so they are only
+// logged with -vv.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(ctx, traceLevel(level))
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Level = traceLevel(r.Level)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+// traceLevel lowers slog.LevelDebug to levelTrace.
+func traceLevel(level slog.Level) slog.Level {
+	if level == slog.LevelDebug {
+		return levelTrace
+	}
+	return level
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVerbosity verifies that -q prints only the summary line, and that -vv logs the
+// discovery steps, the values overridden by upper layers, and every compared key, as text or
+// JSON records.
+func TestVerbosity(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\nport: 80\n",
+		"overrides.yaml":    "replicaCount: 2\n",
+		"values.yaml":       "replicaCount: 1\nport: http\n",
+	})
+	defer func(level int, w io.Writer, l *slog.Logger) {
+		verbosity, logOutput, logger = level, w, l
+	}(verbosity, logOutput, logger)
+	var log bytes.Buffer
+	logOutput = &log
+
+	flags := &logFlags{quiet: true}
+	if err := flags.apply(); err != nil {
+		t.Fatal(err)
+	}
+	o := &validateOptions{output: "text", groupBy: "none", jobs: 1}
+	o.valuesFiles = []string{filepath.Join(dir, "overrides.yaml"), filepath.Join(dir, "values.yaml")}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, filepath.Join(dir, "chart")); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	if want := "Validation completed: Issues were found (1 errors, 0 warnings, 0 info).\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+	if log.Len() > 0 {
+		t.Errorf("expected no diagnostics, got:\n%s", log.String())
+	}
+
+	flags = &logFlags{verbose: 2}
+	if err := flags.apply(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.run(context.Background(), &out, filepath.Join(dir, "chart")); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	for _, want := range []string{
+		`level=DEBUG msg="merging values" sources="[` + o.valuesFiles[0] + " " + o.valuesFiles[1] + `]"`,
+		`level=TRACE msg="value overridden" key=replicaCount source=` + o.valuesFiles[1] + " overrides=" + o.valuesFiles[0] + "\n",
+		`level=TRACE msg="comparing key" files="[` + o.valuesFiles[0] + " " + o.valuesFiles[1] + `]" key=port default=80 provided="\"http\""` + "\n",
+	} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("expected %q in the diagnostics, got:\n%s", want, log.String())
+		}
+	}
+
+	log.Reset()
+	flags = &logFlags{verbose: 1, format: logFormatJSON}
+	if err := flags.apply(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.run(context.Background(), &out, filepath.Join(dir, "chart")); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	var record struct {
+		Level   string   `json:"level"`
+		Msg     string   `json:"msg"`
+		Sources []string `json:"sources"`
+	}
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil || record.Msg != "merging values" || len(record.Sources) != 2 {
+		t.Errorf("expected a JSON record of the merge, got %v:\n%s", err, log.String())
+	}
+
+	if err := (&logFlags{quiet: true, verbose: 1}).apply(); err == nil {
+		t.Error("expected -q and -v to be rejected together")
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"sigs.k8s.io/yaml"
+)
+
+// lspOptions holds the flags of the lsp command.
+type lspOptions struct {
+	validateOptions
+}
+
+func newLSPCmd() *cobra.Command {
+	o := &lspOptions{}
+	cmd := &cobra.Command{
+		Use:   "lsp <chart>",
+		Short: "Serve diagnostics of values files to editors over the Language Server Protocol",
+		Long: `Run a language server on stdin and stdout that validates values files as they are edited.
+
+When a values file of an auto-detected values set, or one of the -f files, is opened or
+changed in the editor, every values set it belongs to is validated with the unsaved
+contents of the open documents, and the findings are published as diagnostics on the keys
+that set them: redundant values, type mismatches, and the findings of every other enabled
+rule. Findings without a key in a file, such as missing required values, are shown at the
+top of the service file.
+
+Values sets are discovered below the root of the workspace, or the working directory if
+the editor sends none, and rediscovered when a file is saved. The flags apply like with
+kc validate.`,
+		Example: `  helm kc lsp ./charts/web_service
+  helm kc lsp bitnami/nginx --version 18.1.0 --config envs/.kaartcontrole.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	return cmd
+}
+
+// LSP error codes of JSON-RPC.
+const (
+	lspMethodNotFound       = -32601
+	lspInvalidParams        = -32602
+	lspServerNotInitialized = -32002
+)
+
+// LSP diagnostic severities.
+const (
+	lspSeverityError       = 1
+	lspSeverityWarning     = 2
+	lspSeverityInformation = 3
+)
+
+// lspMessage is a JSON-RPC request, response, or notification.
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// lspResponse is the response to a request. Result is sent as null if there is no error.
+type lspResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspPublishDiagnosticsParams struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+// lspTextDocumentParams holds the parameters of the textDocument notifications kc handles.
+// With full document sync, the last content change is the whole document.
+type lspTextDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// lspDocument is a document open in the editor.
+type lspDocument struct {
+	uri  string
+	path string
+	text []byte
+}
+
+// lspServer validates the documents open in an editor. Messages are handled one at a time,
+// so its state needs no locking.
+type lspServer struct {
+	o         *lspOptions
+	out       io.Writer
+	chartPath string
+	opts      kc.Options
+
+	initialized bool
+	shutdown    bool
+	// chart and sets are loaded on first use and reloaded when files are saved.
+	chart *loadedChart
+	sets  [][]string
+	// docs are the open documents by absolute path.
+	docs map[string]*lspDocument
+}
+
+func (o *lspOptions) run(ctx context.Context, in io.Reader, out io.Writer, chartPath string) error {
+	// Findings are published as diagnostics, never printed: stdout carries the protocol.
+	o.begin(&kc.Collector{})
+	_, opts, err := o.prepare(io.Discard)
+	if err != nil {
+		return err
+	}
+	// The working directory changes to the root of the workspace on initialize.
+	if _, err := os.Stat(chartPath); err == nil {
+		if chartPath, err = filepath.Abs(chartPath); err != nil {
+			return err
+		}
+	}
+	s := &lspServer{o: o, out: out, chartPath: chartPath, opts: opts, docs: map[string]*lspDocument{}}
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readLSPMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exited without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(ctx, msg); err != nil {
+			return err
+		}
+	}
+}
+
+// readLSPMessage reads a message with its Content-Length header.
+func readLSPMessage(r *bufio.Reader) (*lspMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading message header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	msg := &lspMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+// write sends a message with its Content-Length header.
+func (s *lspServer) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *lspServer) reply(id *json.RawMessage, result interface{}, rpcErr *lspError) error {
+	return s.write(lspResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+func (s *lspServer) notify(method string, params interface{}) error {
+	return s.write(lspNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// showError shows err to the user, for failures that are not about a single document.
+func (s *lspServer) showError(err error) error {
+	logger.Error("validation failed", "err", err)
+	return s.notify("window/showMessage", map[string]interface{}{"type": lspSeverityError, "message": "kc: " + err.Error()})
+}
+
+func (s *lspServer) handle(ctx context.Context, msg *lspMessage) error {
+	isRequest := msg.ID != nil
+	if !s.initialized && msg.Method != "initialize" {
+		if isRequest {
+			return s.reply(msg.ID, nil, &lspError{Code: lspServerNotInitialized, Message: "server not initialized"})
+		}
+		return nil
+	}
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			RootURI string `json:"rootUri"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.reply(msg.ID, nil, &lspError{Code: lspInvalidParams, Message: err.Error()})
+		}
+		if root := uriToPath(params.RootURI); root != "" {
+			// Values sets are discovered relative to the working directory, like kc validate.
+			if err := os.Chdir(root); err != nil {
+				return s.reply(msg.ID, nil, &lspError{Code: lspInvalidParams, Message: err.Error()})
+			}
+		}
+		s.initialized = true
+		return s.reply(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// Full document sync: every change sends the whole document.
+				"textDocumentSync": map[string]interface{}{"openClose": true, "change": 1, "save": true},
+			},
+			"serverInfo": map[string]string{"name": "kc", "version": currentBuild().Version},
+		}, nil)
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(msg.ID, nil, nil)
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didSave", "textDocument/didClose":
+		var params lspTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			logger.Warn("ignoring invalid notification", "method", msg.Method, "err", err)
+			return nil
+		}
+		return s.didChange(ctx, msg.Method, params)
+	}
+	if isRequest {
+		return s.reply(msg.ID, nil, &lspError{Code: lspMethodNotFound, Message: "method not found: " + msg.Method})
+	}
+	// Other notifications, such as initialized and $/cancelRequest, need no handling.
+	return nil
+}
+
+// didChange updates the open documents and publishes the diagnostics of every one of them,
+// since a change to a shared file like overrides.yaml moves findings between files.
+func (s *lspServer) didChange(ctx context.Context, method string, params lspTextDocumentParams) error {
+	uri := params.TextDocument.URI
+	path := uriToPath(uri)
+	if path == "" {
+		return nil
+	}
+	switch method {
+	case "textDocument/didOpen":
+		s.docs[path] = &lspDocument{uri: uri, path: path, text: []byte(params.TextDocument.Text)}
+		// A new file may belong to a values set that was not there before.
+		s.sets = nil
+	case "textDocument/didChange":
+		doc, ok := s.docs[path]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		doc.text = []byte(params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didSave":
+		if s.chart != nil && isWithin(s.chart.path, path) {
+			s.chart = nil
+		}
+		s.sets = nil
+	case "textDocument/didClose":
+		delete(s.docs, path)
+		if err := s.notify("textDocument/publishDiagnostics", lspPublishDiagnosticsParams{URI: uri, Diagnostics: []lspDiagnostic{}}); err != nil {
+			return err
+		}
+	}
+
+	if err := s.load(ctx); err != nil {
+		return s.showError(err)
+	}
+	paths := make([]string, 0, len(s.docs))
+	for path := range s.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		diagnostics, err := s.diagnose(ctx, s.docs[path])
+		if err != nil {
+			return s.showError(err)
+		}
+		if err := s.notify("textDocument/publishDiagnostics", lspPublishDiagnosticsParams{URI: s.docs[path].uri, Diagnostics: diagnostics}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load loads the chart and discovers the values sets, unless they are loaded already.
+func (s *lspServer) load(ctx context.Context) error {
+	var err error
+	if s.chart == nil {
+		if s.chart, err = s.o.loadChart(ctx, s.chartPath); err != nil {
+			return err
+		}
+		s.sets = nil
+	}
+	if s.sets == nil {
+		if s.sets, err = s.o.valuesSets(ctx, s.chart.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lspParseErrorLine matches the line of YAML syntax errors.
+var lspParseErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// diagnose validates every values set doc belongs to, with the open documents in place of
+// their files, and returns the findings located in doc.
+func (s *lspServer) diagnose(ctx context.Context, doc *lspDocument) ([]lspDiagnostic, error) {
+	diagnostics := []lspDiagnostic{}
+	if err := yaml.Unmarshal(doc.text, &map[string]interface{}{}); err != nil {
+		// The document is validated again once it parses.
+		line := 0
+		if m := lspParseErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			line--
+		}
+		return append(diagnostics, lspDiagnostic{
+			Range:    lspRange{Start: lspPosition{Line: max(line, 0)}, End: lspPosition{Line: max(line, 0) + 1}},
+			Severity: lspSeverityError,
+			Source:   "kc",
+			Message:  err.Error(),
+		}), nil
+	}
+
+	// Documents are read from the editor rather than from disk, so nothing is cached.
+	s.o.begin(nil)
+	s.o.reader.cache = map[string][]byte{}
+	locator := kc.NewLocator()
+	seen := map[lspDiagnostic]bool{}
+	for _, files := range s.sets {
+		in := false
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				continue
+			}
+			if open, ok := s.docs[abs]; ok {
+				s.o.reader.cache[file] = open.text
+				locator.SetFile(file, open.text)
+			}
+			in = in || abs == doc.path
+		}
+		if !in {
+			continue
+		}
+		result := s.o.validateSet(ctx, s.chart, files, s.opts)
+		if result.loadErr != nil {
+			// Another file of the set fails to load; that file is diagnosed on its own.
+			logger.Warn("failed to load values", "files", files, "err", result.loadErr)
+			continue
+		}
+		if result.err != nil {
+			return nil, result.err
+		}
+		for _, f := range result.findings {
+			d, ok := s.diagnostic(doc, files, locator, f)
+			if ok && !seen[d] {
+				seen[d] = true
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Range.Start.Line < diagnostics[j].Range.Start.Line
+	})
+	return diagnostics, nil
+}
+
+// diagnostic returns the diagnostic of f if it is located in doc: on the key in the last file
+// of the set that sets it, or at the top of the service file, the last one of the set, if no
+// file sets it.
+func (s *lspServer) diagnostic(doc *lspDocument, files []string, locator *kc.Locator, f kc.Finding) (lspDiagnostic, bool) {
+	var r lspRange
+	if loc, ok := locator.Locate(f); ok {
+		abs, err := filepath.Abs(loc.File)
+		if err != nil || abs != doc.path {
+			return lspDiagnostic{}, false
+		}
+		start := lspPosition{Line: max(loc.Line-1, 0), Character: max(loc.Column-1, 0)}
+		r = lspRange{Start: start, End: lspPosition{Line: start.Line, Character: start.Character + loc.Length}}
+	} else if abs, err := filepath.Abs(files[len(files)-1]); err != nil || abs != doc.path {
+		return lspDiagnostic{}, false
+	}
+	severity := lspSeverityInformation
+	switch f.Severity {
+	case kc.SeverityError:
+		severity = lspSeverityError
+	case kc.SeverityWarning:
+		severity = lspSeverityWarning
+	}
+	return lspDiagnostic{Range: r, Severity: severity, Code: f.RuleID, Source: "kc", Message: f.Message}, true
+}
+
+// uriToPath returns the absolute path of a file URI, or "" for other URIs.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/envs/web.yaml
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// isWithin reports whether path is dir or below it.
+func isWithin(dir, path string) bool {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(abs, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
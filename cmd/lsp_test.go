@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLSP verifies that open values files are validated with their unsaved contents, and that
+// findings are published on the keys of the file that sets them.
+func TestLSP(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"charts/web/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"envs/overrides.yaml":    "image:\n  tag: latest\n",
+		"envs/prod/web.yaml":     "replicaCount: 3\n",
+	})
+	cwd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &lspOptions{}
+	o.jobs = 1
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- o.run(context.Background(), serverIn, serverOut, filepath.Join(dir, "charts/web"))
+		serverOut.Close()
+	}()
+	r := bufio.NewReader(clientIn)
+	send := func(id int, method string, params interface{}) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		if id > 0 {
+			msg["id"] = id
+		}
+		body, _ := json.Marshal(msg)
+		if _, err := fmt.Fprintf(clientOut, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// receive returns the diagnostics published for each document after a notification.
+	receive := func(n int) map[string][]lspDiagnostic {
+		published := map[string][]lspDiagnostic{}
+		for len(published) < n {
+			msg, err := readLSPMessage(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.Method != "textDocument/publishDiagnostics" {
+				t.Fatalf("unexpected message %s: %s", msg.Method, msg.Params)
+			}
+			var params lspPublishDiagnosticsParams
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				t.Fatal(err)
+			}
+			published[params.URI] = params.Diagnostics
+		}
+		return published
+	}
+
+	send(1, "initialize", map[string]string{"rootUri": "file://" + filepath.Join(dir, "envs")})
+	if msg, err := readLSPMessage(r); err != nil || msg.ID == nil {
+		t.Fatalf("expected the initialize response, got %v (%v)", msg, err)
+	}
+	send(0, "initialized", map[string]string{})
+
+	overrides := "file://" + filepath.Join(dir, "envs/overrides.yaml")
+	service := "file://" + filepath.Join(dir, "envs/prod/web.yaml")
+	send(0, "textDocument/didOpen", map[string]interface{}{"textDocument": map[string]string{"uri": overrides, "text": "image:\n  tag: latest\n"}})
+	diagnostics := receive(1)[overrides]
+	want := lspDiagnostic{
+		Range:    lspRange{Start: lspPosition{Line: 1, Character: 2}, End: lspPosition{Line: 1, Character: 5}},
+		Severity: lspSeverityWarning,
+		Code:     "KC001",
+		Source:   "kc",
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Code != want.Code || diagnostics[0].Range != want.Range || diagnostics[0].Severity != want.Severity {
+		t.Fatalf("expected %+v, got %+v", want, diagnostics)
+	}
+
+	// The unsaved service file now sets the tag, moving the finding to it.
+	send(0, "textDocument/didOpen", map[string]interface{}{"textDocument": map[string]string{"uri": service, "text": "replicaCount: 3\n"}})
+	receive(2)
+	send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]string{"uri": service},
+		"contentChanges": []map[string]string{{"text": "replicaCount: 3\nimage:\n  tag: [1]\n"}},
+	})
+	published := receive(2)
+	if len(published[overrides]) != 0 {
+		t.Errorf("expected no diagnostics in overrides.yaml, got %+v", published[overrides])
+	}
+	if d := published[service]; len(d) != 1 || d[0].Code != "KC002" || d[0].Range.Start.Line != 2 {
+		t.Errorf("expected a type mismatch on line 3 of web.yaml, got %+v", d)
+	}
+
+	send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]string{"uri": service},
+		"contentChanges": []map[string]string{{"text": "image: [\n"}},
+	})
+	if d := receive(2)[service]; len(d) != 1 || d[0].Severity != lspSeverityError {
+		t.Errorf("expected a syntax error, got %+v", d)
+	}
+
+	send(2, "shutdown", nil)
+	if msg, err := readLSPMessage(r); err != nil || msg.ID == nil {
+		t.Fatalf("expected the shutdown response, got %v (%v)", msg, err)
+	}
+	send(0, "exit", nil)
+	if err := <-done; err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+}
@@ -1,99 +1,22 @@
 package main
 
 import (
-	"flag"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"reflect"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
-	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/chart/loader"
-	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/cli/values"
+	"github.com/spf13/cobra"
 )
 
-// IgnoreList holds fields to be ignored during validation.
-type IgnoreList []string
-
-func (i *IgnoreList) String() string {
-	return strings.Join(*i, ",")
-}
-
-func (i *IgnoreList) Set(value string) error {
-	*i = append(*i, value)
-	return nil
-}
-
-// ValueFiles holds the list of values files passed via -f.
-type ValueFiles []string
-
-func (v *ValueFiles) String() string {
-	return strings.Join(*v, ",")
-}
-
-func (v *ValueFiles) Set(value string) error {
-	*v = append(*v, value)
-	return nil
-}
-
-func shouldIgnore(path string, ignoreList IgnoreList) bool {
-	for _, ignore := range ignoreList {
-		if strings.HasPrefix(path, ignore) {
-			return true
-		}
-	}
-	return false
-}
-
-func validateChartValues(defaultValues, providedValues map[string]interface{}, prefix string, issuesFound *bool, ignoreList IgnoreList) {
-	for key, providedValue := range providedValues {
-		fullKey := key
-		if prefix != "" {
-			fullKey = prefix + "." + key
-		}
-
-		if shouldIgnore(fullKey, ignoreList) {
-			continue
-		}
-
-		defaultValue, exists := defaultValues[key]
-		if !exists {
-			//fmt.Printf("❌ Unexpected key: '%s' is not defined in chart defaults\n", fullKey)
-			//*issuesFound = true
-			continue
-		}
-
-		if defaultMap, isDefaultMap := defaultValue.(map[string]interface{}); isDefaultMap {
-			if providedMap, isProvidedMap := providedValue.(map[string]interface{}); isProvidedMap {
-				validateChartValues(defaultMap, providedMap, fullKey, issuesFound, ignoreList)
-			} else {
-				fmt.Printf("❌ Type mismatch for '%s': expected map, got %T\n", fullKey, providedValue)
-				*issuesFound = true
-			}
-			continue
-		}
-
-		if reflect.DeepEqual(defaultValue, providedValue) {
-			fmt.Printf("⚠️  Redundant value: '%s' matches default value: %v\n", fullKey, providedValue)
-			*issuesFound = true
-			continue
-		}
-
-		if defaultValue != nil && providedValue != nil {
-			defaultType := reflect.TypeOf(defaultValue)
-			providedType := reflect.TypeOf(providedValue)
-
-			if defaultType != providedType {
-				fmt.Printf("❌ Type mismatch for '%s': expected %T, got %T\n", fullKey, defaultValue, providedValue)
-				*issuesFound = true
-			}
-		}
-	}
-}
-
 func findChart(chartPath string) (string, error) {
 	if strings.HasPrefix(chartPath, "/") || strings.HasPrefix(chartPath, "./") || strings.HasPrefix(chartPath, "../") {
 		return chartPath, nil
@@ -119,181 +42,421 @@ func findChart(chartPath string) (string, error) {
 	return "", fmt.Errorf("chart not found: %s", chartPath)
 }
 
-// valuePair represents a candidate pair of values files:
-// one overrides file and one service file (e.g. web_service.yaml).
+// valuePair represents a candidate set of values files: the overrides files of every layer
+// that was found, from the most general one, one service file (e.g. web_service.yaml), and
+// the fragments of a values.d directory next to the service file.
 type valuePair struct {
-	override string
-	service  string
+	overrides []string
+	service   string
+	fragments []string
+}
+
+// files returns the values files of the pair in the order they are merged.
+func (p valuePair) files() []string {
+	files := append(append([]string{}, p.overrides...), p.service)
+	return append(files, p.fragments...)
 }
 
 // detectPairs searches starting at baseDir (for example, the current working directory)
-// for every file named "<chartName>.yaml". For each such service file, it traverses upward
-// (but not past baseDir) to locate the nearest overrides.yaml. If found, the pair is recorded.
-func detectPairs(baseDir, chartName string) ([]valuePair, error) {
+// for every service file of the naming convention (by default "<chartName>.yaml"). For each
+// such service file, it traverses upward (but not past baseDir) to locate the nearest
+// overrides file of every layer (by default only overrides.yaml). If any is found, the pair
+// is recorded.
+// Directories matching opts.excludeDirs or the default exclusions, and paths ignored by
+// .gitignore or .kcignore files, are skipped. Symbolic links are only followed with
+// opts.followSymlinks.
+//
+// If scopes are given, only the directories below them are searched, while overrides and
+// ignore files are still looked up to baseDir. Scopes outside of baseDir are searched on
+// their own.
+func detectPairs(ctx context.Context, baseDir string, opts discoveryOptions, scopes ...string) ([]valuePair, error) {
+	found, err := findServiceFiles(ctx, baseDir, opts, scopes...)
+	if err != nil {
+		return nil, err
+	}
 	var pairs []valuePair
-	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		// Look for files named "<chartName>.yaml" (e.g. "web_service.yaml")
-		if !info.IsDir() && filepath.Base(path) == chartName+".yaml" {
-			currentDir := filepath.Dir(path)
-			var overridePath string
-			// Traverse upward until reaching the baseDir.
-			for {
-				candidate := filepath.Join(currentDir, "overrides.yaml")
-				if stat, err := os.Stat(candidate); err == nil && !stat.IsDir() {
-					overridePath = candidate
-					break
-				}
-				if currentDir == baseDir {
-					break
-				}
-				parent := filepath.Dir(currentDir)
-				if parent == currentDir {
-					break
-				}
-				currentDir = parent
-			}
-			if overridePath != "" {
-				pairs = append(pairs, valuePair{override: overridePath, service: path})
-			}
+	for _, pair := range found {
+		if len(pair.overrides) > 0 {
+			pairs = append(pairs, pair)
+		} else {
+			logger.Debug("skipping service file without overrides", "file", pair.service, "layers", opts.naming.layers)
 		}
-		return nil
-	})
+	}
+	return pairs, nil
+}
+
+// findServiceFiles searches like detectPairs, but also returns the service files without any
+// overrides file.
+func findServiceFiles(ctx context.Context, baseDir string, opts discoveryOptions, scopes ...string) ([]valuePair, error) {
+	pairs, _, err := searchScopes(ctx, baseDir, opts, scopes)
 	if err != nil {
 		return nil, err
 	}
 
 	// Sort pairs for consistent output.
 	sort.Slice(pairs, func(i, j int) bool {
-		if pairs[i].override == pairs[j].override {
-			return pairs[i].service < pairs[j].service
+		a, b := pairs[i].files(), pairs[j].files()
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
 		}
-		return pairs[i].override < pairs[j].override
+		return len(a) < len(b)
 	})
 	return pairs, nil
 }
 
-func main() {
-	var ignoreList IgnoreList
-	var valuesFiles ValueFiles
-
-	flag.Var(&ignoreList, "ignore", "Fields to ignore in validation (can be specified multiple times)")
-	flag.Var(&valuesFiles, "f", "Values file (can be specified multiple times)")
-	flag.Parse()
-
-	args := flag.Args()
-	if len(args) < 1 {
-		fmt.Printf("Usage: helm kc [--ignore field1,field2,...] <chart> [-f <values-file> ...]\n")
-		fmt.Printf("\nExamples:\n")
-		fmt.Printf("  helm kc ./mychart -f values.yaml\n")
-		fmt.Printf("  helm kc ./mychart -f overrides.yaml -f infra/web_service.yaml\n")
-		fmt.Printf("If no -f is provided, the plugin auto-detects valid pairs from the environment tree.\n")
-		os.Exit(1)
+// findOverridesFiles searches like detectPairs, but returns every overrides file below the
+// searched directories, whether or not a service file was found below it, sorted.
+func findOverridesFiles(ctx context.Context, baseDir string, opts discoveryOptions, scopes ...string) ([]string, error) {
+	_, overrides, err := searchScopes(ctx, baseDir, opts, scopes)
+	if err != nil {
+		return nil, err
 	}
+	sort.Strings(overrides)
+	return overrides, nil
+}
 
-	chartPath := args[0]
+// searchScopes searches every scope, baseDir if none are given, and returns the values sets
+// and the overrides files found, each once.
+func searchScopes(ctx context.Context, baseDir string, opts discoveryOptions, scopes []string) ([]valuePair, []string, error) {
+	if len(scopes) == 0 {
+		scopes = []string{baseDir}
+	}
+	var pairs []valuePair
+	var overrides []string
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		found, layers, err := detectPairsIn(ctx, baseDir, scope, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Scopes may overlap, e.g. "environments" and "environments/prod".
+		for _, pair := range found {
+			if !seen[pair.service] {
+				seen[pair.service] = true
+				pairs = append(pairs, pair)
+			}
+		}
+		for _, layer := range layers {
+			if !seen[layer] {
+				seen[layer] = true
+				overrides = append(overrides, layer)
+			}
+		}
+	}
+	return pairs, overrides, nil
+}
 
-	chartDir, err := findChart(chartPath)
-	if err != nil {
-		fmt.Printf("Error locating chart: %v\n", err)
-		os.Exit(1)
+// detectPairsIn searches scope for values sets, looking up overrides and ignore files up to
+// baseDir. It also returns the overrides files found in scope.
+func detectPairsIn(ctx context.Context, baseDir, scope string, opts discoveryOptions) ([]valuePair, []string, error) {
+	if rel, err := filepath.Rel(baseDir, scope); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		baseDir = scope
+	}
+	d := &discovery{
+		ctx:     ctx,
+		opts:    opts,
+		matcher: newIgnoreMatcher(baseDir, opts.excludeDirs),
+		slots:   make(chan struct{}, discoveryWorkers),
 	}
 
-	if _, err := os.Stat(chartDir); os.IsNotExist(err) {
-		fmt.Printf("Chart directory does not exist: %s\n", chartDir)
-		os.Exit(1)
+	// The directories between baseDir and scope contribute their ignore and overrides files,
+	// outermost first.
+	var above []string
+	for dir := scope; dir != baseDir; {
+		dir = filepath.Dir(dir)
+		above = append([]string{dir}, above...)
+	}
+	overrides := make([]string, len(opts.naming.layers))
+	for _, dir := range above {
+		if err := d.matcher.loadDir(dir); err != nil {
+			return nil, nil, err
+		}
+		for i, layer := range opts.naming.layers {
+			path := filepath.Join(dir, layer)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				overrides[i] = path
+			}
+		}
 	}
 
-	settings := cli.New()
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), nil); err != nil {
-		fmt.Printf("Failed to initialize Helm configuration: %v\n", err)
-		os.Exit(1)
+	if err := d.matcher.loadDir(scope); err != nil {
+		return nil, nil, err
+	}
+	var chain []string
+	if opts.followSymlinks {
+		real, err := filepath.EvalSymlinks(scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		chain = []string{real}
+	}
+	d.walk(scope, overrides, chain)
+	d.wg.Wait()
+	if d.err != nil {
+		return nil, nil, d.err
 	}
+	return d.pairs, d.overrides, nil
+}
 
-	chart, err := loader.Load(chartDir)
+// discoveryOptions controls which files values discovery picks up.
+type discoveryOptions struct {
+	naming         namingConvention
+	excludeDirs    []string
+	followSymlinks bool
+}
+
+// discoveryWorkers bounds the number of directories read concurrently during discovery.
+var discoveryWorkers = 4 * runtime.NumCPU()
+
+// discovery is the state of a concurrent detectPairs traversal.
+type discovery struct {
+	// ctx stops the traversal when it is done.
+	ctx     context.Context
+	opts    discoveryOptions
+	matcher *ignoreMatcher
+	slots   chan struct{}
+	wg      sync.WaitGroup
+
+	mu    sync.Mutex
+	pairs []valuePair
+	// overrides holds the overrides files found while walking.
+	overrides []string
+	err       error
+}
+
+// walk reads dir, whose ignore files are already loaded, and descends into its
+// subdirectories, in new goroutines while worker slots are free. overrides holds the nearest
+// overrides file of every layer above dir, empty where none was found; they are handed down
+// instead of being looked up again for every service file. chain holds the real paths of dir
+// and its parents when symbolic links are followed, to detect cycles.
+func (d *discovery) walk(dir string, overrides []string, chain []string) {
+	if d.ctx.Err() != nil {
+		d.fail(fmt.Errorf("searching %s: %w", dir, context.Cause(d.ctx)))
+		return
+	}
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		fmt.Printf("Failed to load chart: %v\n", err)
-		os.Exit(1)
+		d.fail(err)
+		return
+	}
+	isDir := make([]bool, len(entries))
+	for i, entry := range entries {
+		isDir[i] = entry.IsDir()
+		if d.opts.followSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			// Broken links are skipped like any other file.
+			if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil {
+				isDir[i] = info.IsDir()
+			}
+		}
 	}
 
-	defaultValues := chart.Values
+	copied := false
+	for i, entry := range entries {
+		if layer := d.opts.naming.layer(entry.Name()); layer >= 0 && !isDir[i] {
+			if !copied {
+				overrides = append([]string{}, overrides...)
+				copied = true
+			}
+			overrides[layer] = filepath.Join(dir, entry.Name())
+			d.mu.Lock()
+			d.overrides = append(d.overrides, overrides[layer])
+			d.mu.Unlock()
+		}
+	}
+	var found []string
+	for _, override := range overrides {
+		if override != "" {
+			found = append(found, override)
+		}
+	}
 
-	// If the user provided explicit -f values, merge and validate them as before.
-	if len(valuesFiles) > 0 {
-		valueOpts := &values.Options{
-			ValueFiles: valuesFiles,
+	var fragments []string
+	fragmentsRead := false
+	for i, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if d.matcher.ignored(path, isDir[i]) {
+			logger.Log(context.Background(), levelTrace, "ignoring path", "path", path)
+			continue
 		}
-		providedValues, err := valueOpts.MergeValues(nil)
-		if err != nil {
-			fmt.Printf("Failed to load values: %v\n", err)
-			os.Exit(1)
+		if !isDir[i] {
+			// Look for service files, by default "<chartName>.yaml" (e.g. "web_service.yaml")
+			if d.opts.naming.isService(entry.Name()) {
+				if !fragmentsRead {
+					if fragments, err = d.fragments(dir); err != nil {
+						d.fail(err)
+						return
+					}
+					fragmentsRead = true
+				}
+				d.mu.Lock()
+				d.pairs = append(d.pairs, valuePair{overrides: found, service: path, fragments: fragments})
+				d.mu.Unlock()
+			}
+			continue
 		}
-		fmt.Printf("\nValidating Helm chart values:\n")
-		fmt.Printf("==============================\n")
-		fmt.Printf("Chart: %s\n", chartDir)
-		fmt.Printf("Values files: %s\n", valuesFiles.String())
-		if len(ignoreList) > 0 {
-			fmt.Printf("Ignoring fields: %s\n", ignoreList.String())
+		if entry.Name() == valuesDirName {
+			// Fragments belong to the service files next to the directory.
+			continue
 		}
-		fmt.Printf("\nStarting validation...\n\n")
-
-		issuesFound := false
-		validateChartValues(defaultValues, providedValues, "", &issuesFound, ignoreList)
-		if !issuesFound {
-			fmt.Printf("\nValidation completed: No issues found.\n")
-		} else {
-			fmt.Printf("\nValidation completed: Issues were found.\n")
-			os.Exit(1)
+		var subchain []string
+		if d.opts.followSymlinks {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				d.fail(err)
+				return
+			}
+			if slices.Contains(chain, real) {
+				// The link points to the directory itself or one of its parents.
+				continue
+			}
+			subchain = append(slices.Clip(chain), real)
+		}
+		if err := d.matcher.loadDir(path); err != nil {
+			d.fail(err)
+			return
+		}
+		select {
+		case d.slots <- struct{}{}:
+			d.wg.Add(1)
+			go func(path string) {
+				defer d.wg.Done()
+				defer func() { <-d.slots }()
+				d.walk(path, overrides, subchain)
+			}(path)
+		default:
+			d.walk(path, overrides, subchain)
 		}
-		return
 	}
+}
 
-	// No -f flags provided: auto-detect valid pairs.
-	// Use the current working directory as the base for environment search.
-	envDir, err := os.Getwd()
+// fragments returns the values files in the values.d directory of dir, in lexicographic
+// order, or nothing if there is no such directory.
+func (d *discovery) fragments(dir string) ([]string, error) {
+	fragmentsDir := filepath.Join(dir, valuesDirName)
+	if info, err := os.Stat(fragmentsDir); err != nil || !info.IsDir() || d.matcher.ignored(fragmentsDir, true) {
+		return nil, nil
+	}
+	if err := d.matcher.loadDir(fragmentsDir); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(fragmentsDir)
 	if err != nil {
-		fmt.Printf("Error determining current directory: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
+	var fragments []string
+	for _, entry := range entries {
+		path := filepath.Join(fragmentsDir, entry.Name())
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") || d.matcher.ignored(path, false) {
+			continue
+		}
+		fragments = append(fragments, path)
+	}
+	return fragments, nil
+}
 
-	chartName := filepath.Base(chartDir)
-	pairs, err := detectPairs(envDir, chartName)
-	if err != nil {
-		fmt.Printf("Error auto-detecting values: %v\n", err)
-		os.Exit(1)
+// fail records the first error of the traversal.
+func (d *discovery) fail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
 	}
-	if len(pairs) == 0 {
-		fmt.Printf("No valid values files (overrides.yaml + %s.yaml) found in base directory: %s\n", chartName, envDir)
-		os.Exit(1)
+}
+
+// errIssuesFound signals that validation completed but reported failing findings.
+// The findings themselves have already been printed by the reporter.
+var errIssuesFound = errors.New("issues found")
+
+// newValidateCmd returns the command that validates chart values. It is the root command and
+// also available as the validate subcommand.
+func newValidateCmd() *cobra.Command {
+	o := &validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate <chart> [-f <values-file> ...]",
+		Short: "KaartControle: validate Helm chart values against defaults",
+		Long: `Validate Helm chart values against the chart defaults and report redundant or mismatched values.
+
+If no -f is provided, the plugin auto-detects valid pairs from the environment tree.
+With --charts, every chart below the given directories is validated instead of a single
+one, each against its own auto-detected values sets, in one aggregated report.`,
+		Example: `  helm kc ./mychart -f values.yaml
+  helm kc ./mychart -f overrides.yaml -f infra/web_service.yaml
+  helm kc validate --charts charts/`,
+		Args:          chartArgs(o),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			if len(o.charts) > 0 {
+				return o.runCharts(cmd.Context(), cmd.OutOrStdout())
+			}
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
 	}
+	o.addFlags(cmd.Flags())
+	o.addJobsFlag(cmd.Flags())
+	o.changed.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringArrayVar(&o.charts, "charts", nil, "Validate every chart below this directory instead of a single chart (can be specified multiple times)")
+	return cmd
+}
 
-	overallIssues := false
-	for _, p := range pairs {
-		valueOpts := &values.Options{
-			// The order matters: the overrides file is applied first.
-			ValueFiles: []string{p.override, p.service},
-		}
-		providedValues, err := valueOpts.MergeValues(nil)
-		if err != nil {
-			fmt.Printf("Failed to load values (%s, %s): %v\n", p.override, p.service, err)
-			overallIssues = true
-			continue
+func newRootCmd() *cobra.Command {
+	cmd := newValidateCmd()
+	cmd.Use = "kc <chart> [-f <values-file> ...]"
+	flags, style := &logFlags{}, &styleFlags{}
+	flags.addFlags(cmd)
+	style.addFlags(cmd)
+	var timeout time.Duration
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Abort the run after this long, e.g. 5m, including chart downloads and values discovery (default no limit)")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		if err := flags.apply(); err != nil {
+			return err
 		}
-
-		issuesFound := false
-		validateChartValues(defaultValues, providedValues, "", &issuesFound, ignoreList)
-		if issuesFound {
-			fmt.Printf("Issues found for (%s, %s)\n", p.override, p.service)
-			overallIssues = true
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeoutCause(cmd.Context(), timeout, fmt.Errorf("--timeout of %s exceeded: %w", timeout, context.DeadlineExceeded))
+			cmd.SetContext(ctx)
+			cobra.OnFinalize(cancel)
 		}
+		return style.apply()
 	}
 
-	if overallIssues {
-		os.Exit(1)
-	} else {
-		fmt.Printf("\nValidation completed: No issues found.\n")
+	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newBaselineCmd())
+	cmd.AddCommand(newHelmfileCmd())
+	cmd.AddCommand(newArgoCmd())
+	cmd.AddCommand(newDriftCmd())
+	cmd.AddCommand(newClusterCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newUpgradeCheckCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newGetCmd())
+	cmd.AddCommand(newExplainCmd())
+	cmd.AddCommand(newMinimizeCmd())
+	cmd.AddCommand(newDocsCmd())
+	cmd.AddCommand(newWatchCmd())
+	cmd.AddCommand(newDiscoverCmd())
+	cmd.AddCommand(newReportCmd())
+	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newSelfUpdateCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newControllerCmd())
+	cmd.AddCommand(newLSPCmd())
+	cmd.AddCommand(newHookCmd())
+	cmd.AddCommand(newCICmd())
+	cmd.AddCommand(newRulesCmd())
+	cmd.AddCommand(newCoverageCmd())
+	addCompletions(cmd)
+	return cmd
+}
+
+func main() {
+	err := newRootCmd().Execute()
+	if err != nil && !errors.Is(err, errIssuesFound) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 	}
+	os.Exit(exitCode(err))
 }
@@ -1,87 +1,18 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"helm.sh/helm/v3/pkg/cli/values"
 )
 
-func TestValidateChartValues(t *testing.T) {
-	tests := []struct {
-		name           string
-		defaultValues  map[string]interface{}
-		providedValues map[string]interface{}
-		ignoreList     IgnoreList
-		wantIssues     bool
-	}{
-		{
-			name: "no issues",
-			defaultValues: map[string]interface{}{
-				"key1": "value1",
-			},
-			providedValues: map[string]interface{}{
-				"key1": "different",
-			},
-			ignoreList: IgnoreList{},
-			wantIssues: false,
-		},
-		{
-			name: "redundant value",
-			defaultValues: map[string]interface{}{
-				"key1": "value1",
-			},
-			providedValues: map[string]interface{}{
-				"key1": "value1",
-			},
-			ignoreList: IgnoreList{},
-			wantIssues: true,
-		},
-		{
-			name: "type mismatch",
-			defaultValues: map[string]interface{}{
-				"key1": "value1",
-			},
-			providedValues: map[string]interface{}{
-				"key1": 123,
-			},
-			ignoreList: IgnoreList{},
-			wantIssues: true,
-		},
-		{
-			name: "ignored field",
-			defaultValues: map[string]interface{}{
-				"resources": map[string]interface{}{
-					"limits": map[string]interface{}{
-						"cpu": "100m",
-					},
-				},
-			},
-			providedValues: map[string]interface{}{
-				"resources": map[string]interface{}{
-					"limits": map[string]interface{}{
-						"cpu": 1,
-					},
-				},
-			},
-			ignoreList: IgnoreList{"resources"},
-			wantIssues: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var issuesFound bool
-			validateChartValues(tt.defaultValues, tt.providedValues, "", &issuesFound, tt.ignoreList)
-			if issuesFound != tt.wantIssues {
-				t.Errorf("validateChartValues() issuesFound = %v, want %v", issuesFound, tt.wantIssues)
-			}
-		})
-	}
-}
-
 // TestMergeValues verifies that merging multiple values files produces the expected result.
 // In this test, we create two temporary YAML files. Values from the second file should override
 // those from the first.
@@ -219,7 +150,7 @@ func TestDetectPairs(t *testing.T) {
 	}
 
 	// Call detectPairs using the temporary baseDir and the chart name.
-	pairs, err := detectPairs(baseDir, chartName)
+	pairs, err := detectPairs(context.Background(), baseDir, discoveryOptions{naming: defaultNaming(t, chartName)})
 	if err != nil {
 		t.Fatalf("detectPairs returned error: %v", err)
 	}
@@ -243,11 +174,150 @@ func TestDetectPairs(t *testing.T) {
 	}
 
 	for i, ep := range expectedPairs {
-		if pairs[i].override != ep.override {
-			t.Errorf("pair %d: expected override %q, got %q", i, ep.override, pairs[i].override)
+		if len(pairs[i].overrides) != 1 || pairs[i].overrides[0] != ep.override {
+			t.Errorf("pair %d: expected override %q, got %q", i, ep.override, pairs[i].overrides)
 		}
 		if pairs[i].service != ep.service {
 			t.Errorf("pair %d: expected service %q, got %q", i, ep.service, pairs[i].service)
 		}
 	}
 }
+
+// TestDetectPairsConcurrent verifies that a traversal split across workers pairs every service
+// file with its nearest overrides.yaml and returns the pairs in a stable order.
+func TestDetectPairsConcurrent(t *testing.T) {
+	workers := discoveryWorkers
+	discoveryWorkers = 2
+	t.Cleanup(func() { discoveryWorkers = workers })
+
+	dir := t.TempDir()
+	files := map[string]string{"overrides.yaml": "a: 1"}
+	var want []valuePair
+	for i := 0; i < 30; i++ {
+		env := filepath.Join(dir, "envs", fmt.Sprintf("env%02d", i))
+		override := filepath.Join(dir, "overrides.yaml")
+		if i%3 == 0 {
+			override = filepath.Join(env, "overrides.yaml")
+			files[filepath.Join("envs", fmt.Sprintf("env%02d", i), "overrides.yaml")] = "a: 2"
+		}
+		files[filepath.Join("envs", fmt.Sprintf("env%02d", i), "services", "deep", "web.yaml")] = "a: 3"
+		want = append(want, valuePair{overrides: []string{override}, service: filepath.Join(env, "services", "deep", "web.yaml")})
+	}
+	writeFiles(t, dir, files)
+	sort.Slice(want, func(i, j int) bool {
+		if want[i].overrides[0] == want[j].overrides[0] {
+			return want[i].service < want[j].service
+		}
+		return want[i].overrides[0] < want[j].overrides[0]
+	})
+
+	pairs, err := detectPairs(context.Background(), dir, discoveryOptions{naming: defaultNaming(t, "web")})
+	if err != nil {
+		t.Fatalf("detectPairs returned error: %v", err)
+	}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("expected %v, got %v", want, pairs)
+	}
+}
+
+// TestValuesSetsBaseDirs verifies that --base-dir limits discovery to the given directories
+// while overrides and ignore files above them still apply.
+func TestValuesSetsBaseDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":                             "old/\n",
+		"overrides.yaml":                         "a: 1",
+		"environments/prod/web.yaml":             "a: 2",
+		"environments/prod/old/web.yaml":         "a: 3",
+		"environments/staging/eu/overrides.yaml": "a: 4",
+		"environments/staging/eu/web.yaml":       "a: 5",
+		"environments/dev/web.yaml":              "a: 6",
+		"sandbox/web.yaml":                       "a: 7",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &validateOptions{baseDirs: []string{"environments/prod", "environments/staging", filepath.Join(dir, "environments", "prod")}}
+	sets, err := o.valuesSets(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want := [][]string{
+		{filepath.Join("environments", "staging", "eu", "overrides.yaml"), filepath.Join("environments", "staging", "eu", "web.yaml")},
+		{"overrides.yaml", filepath.Join("environments", "prod", "web.yaml")},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	o = &validateOptions{baseDirs: []string{"missing"}}
+	if _, err := o.valuesSets(context.Background(), "web"); err == nil {
+		t.Errorf("expected an error for a missing base directory")
+	}
+}
+
+// TestDetectPairsSymlinks verifies that linked directories are only searched with
+// followSymlinks, once per link, and that links back to a parent don't loop.
+func TestDetectPairsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shared/web/web.yaml":          "a: 1",
+		"clusters/a/overrides.yaml":    "a: 2",
+		"clusters/b/overrides.yaml":    "a: 3",
+		"clusters/b/local/web.yaml":    "a: 4",
+		"clusters/b/local/placeholder": "",
+	})
+	for _, link := range []struct{ target, name string }{
+		{filepath.Join(dir, "shared", "web"), filepath.Join(dir, "clusters", "a", "web")},
+		{filepath.Join(dir, "shared", "web"), filepath.Join(dir, "clusters", "b", "web")},
+		{filepath.Join(dir, "clusters"), filepath.Join(dir, "clusters", "b", "local", "loop")},
+		{filepath.Join(dir, "missing"), filepath.Join(dir, "clusters", "b", "broken")},
+	} {
+		if err := os.Symlink(link.target, link.name); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+	naming := defaultNaming(t, "web")
+
+	pairs, err := detectPairs(context.Background(), dir, discoveryOptions{naming: naming})
+	if err != nil {
+		t.Fatalf("detectPairs returned error: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].service != filepath.Join(dir, "clusters", "b", "local", "web.yaml") {
+		t.Errorf("expected only the pair without links, got %v", pairs)
+	}
+
+	pairs, err = detectPairs(context.Background(), dir, discoveryOptions{naming: naming, followSymlinks: true})
+	if err != nil {
+		t.Fatalf("detectPairs returned error: %v", err)
+	}
+	var found []string
+	for _, pair := range pairs {
+		rel, _ := filepath.Rel(dir, pair.service)
+		found = append(found, pair.overrides[0][len(dir)+1:]+" "+filepath.ToSlash(rel))
+	}
+	want := []string{
+		"clusters/a/overrides.yaml clusters/a/web/web.yaml",
+		"clusters/b/overrides.yaml clusters/b/local/web.yaml",
+		"clusters/b/overrides.yaml clusters/b/web/web.yaml",
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("expected %v, got %v", want, found)
+	}
+}
+
+// TestRootCmd verifies that every command can be built, which panics on clashing flags.
+func TestRootCmd(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		// Merges the persistent flags of the parents, which panics on clashes as well.
+		cmd.InheritedFlags()
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(newRootCmd())
+}
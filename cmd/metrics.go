@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Results of a validation request, the result label of the metrics.
+const (
+	resultPassed = "passed"
+	resultFailed = "failed"
+	resultError  = "error"
+)
+
+// serveMetrics are the Prometheus metrics of the serve command.
+type serveMetrics struct {
+	registry    *prometheus.Registry
+	validations *prometheus.CounterVec
+	findings    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+}
+
+// newServeMetrics registers the metrics of the server, with the Go runtime and process
+// metrics, in a registry of their own.
+func newServeMetrics() *serveMetrics {
+	m := &serveMetrics{
+		registry: prometheus.NewRegistry(),
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kc_validations_total",
+			Help: "Validation requests by result: passed, failed by their findings, or error.",
+		}, []string{"result"}),
+		findings: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kc_findings_total",
+			Help: "Findings reported by validation requests, by rule and severity.",
+		}, []string{"rule", "severity"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kc_validation_duration_seconds",
+			Help:    "Duration of validation requests, including loading the chart, by result.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"result"}),
+	}
+	info := currentBuild()
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kc_build_info",
+		Help: "Version of kc and of the Helm SDK it is compiled against; always 1.",
+	}, []string{"version", "helm"})
+	buildInfo.WithLabelValues(info.Version, info.Helm).Set(1)
+	m.registry.MustRegister(
+		m.validations, m.findings, m.duration, buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// Results are exported before the first request, so rates start at zero.
+	for _, result := range []string{resultPassed, resultFailed, resultError} {
+		m.validations.WithLabelValues(result)
+	}
+	return m
+}
+
+// observe records a validation request that took since start. resp is nil if it failed.
+func (m *serveMetrics) observe(start time.Time, resp *validateResponse) {
+	result := resultError
+	if resp != nil {
+		result = resultFailed
+		if resp.Passed {
+			result = resultPassed
+		}
+		for _, f := range resp.Findings {
+			m.findings.WithLabelValues(f.RuleID, string(f.Severity)).Inc()
+		}
+	}
+	m.validations.WithLabelValues(result).Inc()
+	m.duration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// handler serves the metrics in the Prometheus exposition format.
+func (m *serveMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// minimizeOptions holds the flags of the minimize command.
+type minimizeOptions struct {
+	validateOptions
+
+	inPlace bool
+}
+
+func newMinimizeCmd() *cobra.Command {
+	o := &minimizeOptions{}
+	cmd := &cobra.Command{
+		Use:   "minimize <chart> [-f <values-file> ...]",
+		Short: "Write values files with only the values that change something",
+		Long: `Print the values files without the values that match the chart defaults, or the value
+already set by an earlier values file of the same set. Comments of the remaining keys are kept.
+
+With --in-place, the values files are rewritten instead. SOPS-encrypted, remote, and stdin
+documents are never rewritten. If no -f is provided, the auto-detected values sets are
+minimized.`,
+		Example: `  helm kc minimize ./mychart -f values.yaml > values.min.yaml
+  helm kc minimize ./mychart -f overrides.yaml -f production/web_service.yaml --in-place`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().BoolVarP(&o.inPlace, "in-place", "i", false, "Rewrite the values files instead of printing them")
+	return cmd
+}
+
+func (o *minimizeOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	o.begin(nil)
+	opts, err := o.options()
+	if err != nil {
+		return err
+	}
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return err
+	}
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return err
+	}
+
+	// Values sets share files like overrides.yaml, which are minimized once.
+	done := map[string]bool{}
+	type document struct {
+		file string
+		data []byte
+	}
+	var documents []document
+	for _, files := range sets {
+		provided, err := o.mergeValues(files)
+		if err != nil {
+			return fmt.Errorf("failed to load values: %w", err)
+		}
+		defaults, err := c.defaults.Get(provided)
+		if err != nil {
+			return fmt.Errorf("computing chart defaults: %w", err)
+		}
+		for i, file := range files {
+			if done[file] {
+				continue
+			}
+			done[file] = true
+
+			earlier, err := o.mergeFiles(files[:i])
+			if err != nil {
+				return err
+			}
+			data, err := o.reader.read(file)
+			if err != nil {
+				return err
+			}
+			minimized, removed, err := kc.Minimize(data, mergeMaps(defaults, earlier), opts)
+			if err != nil {
+				return fmt.Errorf("minimizing %s: %w", file, err)
+			}
+			if !o.inPlace {
+				documents = append(documents, document{file: file, data: minimized})
+				continue
+			}
+			if len(removed) == 0 || !o.isLocalFile(file) {
+				continue
+			}
+			written, err := writeMinimized(file, minimized)
+			if err != nil {
+				return err
+			}
+			if written {
+				fmt.Fprintf(out, "Minimized %s: removed %d values\n", file, len(removed))
+			}
+		}
+	}
+
+	for _, doc := range documents {
+		if len(documents) > 1 {
+			fmt.Fprintf(out, "---\n# Source: %s\n", doc.file)
+		}
+		out.Write(doc.data)
+	}
+	return nil
+}
+
+// writeMinimized rewrites a values file unless it is encrypted with SOPS, since the
+// minimized document holds the decrypted values. It reports whether the file was written.
+func writeMinimized(file string, data []byte) (bool, error) {
+	original, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	if isSOPSEncrypted(original) {
+		logger.Warn("skipping encrypted file, which is not rewritten", "file", file)
+		return false, nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, data, info.Mode().Perm())
+}
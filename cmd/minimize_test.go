@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMinimizeRun verifies that values matching the defaults or an earlier file are removed
+// from each file of the set, in place.
+func TestMinimizeRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"overrides.yaml":          "replicaCount: 1\nimage:\n  tag: \"1.25\"\n",
+		"web_service.yaml":        "replicaCount: 3\nimage:\n  tag: \"1.25\"\n",
+	})
+
+	o := &minimizeOptions{inPlace: true}
+	o.valuesFiles = []string{filepath.Join(dir, "overrides.yaml"), filepath.Join(dir, "web_service.yaml")}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, filepath.Join(dir, "web_service")); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"overrides.yaml":   "image:\n  tag: \"1.25\"\n",
+		"web_service.yaml": "replicaCount: 3\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+}
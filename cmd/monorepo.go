@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// findCharts returns every chart directory below the given directories, i.e. every directory
+// with a Chart.yaml. Charts are not searched for further charts, so vendored subcharts are
+// validated as part of their parent.
+func findCharts(dirs []string) ([]string, error) {
+	var charts []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != dir && isDefaultExcluded(d.Name()) {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, chartutil.ChartfileName)); err == nil {
+				charts = append(charts, path)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return charts, nil
+}
+
+// isDefaultExcluded reports whether a directory named name is never searched.
+func isDefaultExcluded(name string) bool {
+	for _, exclude := range defaultExcludeDirs {
+		if name == exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// runCharts validates every chart below the --charts directories against its auto-detected
+// values sets and reports all findings together.
+func (o *validateOptions) runCharts(ctx context.Context, out io.Writer) error {
+	if len(o.serviceNames) > 0 {
+		return fmt.Errorf("--service-name applies to a single chart; map chart names to service names with discovery.serviceNames in the config file instead")
+	}
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	charts, err := findCharts(o.charts)
+	if err != nil {
+		return fmt.Errorf("finding charts: %w", err)
+	}
+	if len(charts) == 0 {
+		return fmt.Errorf("no charts found in %v", o.charts)
+	}
+
+	verbose := o.banners()
+	issuesFound := false
+	for _, chartPath := range charts {
+		if verbose {
+			fmt.Fprintf(out, "\nChart: %s\n", chartPath)
+		}
+		found, err := o.validate(ctx, out, chartPath, opts, reporter, verbose)
+		if errors.Is(err, errNoValuesSets) {
+			if verbose {
+				fmt.Fprintln(out, "No values sets found, skipping.")
+			}
+			continue
+		}
+		if err != nil {
+			logger.Error("failed to validate chart", "chart", chartPath, "err", err)
+			o.loadFailures++
+			continue
+		}
+		issuesFound = issuesFound || found
+		if o.failedFast {
+			break
+		}
+	}
+	overridesFound, err := o.checkUnusedOverrides(ctx, opts, reporter)
+	if err != nil {
+		return err
+	}
+	issuesFound = issuesFound || overridesFound
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestRunCharts verifies that every chart below --charts is validated against its own service
+// files, that charts without values sets are skipped, and that subcharts are not validated on
+// their own.
+func TestRunCharts(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"charts/web/Chart.yaml":               "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml":              "replicaCount: 1\n",
+		"charts/web/charts/redis/Chart.yaml":  "apiVersion: v2\nname: redis\nversion: 1.0.0\n",
+		"charts/web/charts/redis/values.yaml": "enabled: true\n",
+		"charts/backend/api/Chart.yaml":       "apiVersion: v2\nname: api\nversion: 1.0.0\n",
+		"charts/backend/api/values.yaml":      "port: 80\n",
+		"charts/cron/Chart.yaml":              "apiVersion: v2\nname: cron\nversion: 1.0.0\n",
+		"charts/cron/values.yaml":             "schedule: '@daily'\n",
+		"envs/overrides.yaml":                 "{}\n",
+		"envs/prod/web.yaml":                  "replicaCount: 1\n",
+		"envs/prod/api.yaml":                  "port: \"8080\"\n",
+		"envs/prod/redis.yaml":                "enabled: true\n",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	charts, err := findCharts([]string{"charts"})
+	if err != nil {
+		t.Fatalf("findCharts returned an error: %v", err)
+	}
+	if len(charts) != 3 {
+		t.Errorf("expected 3 charts, got %v", charts)
+	}
+
+	o := &validateOptions{charts: []string{"charts"}, output: "json", jobs: 1}
+	var out bytes.Buffer
+	if err := o.runCharts(context.Background(), &out); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	var report struct {
+		Findings []kc.Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	var found []string
+	for _, f := range report.Findings {
+		found = append(found, f.Files[len(f.Files)-1]+":"+f.Path)
+	}
+	want := []string{"envs/prod/api.yaml:port", "envs/prod/web.yaml:replicaCount"}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("expected findings %v, got %v", want, found)
+	}
+}
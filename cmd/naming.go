@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultOverrideName is the shared values file looked up above every service file.
+	defaultOverrideName = "overrides.yaml"
+	// defaultServicePattern names the values file of a service after its chart.
+	defaultServicePattern = "{chart}.yaml"
+	// valuesDirName is the directory of values fragments merged after the service files next
+	// to it.
+	valuesDirName = "values.d"
+)
+
+// namingConvention describes the file names of auto-detected values sets: the layers of
+// shared overrides files and the pattern of the service files.
+type namingConvention struct {
+	// layers are the file names of the overrides layers, from the most general to the most
+	// specific one.
+	layers  []string
+	pattern string
+	service *regexp.Regexp
+}
+
+// newNamingConvention builds the naming convention of a chart whose service files are named
+// after any of serviceNames. layers default to a single overrides.yaml. In servicePattern,
+// "{chart}" is replaced with a service name, "{env}" and "*" match any part of a file name,
+// and "?" matches a single character.
+func newNamingConvention(serviceNames []string, layers []string, servicePattern string) (namingConvention, error) {
+	if len(layers) == 0 {
+		layers = []string{defaultOverrideName}
+	}
+	if servicePattern == "" {
+		servicePattern = defaultServicePattern
+	}
+	seen := map[string]bool{}
+	for _, layer := range layers {
+		if layer == "" || strings.ContainsRune(layer, '/') {
+			return namingConvention{}, fmt.Errorf("override layer must be a file name: %q", layer)
+		}
+		if seen[layer] {
+			return namingConvention{}, fmt.Errorf("override layer %s is listed more than once", layer)
+		}
+		seen[layer] = true
+	}
+	if strings.ContainsRune(servicePattern, '/') {
+		return namingConvention{}, fmt.Errorf("service pattern must match a file name: %s", servicePattern)
+	}
+
+	quoted := make([]string, len(serviceNames))
+	for i, name := range serviceNames {
+		if name == "" || strings.ContainsRune(name, '/') {
+			return namingConvention{}, fmt.Errorf("service name must be a file name: %q", name)
+		}
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	names := serviceNames[0]
+	if len(serviceNames) > 1 {
+		names = "(" + strings.Join(serviceNames, "|") + ")"
+	}
+
+	var b strings.Builder
+	for rest := servicePattern; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "{chart}"):
+			b.WriteString("(?:" + strings.Join(quoted, "|") + ")")
+			rest = rest[len("{chart}"):]
+		case strings.HasPrefix(rest, "{env}"):
+			b.WriteString(".+")
+			rest = rest[len("{env}"):]
+		case strings.HasPrefix(rest, "{"):
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			return namingConvention{}, fmt.Errorf("unknown placeholder %s in service pattern %s", rest[:end+1], servicePattern)
+		case rest[0] == '*':
+			b.WriteString(".*")
+			rest = rest[1:]
+		case rest[0] == '?':
+			b.WriteString(".")
+			rest = rest[1:]
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+		}
+	}
+	return namingConvention{
+		layers:  layers,
+		pattern: strings.ReplaceAll(servicePattern, "{chart}", names),
+		service: regexp.MustCompile("^" + b.String() + "$"),
+	}, nil
+}
+
+// layer returns the index of the overrides layer named name, or -1.
+func (n namingConvention) layer(name string) int {
+	for i, layer := range n.layers {
+		if layer == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isService reports whether name is the file name of a service values file.
+func (n namingConvention) isService(name string) bool {
+	return n.layer(name) < 0 && n.service.MatchString(name)
+}
+
+// String describes the convention for messages, e.g. "overrides.yaml + web.yaml".
+func (n namingConvention) String() string {
+	return strings.Join(append(append([]string{}, n.layers...), n.pattern), " + ")
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// defaultNaming returns the default naming convention of a chart.
+func defaultNaming(t *testing.T, chartName string) namingConvention {
+	t.Helper()
+	naming, err := newNamingConvention([]string{chartName}, nil, "")
+	if err != nil {
+		t.Fatalf("newNamingConvention returned an error: %v", err)
+	}
+	return naming
+}
+
+// TestNamingConvention verifies the placeholders and wildcards of service patterns.
+func TestNamingConvention(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"", "web.yaml", true},
+		{"", "web.yml", false},
+		{"", "webXyaml", false},
+		{"{chart}-{env}.yaml", "web-prod.yaml", true},
+		{"{chart}-{env}.yaml", "web-.yaml", false},
+		{"{chart}-{env}.yaml", "api-prod.yaml", false},
+		{"values-{chart}.y*ml", "values-web.yml", true},
+		{"values-{chart}.y*ml", "values-web.yaml", true},
+		{"{env}.yaml", "values-common.yaml", false},
+	}
+	for _, tt := range tests {
+		naming, err := newNamingConvention([]string{"web"}, []string{"values-common.yaml"}, tt.pattern)
+		if err != nil {
+			t.Fatalf("newNamingConvention(%q) returned an error: %v", tt.pattern, err)
+		}
+		if got := naming.isService(tt.name); got != tt.want {
+			t.Errorf("pattern %q, name %q: expected %v, got %v", tt.pattern, tt.name, tt.want, got)
+		}
+	}
+
+	for _, pattern := range []string{"{service}.yaml", "envs/{chart}.yaml", "{chart"} {
+		if _, err := newNamingConvention([]string{"web"}, nil, pattern); err == nil {
+			t.Errorf("expected an error for pattern %q", pattern)
+		}
+	}
+}
+
+// TestValuesSetsNamingConvention verifies that auto-detection follows the naming convention
+// from the flags, falling back to the configuration file.
+func TestValuesSetsNamingConvention(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".kaartcontrole.yaml":              "discovery:\n  overrideName: values-common.yaml\n  servicePattern: '{chart}-{env}.yaml'\n",
+		"values-common.yaml":               "a: 1",
+		"prod/web-prod.yaml":               "a: 2",
+		"staging/web-staging.yaml":         "a: 3",
+		"staging/overrides.yaml":           "a: 4",
+		"staging/web.yaml":                 "a: 5",
+		"staging/api-staging.yaml":         "a: 6",
+		"staging/nested/values-common.yml": "a: 7",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &validateOptions{}
+	sets, err := o.valuesSets(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want := [][]string{
+		{"values-common.yaml", filepath.Join("prod", "web-prod.yaml")},
+		{"values-common.yaml", filepath.Join("staging", "web-staging.yaml")},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	// Flags take precedence over the configuration file.
+	o = &validateOptions{overrideName: "overrides.yaml", servicePat: "{chart}.yaml"}
+	sets, err = o.valuesSets(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want = [][]string{{filepath.Join("staging", "overrides.yaml"), filepath.Join("staging", "web.yaml")}}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	o = &validateOptions{servicePat: "{chart}.json"}
+	if _, err := o.valuesSets(context.Background(), "web"); err == nil || err.Error() != "no valid values files (values-common.yaml + web.json) found in base directory: "+dir {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestValuesSetsLayers verifies that auto-detection stacks the nearest file of every layer,
+// from the most general one, and skips layers that are missing for a service.
+func TestValuesSetsLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"overrides.yaml":                       "a: 1",
+		"eu/region.yaml":                       "a: 2",
+		"eu/prod/cluster.yaml":                 "a: 3",
+		"eu/prod/web.yaml":                     "a: 4",
+		"eu/staging/web.yaml":                  "a: 5",
+		"us/cluster.yaml":                      "a: 6",
+		"us/prod/web.yaml":                     "a: 7",
+		"us/prod/region.yaml":                  "a: 8",
+		"us/prod/services/overrides.yaml":      "a: 9",
+		"us/prod/services/nested/web.yaml":     "a: 10",
+		"unrelated/nested/cluster.yaml":        "a: 11",
+		"unrelated/nested/services/other.yaml": "a: 12",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &validateOptions{layers: []string{"overrides.yaml", "region.yaml", "cluster.yaml"}}
+	sets, err := o.valuesSets(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want := [][]string{
+		{"overrides.yaml", filepath.Join("eu", "region.yaml"), filepath.Join("eu", "prod", "cluster.yaml"), filepath.Join("eu", "prod", "web.yaml")},
+		{"overrides.yaml", filepath.Join("eu", "region.yaml"), filepath.Join("eu", "staging", "web.yaml")},
+		{"overrides.yaml", filepath.Join("us", "prod", "region.yaml"), filepath.Join("us", "cluster.yaml"), filepath.Join("us", "prod", "web.yaml")},
+		{filepath.Join("us", "prod", "services", "overrides.yaml"), filepath.Join("us", "prod", "region.yaml"), filepath.Join("us", "cluster.yaml"), filepath.Join("us", "prod", "services", "nested", "web.yaml")},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	o = &validateOptions{layers: []string{"overrides.yaml"}, overrideName: "values.yaml"}
+	if _, err := o.valuesSets(context.Background(), "web"); err == nil {
+		t.Errorf("expected an error for --layer with --override-name")
+	}
+}
+
+// TestValuesSetsServiceNames verifies that service files can be named differently from the
+// chart, with --service-name or a mapping in the configuration file.
+func TestValuesSetsServiceNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".kaartcontrole.yaml":      "discovery:\n  serviceNames:\n    web-service: [web_service, frontend]\n",
+		"overrides.yaml":           "a: 1",
+		"prod/web_service.yaml":    "a: 2",
+		"staging/frontend.yaml":    "a: 3",
+		"staging/web-service.yaml": "a: 4",
+		"dev/backend.yaml":         "a: 5",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &validateOptions{}
+	sets, err := o.valuesSets(context.Background(), "web-service")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want := [][]string{
+		{"overrides.yaml", filepath.Join("prod", "web_service.yaml")},
+		{"overrides.yaml", filepath.Join("staging", "frontend.yaml")},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	// The flag takes precedence over the configuration file.
+	o = &validateOptions{serviceNames: []string{"backend"}}
+	sets, err = o.valuesSets(context.Background(), "web-service")
+	if err != nil {
+		t.Fatalf("valuesSets returned an error: %v", err)
+	}
+	want = [][]string{{"overrides.yaml", filepath.Join("dev", "backend.yaml")}}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("expected %v, got %v", want, sets)
+	}
+
+	o = &validateOptions{serviceNames: []string{"api", "worker"}}
+	if _, err := o.valuesSets(context.Background(), "web-service"); err == nil || err.Error() != "no valid values files (overrides.yaml + (api|worker).yaml) found in base directory: "+dir {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/spf13/pflag"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// reporterOptions configures the reporters of the output formats that support it.
+type reporterOptions struct {
+	groupBy kc.GroupBy
+	quiet   bool
+	ascii   bool
+}
+
+// reporters holds the constructors of the reporters of every output format.
+var reporters = map[string]func(io.Writer, reporterOptions) kc.Reporter{
+	"text": func(w io.Writer, opts reporterOptions) kc.Reporter {
+		r := kc.NewConsoleReporter(w)
+		r.GroupBy = opts.groupBy
+		r.Quiet = opts.quiet
+		r.ASCII = opts.ascii
+		r.Color = useColor(w)
+		return r
+	},
+	"json":        func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewJSONReporter(w) },
+	"ndjson":      func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewNDJSONReporter(w) },
+	"sarif":       func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewSARIFReporter(w) },
+	"html":        func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewHTMLReporter(w) },
+	"markdown":    func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewMarkdownReporter(w) },
+	"teamcity":    func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewTeamCityReporter(w) },
+	"azure":       func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewAzureReporter(w) },
+	"buildkite":   func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewBuildkiteReporter(w) },
+	"github":      func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewGitHubReporter(w) },
+	"codequality": func(w io.Writer, _ reporterOptions) kc.Reporter { return kc.NewCodeQualityReporter(w) },
+}
+
+func (o *validateOptions) addOutputFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.output, "output", "o", "text", "Output format: text, json, ndjson, sarif, html, markdown, teamcity, azure, buildkite, github, codequality, or go-template='{{...}}'")
+	fs.StringVar(&o.templateFile, "output-template-file", "", "File with the Go template of -o go-template")
+	fs.StringVar(&o.minSeverity, "min-severity", "", "Minimum severity of the findings to report: error, warning, or info (default info)")
+	fs.StringVar(&o.groupBy, "group-by", string(kc.GroupByEnvironment), "Grouping of findings in text output: environment, file, rule, or none")
+	fs.StringArrayVar(&o.outputFiles, "output-file", nil, "Write the report to this file instead of stdout, or write an additional report with format=path, e.g. sarif=kc.sarif; it has no shorthand, since -o is the output format (can be specified multiple times)")
+}
+
+// newReporter returns the Reporter implementation for the requested output format.
+func newReporter(format string, w io.Writer, opts reporterOptions) (kc.Reporter, error) {
+	if format == "" {
+		format = "text"
+	}
+	if text, ok := strings.CutPrefix(format, "go-template="); ok {
+		return newTemplateReporter(text, w)
+	}
+	if format == "go-template" {
+		return nil, errors.New("-o go-template needs a template: use go-template='{{...}}' or --output-template-file")
+	}
+	newReporter, ok := reporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+	return newReporter(w, opts), nil
+}
+
+// newReporters returns the reporter of --output, writing to out unless --output-file
+// redirects it, combined with the additional reporters of --output-file.
+func (o *validateOptions) newReporters(out io.Writer) (kc.Reporter, error) {
+	format := o.output
+	if o.templateFile != "" {
+		if o.output != "text" && o.output != "go-template" {
+			return nil, errors.New("--output-template-file can only be used with -o go-template")
+		}
+		data, err := os.ReadFile(o.templateFile)
+		if err != nil {
+			return nil, err
+		}
+		// The output format holds the template.
+		format = "go-template=" + string(data)
+	}
+	groupBy, err := kc.ParseGroupBy(o.groupBy)
+	if err != nil {
+		return nil, err
+	}
+	opts := reporterOptions{groupBy: groupBy, quiet: verbosity == levelQuiet, ascii: asciiOutput}
+	if _, err := newReporter(format, io.Discard, opts); err != nil {
+		return nil, err
+	}
+	var minSeverity kc.Severity
+	if o.minSeverity != "" {
+		if minSeverity, err = kc.ParseSeverity(o.minSeverity); err != nil {
+			return nil, fmt.Errorf("--min-severity: %w", err)
+		}
+	}
+	type output struct{ format, path string }
+	var outputs []output
+	redirected := false
+	for _, value := range o.outputFiles {
+		outputFormat, path, ok := strings.Cut(value, "=")
+		if _, known := reporters[outputFormat]; !ok || !known {
+			if redirected {
+				return nil, errors.New("--output-file without a format can only be specified once")
+			}
+			outputFormat, path, redirected = format, value, true
+		}
+		if path == "" {
+			return nil, fmt.Errorf("--output-file %s: missing path", value)
+		}
+		outputs = append(outputs, output{outputFormat, path})
+	}
+
+	var multi kc.MultiReporter
+	if !redirected {
+		reporter, _ := newReporter(format, out, opts)
+		multi = append(multi, reporter)
+	}
+	for _, output := range outputs {
+		f, err := os.Create(output.path)
+		if err != nil {
+			return nil, err
+		}
+		reporter, _ := newReporter(output.format, f, opts)
+		multi = append(multi, &fileReporter{Reporter: reporter, f: f})
+	}
+	var reporter kc.Reporter = multi
+	if len(multi) == 1 {
+		reporter = multi[0]
+	}
+	if minSeverity != "" {
+		reporter = kc.SeverityFilter{Reporter: reporter, Min: minSeverity}
+	}
+	return reporter, nil
+}
+
+// fileReporter closes the file of a reporter once its report is written.
+type fileReporter struct {
+	kc.Reporter
+	f *os.File
+}
+
+func (r *fileReporter) Summary() {
+	r.Reporter.Summary()
+	if err := r.f.Close(); err != nil {
+		logger.Error("failed to write report", "file", r.f.Name(), "err", err)
+	}
+}
+
+// templateReporter formats findings with a Go template, executed on the document of the json
+// output format, so fields are accessed by their JSON names: {{range .findings}}{{.ruleId}}{{end}}.
+type templateReporter struct {
+	w        io.Writer
+	tmpl     *template.Template
+	findings []kc.Finding
+}
+
+func newTemplateReporter(text string, w io.Writer) (*templateReporter, error) {
+	tmpl, err := template.New("output").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing output template: %w", err)
+	}
+	return &templateReporter{w: w, tmpl: tmpl}, nil
+}
+
+func (r *templateReporter) Report(f kc.Finding) {
+	r.findings = append(r.findings, f)
+}
+
+func (r *templateReporter) Summary() {
+	findings := r.findings
+	if findings == nil {
+		findings = []kc.Finding{}
+	}
+	data, err := json.Marshal(map[string]interface{}{"findings": findings})
+	if err == nil {
+		var doc interface{}
+		if err = json.Unmarshal(data, &doc); err == nil {
+			err = r.tmpl.Execute(r.w, doc)
+		}
+	}
+	if err != nil {
+		logger.Error("failed to execute output template", "err", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputFiles verifies that --output-file redirects the report of --output and adds
+// reports in other formats.
+func TestOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\n",
+		"values.yaml":       "replicaCount: 1\n",
+	})
+	chart := filepath.Join(dir, "chart")
+	sarif := filepath.Join(dir, "kc.sarif")
+
+	o := &validateOptions{output: "text", jobs: 1, outputFiles: []string{"sarif=" + sarif}}
+	o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, chart); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Validation completed: Issues were found") {
+		t.Errorf("expected a text report on stdout, got:\n%s", out.String())
+	}
+	data, err := os.ReadFile(sarif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifDocument
+	if err := json.Unmarshal(data, &log); err != nil || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Errorf("expected a SARIF report with one result, got %v:\n%s", err, data)
+	}
+
+	report := filepath.Join(dir, "report.json")
+	o.output, o.outputFiles = "json", []string{report}
+	out.Reset()
+	if err := o.run(context.Background(), &out, chart); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output on stdout, got:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(report); !bytes.Contains(data, []byte(`"ruleId": "KC001"`)) {
+		t.Errorf("expected a JSON report, got:\n%s", data)
+	}
+
+	o.outputFiles = []string{report, report}
+	if err := o.run(context.Background(), &out, chart); err == nil {
+		t.Error("expected an error for two redirections")
+	}
+}
+
+// sarifDocument is the part of a SARIF log checked by tests.
+type sarifDocument struct {
+	Runs []struct {
+		Results []json.RawMessage `json:"results"`
+	} `json:"runs"`
+}
+
+// TestOutputTemplate verifies that -o go-template formats findings by their JSON names, from
+// the flag or from --output-template-file.
+func TestOutputTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"chart/values.yaml": "replicaCount: 1\nport: 80\n",
+		"values.yaml":       "replicaCount: 1\nport: http\n",
+		"report.tmpl":       `{{len .findings}} findings{{"\n"}}`,
+	})
+	chart := filepath.Join(dir, "chart")
+
+	o := &validateOptions{output: `go-template={{range .findings}}{{.ruleId}} {{.path | upper}}{{"\n"}}{{end}}`, jobs: 1}
+	o.valuesFiles = []string{filepath.Join(dir, "values.yaml")}
+	var out bytes.Buffer
+	if err := o.run(context.Background(), &out, chart); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	if want := "KC002 PORT\nKC001 REPLICACOUNT\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	o.output, o.templateFile = "text", filepath.Join(dir, "report.tmpl")
+	out.Reset()
+	if err := o.run(context.Background(), &out, chart); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	if want := "2 findings\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	o.output, o.templateFile = "go-template={{.findings", ""
+	if err := o.run(context.Background(), &out, chart); err == nil || !strings.Contains(err.Error(), "parsing output template") {
+		t.Errorf("expected a template error, got %v", err)
+	}
+}
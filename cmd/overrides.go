@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// overridesUsage records the overrides files merged into the validated values sets, for
+// --check-overrides.
+type overridesUsage struct {
+	// chartName is a validated chart, to search for overrides files like its discovery did.
+	chartName string
+	// charts holds the charts every overrides file was merged for, by path, and keys the
+	// top-level keys those charts define.
+	charts map[string][]string
+	keys   map[string]map[string]bool
+}
+
+// recordOverrides records the overrides files of the values sets of c.
+func (o *validateOptions) recordOverrides(c *loadedChart, sets [][]string) error {
+	discoveryOpts, err := o.discoveryOptions(c.name)
+	if err != nil {
+		return err
+	}
+	if o.overrides == nil {
+		o.overrides = &overridesUsage{charts: map[string][]string{}, keys: map[string]map[string]bool{}}
+	}
+	o.overrides.chartName = c.name
+	keys := chartKeys(c)
+	for _, files := range sets {
+		for _, file := range files {
+			if discoveryOpts.naming.layer(filepath.Base(file)) < 0 {
+				continue
+			}
+			charts := o.overrides.charts[file]
+			if len(charts) > 0 && charts[len(charts)-1] == c.name {
+				continue
+			}
+			o.overrides.charts[file] = append(charts, c.name)
+			if o.overrides.keys[file] == nil {
+				o.overrides.keys[file] = map[string]bool{}
+			}
+			for key := range keys {
+				o.overrides.keys[file][key] = true
+			}
+		}
+	}
+	return nil
+}
+
+// chartKeys returns the top-level keys of the values of c: its defaults and its subcharts.
+func chartKeys(c *loadedChart) map[string]bool {
+	keys := map[string]bool{}
+	for key := range c.chart.Values {
+		keys[key] = true
+	}
+	for _, dep := range c.chart.Dependencies() {
+		keys[dep.Name()] = true
+	}
+	if c.chart.Metadata != nil {
+		for _, dep := range c.chart.Metadata.Dependencies {
+			keys[dep.Name] = true
+			if dep.Alias != "" {
+				keys[dep.Alias] = true
+			}
+		}
+	}
+	return keys
+}
+
+// checkUnusedOverrides reports the overrides files below the searched directories that were
+// not merged into any validated values set, or set keys none of their charts define. Runs
+// that do not validate every auto-detected values set are not checked.
+func (o *validateOptions) checkUnusedOverrides(ctx context.Context, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	if !o.checkOverrides || o.overrides == nil || o.changed.enabled() || o.failedFast {
+		return false, nil
+	}
+	envDir, discoveryOpts, scopes, err := o.discoveryScope(o.overrides.chartName)
+	if err != nil {
+		return false, err
+	}
+	paths, err := findOverridesFiles(ctx, envDir, discoveryOpts, scopes...)
+	if err != nil {
+		return false, fmt.Errorf("finding overrides files: %w", err)
+	}
+	files := make([]kc.OverridesFile, 0, len(paths))
+	for _, path := range paths {
+		path = relPath(envDir, path)
+		values, err := o.mergeFiles([]string{path})
+		if err != nil {
+			return false, &loadError{fmt.Errorf("failed to load values: %w", err)}
+		}
+		suppressions, err := o.suppressions([]string{path})
+		if err != nil {
+			return false, fmt.Errorf("reading inline suppressions: %w", err)
+		}
+		charts := o.overrides.charts[path]
+		sort.Strings(charts)
+		files = append(files, kc.OverridesFile{
+			Path:         path,
+			Values:       values,
+			Charts:       charts,
+			Keys:         o.overrides.keys[path],
+			Suppressions: suppressions,
+		})
+	}
+	return kc.CheckUnusedOverrides(files, opts, reporter), nil
+}
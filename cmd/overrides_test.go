@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestCheckUnusedOverrides verifies that overrides files no chart merges are reported, as are
+// the keys of shared overrides files that none of the charts merging them define.
+func TestCheckUnusedOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"charts/web/Chart.yaml":       "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml":      "replicaCount: 1\n",
+		"charts/api/Chart.yaml":       "apiVersion: v2\nname: api\nversion: 1.0.0\ndependencies:\n  - name: redis\n    version: 1.0.0\n    alias: cache\n",
+		"charts/api/values.yaml":      "port: 80\n",
+		"envs/overrides.yaml":         "replicaCount: 2\nport: 8080\ncache:\n  enabled: true\nlegacy: true\nglobal:\n  env: prod\n",
+		"envs/prod/web.yaml":          "replicaCount: 3\n",
+		"envs/prod/api.yaml":          "port: 8081\n",
+		"envs/retired/overrides.yaml": "replicaCount: 1\n",
+		"envs/retired/notes.yaml":     "owner: nobody\n",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &validateOptions{charts: []string{"charts"}, output: "json", jobs: 1, checkOverrides: true, disableRules: []string{"KC004"}}
+	var out bytes.Buffer
+	if err := o.runCharts(context.Background(), &out); !errors.Is(err, errIssuesFound) {
+		t.Fatalf("expected issues to be found, got %v", err)
+	}
+	var report struct {
+		Findings []kc.Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	want := []string{
+		"Unused override: 'legacy' is not defined by the charts envs/overrides.yaml is merged for: api, web",
+		"Unused overrides: envs/retired/overrides.yaml is not merged into any values set",
+	}
+	var got []string
+	for _, f := range report.Findings {
+		if f.RuleID == kc.RuleUnusedOverrides {
+			got = append(got, f.Message)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %q, got %q", want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/plugin"
+)
+
+// TestPluginMetadata verifies that Helm loads plugin.yaml and passes the arguments of
+// helm kc on to the binary of the platform.
+func TestPluginMetadata(t *testing.T) {
+	dir, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := plugin.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("loading plugin.yaml: %v", err)
+	}
+	if p.Metadata.Name != "kc" || p.Metadata.Hooks[plugin.Install] == "" || p.Metadata.Hooks[plugin.Update] == "" {
+		t.Errorf("expected the kc plugin with install and update hooks, got %+v", p.Metadata)
+	}
+
+	t.Setenv("HELM_PLUGIN_DIR", dir)
+	main, args, err := p.PrepareCommand([]string{"./mychart", "-f", "values.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "bin", "kc")
+	if runtime.GOOS == "windows" {
+		want += ".exe"
+	}
+	if filepath.Clean(main) != want {
+		t.Errorf("expected the command %s, got %s", want, main)
+	}
+	if !reflect.DeepEqual(args, []string{"./mychart", "-f", "values.yaml"}) {
+		t.Errorf("expected the arguments to be passed on, got %v", args)
+	}
+}
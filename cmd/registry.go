@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// newRegistryClient creates an OCI registry client that reuses the credentials stored by
+// `helm registry login` in Helm's registry config.
+func (o *chartOptions) newRegistryClient(settings *cli.EnvSettings) (*registry.Client, error) {
+	if o.CertFile != "" && o.KeyFile != "" || o.CaFile != "" || o.InsecureSkipTLSverify {
+		return registry.NewRegistryClientWithTLS(os.Stderr, o.CertFile, o.KeyFile, o.CaFile,
+			o.InsecureSkipTLSverify, settings.RegistryConfig, settings.Debug)
+	}
+
+	opts := []registry.ClientOption{
+		registry.ClientOptDebug(settings.Debug),
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptWriter(os.Stderr),
+		registry.ClientOptCredentialsFile(settings.RegistryConfig),
+		registry.ClientOptBasicAuth(o.Username, o.Password),
+	}
+	if o.PlainHTTP {
+		opts = append(opts, registry.ClientOptPlainHTTP())
+	}
+	return registry.NewClient(opts...)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// resolvedRelease is a chart together with the layered values it is deployed with, as declared
+// by a deployment tool such as helmfile or ArgoCD.
+type resolvedRelease struct {
+	name      string
+	namespace string
+	labels    map[string]string
+
+	chart    string
+	version  string
+	repoURL  string
+	username string
+	password string
+
+	// valuesFiles are applied in order, followed by the --set style values.
+	valuesFiles  []string
+	setValues    []string
+	stringValues []string
+	fileValues   []string
+	// missingFiles are optional values files that were skipped because they don't exist.
+	missingFiles []string
+}
+
+// validateReleases validates every release with the chart and values it declares. kind names
+// the releases in the text banners, e.g. "Release" or "Application".
+// It returns true if any failing issues were found.
+func (o *validateOptions) validateReleases(ctx context.Context, out io.Writer, kind string, releases []resolvedRelease, opts kc.Options, reporter kc.Reporter) bool {
+	verbose := o.banners()
+	issuesFound := false
+	for i, release := range releases {
+		if ctx.Err() != nil {
+			logger.Error("stopped validating releases", "kind", kind, "skipped", len(releases)-i, "err", context.Cause(ctx))
+			o.loadFailures++
+			break
+		}
+		if verbose {
+			fmt.Fprintf(out, "\n%s: %s (%s)\n", kind, release.name, release.chart)
+			for _, file := range release.missingFiles {
+				fmt.Fprintf(out, "Skipping missing values file: %s\n", file)
+			}
+		}
+		found, err := o.validateRelease(ctx, release, opts, reporter)
+		if err != nil {
+			logger.Error("failed to validate release", "kind", kind, "name", release.name, "err", err)
+			o.loadFailures++
+			continue
+		}
+		issuesFound = issuesFound || found
+		if o.stopFast() {
+			logger.Info("stopping at the first error (--fail-fast)", "kind", kind, "name", release.name)
+			break
+		}
+	}
+	return issuesFound
+}
+
+// validateRelease validates a single release with the chart and values it declares.
+func (o *validateOptions) validateRelease(ctx context.Context, release resolvedRelease, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	ro := *o
+	ro.Version = release.version
+	if release.repoURL != "" {
+		ro.RepoURL = release.repoURL
+	}
+	if release.username != "" {
+		ro.Username = release.username
+		ro.Password = release.password
+	}
+	ro.setValues.Values = append(append([]string{}, release.setValues...), o.setValues.Values...)
+	ro.setValues.StringValues = append(append([]string{}, release.stringValues...), o.setValues.StringValues...)
+	ro.setValues.FileValues = append(append([]string{}, release.fileValues...), o.setValues.FileValues...)
+
+	c, err := ro.loadChart(ctx, release.chart)
+	if err != nil {
+		return false, err
+	}
+	files := append(append([]string{}, release.valuesFiles...), o.valuesFiles...)
+	if o.fix {
+		if err := ro.fixFiles(c, files, opts); err != nil {
+			return false, err
+		}
+	}
+	providedValues, err := ro.mergeValues(files)
+	if err != nil {
+		return false, fmt.Errorf("failed to load values: %w", err)
+	}
+	return ro.validateValues(ctx, c, files, providedValues, opts, reporter)
+}
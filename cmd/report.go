@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Validate chart values and publish the findings to a code review or CI platform",
+	}
+	cmd.AddCommand(newGitHubPRCmd())
+	cmd.AddCommand(newGitLabMRCmd())
+	cmd.AddCommand(newBuildkiteCmd())
+	return cmd
+}
+
+// chartArgs accepts a chart argument unless --charts is set.
+func chartArgs(o *validateOptions) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(o.charts) > 0 {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
+// addReportFlags adds the validation flags of the report subcommands.
+func (o *validateOptions) addReportFlags(cmd *cobra.Command) {
+	o.addFlags(cmd.Flags())
+	o.addJobsFlag(cmd.Flags())
+	o.changed.addFlags(cmd.Flags())
+	cmd.Flags().StringArrayVar(&o.charts, "charts", nil, "Validate every chart below this directory instead of a single chart (can be specified multiple times)")
+}
+
+// runReport validates the chart given in args, or the charts of --charts, reporting the
+// findings to reporter, and returns whether issues were found.
+func (o *validateOptions) runReport(ctx context.Context, reporter kc.Reporter, args []string) (bool, error) {
+	o.begin(reporter)
+	var err error
+	if len(o.charts) > 0 {
+		err = o.runCharts(ctx, io.Discard)
+	} else {
+		err = o.run(ctx, io.Discard, args[0])
+	}
+	if errors.Is(err, errIssuesFound) {
+		return true, nil
+	}
+	return false, err
+}
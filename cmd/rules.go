@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// rulesOptions holds the flags of the rules commands.
+type rulesOptions struct {
+	configPath string
+	output     string
+}
+
+// catalogRule is a rule of the catalog: a built-in rule, or a custom rule of the
+// configuration, with the severity and state the configuration gives it.
+type catalogRule struct {
+	kc.Rule
+	// Custom rules are reported under the ID of custom-rule, with their own name.
+	Custom     bool   `json:"custom,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	When       string `json:"when,omitempty"`
+}
+
+func newRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "List and describe the rules kc checks",
+	}
+	cmd.AddCommand(newRulesListCmd())
+	cmd.AddCommand(newRulesDescribeCmd())
+	return cmd
+}
+
+func newRulesListCmd() *cobra.Command {
+	o := &rulesOptions{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the built-in rules and the custom rules of the configuration",
+		Long: `List the built-in rules and the custom rules of the configuration, with their IDs, names,
+severities, and whether they run, as set by the configuration file.
+
+With -o markdown, the list is printed as the rules table of the README.`,
+		Example: `  helm kc rules list
+  helm kc rules list -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.list(cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd, "text, json, or markdown")
+	return cmd
+}
+
+func newRulesDescribeCmd() *cobra.Command {
+	o := &rulesOptions{}
+	cmd := &cobra.Command{
+		Use:   "describe <rule>",
+		Short: "Describe a rule and how to resolve its findings",
+		Long: `Describe a built-in rule, by ID or name, or a custom rule of the configuration, by name, and
+how to resolve its findings.`,
+		Example: `  helm kc rules describe KC001
+  helm kc rules describe type-mismatch`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeRules(cmd, args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.describe(cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd, "text or json")
+	return cmd
+}
+
+func (o *rulesOptions) addFlags(cmd *cobra.Command, formats string) {
+	cmd.Flags().StringVar(&o.configPath, "config", "", "Path to the configuration file (default "+kc.DefaultConfigFile+" if present)")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: "+formats)
+}
+
+// catalog returns the built-in rules and the custom rules of the configuration.
+func (o *rulesOptions) catalog() ([]catalogRule, error) {
+	cfg, err := kc.LoadConfig(configOrDefault(o.configPath), o.configPath != "")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cfg.RuleSet()
+	if err != nil {
+		return nil, err
+	}
+	severities, err := cfg.Severities()
+	if err != nil {
+		return nil, err
+	}
+	custom, err := cfg.CustomRules()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make([]catalogRule, 0, len(kc.Rules)+len(custom))
+	for _, rule := range kc.Rules {
+		rule.Enabled = rules.Enabled(rule.ID)
+		if severity, ok := severities[rule.ID]; ok {
+			rule.Severity = severity
+		}
+		catalog = append(catalog, catalogRule{Rule: rule})
+	}
+	builtin, _ := kc.LookupRule(kc.RuleCustom)
+	for _, c := range custom {
+		rule := catalogRule{Rule: builtin, Custom: true, Expression: c.Expression, When: c.When}
+		rule.Name = c.Name
+		rule.Description = c.Message
+		if rule.Description == "" {
+			rule.Description = "Custom rule: " + c.Expression
+		}
+		if c.Severity != "" {
+			rule.Severity = c.Severity
+		}
+		if severity, ok := severities[kc.RuleCustom]; ok {
+			rule.Severity = severity
+		}
+		rule.Enabled = rules.Enabled(kc.RuleCustom)
+		catalog = append(catalog, rule)
+	}
+	return catalog, nil
+}
+
+func (o *rulesOptions) list(out io.Writer) error {
+	if o.output != "text" && o.output != "json" && o.output != "markdown" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	catalog, err := o.catalog()
+	if err != nil {
+		return err
+	}
+	switch o.output {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(catalog)
+	case "markdown":
+		return writeRulesTable(out, catalog)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSEVERITY\tENABLED\tDESCRIPTION")
+	for _, rule := range catalog {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", rule.ID, rule.Name, rule.Severity, yesNo(rule.Enabled), rule.Description)
+	}
+	return w.Flush()
+}
+
+// writeRulesTable writes the rules as a Markdown table, the rules table of the README.
+func writeRulesTable(out io.Writer, catalog []catalogRule) error {
+	var b strings.Builder
+	b.WriteString("| ID | Name | Default | Severity | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, rule := range catalog {
+		state := "disabled"
+		if rule.Enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s |\n", rule.ID, rule.Name, state, rule.Severity, strings.ReplaceAll(rule.Description, "|", `\|`))
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (o *rulesOptions) describe(out io.Writer, id string) error {
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	catalog, err := o.catalog()
+	if err != nil {
+		return err
+	}
+	var found *catalogRule
+	for i, rule := range catalog {
+		// IDs of built-in rules take precedence over custom rules reported under KC017.
+		if (!rule.Custom && strings.EqualFold(rule.ID, id)) || rule.Name == id {
+			found = &catalog[i]
+			break
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("unknown rule: %s", id)
+	}
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(found)
+	}
+
+	fmt.Fprintf(out, "%s %s\n\n%s\n\n", found.ID, found.Name, found.Description)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Severity:\t%s\n", found.Severity)
+	fmt.Fprintf(w, "Enabled:\t%s\n", yesNo(found.Enabled))
+	if found.Custom {
+		fmt.Fprintf(w, "Expression:\t%s\n", found.Expression)
+		if found.When != "" {
+			fmt.Fprintf(w, "When:\t%s\n", found.When)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "\nRemediation:\n  %s\n", found.Remediation)
+	return err
+}
+
+// yesNo formats a boolean for text output.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestRulesTableInReadme verifies that the rules table of the README is generated from the
+// rule registry.
+func TestRulesTableInReadme(t *testing.T) {
+	readme, err := os.ReadFile(filepath.Join("..", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog := make([]catalogRule, len(kc.Rules))
+	for i, rule := range kc.Rules {
+		catalog[i] = catalogRule{Rule: rule}
+	}
+	var table bytes.Buffer
+	if err := writeRulesTable(&table, catalog); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(readme), table.String()) {
+		t.Errorf("the rules table of the README is out of date; regenerate it with kc rules list -o markdown:\n%s", table.String())
+	}
+}
+
+// TestRulesDescribe verifies that built-in rules are described with the configured state, and
+// custom rules of the configuration by name.
+func TestRulesDescribe(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".kaartcontrole.yaml": "disable: [KC001]\nseverity:\n  KC002: warning\nrules:\n  - name: prod-replicas\n    expression: values.replicaCount >= 2\n    when: env == 'prod'\n",
+	})
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	o := &rulesOptions{output: "text"}
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"KC001", []string{"KC001 redundant-value\n", "Enabled:   no\n", "Remediation:\n  Remove the value"}},
+		{"type-mismatch", []string{"KC002 type-mismatch\n", "Severity:  warning\n", "Enabled:   yes\n"}},
+		{"prod-replicas", []string{"KC017 prod-replicas\n", "Expression:  values.replicaCount >= 2\n", "When:        env == 'prod'\n"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := o.describe(&out, tt.rule); err != nil {
+			t.Fatalf("%s: %v", tt.rule, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: expected %q in:\n%s", tt.rule, want, out.String())
+			}
+		}
+	}
+	if err := o.describe(&bytes.Buffer{}, "KC999"); err == nil || err.Error() != "unknown rule: KC999" {
+		t.Errorf("expected an unknown rule error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := o.list(&out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != len(kc.Rules)+2 || !strings.HasPrefix(lines[len(lines)-1], "KC017  prod-replicas") {
+		t.Errorf("expected the built-in rules and the custom rule, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jedisct1/go-minisign"
+	"github.com/spf13/cobra"
+)
+
+// releaseRepo is the GitHub repository publishing the releases of kc.
+const releaseRepo = "tiulpin/kaartcontrole"
+
+// selfUpdateOptions holds the flags of the self-update command.
+type selfUpdateOptions struct {
+	check   bool
+	version string
+	// executable is the binary to replace, the running one if empty.
+	executable string
+}
+
+func newSelfUpdateCmd() *cobra.Command {
+	o := &selfUpdateOptions{}
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Replace the kc binary with the latest release",
+		Long: `Download the latest release of kc for this platform from GitHub, verify it against the
+SHA256 checksums published with the release, and replace the running binary with it. The
+checksums are only trusted if their minisign signature matches the release key built into
+kc, so a release asset replaced on GitHub is never installed.
+
+This updates the binary of a plugin installed from a release archive or built from a
+checkout in place, for setups that do not run "helm plugin update kc". GITHUB_TOKEN, if
+set, authenticates the requests, and GITHUB_API_URL selects another API endpoint.`,
+		Example: `  helm kc self-update
+  helm kc self-update --check
+  helm kc self-update --version v0.2.0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&o.check, "check", false, "Only report whether a newer release is available")
+	cmd.Flags().StringVar(&o.version, "version", "", "Release tag to install instead of the latest release, e.g. v0.2.0")
+	return cmd
+}
+
+// gitHubRelease is a release of the GitHub API.
+type gitHubRelease struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a file attached to a release.
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func (o *selfUpdateOptions) run(ctx context.Context, out io.Writer) error {
+	client := newReleaseClient()
+	path := "/repos/" + releaseRepo + "/releases/latest"
+	if o.version != "" {
+		path = "/repos/" + releaseRepo + "/releases/tags/" + url.PathEscape(o.version)
+	}
+	var rel gitHubRelease
+	if err := client.do(http.MethodGet, path, nil, &rel); err != nil {
+		return fmt.Errorf("looking up the release: %w", err)
+	}
+
+	current := currentBuild().Version
+	if o.version == "" && !isNewer(rel.TagName, current) {
+		fmt.Fprintf(out, "kc %s is up to date\n", current)
+		return nil
+	}
+	if o.check {
+		fmt.Fprintf(out, "kc %s is available (installed: %s)\n", rel.TagName, current)
+		return nil
+	}
+
+	archive, checksums := releaseArchiveName(runtime.GOOS, runtime.GOARCH), checksumsName(rel.TagName)
+	archiveAsset, checksumsAsset := rel.asset(archive), rel.asset(checksums)
+	if archiveAsset == nil {
+		return fmt.Errorf("release %s has no %s for %s/%s", rel.TagName, archive, runtime.GOOS, runtime.GOARCH)
+	}
+	if checksumsAsset == nil {
+		return fmt.Errorf("release %s has no %s to verify %s", rel.TagName, checksums, archive)
+	}
+	signatureAsset := rel.asset(checksums + ".minisig")
+	if signatureAsset == nil {
+		return fmt.Errorf("release %s has no signature of %s", rel.TagName, checksums)
+	}
+	sums, err := download(ctx, checksumsAsset.URL)
+	if err != nil {
+		return err
+	}
+	signature, err := download(ctx, signatureAsset.URL)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(checksums, sums, signature); err != nil {
+		return err
+	}
+	data, err := download(ctx, archiveAsset.URL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(archive, data, sums); err != nil {
+		return err
+	}
+	binary, err := extractBinary(archive, data)
+	if err != nil {
+		return fmt.Errorf("unpacking %s: %w", archive, err)
+	}
+
+	exe := o.executable
+	if exe == "" {
+		if exe, err = os.Executable(); err != nil {
+			return err
+		}
+	}
+	if err := replaceExecutable(exe, binary); err != nil {
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	fmt.Fprintf(out, "Updated kc from %s to %s\n", current, rel.TagName)
+	return nil
+}
+
+// newReleaseClient returns a client of the GitHub API, authenticated with GITHUB_TOKEN if set.
+func newReleaseClient() *apiClient {
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	header := http.Header{
+		"Accept":               {"application/vnd.github+json"},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return &apiClient{baseURL: strings.TrimSuffix(apiURL, "/"), header: header}
+}
+
+// asset returns the asset of the release with the given name, or nil.
+func (r *gitHubRelease) asset(name string) *releaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// isNewer reports whether tag is a newer version than current. Versions that are not semantic
+// versions, such as "dev" builds, are always older.
+func isNewer(tag, current string) bool {
+	latest, err := semver.NewVersion(tag)
+	if err != nil {
+		return false
+	}
+	installed, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+	return latest.GreaterThan(installed)
+}
+
+// releaseArchiveName returns the name of the release archive of a platform, as published by
+// .goreleaser.yaml and downloaded by install_binary.sh.
+func releaseArchiveName(goos, goarch string) string {
+	arch := goarch
+	if arch == "amd64" {
+		arch = "x86_64"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return "kaartcontrole_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + arch + ext
+}
+
+// checksumsName returns the name of the checksums file of the release tag.
+func checksumsName(tag string) string {
+	return "kaartcontrole_" + strings.TrimPrefix(tag, "v") + "_checksums.txt"
+}
+
+// download returns the contents of the URL.
+func download(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("downloading %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verifySignature checks that signature is the minisign signature of the checksums file name
+// by releasePublicKey, since anyone able to replace a release asset can replace its checksums.
+func verifySignature(name string, sums, signature []byte) error {
+	if releasePublicKey == "" {
+		return errors.New("this build of kc has no release key to verify updates with; use helm plugin update kc instead")
+	}
+	key, err := minisign.NewPublicKey(releasePublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid release key: %w", err)
+	}
+	sig, err := minisign.DecodeSignature(string(signature))
+	if err != nil {
+		return fmt.Errorf("invalid signature of %s: %w", name, err)
+	}
+	if ok, err := key.Verify(sums, sig); !ok {
+		return fmt.Errorf("signature mismatch for %s: %w", name, err)
+	}
+	return nil
+}
+
+// verifyChecksum checks data against the SHA256 of the file name in the sha256sum output sums.
+func verifyChecksum(name string, data, sums []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, fields[0]) {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, fields[0], got)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// extractBinary returns the kc binary in the release archive name.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.Name == "kc.exe" {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, errors.New("no kc.exe in the archive")
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no kc in the archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == "kc" && hdr.Typeflag == tar.TypeReg {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable replaces the binary exe with binary. The new binary is written next to
+// exe first, so a failed write leaves exe untouched. Windows cannot overwrite a running
+// binary, but can rename it, so exe is moved aside before the new binary takes its place.
+func replaceExecutable(exe string, binary []byte) error {
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".kc-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+	old := exe + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		_ = os.Rename(old, exe)
+		return err
+	}
+	// Removing the running binary fails on Windows; it is removed by the next update.
+	_ = os.Remove(old)
+	return nil
+}
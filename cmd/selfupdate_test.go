@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestSelfUpdate verifies that self-update installs a newer release only if its checksum
+// matches, and the checksums are signed with the release key.
+func TestSelfUpdate(t *testing.T) {
+	oldVersion, oldKey := version, releasePublicKey
+	t.Cleanup(func() { version, releasePublicKey = oldVersion, oldKey })
+	version = "v0.1.0"
+	releaseKey, signingKey := minisignKey(t)
+	releasePublicKey = releaseKey
+
+	archive := releaseArchiveName(runtime.GOOS, runtime.GOARCH)
+	data := releaseArchive(t, archive, "new binary")
+	sum := sha256.Sum256(data)
+	checksums := hex.EncodeToString(sum[:]) + "  " + archive + "\n"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/tiulpin/kaartcontrole/releases/latest":
+			json.NewEncoder(w).Encode(gitHubRelease{TagName: "v0.3.0", Assets: []releaseAsset{
+				{Name: archive, URL: server.URL + "/download/" + archive},
+				{Name: "kaartcontrole_0.3.0_checksums.txt", URL: server.URL + "/download/checksums.txt"},
+				{Name: "kaartcontrole_0.3.0_checksums.txt.minisig", URL: server.URL + "/download/checksums.txt.minisig"},
+			}})
+		case "/download/" + archive:
+			w.Write(data)
+		case "/download/checksums.txt":
+			w.Write([]byte(checksums))
+		case "/download/checksums.txt.minisig":
+			w.Write(minisignSignature(signingKey, []byte(checksums)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "")
+
+	exe := filepath.Join(t.TempDir(), "kc")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := (&selfUpdateOptions{check: true, executable: exe}).run(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "kc v0.3.0 is available (installed: v0.1.0)\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	checksums = strings.Repeat("0", 64) + "  " + archive + "\n"
+	if err := (&selfUpdateOptions{executable: exe}).run(context.Background(), &out); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+		t.Errorf("expected the binary to be kept, got %q", got)
+	}
+
+	checksums = hex.EncodeToString(sum[:]) + "  " + archive + "\n"
+	_, signingKey = minisignKey(t)
+	if err := (&selfUpdateOptions{executable: exe}).run(context.Background(), &out); err == nil || !strings.Contains(err.Error(), "signature mismatch") {
+		t.Errorf("expected a signature mismatch, got %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+		t.Errorf("expected the binary to be kept, got %q", got)
+	}
+
+	releasePublicKey, signingKey = minisignKey(t)
+	out.Reset()
+	if err := (&selfUpdateOptions{executable: exe}).run(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new binary" {
+		t.Errorf("expected the binary to be replaced, got %q", got)
+	}
+	if want := "Updated kc from v0.1.0 to v0.3.0\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	version = "v0.3.0"
+	out.Reset()
+	if err := (&selfUpdateOptions{executable: exe}).run(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "kc v0.3.0 is up to date\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	releasePublicKey = ""
+	if err := verifySignature("checksums.txt", []byte(checksums), minisignSignature(signingKey, []byte(checksums))); err == nil {
+		t.Errorf("expected builds without a release key to refuse updates")
+	}
+}
+
+// releaseArchive returns a release archive with a kc binary of the given contents.
+func releaseArchive(t *testing.T, name, contents string) []byte {
+	var buf bytes.Buffer
+	if strings.HasSuffix(name, ".zip") {
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("kc.exe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(contents))
+		zw.Close()
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "kc", Mode: 0o755, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(contents))
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// minisignKey returns a new minisign public key, encoded like in minisign.pub, and its private
+// key.
+func minisignKey(t *testing.T) (string, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := append(append([]byte("Ed"), minisignKeyID(public)...), public...)
+	return base64.StdEncoding.EncodeToString(key), private
+}
+
+// minisignKeyID returns the ID of the key, derived from the public key in tests.
+func minisignKeyID(public []byte) []byte {
+	return public[:8]
+}
+
+// minisignSignature returns the minisign signature of data by key, as written by minisign -S.
+func minisignSignature(key ed25519.PrivateKey, data []byte) []byte {
+	sig := append(append([]byte("Ed"), minisignKeyID(key.Public().(ed25519.PublicKey))...), ed25519.Sign(key, data)...)
+	trusted := "timestamp:0\tfile:checksums.txt"
+	global := ed25519.Sign(key, append(append([]byte{}, sig[10:]...), trusted...))
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sig) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
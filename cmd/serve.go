@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// maxRequestSize limits the size of the body of a validation request.
+const maxRequestSize = 16 << 20
+
+// serveOptions holds the flags of the serve command.
+type serveOptions struct {
+	validateOptions
+
+	addr     string
+	grpcAddr string
+	// allowRepos lists the repository and registry URLs requests may load charts from.
+	allowRepos []string
+	// allowLocalCharts lets requests load charts from the file system of the server.
+	allowLocalCharts bool
+	// metrics are exported on /metrics, created with the handler.
+	metrics *serveMetrics
+}
+
+func newServeCmd() *cobra.Command {
+	o := &serveOptions{}
+	cmd := &cobra.Command{
+		Use:   "serve [--addr <host:port>]",
+		Short: "Serve validation over an HTTP API",
+		Long: `Serve an HTTP API for platforms that validate values as a service instead of running kc.
+
+  POST /validate  validates the values in the JSON body against a chart
+  GET  /healthz   reports that the server is up
+
+The body of POST /validate names the chart like the <chart> argument, and carries the
+values files in order of precedence:
+
+  {"chart": "bitnami/nginx", "version": "18.1.0",
+   "values": [{"name": "overrides.yaml", "content": "replicaCount: 2\n"}],
+   "set": ["image.tag=1.2.3"]}
+
+The response holds the findings, as with -o json, and whether they fail the run:
+
+  {"findings": [...], "passed": false}
+
+The flags apply to every request, like the configuration file in the working directory
+of the server; -f files are merged below the values of each request. Invalid requests
+are answered with 400, charts and values that fail to load with 422.
+
+Requests may load charts from the repositories configured for Helm on the server, from
+the repository of --repo, and from the repositories and registries of --allow-repo. Charts
+on the file system of the server, and any other URL, are answered with 403 unless
+--allow-local-charts is set or the URL is allowed.
+
+With --grpc-addr, the same validation is served over gRPC, with the ValidatorService of
+api/kaartcontrole/v1/validator.proto, the gRPC health service, and reflection.`,
+		Example: `  helm kc serve --addr :8080 --config platform/.kaartcontrole.yaml
+  helm kc serve --grpc-addr :9090
+  curl -s localhost:8080/validate -d '{"chart": "./mychart", "values": [{"content": "replicaCount: 1"}]}'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return o.run(ctx)
+		},
+	}
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.addr, "addr", ":8080", "Address to listen on")
+	cmd.Flags().StringVar(&o.grpcAddr, "grpc-addr", "", "Address to serve the gRPC API on, e.g. :9090 (default disabled)")
+	cmd.Flags().StringArrayVar(&o.allowRepos, "allow-repo", nil, "Repository or registry URL requests may load charts from, e.g. oci://registry.example.com/charts (can be specified multiple times)")
+	cmd.Flags().BoolVar(&o.allowLocalCharts, "allow-local-charts", false, "Let requests load charts from the file system of the server")
+	return cmd
+}
+
+// validateRequest is the body of POST /validate.
+type validateRequest struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+	// Values are the values files, later ones taking precedence.
+	Values []valuesPayload `json:"values"`
+	// Set holds --set expressions applied over the values.
+	Set     []string `json:"set,omitempty"`
+	Ignore  []string `json:"ignore,omitempty"`
+	Enable  []string `json:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty"`
+	FailOn  string   `json:"failOn,omitempty"`
+}
+
+// valuesPayload is a values file sent with a request.
+type valuesPayload struct {
+	// Name is the file name findings refer to (default values-<n>.yaml).
+	Name    string `json:"name,omitempty"`
+	Content string `json:"content"`
+}
+
+// validateResponse is the body of a successful POST /validate.
+type validateResponse struct {
+	Findings []kc.Finding `json:"findings"`
+	// Passed is false if the findings fail the run, as with exit code 1.
+	Passed bool `json:"passed"`
+}
+
+func (o *serveOptions) run(ctx context.Context) error {
+	// Request options are validated once up front, so a misconfigured server fails to start.
+	if _, err := o.options(); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", o.addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: o.handler(), ReadHeaderTimeout: 10 * time.Second}
+	logger.Info("serving the validation API", "addr", listener.Addr().String())
+
+	errs := make(chan error, 2)
+	go func() { errs <- server.Serve(listener) }()
+	if o.grpcAddr != "" {
+		grpcListener, err := net.Listen("tcp", o.grpcAddr)
+		if err != nil {
+			_ = server.Close()
+			return err
+		}
+		grpcServer := o.grpcServer()
+		defer grpcServer.GracefulStop()
+		logger.Info("serving the gRPC API", "addr", grpcListener.Addr().String())
+		go func() { errs <- grpcServer.Serve(grpcListener) }()
+	}
+	select {
+	case err := <-errs:
+		_ = server.Close()
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// initMetrics creates the metrics shared by the HTTP and gRPC APIs.
+func (o *serveOptions) initMetrics() {
+	if o.metrics == nil {
+		o.metrics = newServeMetrics()
+	}
+}
+
+// handler returns the routes of the API.
+func (o *serveOptions) handler() http.Handler {
+	o.initMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("POST /validate", o.serveValidate)
+	mux.Handle("GET /metrics", o.metrics.handler())
+	return mux
+}
+
+func (o *serveOptions) serveValidate(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	var req validateRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		o.metrics.observe(start, nil)
+		return
+	}
+	var resp *validateResponse
+	collector := &kc.Collector{}
+	passed, err := o.validateRequest(r.Context(), req, collector)
+	if err == nil {
+		resp = &validateResponse{Findings: collector.Findings, Passed: passed}
+		if resp.Findings == nil {
+			resp.Findings = []kc.Finding{}
+		}
+	}
+	o.metrics.observe(start, resp)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, err)
+	case errors.Is(err, errChartNotAllowed):
+		writeError(w, http.StatusForbidden, err)
+	case errors.As(err, new(*loadError)):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeJSON(w, http.StatusOK, resp)
+	}
+	logger.Debug("validated request", "chart", req.Chart, "values", len(req.Values), "err", err)
+}
+
+// validateRequest validates the values of a request with options of its own, so requests
+// can be served concurrently, and reports the findings to reporter. It returns whether the
+// findings pass the run.
+func (o *serveOptions) validateRequest(ctx context.Context, req validateRequest, reporter kc.Reporter) (bool, error) {
+	if req.Chart == "" {
+		return false, errors.New("missing chart")
+	}
+	if err := o.checkChart(req.Chart, req.Repo); err != nil {
+		return false, err
+	}
+	if len(req.Values) == 0 && len(o.valuesFiles) == 0 {
+		// Without values, the server would auto-detect values sets in its working directory.
+		return false, errors.New("missing values")
+	}
+
+	// The flags of the server are copied, never modified, and every request is a run of its own.
+	v := o.validateOptions
+	v.begin(reporter)
+	v.reader.cache = map[string][]byte{}
+	v.valuesFiles = append([]string{}, o.valuesFiles...)
+	for i, payload := range req.Values {
+		name := payload.Name
+		if name == "" {
+			name = fmt.Sprintf("values-%d.yaml", i+1)
+		}
+		if _, ok := v.reader.cache[name]; ok {
+			return false, fmt.Errorf("duplicate values name %q", name)
+		}
+		// Request values are never read from the file system of the server.
+		v.reader.cache[name] = []byte(payload.Content)
+		v.valuesFiles = append(v.valuesFiles, name)
+	}
+	v.setValues.Values = append(append([]string{}, o.setValues.Values...), req.Set...)
+	v.ignoreList = append(append(kc.IgnoreList{}, o.ignoreList...), req.Ignore...)
+	v.enableRules = append(append([]string{}, o.enableRules...), req.Enable...)
+	v.disableRules = append(append([]string{}, o.disableRules...), req.Disable...)
+	if req.FailOn != "" {
+		v.failOn = req.FailOn
+	}
+	if req.Version != "" {
+		v.Version = req.Version
+	}
+	if req.Repo != "" {
+		v.RepoURL = req.Repo
+	}
+	// Request values only live in memory and sets are not selected by changes on the server.
+	v.fix, v.changed = false, changedOptions{}
+
+	counter, opts, err := v.prepare(io.Discard)
+	if err != nil {
+		return false, err
+	}
+	issuesFound, err := v.validate(ctx, io.Discard, req.Chart, opts, counter, false)
+	if err != nil {
+		return false, err
+	}
+	err = v.result(issuesFound, opts.FailOn)
+	if err != nil && !errors.Is(err, errIssuesFound) {
+		return false, err
+	}
+	return err == nil, nil
+}
+
+// errChartNotAllowed is returned for charts that requests may not load.
+var errChartNotAllowed = errors.New("chart not allowed")
+
+// checkChart returns errChartNotAllowed unless a request may load chart, from repo if set:
+// a chart of the file system of the server needs --allow-local-charts, and a chart of
+// another repository than those configured on the server an --allow-repo URL.
+func (o *serveOptions) checkChart(chart, repo string) error {
+	chart = strings.TrimSpace(chart)
+	if !o.allowLocalCharts && isServerChart(chart) {
+		return fmt.Errorf("%w: %s is on the file system of the server, see --allow-local-charts", errChartNotAllowed, chart)
+	}
+	switch {
+	case repo != "" && !o.allowedRepo(repo):
+		return fmt.Errorf("%w: repository %s is not allowed, see --allow-repo", errChartNotAllowed, repo)
+	case (registry.IsOCI(chart) || isChartURL(chart)) && !o.allowedRepo(chart):
+		return fmt.Errorf("%w: %s is not in an allowed repository, see --allow-repo", errChartNotAllowed, chart)
+	}
+	return nil
+}
+
+// allowedRepo reports whether ref is one of the --allow-repo URLs or below one of them.
+func (o *serveOptions) allowedRepo(ref string) bool {
+	for _, allowed := range o.allowRepos {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if ref == allowed || strings.HasPrefix(ref, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isServerChart reports whether chart refers to the file system of the server rather than a
+// repository, as findChart resolves it.
+func isServerChart(chart string) bool {
+	if registry.IsOCI(chart) || isChartURL(chart) {
+		return false
+	}
+	if strings.HasPrefix(chart, ".") || isLocalChart("", chart) {
+		return true
+	}
+	_, err := findChart(chart)
+	return err == nil
+}
+
+// writeJSON writes v as the JSON body of a response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError writes err as the JSON body of a response with the given status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestServe verifies the validation endpoint with values sent in the request.
+func TestServe(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+	})
+	chart := filepath.Join(dir, "web_service")
+	o := &serveOptions{allowLocalCharts: true}
+	o.jobs = 1
+	server := httptest.NewServer(o.handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected /healthz to be OK, got %s", resp.Status)
+	}
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"chart": "` + chart + `", "values": [{"name": "prod.yaml", "content": "replicaCount: 1\n"}]}`, http.StatusOK, `"prod.yaml"`},
+		{`{"chart": "` + chart + `", "values": [{"content": "replicaCount: 3\n"}]}`, http.StatusOK, `"findings": [],`},
+		{`{"chart": "` + chart + `", "values": [{"content": "replicaCount: 1\n"}], "disable": ["KC001"]}`, http.StatusOK, `"passed": true`},
+		{`{"chart": "` + chart + `", "values": [{"content": "a: [b"}]}`, http.StatusUnprocessableEntity, "failed to load values"},
+		{`{"chart": "` + filepath.Join(dir, "missing") + `", "values": [{"content": "a: 1"}]}`, http.StatusUnprocessableEntity, "error"},
+		{`{"chart": "` + chart + `"}`, http.StatusBadRequest, "missing values"},
+		{`{"chart": "` + chart + `", "values": [{"content": "a: 1"}], "unknown": true}`, http.StatusBadRequest, "invalid request"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(server.URL+"/validate", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.want) {
+			t.Errorf("POST %s: expected %d with %q, got %s:\n%s", tt.body, tt.status, tt.want, resp.Status, body)
+		}
+	}
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`kc_validations_total{result="passed"} 2`,
+		`kc_validations_total{result="failed"} 1`,
+		`kc_validations_total{result="error"} 4`,
+		`kc_findings_total{rule="KC001",severity="warning"} 1`,
+		`kc_validation_duration_seconds_count{result="passed"} 2`,
+	} {
+		if !strings.Contains(string(metrics), want) {
+			t.Errorf("expected %s in the metrics:\n%s", want, metrics)
+		}
+	}
+}
+
+// TestServeConcurrentRequests verifies that concurrent requests are runs of their own, which
+// share no values or findings.
+func TestServeConcurrentRequests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\n",
+	})
+	chart := filepath.Join(dir, "web_service")
+	o := &serveOptions{allowLocalCharts: true}
+	o.jobs = 1
+	server := httptest.NewServer(o.handler())
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("env-%d.yaml", i)
+			body := `{"chart": "` + chart + `", "values": [{"name": "` + name + `", "content": "replicaCount: 1\n"}]}`
+			resp, err := http.Post(server.URL+"/validate", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var got validateResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Error(err)
+				return
+			}
+			if len(got.Findings) != 1 || len(got.Findings[0].Files) != 1 || got.Findings[0].Files[0] != name {
+				t.Errorf("%s: expected one finding of its own values, got %+v", name, got.Findings)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestServeCheckChart verifies that requests only load charts of the file system of the server
+// with --allow-local-charts, and charts of other repositories than the configured ones from
+// those of --allow-repo.
+func TestServeCheckChart(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml": "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+	})
+	chart := filepath.Join(dir, "web_service")
+	o := &serveOptions{allowRepos: []string{"oci://registry.example.com/charts", "https://charts.example.com/"}}
+
+	tests := []struct {
+		chart   string
+		repo    string
+		allowed bool
+	}{
+		{chart, "", false},
+		{"../web_service", "", false},
+		{"/etc", "", false},
+		{"bitnami/nginx", "", true},
+		{"oci://registry.example.com/charts/nginx", "", true},
+		{"oci://registry.example.com/charts-evil/nginx", "", false},
+		{"oci://evil.example.com/nginx", "", false},
+		{"https://charts.example.com/nginx-1.0.0.tgz", "", true},
+		{"https://charts.example.com.evil.io/nginx-1.0.0.tgz", "", false},
+		{"nginx", "https://charts.example.com", true},
+		{"nginx", "http://169.254.169.254/latest", false},
+	}
+	for _, tt := range tests {
+		err := o.checkChart(tt.chart, tt.repo)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("checkChart(%q, %q) = %v, want allowed %v", tt.chart, tt.repo, err, tt.allowed)
+		}
+	}
+
+	server := httptest.NewServer(o.handler())
+	defer server.Close()
+	body := `{"chart": "` + chart + `", "values": [{"content": "replicaCount: 1\n"}]}`
+	resp, err := http.Post(server.URL+"/validate", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a local chart to be forbidden, got %s", resp.Status)
+	}
+}
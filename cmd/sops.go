@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// sopsMetadataKey is the top-level key SOPS stores its encryption metadata under.
+const sopsMetadataKey = "sops"
+
+// isSOPSEncrypted reports whether a values document was encrypted with SOPS.
+func isSOPSEncrypted(data []byte) bool {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return hasSOPSMetadata(doc)
+}
+
+func hasSOPSMetadata(values map[string]interface{}) bool {
+	metadata, ok := values[sopsMetadataKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = metadata["mac"]
+	return ok
+}
+
+// decryptSOPS decrypts a SOPS-encrypted values document with the sops binary, which picks
+// up age, KMS, and PGP keys the same way it does on the command line, and returns the
+// plaintext sops prints; files on disk are never rewritten. Local files are passed by path so
+// sops can use their extension; other documents are piped through stdin.
+func decryptSOPS(path string, data []byte, local bool) ([]byte, error) {
+	bin, err := exec.LookPath("sops")
+	if err != nil {
+		return nil, fmt.Errorf("%s is SOPS-encrypted but the sops binary was not found in PATH (use --no-decrypt to validate it as is)", path)
+	}
+
+	args := []string{"--decrypt"}
+	if local {
+		args = append(args, path)
+	} else {
+		args = append(args, "--input-type", "yaml", "--output-type", "yaml", "/dev/stdin")
+	}
+	cmd := exec.Command(bin, args...)
+	if !local {
+		cmd.Stdin = bytes.NewReader(data)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return nil, fmt.Errorf("decrypting %s: %s", path, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+	return out, nil
+}
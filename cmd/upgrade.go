@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// upgradeOptions holds the flags of the upgrade-check command.
+type upgradeOptions struct {
+	validateOptions
+
+	from string
+	to   string
+}
+
+func newUpgradeCheckCmd() *cobra.Command {
+	o := &upgradeOptions{}
+	cmd := &cobra.Command{
+		Use:   "upgrade-check <chart> --from <version> [--to <version>] [-f <values-file> ...]",
+		Short: "Check values against a newer chart version before upgrading",
+		Long: `Load two versions of a chart and report provided keys that were removed, renamed,
+or changed type or default in the newer version.
+
+--from and --to are chart versions resolved like --version, or paths to local charts.
+--to defaults to the latest version.`,
+		Example: `  helm kc upgrade-check bitnami/nginx --from 15.0.0 --to 18.0.0 -f values.yaml
+  helm kc upgrade-check ./charts/web --from ../release-1.x/charts/web -f values.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.stdin = cmd.InOrStdin()
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	o.addOutputFlags(cmd.Flags())
+	cmd.Flags().StringVar(&o.from, "from", "", "Current chart version, or path to the current chart")
+	cmd.Flags().StringVar(&o.to, "to", "", "Newer chart version, or path to the newer chart (default latest)")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+// loadVersion loads chartPath at the given version, or the chart at version if it is a path.
+func (o *upgradeOptions) loadVersion(ctx context.Context, chartPath, version string) (*loadedChart, error) {
+	if _, err := os.Stat(version); err == nil {
+		return o.loadChart(ctx, version)
+	}
+	co := o.chartOptions
+	co.Version = version
+	return co.loadChart(ctx, chartPath)
+}
+
+func (o *upgradeOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	current, err := o.loadVersion(ctx, chartPath, o.from)
+	if err != nil {
+		return fmt.Errorf("loading current chart: %w", err)
+	}
+	newer, err := o.loadVersion(ctx, chartPath, o.to)
+	if err != nil {
+		return fmt.Errorf("loading newer chart: %w", err)
+	}
+
+	sets, err := o.valuesSets(ctx, current.name)
+	if err != nil {
+		return err
+	}
+	if o.banners() {
+		fmt.Fprintf(out, "\nUpgrade: %s %s -> %s\n\n", current.chart.Name(), current.chart.Metadata.Version, newer.chart.Metadata.Version)
+	}
+
+	issuesFound := false
+	for _, files := range sets {
+		provided, err := o.mergeValues(files)
+		if err != nil {
+			return fmt.Errorf("failed to load values (%s): %w", strings.Join(files, ", "), err)
+		}
+		setOpts := opts
+		setOpts.Files = files
+		if setOpts.Suppressions, err = o.suppressions(files); err != nil {
+			return fmt.Errorf("reading inline suppressions: %w", err)
+		}
+		oldDefaults, err := kc.ChartDefaults(current.chart, provided)
+		if err != nil {
+			return fmt.Errorf("computing chart defaults: %w", err)
+		}
+		newDefaults, err := kc.ChartDefaults(newer.chart, provided)
+		if err != nil {
+			return fmt.Errorf("computing chart defaults: %w", err)
+		}
+		issuesFound = kc.CheckUpgrade(oldDefaults, newDefaults, provided, setOpts, reporter) || issuesFound
+	}
+
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
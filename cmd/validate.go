@@ -0,0 +1,815 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+// validateOptions holds the flags shared by every command that validates values. They are
+// not modified while validating; the state of a run lives in validateRun.
+type validateOptions struct {
+	chartOptions
+
+	ignoreList     kc.IgnoreList
+	sensitive      kc.IgnoreList
+	ignoreRegex    []string
+	valuesFiles    []string
+	excludeDirs    []string
+	baseDirs       []string
+	overrideName   string
+	layers         []string
+	servicePat     string
+	serviceNames   []string
+	followSymlinks bool
+	setValues      values.Options
+	noDecrypt      bool
+	output         string
+	configPath     string
+	enableRules    []string
+	disableRules   []string
+	failOn         string
+	maxIssues      int
+	maxWarnings    int
+	failFast       bool
+	baselinePath   string
+	checkUnused    bool
+	consistency    bool
+	checkOverrides bool
+	numTolerant    bool
+	render         bool
+	lint           bool
+	schemas        bool
+	schemaOpts     kc.SchemaOptions
+	kubeVersion    string
+	apiVersions    []string
+	renamesPath    string
+	policies       []string
+	plugins        []string
+	fix            bool
+	jobs           int
+	changed        changedOptions
+	charts         []string
+	outputFiles    []string
+	templateFile   string
+	minSeverity    string
+	groupBy        string
+	// stdin is read by the values file "-".
+	stdin io.Reader
+
+	// validateRun is the state of the current run, started by begin.
+	*validateRun
+}
+
+// validateRun holds the state of a single run: one invocation of a command, one iteration
+// of watch mode, or one request of the servers. Every run starts with a fresh one, so runs
+// never share caches or counters.
+type validateRun struct {
+	reader valuesReader
+	// reporter replaces the reporter of the output format, for commands that publish the
+	// findings themselves.
+	reporter kc.Reporter
+	// counter counts the findings of the run for the thresholds.
+	counter *issueCounter
+	// overrides records the overrides files of the validated charts for --check-overrides.
+	overrides *overridesUsage
+	// linted records the values files whose YAML was checked, since overrides files are
+	// shared between values sets and charts.
+	linted map[string]bool
+	// failedFast is set once --fail-fast stopped the run at an error.
+	failedFast bool
+	// loadFailures counts the charts, values sets, and releases that failed to load while
+	// the others were still validated.
+	loadFailures int
+}
+
+// begin starts a new run. reporter, if not nil, replaces the reporter of the output format.
+func (o *validateOptions) begin(reporter kc.Reporter) {
+	o.validateRun = &validateRun{
+		reader:   valuesReader{stdin: o.stdin, noDecrypt: o.noDecrypt},
+		reporter: reporter,
+	}
+}
+
+func (o *validateOptions) addFlags(fs *pflag.FlagSet) {
+	o.chartOptions.addFlags(fs)
+	fs.Var(&o.ignoreList, "ignore", "Fields to ignore in validation; supports prefixes and globs like 'resources.*' (can be specified multiple times)")
+	fs.StringArrayVar(&o.ignoreRegex, "ignore-regex", nil, "Regular expression of fields to ignore in validation (can be specified multiple times)")
+	fs.Var(&o.sensitive, "sensitive", "Fields whose values are masked in reports, in addition to keys named like credentials; supports prefixes and globs (can be specified multiple times)")
+	fs.StringArrayVarP(&o.valuesFiles, "values", "f", nil, "Values file or URL, or '-' to read from stdin (can be specified multiple times)")
+	o.addDiscoveryFlags(fs)
+	fs.BoolVar(&o.noDecrypt, "no-decrypt", false, "Do not decrypt SOPS-encrypted values files; validate them as they are")
+	fs.StringArrayVar(&o.setValues.Values, "set", nil, "Set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	fs.StringArrayVar(&o.setValues.StringValues, "set-string", nil, "Set STRING values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	fs.StringArrayVar(&o.setValues.FileValues, "set-file", nil, "Set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
+	fs.StringArrayVar(&o.setValues.JSONValues, "set-json", nil, "Set JSON values on the command line (can specify multiple or separate values with commas: key1=jsonval1,key2=jsonval2)")
+	fs.StringArrayVar(&o.setValues.LiteralValues, "set-literal", nil, "Set a literal STRING value on the command line")
+	fs.StringVar(&o.configPath, "config", "", "Path to the configuration file (default "+kc.DefaultConfigFile+" if present)")
+	fs.StringSliceVar(&o.enableRules, "enable", nil, "Rule IDs to enable (can be specified multiple times)")
+	fs.StringSliceVar(&o.disableRules, "disable", nil, "Rule IDs to disable (can be specified multiple times)")
+	fs.BoolVar(&o.numTolerant, "numeric-tolerant", false, "Compare numbers by value, so an int and a float of the same number, such as 3 and 3.0, are not a type mismatch")
+	fs.StringVar(&o.failOn, "fail-on", "", "Minimum severity that fails the run: error, warning, or info (default warning)")
+	o.addThresholdFlags(fs)
+	fs.BoolVar(&o.checkUnused, "check-unused", false, "Render the chart templates and report provided values that no template references")
+	fs.BoolVar(&o.consistency, "check-consistency", false, "Compare every key across the values sets and report the set that leaves out a key, or sets a different value, unlike all others")
+	fs.BoolVar(&o.checkOverrides, "check-overrides", false, "Report overrides files that no validated values set merges, or that set keys none of their charts define")
+	fs.BoolVar(&o.render, "render", false, "Render the chart templates with the merged values and report render errors")
+	fs.BoolVar(&o.lint, "lint", false, "Run Helm's lint rules on the chart with the merged values")
+	fs.BoolVar(&o.schemas, "validate-schemas", false, "Validate the rendered manifests against Kubernetes OpenAPI schemas (implies --render)")
+	fs.StringArrayVar(&o.schemaOpts.Locations, "schema-location", nil, "kubeconform schema location template (can be specified multiple times)")
+	fs.BoolVar(&o.schemaOpts.IgnoreMissingSchemas, "ignore-missing-schemas", false, "Skip resources without a known schema, such as custom resources")
+	fs.StringVar(&o.schemaOpts.Cache, "schema-cache", helmpath.CachePath("kaartcontrole", "schemas"), "Directory where downloaded Kubernetes schemas are cached across runs (empty to disable)")
+	fs.BoolVar(&o.schemaOpts.Strict, "strict-schemas", false, "Reject fields not documented in the Kubernetes schemas")
+	fs.StringVar(&o.kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion when rendering")
+	fs.StringSliceVarP(&o.apiVersions, "api-versions", "a", nil, "Kubernetes API versions used for Capabilities.APIVersions when rendering")
+	fs.StringVar(&o.renamesPath, "renames", "", "Mapping file of renamed values keys to report")
+	fs.StringArrayVar(&o.policies, "policy", nil, "Rego policy file or directory evaluated against the merged values (can be specified multiple times)")
+	fs.StringArrayVar(&o.plugins, "plugin", nil, "Executable or .wasm WASI module run as an external rule plugin, reading the merged values as JSON on stdin (can be specified multiple times)")
+	fs.BoolVar(&o.fix, "fix", false, "Migrate renamed keys in the values files in place")
+	fs.StringVar(&o.baselinePath, "baseline", "", "Baseline file with known findings to suppress (default "+kc.DefaultBaselineFile+" if present)")
+}
+
+// addDiscoveryFlags adds the flags controlling which values sets are auto-detected.
+func (o *validateOptions) addDiscoveryFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.baseDirs, "base-dir", nil, "Directory to search when auto-detecting values files instead of the whole working directory (can be specified multiple times)")
+	fs.BoolVar(&o.followSymlinks, "follow-symlinks", false, "Follow symbolic links to directories when auto-detecting values files")
+	fs.StringArrayVar(&o.excludeDirs, "exclude-dir", nil, "Directory name, path, or glob to skip when auto-detecting values files (can be specified multiple times)")
+	fs.StringVar(&o.overrideName, "override-name", "", "File name of the shared values file when auto-detecting values files (default "+defaultOverrideName+")")
+	fs.StringArrayVar(&o.layers, "layer", nil, "File name of an overrides layer when auto-detecting values files, from the most general to the most specific (can be specified multiple times)")
+	fs.StringArrayVar(&o.serviceNames, "service-name", nil, "Name of the chart's service files when auto-detecting, if it differs from the chart directory (can be specified multiple times)")
+	fs.StringVar(&o.servicePat, "service-pattern", "", "File name pattern of service values files when auto-detecting, with {chart} and {env} placeholders (default "+defaultServicePattern+")")
+}
+
+// addJobsFlag adds the flag controlling how many values sets are validated concurrently.
+func (o *validateOptions) addJobsFlag(fs *pflag.FlagSet) {
+	fs.IntVarP(&o.jobs, "jobs", "j", runtime.NumCPU(), "Number of values sets to validate concurrently")
+}
+
+// configOrDefault returns the explicitly requested config path or the default one.
+func configOrDefault(path string) string {
+	if path != "" {
+		return path
+	}
+	return kc.DefaultConfigFile
+}
+
+// options resolves the configuration file and flags into validator options.
+func (o *validateOptions) options() (kc.Options, error) {
+	cfg, err := kc.LoadConfig(configOrDefault(o.configPath), o.configPath != "")
+	if err != nil {
+		return kc.Options{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	rules, err := cfg.RuleSet()
+	if err != nil {
+		return kc.Options{}, err
+	}
+	if err := rules.Enable(o.enableRules...); err != nil {
+		return kc.Options{}, err
+	}
+	if err := rules.Disable(o.disableRules...); err != nil {
+		return kc.Options{}, err
+	}
+	severities, err := cfg.Severities()
+	if err != nil {
+		return kc.Options{}, err
+	}
+	customRules, err := cfg.CustomRules()
+	if err != nil {
+		return kc.Options{}, err
+	}
+	constraints, err := cfg.CompileConstraints()
+	if err != nil {
+		return kc.Options{}, err
+	}
+
+	failOn := o.failOn
+	if failOn == "" {
+		failOn = string(cfg.FailOn)
+	}
+	if failOn == "" {
+		failOn = string(kc.SeverityWarning)
+	}
+	failOnSeverity, err := kc.ParseSeverity(failOn)
+	if err != nil {
+		return kc.Options{}, err
+	}
+
+	ignoreRegex, err := kc.CompileIgnoreRegex(append(cfg.IgnoreRegex, o.ignoreRegex...))
+	if err != nil {
+		return kc.Options{}, fmt.Errorf("invalid --ignore-regex: %w", err)
+	}
+
+	caps, err := kc.NewCapabilities(o.kubeVersion, o.apiVersions)
+	if err != nil {
+		return kc.Options{}, err
+	}
+
+	renames := cfg.Renames
+	if o.renamesPath != "" {
+		fileRenames, err := kc.LoadRenames(o.renamesPath)
+		if err != nil {
+			return kc.Options{}, fmt.Errorf("failed to load renames: %w", err)
+		}
+		renames = append(renames, fileRenames...)
+	}
+
+	// Policies are compiled once, and evaluated for every values set.
+	policies, err := kc.PreparePolicies(context.Background(), append(append([]string{}, cfg.Policies...), o.policies...))
+	if err != nil {
+		return kc.Options{}, err
+	}
+
+	// The schema validator is created once, so every values set reuses the downloaded schemas.
+	var schemas *kc.Schemas
+	if o.schemas {
+		schemaOpts := o.schemaOpts
+		schemaOpts.KubernetesVersion = o.kubeVersion
+		if schemas, err = kc.PrepareSchemas(schemaOpts); err != nil {
+			return kc.Options{}, err
+		}
+	}
+
+	plugins := append([]kc.Plugin{}, cfg.Plugins...)
+	for _, plugin := range o.plugins {
+		plugins = append(plugins, kc.NewPlugin(plugin))
+	}
+
+	return kc.Options{
+		Ignore:           append(kc.CompileIgnoreList(append(kc.IgnoreList(cfg.Ignore), o.ignoreList...)), ignoreRegex...),
+		Sensitive:        kc.CompileIgnoreList(append(kc.IgnoreList(cfg.Sensitive), o.sensitive...)),
+		ResourceProfiles: cfg.ResourceProfiles,
+		Rules:            rules,
+		Severities:       severities,
+		FailOn:           failOnSeverity,
+		Render:           kc.RenderOptions{Capabilities: caps},
+		Renames:          renames,
+		Policies:         policies,
+		Schemas:          schemas,
+		CustomRules:      customRules,
+		Constraints:      constraints,
+		Plugins:          plugins,
+		// Numbers are compared by value if either the flag or the configuration asks for it.
+		NumericTolerant: o.numTolerant || cfg.NumericTolerant,
+	}, nil
+}
+
+// errNoValuesSets is returned when auto-detection finds no values sets for a chart.
+var errNoValuesSets = errors.New("no valid values files")
+
+// valuesSets returns the ordered lists of values files to validate: either the files given
+// via -f, or every auto-detected (overrides, service) pair below the working directory or the
+// --base-dir directories.
+func (o *validateOptions) valuesSets(ctx context.Context, chartName string) ([][]string, error) {
+	if len(o.valuesFiles) > 0 {
+		return [][]string{o.valuesFiles}, nil
+	}
+
+	envDir, discoveryOpts, scopes, err := o.discoveryScope(chartName)
+	if err != nil {
+		return nil, err
+	}
+	searched := []string{envDir}
+	if len(scopes) > 0 {
+		searched = scopes
+	}
+	pairs, err := detectPairs(ctx, envDir, discoveryOpts, scopes...)
+	if err != nil {
+		return nil, &loadError{fmt.Errorf("auto-detecting values: %w", err)}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("%w (%s) found in base directory: %s", errNoValuesSets, discoveryOpts.naming, strings.Join(searched, ", "))
+	}
+
+	sets := make([][]string, 0, len(pairs))
+	for _, p := range pairs {
+		// The order matters: the overrides files are applied first.
+		var files []string
+		for _, file := range p.files() {
+			files = append(files, relPath(envDir, file))
+		}
+		logger.Debug("found values set", "files", files)
+		sets = append(sets, files)
+	}
+	return sets, nil
+}
+
+// discoveryScope returns the base directory of auto-detection, the working directory, along
+// with the options of discovery and the absolute --base-dir directories.
+func (o *validateOptions) discoveryScope(chartName string) (string, discoveryOptions, []string, error) {
+	envDir, err := os.Getwd()
+	if err != nil {
+		return "", discoveryOptions{}, nil, fmt.Errorf("determining current directory: %w", err)
+	}
+	opts, err := o.discoveryOptions(chartName)
+	if err != nil {
+		return "", discoveryOptions{}, nil, err
+	}
+	var scopes []string
+	for _, dir := range o.baseDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(envDir, dir)
+		}
+		scopes = append(scopes, filepath.Clean(dir))
+	}
+	return envDir, opts, scopes, nil
+}
+
+// discoveryOptions returns how values sets are auto-detected, from the flags or else the
+// configuration file.
+func (o *validateOptions) discoveryOptions(chartName string) (discoveryOptions, error) {
+	cfg, err := kc.LoadConfig(configOrDefault(o.configPath), o.configPath != "")
+	if err != nil {
+		return discoveryOptions{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(o.layers) > 0 && o.overrideName != "" {
+		return discoveryOptions{}, fmt.Errorf("--override-name and --layer cannot be used together")
+	}
+	layers, servicePattern := cfg.Discovery.Layers, cfg.Discovery.ServicePattern
+	if len(layers) == 0 && cfg.Discovery.OverrideName != "" {
+		layers = []string{cfg.Discovery.OverrideName}
+	}
+	switch {
+	case len(o.layers) > 0:
+		layers = o.layers
+	case o.overrideName != "":
+		layers = []string{o.overrideName}
+	}
+	if o.servicePat != "" {
+		servicePattern = o.servicePat
+	}
+	serviceNames := []string{chartName}
+	if names, ok := cfg.Discovery.ServiceNames[chartName]; ok && len(names) > 0 {
+		serviceNames = names
+	}
+	if len(o.serviceNames) > 0 {
+		serviceNames = o.serviceNames
+	}
+	naming, err := newNamingConvention(serviceNames, layers, servicePattern)
+	if err != nil {
+		return discoveryOptions{}, err
+	}
+	return discoveryOptions{
+		naming:         naming,
+		excludeDirs:    o.excludeDirs,
+		followSymlinks: o.followSymlinks || cfg.Discovery.FollowSymlinks,
+	}, nil
+}
+
+// relPath returns path relative to base when possible, so findings and baselines don't
+// depend on where the repository is checked out.
+func relPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// validate runs the validator over every values set and reports findings to reporter.
+// It returns true if any failing issues were found.
+func (o *validateOptions) validate(ctx context.Context, out io.Writer, chartPath string, opts kc.Options, reporter kc.Reporter, verbose bool) (bool, error) {
+	c, err := o.loadChart(ctx, chartPath)
+	if err != nil {
+		return false, err
+	}
+
+	sets, err := o.valuesSets(ctx, c.name)
+	if err != nil {
+		return false, err
+	}
+	if o.changed.enabled() {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return false, fmt.Errorf("determining current directory: %w", err)
+		}
+		changed, err := o.changed.files(cwd)
+		if err != nil {
+			return false, fmt.Errorf("finding changed files: %w", err)
+		}
+		if sets = affectedSets(c, sets, changed); len(sets) == 0 {
+			if verbose {
+				fmt.Fprintf(out, "No values sets affected by %s.\n", o.changed.String())
+			}
+			return false, nil
+		}
+		if o.changed.staged {
+			if err := o.readStaged(cwd, sets, changed); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if verbose && len(o.valuesFiles) > 0 {
+		fmt.Fprintf(out, "\nValidating Helm chart values:\n")
+		fmt.Fprintf(out, "==============================\n")
+		fmt.Fprintf(out, "Chart: %s\n", c.path)
+		fmt.Fprintf(out, "Values files: %s\n", strings.Join(o.valuesFiles, ","))
+		if len(o.ignoreList) > 0 {
+			fmt.Fprintf(out, "Ignoring fields: %s\n", o.ignoreList.String())
+		}
+		fmt.Fprintf(out, "\nStarting validation...\n\n")
+	}
+
+	if o.fix {
+		// Fixes are applied before validating anything, since files like overrides.yaml are
+		// shared between values sets.
+		for _, files := range sets {
+			if err := o.fixFiles(c, files, opts); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	overallIssues, err := o.checkSyntax(sets, opts, reporter)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	loaded := make([]kc.ValuesSet, 0, len(sets))
+	for i, pending := range o.validateSets(ctx, c, sets, opts) {
+		var result setResult
+		select {
+		case result = <-pending:
+		case <-ctx.Done():
+			return false, fmt.Errorf("validating %s: %w", strings.Join(sets[i], ", "), context.Cause(ctx))
+		}
+		files := sets[i]
+		if result.loadErr != nil {
+			if len(sets) == 1 {
+				return false, &loadError{fmt.Errorf("failed to load values: %w", result.loadErr)}
+			}
+			logger.Error("failed to load values", "files", files, "err", result.loadErr)
+			o.loadFailures++
+			continue
+		}
+		if result.err != nil {
+			return false, result.err
+		}
+		loaded = append(loaded, kc.ValuesSet{Files: files})
+		findings := result.findings
+		if o.failFast {
+			for j, f := range findings {
+				if f.Severity == kc.SeverityError {
+					findings, o.failedFast = findings[:j+1], true
+					break
+				}
+			}
+		}
+		for _, f := range findings {
+			reporter.Report(f)
+		}
+		if result.issuesFound {
+			// Grouped findings are printed under the values set by the reporter itself.
+			if groupBy, _ := kc.ParseGroupBy(o.groupBy); verbose && len(o.valuesFiles) == 0 && groupBy == kc.GroupByNone {
+				fmt.Fprintf(out, "Issues found for (%s)\n", strings.Join(files, ", "))
+			}
+			overallIssues = true
+		}
+		if o.failedFast {
+			logger.Info("stopping at the first error (--fail-fast)", "files", files, "skipped", len(sets)-i-1)
+			break
+		}
+	}
+	if len(o.valuesFiles) == 0 && !o.failedFast {
+		identicalFound, err := o.checkIdentical(c, loaded, opts, reporter)
+		if err != nil {
+			return false, err
+		}
+		overallIssues = overallIssues || identicalFound
+	}
+	if o.checkOverrides && len(o.valuesFiles) == 0 {
+		if err := o.recordOverrides(c, sets); err != nil {
+			return false, err
+		}
+	}
+	if o.consistency && !o.failedFast {
+		consistencyFound, err := o.checkConsistency(loaded, opts, reporter)
+		if err != nil {
+			return false, err
+		}
+		overallIssues = overallIssues || consistencyFound
+	}
+	return overallIssues, nil
+}
+
+// checkSecrets scans the values files of a set for credentials, except the ones decrypted
+// with SOPS, which keep them encrypted at rest.
+func (o *validateOptions) checkSecrets(files []string, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	layers := make([]map[string]interface{}, 0, len(files))
+	for _, file := range files {
+		if o.reader.encrypted[file] {
+			continue
+		}
+		values, err := o.reader.values(file)
+		if err != nil {
+			return false, err
+		}
+		layers = append(layers, values)
+	}
+	return kc.CheckSecrets(layers, opts, reporter), nil
+}
+
+// checkSyntax reports the YAML of the values files of the sets that Helm reads differently
+// than it looks, once per run. Files that cannot be read fail to load later.
+func (o *validateOptions) checkSyntax(sets [][]string, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	if !opts.Rules.Enabled(kc.RuleYAMLSyntax) {
+		return false, nil
+	}
+	if o.linted == nil {
+		o.linted = map[string]bool{}
+	}
+	var files []string
+	var docs [][]byte
+	for _, set := range sets {
+		for _, file := range set {
+			if o.linted[file] {
+				continue
+			}
+			o.linted[file] = true
+			data, err := o.reader.read(file)
+			if err != nil {
+				continue
+			}
+			files, docs = append(files, file), append(docs, data)
+		}
+	}
+	opts.Suppressions, _ = kc.ParseSuppressions(docs...)
+	return kc.CheckYAMLSyntax(files, docs, opts, reporter), nil
+}
+
+// checkIdentical compares the service files of the values sets that were validated.
+func (o *validateOptions) checkIdentical(c *loadedChart, sets []kc.ValuesSet, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	if len(sets) < 2 || !opts.Rules.Enabled(kc.RuleIdenticalServices) {
+		return false, nil
+	}
+	discoveryOpts, err := o.discoveryOptions(c.name)
+	if err != nil {
+		return false, err
+	}
+	var services []kc.ServiceFile
+	for _, set := range sets {
+		for _, file := range set.Files {
+			if !discoveryOpts.naming.isService(filepath.Base(file)) {
+				continue
+			}
+			data, err := o.reader.read(file)
+			if err != nil {
+				return false, err
+			}
+			values, err := o.reader.values(file)
+			if err != nil {
+				return false, err
+			}
+			suppressions, err := o.suppressions(set.Files)
+			if err != nil {
+				return false, fmt.Errorf("reading inline suppressions: %w", err)
+			}
+			services = append(services, kc.ServiceFile{Path: file, Data: data, Values: values, Files: set.Files, Suppressions: suppressions})
+		}
+	}
+	return kc.CheckIdenticalServiceFiles(services, opts, reporter), nil
+}
+
+// checkConsistency compares the values sets that were validated with each other.
+func (o *validateOptions) checkConsistency(sets []kc.ValuesSet, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	for i := range sets {
+		values, err := o.mergeValues(sets[i].Files)
+		if err != nil {
+			return false, err
+		}
+		if sets[i].Suppressions, err = o.suppressions(sets[i].Files); err != nil {
+			return false, fmt.Errorf("reading inline suppressions: %w", err)
+		}
+		sets[i].Values = values
+	}
+	return kc.CheckConsistency(sets, opts, reporter), nil
+}
+
+// setResult is the outcome of validating a single values set.
+type setResult struct {
+	findings    []kc.Finding
+	issuesFound bool
+	// loadErr is set if the values could not be loaded, err if validation failed.
+	loadErr error
+	err     error
+}
+
+// validateSets validates the values sets concurrently with up to o.jobs workers. Findings
+// are collected per set and the result of every set is delivered on its own channel, so they
+// can be reported in the order of the sets, keeping output deterministic, while later sets are
+// still being validated. Once ctx is cancelled, the remaining sets are not validated and their
+// results hold the context's error.
+func (o *validateOptions) validateSets(ctx context.Context, c *loadedChart, sets [][]string, opts kc.Options) []chan setResult {
+	// The values reader caches documents and their parsed values. Reading every document
+	// once up front leaves the workers with lookups only, which are safe to run
+	// concurrently. Errors are reported by the workers.
+	for _, files := range sets {
+		for _, file := range files {
+			_, _ = o.reader.values(file)
+		}
+	}
+	_, _ = o.mergeValues(nil)
+
+	results := make([]chan setResult, len(sets))
+	for i := range results {
+		results[i] = make(chan setResult, 1)
+	}
+	indexes := make(chan int)
+	for w := 0; w < min(max(o.jobs, 1), len(sets)); w++ {
+		go func() {
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i] <- setResult{err: err}
+					continue
+				}
+				results[i] <- o.validateSet(ctx, c, sets[i], opts)
+			}
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for i := range sets {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// validateSet merges and validates a single values set, collecting its findings.
+func (o *validateOptions) validateSet(ctx context.Context, c *loadedChart, files []string, opts kc.Options) setResult {
+	providedValues, err := o.mergeValues(files)
+	if err != nil {
+		return setResult{loadErr: err}
+	}
+	collector := &kc.Collector{}
+	issuesFound, err := o.validateValues(ctx, c, files, providedValues, opts, collector)
+	return setResult{findings: collector.Findings, issuesFound: issuesFound, err: err}
+}
+
+// validateValues runs every enabled check for a single set of merged values against the chart.
+// Policies and plugins are stopped once ctx is done. It returns true if any failing issues were found.
+func (o *validateOptions) validateValues(ctx context.Context, c *loadedChart, files []string, providedValues map[string]interface{}, opts kc.Options, reporter kc.Reporter) (bool, error) {
+	var err error
+	setOpts := opts
+	setOpts.Files = files
+	setOpts.Values = providedValues
+	setOpts.Suppressions, err = o.suppressions(files)
+	if err != nil {
+		return false, fmt.Errorf("reading inline suppressions: %w", err)
+	}
+	names := o.setSources(files)
+	logger.Debug("merging values", "sources", names)
+	if len(files) > 1 || len(o.setFlags()) > 0 {
+		// A single layer cannot restore a default over a lower one.
+		if setOpts.Layers, err = o.fileLayers(files); err != nil {
+			return false, err
+		}
+		logOverrides(names, setOpts.Layers)
+	}
+	if verbosity >= levelDebug {
+		// Sets are validated concurrently, so every key is logged with its set.
+		setOpts.Logger = slog.New(traceHandler{logger.Handler()}).With("files", files)
+	}
+	defaultValues, err := c.defaults.Get(providedValues)
+	if err != nil {
+		return false, fmt.Errorf("computing chart defaults: %w", err)
+	}
+	issuesFound := kc.ValidateChartValues(defaultValues, providedValues, setOpts, reporter)
+	issuesFound = kc.CheckLayerValues(defaultValues, names, setOpts, reporter) || issuesFound
+	if setOpts.Rules.Enabled(kc.RulePossibleSecret) {
+		secretsFound, err := o.checkSecrets(files, setOpts, reporter)
+		if err != nil {
+			return false, err
+		}
+		issuesFound = issuesFound || secretsFound
+	}
+	if setOpts.Rules.Enabled(kc.RuleLimitBelowRequest) || setOpts.Rules.Enabled(kc.RuleMissingRequests) || setOpts.Rules.Enabled(kc.RuleResourceRange) {
+		effective, err := kc.EffectiveValues(c.chart, providedValues)
+		if err != nil {
+			return false, fmt.Errorf("computing effective values: %w", err)
+		}
+		resourcesFound, err := kc.CheckResources(effective, setOpts, reporter)
+		if err != nil {
+			return false, err
+		}
+		issuesFound = issuesFound || resourcesFound
+	}
+	if setOpts.Rules.Enabled(kc.RuleAnchoredValue) {
+		docs, err := o.documents(files)
+		if err != nil {
+			return false, err
+		}
+		issuesFound = kc.CheckAnchors(files, docs, setOpts, reporter) || issuesFound
+	}
+	if setOpts.Renames, err = chartRenames(c, setOpts); err != nil {
+		return false, err
+	}
+	issuesFound = kc.CheckRenames(setOpts.Renames, providedValues, setOpts, reporter) || issuesFound
+	issuesFound = kc.CheckConstraints(setOpts.Constraints, defaultValues, providedValues, setOpts, reporter) || issuesFound
+	requiredFound, err := kc.CheckRequiredValues(c.chart, providedValues, setOpts, reporter)
+	if err != nil {
+		return false, err
+	}
+	issuesFound = issuesFound || requiredFound
+	var rendered map[string]string
+	if o.render || o.schemas {
+		var renderFailed bool
+		rendered, renderFailed = kc.CheckRender(c.chart, providedValues, setOpts, reporter)
+		issuesFound = issuesFound || renderFailed
+		if o.schemas && rendered != nil {
+			issuesFound = kc.CheckSchemas(setOpts.Schemas, rendered, setOpts, reporter) || issuesFound
+		}
+	}
+	if o.lint {
+		if _, err := os.Stat(c.path); err != nil {
+			return false, fmt.Errorf("--lint requires a chart available on disk: %s", c.path)
+		}
+		issuesFound = kc.CheckLint(c.path, providedValues, setOpts, reporter) || issuesFound
+	}
+	if o.checkUnused {
+		unusedFound, err := kc.CheckUnusedValues(c.chart, providedValues, setOpts, reporter)
+		if err != nil {
+			return false, err
+		}
+		issuesFound = issuesFound || unusedFound
+	}
+	if setOpts.Policies != nil || len(setOpts.CustomRules) > 0 || len(setOpts.Plugins) > 0 {
+		input, err := kc.NewPolicyInput(c.chart, defaultValues, providedValues, rendered, files)
+		if err != nil {
+			return false, err
+		}
+		policyFound, err := kc.CheckPolicies(ctx, setOpts.Policies, input, setOpts, reporter)
+		if err != nil {
+			return false, err
+		}
+		pluginFound, err := kc.CheckPlugins(ctx, input, setOpts.Plugins, setOpts, reporter)
+		if err != nil {
+			return false, err
+		}
+		issuesFound = kc.CheckCustomRules(setOpts.CustomRules, input, setOpts, reporter) || policyFound || pluginFound || issuesFound
+	}
+	return issuesFound, nil
+}
+
+// prepare resolves the validator options, including the baseline, and creates the reporter
+// for the selected output formats. It starts a new run, unless the caller began one that was
+// not prepared yet.
+func (o *validateOptions) prepare(out io.Writer) (kc.Reporter, kc.Options, error) {
+	if o.validateRun == nil || o.counter != nil {
+		o.begin(nil)
+	}
+	opts, err := o.options()
+	if err != nil {
+		return nil, kc.Options{}, err
+	}
+	opts.Baseline, err = kc.LoadBaseline(baselineOrDefault(o.baselinePath), o.baselinePath != "")
+	if err != nil {
+		return nil, kc.Options{}, fmt.Errorf("failed to load baseline: %w", err)
+	}
+	reporter := o.reporter
+	if reporter == nil {
+		if reporter, err = o.newReporters(out); err != nil {
+			return nil, kc.Options{}, err
+		}
+	}
+	// Every finding is counted, including the ones hidden by --min-severity.
+	o.counter = &issueCounter{Reporter: reporter, counts: map[kc.Severity]int{}}
+	return o.counter, opts, nil
+}
+
+// run validates the chart and reports findings in the selected output format.
+func (o *validateOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+
+	// Banners are only printed for human-readable output so machine formats stay parseable.
+	issuesFound, err := o.validate(ctx, out, chartPath, opts, reporter, o.banners())
+	if err != nil {
+		return err
+	}
+	overridesFound, err := o.checkUnusedOverrides(ctx, opts, reporter)
+	if err != nil {
+		return err
+	}
+	issuesFound = issuesFound || overridesFound
+	reporter.Summary()
+	return o.result(issuesFound, opts.FailOn)
+}
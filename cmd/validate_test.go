@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// TestValidateParallel verifies that values sets validated concurrently report their findings
+// in the order of the sets.
+func TestValidateParallel(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"envs/overrides.yaml":     "image:\n  tag: latest\n",
+	}
+	const envs = 20
+	for i := 0; i < envs; i++ {
+		files[fmt.Sprintf("envs/env%02d/web_service.yaml", i)] = "replicaCount: 1\n"
+	}
+	writeFiles(t, dir, files)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "envs")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	// The service files are identical on purpose.
+	o := &validateOptions{jobs: 4, disableRules: []string{kc.RuleIdenticalServices}}
+	o.begin(nil)
+	opts, err := o.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &kc.Collector{}
+	issuesFound, err := o.validate(context.Background(), io.Discard, filepath.Join(dir, "web_service"), opts, collector, false)
+	if err != nil {
+		t.Fatalf("validate() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Error("validate() found no issues")
+	}
+
+	var got, want [][]string
+	for _, f := range collector.Findings {
+		got = append(got, append([]string{f.Path}, f.Files...))
+	}
+	for i := 0; i < envs; i++ {
+		service := fmt.Sprintf("env%02d/web_service.yaml", i)
+		want = append(want,
+			[]string{"image.tag", "overrides.yaml", service},
+			[]string{"replicaCount", "overrides.yaml", service},
+		)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings in unexpected order:\n%v\nwant\n%v", got, want)
+	}
+}
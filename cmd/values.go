@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+// stdinPath is the values file name that reads the values document from stdin.
+const stdinPath = "-"
+
+// valuesReader reads values files from disk, stdin, or any URL scheme supported by Helm's
+// getters (http, https, oci, and getter plugins such as s3 or gs). SOPS-encrypted documents
+// are decrypted unless noDecrypt is set. Stdin, remote, and decrypted documents are buffered
+// on first use so the same document can be merged and scanned for inline suppressions.
+// Parsed documents are memoized as well, since files like overrides.yaml are shared between
+// values sets.
+type valuesReader struct {
+	stdin     io.Reader
+	providers getter.Providers
+	noDecrypt bool
+	cache     map[string][]byte
+	parsed    map[string]map[string]interface{}
+	// encrypted records the documents that were decrypted, which keep their secrets
+	// encrypted at rest.
+	encrypted map[string]bool
+}
+
+func (r *valuesReader) read(path string) ([]byte, error) {
+	if data, ok := r.cache[path]; ok {
+		return data, nil
+	}
+	var data []byte
+	var err error
+	local := false
+	if strings.TrimSpace(path) == stdinPath {
+		data, err = r.readStdin()
+	} else if g, ok := r.getter(path); ok {
+		data, err = r.readURL(g, path)
+	} else {
+		data, err = os.ReadFile(path)
+		local = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !r.noDecrypt && isSOPSEncrypted(data) {
+		if data, err = decryptSOPS(path, data, local); err != nil {
+			return nil, err
+		}
+		if r.encrypted == nil {
+			r.encrypted = map[string]bool{}
+		}
+		r.encrypted[path] = true
+	} else if local {
+		return data, nil
+	}
+	if r.cache == nil {
+		r.cache = map[string][]byte{}
+	}
+	r.cache[path] = data
+	return data, nil
+}
+
+// values reads and parses a values document. The result is a copy the caller may modify.
+func (r *valuesReader) values(path string) (map[string]interface{}, error) {
+	if values, ok := r.parsed[path]; ok {
+		return copyValue(values).(map[string]interface{}), nil
+	}
+	data, err := r.read(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, kc.DiagnoseYAML(data, err))
+	}
+	if r.parsed == nil {
+		r.parsed = map[string]map[string]interface{}{}
+	}
+	r.parsed[path] = values
+	return copyValue(values).(map[string]interface{}), nil
+}
+
+// copyValue returns a deep copy of a parsed values tree.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = copyValue(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = copyValue(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (r *valuesReader) readStdin() ([]byte, error) {
+	in := r.stdin
+	if in == nil {
+		in = os.Stdin
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("reading values from stdin: %w", err)
+	}
+	return data, nil
+}
+
+// getter returns the Helm getter for the URL scheme of path, if there is one.
+func (r *valuesReader) getter(path string) (getter.Getter, bool) {
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme == "" {
+		return nil, false
+	}
+	if r.providers == nil {
+		r.providers = getter.All(cli.New())
+	}
+	g, err := r.providers.ByScheme(u.Scheme)
+	if err != nil {
+		return nil, false
+	}
+	return g, true
+}
+
+func (r *valuesReader) readURL(g getter.Getter, path string) ([]byte, error) {
+	buf, err := g.Get(path, getter.WithURL(path))
+	if err != nil {
+		return nil, fmt.Errorf("fetching values from %s: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeValues merges the given values files followed by the --set family of flags,
+// in the same order of precedence as Helm's values.Options.MergeValues.
+func (o *validateOptions) mergeValues(files []string) (map[string]interface{}, error) {
+	base, err := o.mergeFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range o.setFlags() {
+		if err := o.parseSetFlag(flag, base); err != nil {
+			return nil, err
+		}
+	}
+	return base, nil
+}
+
+// mergeFiles merges the given values files, later files taking precedence.
+func (o *validateOptions) mergeFiles(files []string) (map[string]interface{}, error) {
+	base := map[string]interface{}{}
+	for _, file := range files {
+		current, err := o.reader.values(file)
+		if err != nil {
+			return nil, err
+		}
+		// Documents left encrypted with --no-decrypt carry SOPS metadata that is not a chart value.
+		if hasSOPSMetadata(current) {
+			delete(current, sopsMetadataKey)
+		}
+		base = mergeMaps(base, current)
+	}
+	return base, nil
+}
+
+// fileLayers returns the values of every file, followed by the values of the --set family of
+// flags, in the order they are merged.
+func (o *validateOptions) fileLayers(files []string) ([]map[string]interface{}, error) {
+	layers := make([]map[string]interface{}, 0, len(files)+1)
+	for _, file := range files {
+		current, err := o.mergeFiles([]string{file})
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, current)
+	}
+	if flags := o.setFlags(); len(flags) > 0 {
+		current := map[string]interface{}{}
+		for _, flag := range flags {
+			if err := o.parseSetFlag(flag, current); err != nil {
+				return nil, err
+			}
+		}
+		layers = append(layers, current)
+	}
+	return layers, nil
+}
+
+// setFlag is a single value of the --set family of flags.
+type setFlag struct {
+	name  string
+	value string
+}
+
+func (f setFlag) String() string {
+	return "--" + f.name + " " + f.value
+}
+
+// setFlags returns the --set family flags in Helm's order of precedence.
+func (o *validateOptions) setFlags() []setFlag {
+	var flags []setFlag
+	for _, group := range []struct {
+		name   string
+		values []string
+	}{
+		{"set-json", o.setValues.JSONValues},
+		{"set", o.setValues.Values},
+		{"set-string", o.setValues.StringValues},
+		{"set-file", o.setValues.FileValues},
+		{"set-literal", o.setValues.LiteralValues},
+	} {
+		for _, value := range group.values {
+			flags = append(flags, setFlag{name: group.name, value: value})
+		}
+	}
+	return flags
+}
+
+// parseSetFlag applies a --set family flag to base.
+func (o *validateOptions) parseSetFlag(flag setFlag, base map[string]interface{}) error {
+	var err error
+	switch flag.name {
+	case "set-json":
+		if err := strvals.ParseJSON(flag.value, base); err != nil {
+			return fmt.Errorf("failed parsing --set-json data %s", flag.value)
+		}
+		return nil
+	case "set":
+		err = strvals.ParseInto(flag.value, base)
+	case "set-string":
+		err = strvals.ParseIntoString(flag.value, base)
+	case "set-file":
+		reader := func(rs []rune) (interface{}, error) {
+			data, err := o.reader.read(string(rs))
+			if err != nil {
+				return nil, err
+			}
+			return string(data), nil
+		}
+		err = strvals.ParseIntoFile(flag.value, base, reader)
+	case "set-literal":
+		err = strvals.ParseLiteralInto(flag.value, base)
+	}
+	if err != nil {
+		return fmt.Errorf("failed parsing --%s data: %w", flag.name, err)
+	}
+	return nil
+}
+
+// suppressions collects the inline suppressions from the given values files.
+func (o *validateOptions) suppressions(files []string) (kc.Suppressions, error) {
+	docs, err := o.documents(files)
+	if err != nil {
+		return nil, err
+	}
+	return kc.ParseSuppressions(docs...)
+}
+
+// documents returns the contents of the values files.
+func (o *validateOptions) documents(files []string) ([][]byte, error) {
+	docs := make([][]byte, 0, len(files))
+	for _, file := range files {
+		data, err := o.reader.read(file)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, data)
+	}
+	return docs, nil
+}
+
+// mergeMaps recursively merges b into a, with values from b taking precedence.
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeMaps(bv, v)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
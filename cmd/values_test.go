@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMergeValuesSetFlags verifies that the --set family of flags is applied on top of the values files.
+func TestMergeValuesSetFlags(t *testing.T) {
+	dir := t.TempDir()
+	valuesFile := filepath.Join(dir, "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("replicaCount: 1\nimage:\n  tag: stable\n"), 0o644); err != nil {
+		t.Fatalf("failed to write values file: %v", err)
+	}
+	configFile := filepath.Join(dir, "config.txt")
+	if err := os.WriteFile(configFile, []byte("from-file"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	o := &validateOptions{}
+	o.begin(nil)
+	o.setValues.Values = []string{"replicaCount=3"}
+	o.setValues.StringValues = []string{"image.tag=1.0"}
+	o.setValues.FileValues = []string{"config=" + configFile}
+	o.setValues.JSONValues = []string{`ports=[80,443]`}
+
+	merged, err := o.mergeValues([]string{valuesFile})
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+
+	if merged["replicaCount"] != int64(3) {
+		t.Errorf("expected replicaCount to be 3, got %#v", merged["replicaCount"])
+	}
+	image, ok := merged["image"].(map[string]interface{})
+	if !ok || image["tag"] != "1.0" {
+		t.Errorf("expected image.tag to be the string '1.0', got %#v", merged["image"])
+	}
+	if merged["config"] != "from-file" {
+		t.Errorf("expected config to be read from file, got %#v", merged["config"])
+	}
+	if ports, ok := merged["ports"].([]interface{}); !ok || len(ports) != 2 {
+		t.Errorf("expected ports to be a JSON list, got %#v", merged["ports"])
+	}
+}
+
+// TestMergeValuesMemoized verifies that values files shared between values sets are parsed
+// once, and that --set flags applied to one set don't leak into the next.
+func TestMergeValuesMemoized(t *testing.T) {
+	dir := t.TempDir()
+	valuesFile := filepath.Join(dir, "overrides.yaml")
+	if err := os.WriteFile(valuesFile, []byte("image:\n  tag: stable\nhosts:\n  - name: a\n"), 0o644); err != nil {
+		t.Fatalf("failed to write values file: %v", err)
+	}
+
+	o := &validateOptions{}
+	o.begin(nil)
+	o.setValues.Values = []string{"image.tag=1.0", "hosts[0].name=b"}
+	if _, err := o.mergeValues([]string{valuesFile}); err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	// Later reads are served from memory.
+	if err := os.Remove(valuesFile); err != nil {
+		t.Fatal(err)
+	}
+	o.setValues.Values = nil
+	merged, err := o.mergeValues([]string{valuesFile})
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	if got := merged["image"].(map[string]interface{})["tag"]; got != "stable" {
+		t.Errorf("expected image.tag from the file, got %#v", got)
+	}
+	if got := merged["hosts"].([]interface{})[0].(map[string]interface{})["name"]; got != "a" {
+		t.Errorf("expected hosts[0].name from the file, got %#v", got)
+	}
+}
+
+// TestMergeValuesStdin verifies that "-" reads the values document from stdin, and that the
+// document is still available for inline suppressions after it has been merged.
+func TestMergeValuesStdin(t *testing.T) {
+	o := &validateOptions{stdin: strings.NewReader("replicaCount: 2 # kc:ignore=KC001\n")}
+	o.begin(nil)
+
+	merged, err := o.mergeValues([]string{stdinPath})
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	if merged["replicaCount"] != float64(2) {
+		t.Errorf("expected replicaCount to be 2, got %#v", merged["replicaCount"])
+	}
+
+	s, err := o.suppressions([]string{stdinPath})
+	if err != nil {
+		t.Fatalf("suppressions() returned error: %v", err)
+	}
+	if rules, ok := s["replicaCount"]; !ok || len(rules) != 1 || rules[0] != "KC001" {
+		t.Errorf("expected replicaCount to suppress KC001, got %v", s)
+	}
+}
+
+// TestMergeValuesURL verifies that values files can be fetched from a URL.
+func TestMergeValuesURL(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("image:\n  tag: remote\n"))
+	}))
+	defer srv.Close()
+
+	o := &validateOptions{}
+	o.begin(nil)
+	files := []string{srv.URL + "/values.yaml"}
+	merged, err := o.mergeValues(files)
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	image, ok := merged["image"].(map[string]interface{})
+	if !ok || image["tag"] != "remote" {
+		t.Errorf("expected image.tag to be 'remote', got %#v", merged["image"])
+	}
+
+	if _, err := o.suppressions(files); err != nil {
+		t.Fatalf("suppressions() returned error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the remote values file to be fetched once, got %d requests", requests)
+	}
+}
+
+// TestMergeValuesSOPS verifies that SOPS-encrypted values files are decrypted with the sops
+// binary, and that --no-decrypt keeps them as they are without the SOPS metadata.
+func TestMergeValuesSOPS(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "secrets.yaml")
+	content := "password: ENC[AES256_GCM,data:abc,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:def,type:str]\n  version: 3.9.0\n"
+	if err := os.WriteFile(encrypted, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write values file: %v", err)
+	}
+
+	// A fake sops binary that prints the decrypted document.
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'password: hunter2'\n"
+	if err := os.WriteFile(filepath.Join(bin, "sops"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake sops: %v", err)
+	}
+	t.Setenv("PATH", bin)
+
+	o := &validateOptions{}
+	o.begin(nil)
+	merged, err := o.mergeValues([]string{encrypted})
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	if merged["password"] != "hunter2" {
+		t.Errorf("expected password to be decrypted, got %#v", merged["password"])
+	}
+
+	o = &validateOptions{noDecrypt: true}
+	o.begin(nil)
+	merged, err = o.mergeValues([]string{encrypted})
+	if err != nil {
+		t.Fatalf("mergeValues() returned error: %v", err)
+	}
+	if _, ok := merged[sopsMetadataKey]; ok {
+		t.Errorf("expected SOPS metadata to be removed, got %#v", merged)
+	}
+	if !strings.HasPrefix(merged["password"].(string), "ENC[") {
+		t.Errorf("expected password to stay encrypted, got %#v", merged["password"])
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, injected at release time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+	// releasePublicKey is the minisign public key the checksums of releases are signed with,
+	// which self-update verifies them against.
+	releasePublicKey = ""
+)
+
+// helmModule is the module path of the Helm SDK.
+const helmModule = "helm.sh/helm/v3"
+
+// buildInfo describes the build of the running binary.
+type buildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// Helm is the version of the Helm SDK that kc is compiled against.
+	Helm     string `json:"helm,omitempty"`
+	Go       string `json:"go"`
+	Platform string `json:"platform"`
+}
+
+// versionOptions holds the flags of the version command.
+type versionOptions struct {
+	output string
+	short  bool
+}
+
+func newVersionCmd() *cobra.Command {
+	o := &versionOptions{}
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit, and build date of kc",
+		Long: `Print the version, commit, and build date of kc, and the version of the Helm SDK it is
+compiled against, which determines how charts are loaded and rendered.`,
+		Example: `  helm kc version
+  helm kc version --short
+  helm kc version -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&o.short, "short", false, "Only print the version")
+	return cmd
+}
+
+func (o *versionOptions) run(out io.Writer) error {
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+	info := currentBuild()
+	switch {
+	case o.short:
+		_, err := fmt.Fprintln(out, info.Version)
+		return err
+	case o.output == "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s\n", info.Version)
+	fmt.Fprintf(w, "Commit:\t%s\n", orUnknown(info.Commit))
+	fmt.Fprintf(w, "Built:\t%s\n", orUnknown(info.Date))
+	fmt.Fprintf(w, "Helm SDK:\t%s\n", orUnknown(info.Helm))
+	fmt.Fprintf(w, "Go:\t%s\n", info.Go)
+	fmt.Fprintf(w, "Platform:\t%s\n", info.Platform)
+	return w.Flush()
+}
+
+// currentBuild returns the build metadata of the running binary. Metadata not injected with
+// -ldflags is taken from the module and VCS information that go build embeds, if any.
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:  version,
+		Commit:   commit,
+		Date:     date,
+		Go:       runtime.Version(),
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.Date == "":
+			info.Date = setting.Value
+		}
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == helmModule {
+			info.Helm = dep.Version
+			if dep.Replace != nil {
+				info.Helm = dep.Replace.Version
+			}
+		}
+	}
+	return info
+}
+
+// orUnknown returns s, or "unknown" if it is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestVersion verifies that the version command prints the injected build metadata and the
+// Helm SDK version in text and JSON.
+func TestVersion(t *testing.T) {
+	oldVersion, oldCommit, oldDate := version, commit, date
+	t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+	version, commit, date = "v1.2.3", "abc123", "2025-01-02T03:04:05Z"
+
+	var out bytes.Buffer
+	if err := (&versionOptions{output: "json"}).run(&out); err != nil {
+		t.Fatal(err)
+	}
+	var info buildInfo
+	if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.2.3" || info.Commit != "abc123" || info.Date != "2025-01-02T03:04:05Z" {
+		t.Errorf("expected the injected metadata, got %+v", info)
+	}
+	if !strings.HasPrefix(info.Helm, "v3.") {
+		t.Errorf("expected the Helm SDK version, got %q", info.Helm)
+	}
+
+	out.Reset()
+	if err := (&versionOptions{output: "text"}).run(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Version:   v1.2.3\n") || !strings.Contains(out.String(), "Helm SDK:  "+info.Helm) {
+		t.Errorf("unexpected text output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := (&versionOptions{output: "text", short: true}).run(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "v1.2.3\n" {
+		t.Errorf("expected only the version, got %q", out.String())
+	}
+}
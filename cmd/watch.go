@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/tiulpin/kaartcontrole/pkg/kc"
+)
+
+// watchDebounce is how long the watcher waits for more changes before re-validating, since
+// editors often write a file in several steps.
+const watchDebounce = 200 * time.Millisecond
+
+// watchOptions holds the flags of the watch command.
+type watchOptions struct {
+	validateOptions
+}
+
+func newWatchCmd() *cobra.Command {
+	o := &watchOptions{}
+	cmd := &cobra.Command{
+		Use:   "watch <chart> [-f <values-file> ...]",
+		Short: "Re-validate whenever the chart or values files change",
+		Long: `Validate the values, then watch the chart and the values files and validate again on
+every change. After the first run, only findings that appeared or were resolved by the
+change are printed.
+
+Without -f, the working directory is watched for auto-detected values sets.`,
+		Example: `  helm kc watch ./mychart -f overrides.yaml -f production/web_service.yaml`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return o.run(ctx, cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd.Flags())
+	return cmd
+}
+
+// watchDirs returns the directories to watch: the chart and the directories of the values
+// files, or the --base-dir directories or working directory if values sets are auto-detected.
+func (o *watchOptions) watchDirs(chartPath string) ([]string, error) {
+	var roots []string
+	if info, err := os.Stat(chartPath); err == nil && info.IsDir() {
+		roots = append(roots, chartPath)
+	}
+	if len(o.valuesFiles) == 0 && len(o.baseDirs) > 0 {
+		roots = append(roots, o.baseDirs...)
+	} else if len(o.valuesFiles) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, cwd)
+	}
+	for _, file := range o.valuesFiles {
+		if file == stdinPath {
+			return nil, fmt.Errorf("values from stdin cannot be watched")
+		}
+		if o.isLocalFile(file) {
+			roots = append(roots, filepath.Dir(file))
+		}
+	}
+
+	seen := map[string]bool{}
+	var dirs []string
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && (d.Name() == ".git" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if abs, err := filepath.Abs(path); err == nil && !seen[abs] {
+				seen[abs] = true
+				dirs = append(dirs, abs)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// validateOnce runs a full validation with fresh caches and returns its findings.
+func (o *watchOptions) validateOnce(ctx context.Context, chartPath string, opts kc.Options) ([]kc.Finding, error) {
+	// Files are re-read on every run, since they are what changed.
+	o.begin(nil)
+	collector := &kc.Collector{}
+	if _, err := o.validate(ctx, io.Discard, chartPath, opts, collector, false); err != nil {
+		return nil, err
+	}
+	return collector.Findings, nil
+}
+
+func (o *watchOptions) run(ctx context.Context, out io.Writer, chartPath string) error {
+	reporter, opts, err := o.prepare(out)
+	if err != nil {
+		return err
+	}
+	dirs, err := o.watchDirs(chartPath)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("determining current directory: %w", err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+
+	previous, err := o.validateOnce(ctx, chartPath, opts)
+	if err != nil {
+		fmt.Fprintf(out, "Validation failed: %v\n", err)
+	}
+	for _, f := range previous {
+		reporter.Report(f)
+	}
+	reporter.Summary()
+	fmt.Fprintf(out, "\nWatching %d directories for changes...\n", len(dirs))
+
+	var timer <-chan time.Time
+	changed := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watching files: %w", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// New directories are watched as well, e.g. a new environment.
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = watcher.Add(event.Name)
+				}
+			}
+			changed[relPath(cwd, event.Name)] = true
+			timer = time.After(watchDebounce)
+		case <-timer:
+			timer = nil
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+
+			fmt.Fprintf(out, "\n[%s] Changed: %s\n", time.Now().Format("15:04:05"), strings.Join(files, ", "))
+			current, err := o.validateOnce(ctx, chartPath, opts)
+			if err != nil {
+				fmt.Fprintf(out, "Validation failed: %v\n", err)
+				continue
+			}
+			printFindingChanges(out, previous, current)
+			previous = current
+		}
+	}
+}
+
+// printFindingChanges prints the findings that appeared or were resolved between two runs.
+func printFindingChanges(out io.Writer, previous, current []kc.Finding) {
+	before, after := kc.NewBaseline(previous), kc.NewBaseline(current)
+	added, resolved := 0, 0
+	for _, f := range current {
+		if !before.Contains(f) {
+			fmt.Fprintf(out, "  new:      %s\n", f.Message)
+			added++
+		}
+	}
+	for _, f := range previous {
+		if !after.Contains(f) {
+			fmt.Fprintf(out, "  resolved: %s\n", f.Message)
+			resolved++
+		}
+	}
+	fmt.Fprintf(out, "%d findings (%d new, %d resolved)\n", len(current), added, resolved)
+}
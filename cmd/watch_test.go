@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that can be written and read from different goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestWatchRun verifies that a change to a values file triggers a new validation that prints
+// the new and resolved findings.
+func TestWatchRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web_service/Chart.yaml":  "apiVersion: v2\nname: web_service\nversion: 1.0.0\n",
+		"web_service/values.yaml": "replicaCount: 1\nimage:\n  tag: latest\n",
+		"values/values.yaml":      "replicaCount: 1\n",
+	})
+
+	o := &watchOptions{}
+	o.valuesFiles = []string{filepath.Join(dir, "values", "values.yaml")}
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- o.run(ctx, out, filepath.Join(dir, "web_service")) }()
+
+	waitFor := func(text string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(out.String(), text) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q in output:\n%s", text, out.String())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitFor("Watching")
+	if !strings.Contains(out.String(), "Redundant value: 'replicaCount'") {
+		t.Errorf("expected the first run to report every finding:\n%s", out.String())
+	}
+
+	writeFiles(t, dir, map[string]string{"values/values.yaml": "image:\n  tag: latest\n"})
+	waitFor("findings (")
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"Changed: ",
+		"  new:      Redundant value: 'image.tag' matches default value: latest",
+		"  resolved: Redundant value: 'replicaCount' matches default value: 1",
+		"1 findings (1 new, 1 resolved)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
@@ -6,8 +6,11 @@ set -euo pipefail
 # for quick development & testing.
 #
 
+HELM_BIN="${HELM_BIN:-helm}"
+
 # 1) Remove any old version of the plugin
-helm plugin remove kc 2>/dev/null || true
+"$HELM_BIN" plugin remove kc 2>/dev/null || true
 
-# 2) Install the plugin from the current directory
-helm plugin install .
\ No newline at end of file
+# 2) Install the plugin from the current checkout, building the binary instead of
+#    downloading a release
+SKIP_BIN_DOWNLOAD=1 "$HELM_BIN" plugin install "$(cd "$(dirname "$0")" && pwd)"
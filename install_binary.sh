@@ -1,14 +1,17 @@
 #!/usr/bin/env sh
 
+# Installs the kc binary of the current platform into the plugin directory. Helm runs it as the
+# install and update hook of the plugin.
 # borrowed from https://github.com/technosophos/helm-template
 
 PROJECT_NAME="kaartcontrole"
 PROJECT_GH="tiulpin/$PROJECT_NAME"
-PROJECT_CHECKSUM_FILE="$PROJECT_NAME-checksum.txt"
+BINARY_NAME="kc"
+HELM_BIN="${HELM_BIN:-helm}"
 HELM_PLUGIN_PATH="$HELM_PLUGIN_DIR"
 
-if [ -z "$HELM_PLUGIN_DIR" ]; then
-   HELM_PLUGIN_PATH=$(helm env | grep HELM_PLUGINS | cut -d'=' -f2 | tr -d '"')
+if [ -z "$HELM_PLUGIN_PATH" ]; then
+  HELM_PLUGIN_PATH="$("$HELM_BIN" env HELM_PLUGINS)/$PROJECT_NAME"
 fi
 
 # Convert the HELM_PLUGIN_PATH to unix if cygpath is
@@ -16,7 +19,7 @@ fi
 # on Windows where helm returns a Windows path but we
 # need a Unix path
 if type cygpath >/dev/null 2>&1; then
-  echo Use Sygpath
+  echo "Using cygpath"
   HELM_PLUGIN_PATH=$(cygpath -u "$HELM_PLUGIN_PATH")
 fi
 
@@ -25,50 +28,44 @@ if [ "$SKIP_BIN_INSTALL" = "1" ]; then
   exit
 fi
 
-if [ "$SKIP_BIN_DOWNLOAD" = "1" ]; then
-  echo "Preparing to install into ${HELM_PLUGIN_PATH}"
-  cp -f plugin.yaml $HELM_PLUGIN_PATH/plugin.yaml
-  cp -f untt $HELM_PLUGIN_PATH/untt
-  chmod +x $HELM_PLUGIN_PATH/untt
-  echo "$PROJECT_NAME installed into $HELM_PLUGIN_PATH"
-  exit
-fi
-
 # initArch discovers the architecture for this system.
 initArch() {
   ARCH=$(uname -m)
   case "$ARCH" in
-    armv5*) ARCH="armv5";;
-    armv6*) ARCH="armv6";;
-    armv7*) ARCH="armv7";;
-    aarch64) ARCH="arm64";;
-    x86) ARCH="386";;
-    x86_64) ARCH="x86_64";;
-    i686) ARCH="386";;
-    i386) ARCH="386";;
+    aarch64|arm64) ARCH="arm64";;
+    x86_64|amd64) ARCH="x86_64";;
   esac
 }
 
-# initOS discovers the operating system for this system.
+# initOS discovers the operating system for this system, and the extension of its binaries.
 initOS() {
   OS=$(uname)
-
+  EXT=""
   case "$OS" in
-    # Msys support
-    msys*) OS='windows';;
-    # Minimalist GNU for Windows
-    mingw*) OS='windows';;
+    # Msys, Minimalist GNU for Windows, and Cygwin
+    MSYS*|MINGW*|CYGWIN*|msys*|mingw*|cygwin*) OS="Windows"; EXT=".exe";;
   esac
 }
 
+# buildBinary builds the binary from the plugin sources instead of downloading a release, for
+# plugins installed from a local checkout.
+buildBinary() {
+  echo "Building $BINARY_NAME from source into $HELM_PLUGIN_PATH/bin"
+  mkdir -p "$HELM_PLUGIN_PATH/bin"
+  go build -ldflags "-X main.version=v$(sed -n -e 's/^version:[ "]*\([^"]*\).*/\1/p' plugin.yaml)" \
+    -o "$HELM_PLUGIN_PATH/bin/$BINARY_NAME$EXT" ./cmd
+}
+
 # verifySupported checks that the os/arch combination is supported for
 # binary builds.
 verifySupported() {
-  local supported="Linux-x86_64\nLinux-arm64\nDarwin-x86_64\nDarwin-arm64"
-  if ! echo "$supported" | grep -q "$OS-$ARCH"; then
-    echo "No prebuild binary for $OS-$ARCH."
-    exit 1
-  fi
+  case "$OS-$ARCH" in
+    Linux-x86_64|Linux-arm64|Darwin-x86_64|Darwin-arm64|Windows-x86_64) ;;
+    *)
+      echo "No prebuilt binary for $OS-$ARCH; install Go and set SKIP_BIN_DOWNLOAD=1 to build it."
+      exit 1
+      ;;
+  esac
 
   if ! type "curl" >/dev/null 2>&1 && ! type "wget" >/dev/null 2>&1; then
     echo "Either curl or wget is required"
@@ -77,79 +74,69 @@ verifySupported() {
   echo "Support $OS-$ARCH"
 }
 
-# getDownloadURL checks the latest available version.
+# getDownloadURL determines the release matching the plugin version.
 getDownloadURL() {
-  # Determine last tag based on VCS download
-  local version=$(git describe --tags --abbrev=0 >/dev/null 2>&1)
-  # If no version found (because of no git), try fetch from plugin
+  # Plugins installed from git are checked out at a tag; otherwise the version comes from
+  # plugin.yaml.
+  version=$(git describe --tags --exact-match 2>/dev/null)
   if [ -z "$version" ]; then
-    echo "No version found"
-    version=v$(sed -n -e 's/version:[ "]*\([^"]*\).*/\1/p' plugin.yaml)
+    version=v$(sed -n -e 's/^version:[ "]*\([^"]*\).*/\1/p' plugin.yaml)
   fi
 
-  # Setup Download Url
-  DOWNLOAD_URL="https://github.com/$PROJECT_GH/releases/download/${version}/${PROJECT_NAME}_${OS}_$ARCH.tar.gz"
-  # Setup Checksum Url
-  # PROJECT_CHECKSUM="https://github.com/$PROJECT_GH/releases/download/${version}/$PROJECT_NAME_${version#v}_checksums.txt"
+  ARCHIVE="${PROJECT_NAME}_${OS}_${ARCH}.tar.gz"
+  if [ "$OS" = "Windows" ]; then
+    ARCHIVE="${PROJECT_NAME}_${OS}_${ARCH}.zip"
+  fi
+  DOWNLOAD_URL="https://github.com/$PROJECT_GH/releases/download/${version}/$ARCHIVE"
+  PROJECT_CHECKSUM="https://github.com/$PROJECT_GH/releases/download/${version}/${PROJECT_NAME}_${version#v}_checksums.txt"
 }
 
-# downloadFile downloads the latest binary package and also the checksum
-# for that binary.
-downloadFile() {
-  PLUGIN_TMP_FOLDER="$HOME/.tmp/_dist/"
-  [ -d "$PLUGIN_TMP_FOLDER" ] && rm -r "$PLUGIN_TMP_FOLDER" >/dev/null
-  mkdir -p "$PLUGIN_TMP_FOLDER"
-  echo "Downloading "$DOWNLOAD_URL" to location $PLUGIN_TMP_FOLDER"
+# download writes the contents of the URL $1 to the file $2.
+download() {
   if type "curl" >/dev/null 2>&1; then
-      (cd "$PLUGIN_TMP_FOLDER" && curl -LO "$DOWNLOAD_URL")
-  elif type "wget" >/dev/null 2>&1; then
-      wget -P "$PLUGIN_TMP_FOLDER" "$DOWNLOAD_URL"
+    curl -fsSL -o "$2" "$1"
+  else
+    wget -q -O "$2" "$1"
   fi
 }
 
-# installFile verifies the SHA256 for the file, then unpacks and
-# installs it.
+# downloadFile downloads the release archive and its checksums.
+downloadFile() {
+  PLUGIN_TMP_FOLDER=$(mktemp -d)
+  echo "Downloading $DOWNLOAD_URL"
+  download "$DOWNLOAD_URL" "$PLUGIN_TMP_FOLDER/$ARCHIVE"
+  if ! download "$PROJECT_CHECKSUM" "$PLUGIN_TMP_FOLDER/checksums.txt"; then
+    rm -f "$PLUGIN_TMP_FOLDER/checksums.txt"
+  fi
+}
+
+# installFile verifies the SHA256 of the archive, then unpacks and installs the binary.
 installFile() {
-  mkdir -p $HOME/.tmp
-  cd $HOME/.tmp
-  DOWNLOAD_FILE=$(find ./_dist -name "*.tar.gz")
-  echo "found: $DOWNLOAD_FILE"
-  if [ -n "$PROJECT_CHECKSUM" ]; then
-    echo Validating Checksum.
-    if type "curl" >/dev/null 2>&1; then
-      if type "shasum" >/dev/null 2>&1; then
-        curl -s -L "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | shasum -a 256 -c -s
-      elif type "sha256sum" >/dev/null 2>&1; then
-        if grep -q "ID=alpine" /etc/os-release; then
-          curl -s -L "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | (sha256sum -c -s || sha256sum -c --status)
-        else
-          curl -s -L "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | sha256sum -c --status
-        fi
-      else
-        echo No Checksum as there is no shasum or sha256sum found.
-      fi
-    elif type "wget" >/dev/null 2>&1; then
-      if type "shasum" >/dev/null 2>&1; then
-        wget -q -O - "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | shasum -a 256 -c -s
-      elif type "sha256sum" >/dev/null 2>&1; then
-        if grep -q "ID=alpine" /etc/os-release; then
-          wget -q -O - "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | (sha256sum -c -s || sha256sum -c --status)
-        else
-          wget -q -O - "$PROJECT_CHECKSUM" | grep "$DOWNLOAD_FILE" | sha256sum -c --status
-        fi
-      else
-        echo No Checksum as there is no shasum or sha256sum found.
-      fi
+  cd "$PLUGIN_TMP_FOLDER"
+  if [ -f checksums.txt ]; then
+    echo "Validating checksum"
+    if type "sha256sum" >/dev/null 2>&1; then
+      grep " $ARCHIVE\$" checksums.txt | sha256sum -c -
+    elif type "shasum" >/dev/null 2>&1; then
+      grep " $ARCHIVE\$" checksums.txt | shasum -a 256 -c -
+    else
+      echo "No checksum validated, as there is no sha256sum or shasum."
     fi
   else
-    echo No Checksum validated.
+    echo "No checksum validated, as the release has no checksums."
   fi
-  echo "Preparing to install into ${HELM_PLUGIN_PATH}"
-  tar -xzf "$DOWNLOAD_FILE" -C "$HELM_PLUGIN_PATH"
+
+  echo "Preparing to install into $HELM_PLUGIN_PATH"
+  case "$ARCHIVE" in
+    *.zip) unzip -o -q "$ARCHIVE" "$BINARY_NAME$EXT";;
+    *) tar -xzf "$ARCHIVE" "$BINARY_NAME$EXT";;
+  esac
   mkdir -p "$HELM_PLUGIN_PATH/bin"
-  mv "$HELM_PLUGIN_PATH/$PROJECT_NAME" "$HELM_PLUGIN_PATH/bin/kc"
-  rm -rf "$HOME/.tmp/_dist"
-  echo "$PROJECT_NAME installed into $HELM_PLUGIN_PATH"
+  mv -f "$BINARY_NAME$EXT" "$HELM_PLUGIN_PATH/bin/$BINARY_NAME$EXT"
+  chmod +x "$HELM_PLUGIN_PATH/bin/$BINARY_NAME$EXT"
+  cd - >/dev/null
+  rm -rf "$PLUGIN_TMP_FOLDER"
+  echo "$PROJECT_NAME $version installed into $HELM_PLUGIN_PATH"
 }
 
 # fail_trap is executed if an error occurs.
@@ -157,17 +144,14 @@ fail_trap() {
   result=$?
   if [ "$result" != "0" ]; then
     echo "Failed to install $PROJECT_NAME"
-    echo "For support, go to https://github.com/kubernetes/helm"
+    echo "For support, go to https://github.com/$PROJECT_GH/issues"
   fi
   exit $result
 }
 
-# testVersion tests the installed client to make sure it is working.
+# testVersion tests the installed binary to make sure it is working.
 testVersion() {
-  # To avoid to keep track of the Windows suffix,
-  # call the plugin assuming it is in the PATH
-  PATH=$PATH:$HELM_PLUGIN_PATH
-  helm kc -h
+  "$HELM_PLUGIN_PATH/bin/$BINARY_NAME$EXT" --help >/dev/null
 }
 
 # Execution
@@ -177,8 +161,12 @@ trap "fail_trap" EXIT
 set -e
 initArch
 initOS
-verifySupported
-getDownloadURL
-downloadFile
-installFile
-testVersion
\ No newline at end of file
+if [ "$SKIP_BIN_DOWNLOAD" = "1" ]; then
+  buildBinary
+else
+  verifySupported
+  getDownloadURL
+  downloadFile
+  installFile
+fi
+testVersion
@@ -0,0 +1,159 @@
+package kc
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveAlias returns the node an alias node refers to, or node itself.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// isMergeKey reports whether node is the "<<" key of a merge, which copies the entries of
+// other mappings into its mapping.
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && node.ShortTag() == "!!merge"
+}
+
+// mergedMappings returns the mappings merged into node with "<<" keys, from the highest to
+// the lowest precedence.
+func mergedMappings(node *yaml.Node) []*yaml.Node {
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isMergeKey(node.Content[i]) {
+			continue
+		}
+		value := resolveAlias(node.Content[i+1])
+		if value.Kind == yaml.SequenceNode {
+			for _, item := range value.Content {
+				merged = append(merged, resolveAlias(item))
+			}
+			continue
+		}
+		merged = append(merged, value)
+	}
+	return merged
+}
+
+// lookupChild returns the key and value nodes of elem below node, following aliases and the
+// entries merged with "<<" keys, which entries of the mapping itself override. For list items
+// the item is returned as both.
+func lookupChild(node *yaml.Node, elem pathElem) (*yaml.Node, *yaml.Node, bool) {
+	node = resolveAlias(node)
+	if node == nil {
+		return nil, nil, false
+	}
+	if i := child(node, elem); i >= 0 {
+		if node.Kind == yaml.MappingNode {
+			return node.Content[i], node.Content[i+1], true
+		}
+		return node.Content[i], node.Content[i], true
+	}
+	if node.Kind != yaml.MappingNode || elem.index >= 0 {
+		return nil, nil, false
+	}
+	for _, merged := range mergedMappings(node) {
+		if key, value, ok := lookupChild(merged, elem); ok {
+			return key, value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// CheckAnchors reports the values that the values files copy verbatim from another place of
+// the same file through a YAML alias, or a mapping that only merges another one, such as
+// an environment section reusing the anchored section of another environment. docs holds the
+// contents of files. It returns true if any failing issues were found.
+func CheckAnchors(files []string, docs [][]byte, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RuleAnchoredValue) {
+		return false
+	}
+	v := &validator{opts: opts, reporter: r}
+	for i, data := range docs {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+			// Parse errors are reported when the values are loaded.
+			continue
+		}
+		a := &anchorWalker{validator: v, file: files[i], anchors: map[string]string{}}
+		a.walk(doc.Content[0], "")
+	}
+	return v.issuesFound
+}
+
+// anchorWalker visits the nodes of a values document in order, recording the key path of
+// every anchor before the aliases that refer to it.
+type anchorWalker struct {
+	*validator
+	file    string
+	anchors map[string]string
+}
+
+func (a *anchorWalker) walk(node *yaml.Node, path string) {
+	if node.Anchor != "" {
+		a.anchors[node.Anchor] = path
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		a.reportCopy(path, "repeats", node.Value)
+	case yaml.MappingNode:
+		onlyMerges := len(node.Content) > 0
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if isMergeKey(key) {
+				continue
+			}
+			onlyMerges = false
+			childPath := key.Value
+			if path != "" {
+				childPath = path + "." + key.Value
+			}
+			a.walk(value, childPath)
+		}
+		if onlyMerges {
+			for i := 1; i < len(node.Content); i += 2 {
+				for _, alias := range aliases(node.Content[i]) {
+					a.reportCopy(path, "merges", alias)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			a.walk(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// reportCopy reports that the value at path copies the value of an anchor.
+func (a *anchorWalker) reportCopy(path, verb, anchor string) {
+	source, ok := a.anchors[anchor]
+	if !ok || path == "" || source == "" {
+		return
+	}
+	a.report(Finding{
+		RuleID:  RuleAnchoredValue,
+		Path:    path,
+		Message: fmt.Sprintf("Anchored value: '%s' %s '%s' verbatim through the alias *%s in %s", path, verb, source, anchor, a.file),
+	})
+}
+
+// aliases returns the names of the anchors the value of a merge key refers to.
+func aliases(node *yaml.Node) []string {
+	if node.Kind == yaml.AliasNode {
+		return []string{node.Value}
+	}
+	var names []string
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if item.Kind == yaml.AliasNode {
+				names = append(names, item.Value)
+			}
+		}
+	}
+	return names
+}
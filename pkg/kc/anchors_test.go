@@ -0,0 +1,93 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+const anchoredValues = `prod: &prod
+  replicaCount: 3 # kc:ignore=KC001
+  image: &image
+    tag: "1.25"
+staging:
+  <<: *prod
+  replicaCount: 2
+dev:
+  <<: *prod
+qa:
+  image: *image
+`
+
+// TestFindKeyPositionAnchors verifies that keys copied through aliases and merge keys are
+// located where the anchored value defines them, unless the mapping overrides them.
+func TestFindKeyPositionAnchors(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"staging.replicaCount", 7},
+		{"staging.image.tag", 4},
+		{"dev.replicaCount", 2},
+		{"qa.image.tag", 4},
+	}
+	for _, tt := range tests {
+		if got, ok := FindKeyLine([]byte(anchoredValues), tt.path); !ok || got != tt.want {
+			t.Errorf("FindKeyLine(%s) = %d, %v, want %d", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+// TestExplainAnchors verifies that values merged from an anchor are attributed to the file
+// and line of the anchor.
+func TestExplainAnchors(t *testing.T) {
+	got, err := Explain([]ValuesLayer{{Source: "values.yaml", Data: []byte(anchoredValues)}}, "dev.image.tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Provenance{{Source: "values.yaml", Line: 4, Value: "1.25", Effective: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain(dev.image.tag) = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestSuppressionsAnchors verifies that inline suppressions apply wherever an anchored value
+// is merged.
+func TestSuppressionsAnchors(t *testing.T) {
+	s, err := ParseSuppressions([]byte(anchoredValues))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Suppressed(Finding{RuleID: RuleRedundant, Path: "dev.replicaCount"}) {
+		t.Error("expected the suppression of prod.replicaCount to apply to dev.replicaCount")
+	}
+	if s.Suppressed(Finding{RuleID: RuleRedundant, Path: "qa.image.tag"}) {
+		t.Error("expected qa.image.tag not to be suppressed")
+	}
+}
+
+// TestCheckAnchors verifies that aliases and mappings that only merge another one are
+// reported as verbatim copies, and mappings that override merged keys are not.
+func TestCheckAnchors(t *testing.T) {
+	collector := &Collector{}
+	opts := Options{Rules: RuleSet{RuleAnchoredValue: true}, FailOn: SeverityError}
+	if CheckAnchors([]string{"values.yaml"}, [][]byte{[]byte(anchoredValues)}, opts, collector) {
+		t.Error("expected no errors")
+	}
+	want := []Finding{
+		{RuleID: RuleAnchoredValue, Severity: SeverityInfo, Path: "dev", Message: "Anchored value: 'dev' merges 'prod' verbatim through the alias *prod in values.yaml"},
+		{RuleID: RuleAnchoredValue, Severity: SeverityInfo, Path: "qa.image", Message: "Anchored value: 'qa.image' repeats 'prod.image' verbatim through the alias *image in values.yaml"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+
+	CheckAnchors([]string{"values.yaml"}, [][]byte{[]byte(anchoredValues)}, Options{}, collector)
+	if len(collector.Findings) != len(want) {
+		t.Error("expected the rule to be disabled by default")
+	}
+}
@@ -0,0 +1,88 @@
+package kc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultBaselineFile is the baseline file looked up in the working directory.
+const DefaultBaselineFile = ".kaartcontrole-baseline.json"
+
+// BaselineEntry identifies a known finding. Messages and values are deliberately left out
+// so that rewording a message or changing a mismatched value does not invalidate the baseline.
+type BaselineEntry struct {
+	RuleID string   `json:"ruleId"`
+	Path   string   `json:"path"`
+	Files  []string `json:"files,omitempty"`
+}
+
+func (e BaselineEntry) key() string {
+	return e.RuleID + "|" + e.Path + "|" + strings.Join(e.Files, ",")
+}
+
+// Baseline is a snapshot of existing findings that should not fail subsequent runs.
+type Baseline struct {
+	Findings []BaselineEntry `json:"findings"`
+
+	// index is built on the first lookup; sets validated in parallel share the baseline.
+	once  sync.Once
+	index map[string]bool
+}
+
+// NewBaseline builds a baseline from the given findings.
+func NewBaseline(findings []Finding) *Baseline {
+	b := &Baseline{Findings: []BaselineEntry{}}
+	seen := map[string]bool{}
+	for _, f := range findings {
+		e := BaselineEntry{RuleID: f.RuleID, Path: f.Path, Files: f.Files}
+		if seen[e.key()] {
+			continue
+		}
+		seen[e.key()] = true
+		b.Findings = append(b.Findings, e)
+	}
+	return b
+}
+
+// LoadBaseline reads a baseline from path. A missing file yields a nil baseline
+// unless required is set.
+func LoadBaseline(path string, required bool) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Save writes the baseline to path.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Contains reports whether f was recorded in the baseline. A nil baseline contains nothing.
+func (b *Baseline) Contains(f Finding) bool {
+	if b == nil {
+		return false
+	}
+	b.once.Do(func() {
+		b.index = map[string]bool{}
+		for _, e := range b.Findings {
+			b.index[e.key()] = true
+		}
+	})
+	return b.index[BaselineEntry{RuleID: f.RuleID, Path: f.Path, Files: f.Files}.key()]
+}
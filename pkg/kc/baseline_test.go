@@ -0,0 +1,74 @@
+package kc
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBaselineSuppressesKnownFindings(t *testing.T) {
+	defaults := map[string]interface{}{"replicas": 1, "name": "web"}
+	provided := map[string]interface{}{"replicas": 1}
+	files := []string{"overrides.yaml", "web_service.yaml"}
+
+	c := &Collector{}
+	ValidateChartValues(defaults, provided, Options{Files: files}, c)
+	if len(c.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(c.Findings))
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultBaselineFile)
+	if err := NewBaseline(c.Findings).Save(path); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	baseline, err := LoadBaseline(path, true)
+	if err != nil {
+		t.Fatalf("LoadBaseline() returned error: %v", err)
+	}
+
+	r := &recordingReporter{}
+	if ValidateChartValues(defaults, provided, Options{Files: files, Baseline: baseline}, r) {
+		t.Errorf("expected baselined finding to not be counted as an issue")
+	}
+	if len(r.findings) != 0 {
+		t.Errorf("expected baselined finding to be suppressed, got %+v", r.findings)
+	}
+
+	// A new finding must still be reported.
+	provided["name"] = 42
+	if !ValidateChartValues(defaults, provided, Options{Files: files, Baseline: baseline}, &recordingReporter{}) {
+		t.Errorf("expected new finding to be reported despite the baseline")
+	}
+}
+
+// TestBaselineParallelSets verifies that sets validated in parallel can share a baseline; run
+// with -race.
+func TestBaselineParallelSets(t *testing.T) {
+	defaults := map[string]interface{}{"replicas": 1, "name": "web"}
+	files := []string{"overrides.yaml", "web_service.yaml"}
+	baseline := NewBaseline([]Finding{{RuleID: RuleRedundant, Path: "replicas", Files: files}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provided := map[string]interface{}{"replicas": 1}
+			r := &recordingReporter{}
+			if ValidateChartValues(defaults, provided, Options{Files: files, Baseline: baseline}, r) || len(r.findings) != 0 {
+				t.Errorf("expected the baselined finding to be suppressed, got %+v", r.findings)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoadBaselineMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if b, err := LoadBaseline(path, false); err != nil || b != nil {
+		t.Errorf("expected nil baseline without error, got %v, %v", b, err)
+	}
+	if _, err := LoadBaseline(path, true); err == nil {
+		t.Errorf("expected error for missing required baseline")
+	}
+}
@@ -0,0 +1,143 @@
+package kc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// CustomRule is a rule of the configuration: a CEL expression over a values set that must
+// hold, optionally only when a condition holds. Expressions see the variables values,
+// defaults, env, files, chart, and manifests, like the input of Rego policies.
+type CustomRule struct {
+	// Name identifies the rule in findings.
+	Name string `json:"name"`
+	// Expression must evaluate to true, e.g. "values.replicaCount >= 2".
+	Expression string `json:"expression"`
+	// When, if set, restricts the rule to the values sets it is true for, e.g. "env == 'prod'".
+	When string `json:"when,omitempty"`
+	// Message describes a violation (default the expression).
+	Message string `json:"message,omitempty"`
+	// Severity of violations (default error).
+	Severity Severity `json:"severity,omitempty"`
+	// Path is the key path violations are located on, if any.
+	Path string `json:"path,omitempty"`
+
+	program cel.Program
+	when    cel.Program
+}
+
+// celEnv returns the environment of custom rules.
+func celEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("values", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("defaults", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("env", cel.StringType),
+		cel.Variable("files", cel.ListType(cel.StringType)),
+		cel.Variable("chart", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("manifests", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		// Values parsed from YAML are doubles, so replicaCount >= 2 compares a double to an int.
+		cel.CrossTypeNumericComparisons(true),
+	)
+}
+
+// Compile checks the expressions of the rule, which must be boolean, and prepares them for
+// CheckCustomRules.
+func (r *CustomRule) Compile() error {
+	if r.Name == "" {
+		return errors.New("custom rule without a name")
+	}
+	if r.Severity != "" {
+		if _, err := ParseSeverity(string(r.Severity)); err != nil {
+			return fmt.Errorf("custom rule %s: %w", r.Name, err)
+		}
+	}
+	env, err := celEnv()
+	if err != nil {
+		return err
+	}
+	compile := func(expr string) (cel.Program, error) {
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, issues.Err()
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, fmt.Errorf("expression must be a bool, got %s", ast.OutputType())
+		}
+		return env.Program(ast)
+	}
+	if r.Expression == "" {
+		return fmt.Errorf("custom rule %s: missing expression", r.Name)
+	}
+	if r.program, err = compile(r.Expression); err != nil {
+		return fmt.Errorf("custom rule %s: %w", r.Name, err)
+	}
+	if r.When != "" {
+		if r.when, err = compile(r.When); err != nil {
+			return fmt.Errorf("custom rule %s: when: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckCustomRules evaluates the compiled custom rules against the input of a values set and
+// reports every rule whose expression is false, or fails to evaluate, e.g. on a missing key
+// not guarded by has(). It returns true if any failing issues were found.
+func CheckCustomRules(rules []*CustomRule, input PolicyInput, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RuleCustom) {
+		return false
+	}
+	v := &validator{opts: opts, reporter: r}
+	manifests := input.Manifests
+	if manifests == nil {
+		manifests = []map[string]interface{}{}
+	}
+	activation := map[string]interface{}{
+		"values":    input.Values,
+		"defaults":  input.Defaults,
+		"env":       input.Environment,
+		"files":     input.Files,
+		"chart":     map[string]string{"name": input.Chart.Name, "version": input.Chart.Version},
+		"manifests": manifests,
+	}
+	for _, rule := range rules {
+		if rule.when != nil {
+			applies, err := evalBool(rule.when, activation)
+			if err != nil || !applies {
+				// A condition that cannot be evaluated, e.g. on a missing key, does not apply.
+				continue
+			}
+		}
+		message := rule.Message
+		if message == "" {
+			message = rule.Expression
+		}
+		ok, err := evalBool(rule.program, activation)
+		if err != nil {
+			message = fmt.Sprintf("%s (%v)", message, err)
+		} else if ok {
+			continue
+		}
+		v.report(Finding{
+			RuleID:   RuleCustom,
+			Severity: rule.Severity,
+			Path:     rule.Path,
+			Message:  fmt.Sprintf("Custom rule %s: %s", rule.Name, message),
+		})
+	}
+	return v.issuesFound
+}
+
+// evalBool evaluates a boolean program.
+func evalBool(program cel.Program, activation map[string]interface{}) (bool, error) {
+	out, _, err := program.Eval(activation)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v, not a bool", out.Value())
+	}
+	return b, nil
+}
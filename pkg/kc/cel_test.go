@@ -0,0 +1,71 @@
+package kc
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckCustomRules verifies that custom rules are reported when their expression is false
+// or fails, and skipped when their condition is false.
+func TestCheckCustomRules(t *testing.T) {
+	rules := []*CustomRule{
+		{Name: "prod-replicas", Expression: "values.replicaCount >= 2", When: "env == 'prod'", Message: "prod runs at least 2 replicas", Path: "replicaCount"},
+		{Name: "pinned-tag", Expression: "values.image.tag != 'latest'", Severity: SeverityWarning},
+		{Name: "ingress-host", Expression: "values.ingress.host.endsWith('.example.com')"},
+		{Name: "staging-only", Expression: "false", When: "env == 'staging'"},
+	}
+	for _, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := PolicyInput{
+		Values:      map[string]interface{}{"replicaCount": float64(1), "image": map[string]interface{}{"tag": "latest"}},
+		Files:       []string{"envs/prod/web.yaml"},
+		Environment: "prod",
+	}
+	collector := &Collector{}
+	if !CheckCustomRules(rules, input, Options{FailOn: SeverityError}, collector) {
+		t.Error("expected the custom rules to fail the run")
+	}
+	want := []Finding{
+		{RuleID: RuleCustom, Severity: SeverityError, Path: "replicaCount", Message: "Custom rule prod-replicas: prod runs at least 2 replicas"},
+		{RuleID: RuleCustom, Severity: SeverityWarning, Message: "Custom rule pinned-tag: values.image.tag != 'latest'"},
+		{RuleID: RuleCustom, Severity: SeverityError, Message: "Custom rule ingress-host: values.ingress.host.endsWith('.example.com') (no such key: ingress)"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+func TestCustomRuleCompile(t *testing.T) {
+	tests := []struct {
+		rule CustomRule
+		err  string
+	}{
+		{CustomRule{Name: "ok", Expression: "has(values.image) && size(files) > 0"}, ""},
+		{CustomRule{Expression: "true"}, "without a name"},
+		{CustomRule{Name: "empty"}, "missing expression"},
+		{CustomRule{Name: "syntax", Expression: "values.replicaCount >="}, "Syntax error"},
+		{CustomRule{Name: "type", Expression: "env + 'x'"}, "must be a bool"},
+		{CustomRule{Name: "when", Expression: "true", When: "environment == 'prod'"}, "undeclared reference"},
+		{CustomRule{Name: "severity", Expression: "true", Severity: "fatal"}, "severity"},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Compile()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.rule.Name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.rule.Name, tt.err, err)
+		}
+	}
+}
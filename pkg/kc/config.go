@@ -0,0 +1,134 @@
+package kc
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultConfigFile is the configuration file looked up in the working directory.
+const DefaultConfigFile = ".kaartcontrole.yaml"
+
+// Config is the on-disk configuration of kaartcontrole.
+type Config struct {
+	// Ignore lists fields to skip during validation, like --ignore.
+	Ignore []string `json:"ignore,omitempty"`
+	// IgnoreRegex lists regular expressions of fields to skip, like --ignore-regex.
+	IgnoreRegex []string `json:"ignoreRegex,omitempty"`
+	// Sensitive lists fields whose values are masked in reports, like --sensitive.
+	Sensitive []string `json:"sensitive,omitempty"`
+	// Enable lists rule IDs to turn on in addition to the defaults.
+	Enable []string `json:"enable,omitempty"`
+	// Disable lists rule IDs to turn off.
+	Disable []string `json:"disable,omitempty"`
+	// Severity overrides the default severity per rule ID.
+	Severity map[string]Severity `json:"severity,omitempty"`
+	// FailOn is the minimum severity that makes a run fail, like --fail-on.
+	FailOn Severity `json:"failOn,omitempty"`
+	// NumericTolerant compares numbers by value regardless of their type, like
+	// --numeric-tolerant.
+	NumericTolerant bool `json:"numericTolerant,omitempty"`
+	// Renames lists renamed values keys, like a --renames mapping file.
+	Renames []Rename `json:"renames,omitempty"`
+	// Policies lists Rego files or directories evaluated against every values set, like
+	// --policy.
+	Policies []string `json:"policies,omitempty"`
+	// Rules lists custom rules, CEL expressions that must hold for every values set.
+	Rules []*CustomRule `json:"rules,omitempty"`
+	// Constraints restrict the values of key paths, for charts without a values schema.
+	Constraints []*Constraint `json:"constraints,omitempty"`
+	// Plugins lists external rule plugins run for every values set, like --plugin.
+	Plugins []Plugin `json:"plugins,omitempty"`
+	// ResourceProfiles holds the thresholds of the resource rules per environment, replacing
+	// the default profiles.
+	ResourceProfiles []ResourceProfile `json:"resourceProfiles,omitempty"`
+	// Discovery configures the file names of auto-detected values sets.
+	Discovery DiscoveryConfig `json:"discovery,omitempty"`
+}
+
+// DiscoveryConfig holds the naming convention of auto-detected values files.
+type DiscoveryConfig struct {
+	// OverrideName is the file name of the shared values file, like --override-name.
+	OverrideName string `json:"overrideName,omitempty"`
+	// Layers are the file names of stacked overrides files, from the most general to the
+	// most specific one, like --layer. They take precedence over OverrideName.
+	Layers []string `json:"layers,omitempty"`
+	// ServicePattern is the file name pattern of service values files, like --service-pattern.
+	ServicePattern string `json:"servicePattern,omitempty"`
+	// ServiceNames maps chart directory names to the names of their service files, like
+	// --service-name, for charts whose service files are not named after the chart directory.
+	ServiceNames map[string][]string `json:"serviceNames,omitempty"`
+	// FollowSymlinks follows symbolic links to directories, like --follow-symlinks.
+	FollowSymlinks bool `json:"followSymlinks,omitempty"`
+}
+
+// LoadConfig reads the configuration from path. A missing file yields an empty Config
+// unless required is set.
+func LoadConfig(path string, required bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// RuleSet returns the rules enabled by this configuration on top of the defaults.
+func (c *Config) RuleSet() (RuleSet, error) {
+	rs := DefaultRuleSet()
+	if err := rs.Enable(c.Enable...); err != nil {
+		return nil, err
+	}
+	if err := rs.Disable(c.Disable...); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// CustomRules returns the custom rules of the configuration, compiled.
+func (c *Config) CustomRules() ([]*CustomRule, error) {
+	seen := map[string]bool{}
+	for _, rule := range c.Rules {
+		if err := rule.Compile(); err != nil {
+			return nil, err
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate custom rule: %s", rule.Name)
+		}
+		seen[rule.Name] = true
+	}
+	return c.Rules, nil
+}
+
+// CompileConstraints returns the constraints of the configuration, compiled.
+func (c *Config) CompileConstraints() ([]*Constraint, error) {
+	for _, constraint := range c.Constraints {
+		if err := constraint.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	return c.Constraints, nil
+}
+
+// Severities returns the per-rule severity overrides keyed by canonical rule ID.
+func (c *Config) Severities() (map[string]Severity, error) {
+	severities := map[string]Severity{}
+	for id, sev := range c.Severity {
+		rule, ok := LookupRule(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown rule: %s", id)
+		}
+		if _, err := ParseSeverity(string(sev)); err != nil {
+			return nil, err
+		}
+		severities[rule.ID] = sev
+	}
+	return severities, nil
+}
@@ -0,0 +1,111 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// minConsistencySets is the number of values sets needed to tell an inconsistent set from the
+// others: with two, neither is the odd one out.
+const minConsistencySets = 3
+
+// ValuesSet holds the merged provided values of a values set, for checks across sets.
+type ValuesSet struct {
+	Files  []string
+	Values map[string]interface{}
+	// Suppressions holds the inline "# kc:ignore" comments of the files of the set.
+	Suppressions Suppressions
+}
+
+// CheckConsistency compares the values of every key across the values sets of a chart, and
+// reports the set that stands out, as left behind by copy and paste: the only set that does
+// not set a key every other set sets, as a warning, or the only set with a different value of
+// a key the other sets agree on, as info. It needs at least three sets, and returns true if any
+// failing issues were found.
+func CheckConsistency(sets []ValuesSet, opts Options, r Reporter) bool {
+	if len(sets) < minConsistencySets || !opts.Rules.Enabled(RuleInconsistentValue) {
+		return false
+	}
+	seen := map[string]bool{}
+	var paths []string
+	for _, set := range sets {
+		for _, path := range leafPaths(set.Values, "") {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	findings := make([][]Finding, len(sets))
+	for _, path := range paths {
+		if shouldIgnore(path, opts.Ignore) {
+			continue
+		}
+		values := make([]interface{}, len(sets))
+		missing := []int{}
+		for i, set := range sets {
+			value, ok := lookupPathOK(set.Values, path)
+			if !ok || value == nil {
+				missing = append(missing, i)
+				continue
+			}
+			values[i] = value
+		}
+		switch len(missing) {
+		case 1:
+			findings[missing[0]] = append(findings[missing[0]], Finding{
+				RuleID:  RuleInconsistentValue,
+				Path:    path,
+				Message: fmt.Sprintf("Inconsistent value for '%s': not set here, but set in the %d other values sets", path, len(sets)-1),
+			})
+		case 0:
+			if odd, ok := oddOneOut(values); ok {
+				other := values[(odd+1)%len(values)]
+				findings[odd] = append(findings[odd], Finding{
+					RuleID:   RuleInconsistentValue,
+					Severity: SeverityInfo,
+					Path:     path,
+					Message:  fmt.Sprintf("Inconsistent value for '%s': %v here, but %v in the %d other values sets", path, values[odd], other, len(sets)-1),
+					Value:    values[odd],
+					quoted:   []interface{}{other},
+				})
+			}
+		}
+	}
+
+	issuesFound := false
+	for i, set := range sets {
+		setOpts := opts
+		setOpts.Files = set.Files
+		setOpts.Suppressions = set.Suppressions
+		v := &validator{opts: setOpts, reporter: r}
+		for _, f := range findings[i] {
+			v.report(f)
+		}
+		issuesFound = issuesFound || v.issuesFound
+	}
+	return issuesFound
+}
+
+// oddOneOut returns the index of the only value that differs from all others, which are equal.
+func oddOneOut(values []interface{}) (int, bool) {
+	// With at least three values, two of the first three are equal to the majority.
+	majority := values[0]
+	if !reflect.DeepEqual(values[0], values[1]) && !reflect.DeepEqual(values[0], values[2]) {
+		majority = values[1]
+	}
+	odd := -1
+	for i, value := range values {
+		if reflect.DeepEqual(value, majority) {
+			continue
+		}
+		if odd >= 0 {
+			return 0, false
+		}
+		odd = i
+	}
+	return odd, odd >= 0
+}
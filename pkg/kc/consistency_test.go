@@ -0,0 +1,59 @@
+package kc
+
+import (
+	"testing"
+)
+
+// TestCheckConsistency verifies that the values set that leaves out a key, or sets a different
+// value than all other sets, is reported, and that keys the sets disagree on are not.
+func TestCheckConsistency(t *testing.T) {
+	sets := []ValuesSet{
+		{Files: []string{"envs/prod-eu/web.yaml"}, Values: map[string]interface{}{
+			"image":        map[string]interface{}{"tag": "1.2.3"},
+			"podLabels":    map[string]interface{}{"team": "web"},
+			"replicaCount": 3,
+			"region":       "eu",
+		}},
+		{Files: []string{"envs/prod-us/web.yaml"}, Values: map[string]interface{}{
+			"image":        map[string]interface{}{"tag": "1.2.3"},
+			"podLabels":    map[string]interface{}{"team": "web"},
+			"replicaCount": 3,
+			"region":       "us",
+		}},
+		{Files: []string{"envs/prod-ap/web.yaml"}, Values: map[string]interface{}{
+			"image":        map[string]interface{}{"tag": "1.2.2"},
+			"replicaCount": 3,
+			"region":       "ap",
+		}},
+		{Files: []string{"envs/prod-sa/web.yaml"}, Values: map[string]interface{}{
+			"image":        map[string]interface{}{"tag": "1.2.3"},
+			"podLabels":    map[string]interface{}{"team": "web"},
+			"replicaCount": 3,
+			"region":       "sa",
+		}},
+	}
+	collector := &Collector{}
+	if !CheckConsistency(sets, Options{FailOn: SeverityWarning}, collector) {
+		t.Error("expected the missing key to fail the run")
+	}
+	want := []Finding{
+		{RuleID: RuleInconsistentValue, Severity: SeverityInfo, Path: "image.tag", Message: "Inconsistent value for 'image.tag': 1.2.2 here, but 1.2.3 in the 3 other values sets"},
+		{RuleID: RuleInconsistentValue, Severity: SeverityWarning, Path: "podLabels.team", Message: "Inconsistent value for 'podLabels.team': not set here, but set in the 3 other values sets"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+		if len(f.Files) != 1 || f.Files[0] != "envs/prod-ap/web.yaml" {
+			t.Errorf("finding %d: expected the files of the inconsistent set, got %v", i, f.Files)
+		}
+	}
+
+	collector = &Collector{}
+	if CheckConsistency(sets[:2], Options{}, collector) || len(collector.Findings) > 0 {
+		t.Errorf("expected no findings for two values sets, got %+v", collector.Findings)
+	}
+}
@@ -0,0 +1,221 @@
+package kc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Constraint restricts the values of a key path, for charts that do not ship a
+// values.schema.json: to a list of allowed values, a range of numbers, or a pattern of strings,
+// or requires it to be set or unset. The elements of lists are checked one by one. With When,
+// the constraint only applies if other keys have the given values, such as a hostname that
+// must be set if ingress is enabled.
+type Constraint struct {
+	// Path is the key path constrained, or a glob like those of IgnoreList matched against
+	// every key path, e.g. "*.image.pullPolicy".
+	Path string `json:"path"`
+	// Enum lists the allowed values.
+	Enum []interface{} `json:"enum,omitempty"`
+	// Min and Max bound numbers, inclusively.
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Pattern is a regular expression strings must match, e.g. "^v[0-9]+\\.".
+	Pattern string `json:"pattern,omitempty"`
+	// Required reports the key if it has no value, and Unused if the values files set it,
+	// since it has no effect, e.g. persistence.size with persistence disabled.
+	Required bool `json:"required,omitempty"`
+	Unused   bool `json:"unused,omitempty"`
+	// When maps key paths to the values they must have for the constraint to apply, e.g.
+	// {"ingress.enabled": true}. Keys the values files do not set have their default value.
+	When map[string]interface{} `json:"when,omitempty"`
+
+	path    *regexp.Regexp
+	pattern *regexp.Regexp
+}
+
+// Compile checks the constraint and prepares it for CheckConstraints.
+func (c *Constraint) Compile() error {
+	if c.Path == "" {
+		return errors.New("constraint without a path")
+	}
+	if len(c.Enum) == 0 && c.Min == nil && c.Max == nil && c.Pattern == "" && !c.Required && !c.Unused {
+		return fmt.Errorf("constraint %s: missing enum, min, max, pattern, required, or unused", c.Path)
+	}
+	if c.Required && c.Unused {
+		return fmt.Errorf("constraint %s: required and unused exclude each other", c.Path)
+	}
+	if (c.Required || c.Unused) && isGlob(c.Path) {
+		return fmt.Errorf("constraint %s: required and unused need a key path without globs", c.Path)
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("constraint %s: min %v is greater than max %v", c.Path, *c.Min, *c.Max)
+	}
+	if c.Pattern != "" {
+		var err error
+		if c.pattern, err = regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("constraint %s: %w", c.Path, err)
+		}
+	}
+	if isGlob(c.Path) {
+		c.path = globToRegexp(c.Path)
+	}
+	return nil
+}
+
+// matches reports whether the constraint applies to the key path.
+func (c *Constraint) matches(path string) bool {
+	if c.path != nil {
+		return c.path.MatchString(path)
+	}
+	return path == c.Path
+}
+
+// applies reports whether the values of the keys of When are the given ones.
+func (c *Constraint) applies(defaultValues, providedValues map[string]interface{}) bool {
+	for path, want := range c.When {
+		if !sameValue(want, effectiveValue(defaultValues, providedValues, path)) {
+			return false
+		}
+	}
+	return true
+}
+
+// condition describes When for messages, e.g. " when ingress.enabled is true".
+func (c *Constraint) condition() string {
+	if len(c.When) == 0 {
+		return ""
+	}
+	conditions := make([]string, 0, len(c.When))
+	for _, path := range sortedKeys(c.When) {
+		conditions = append(conditions, fmt.Sprintf("%s is %v", path, c.When[path]))
+	}
+	return " when " + strings.Join(conditions, " and ")
+}
+
+// effectiveValue returns the value templates see at path: the provided value, or the default
+// value if the values files do not set it.
+func effectiveValue(defaultValues, providedValues map[string]interface{}, path string) interface{} {
+	if value, ok := lookupPathOK(providedValues, path); ok {
+		return value
+	}
+	return lookupPath(defaultValues, path)
+}
+
+// violation returns why value violates the constraint, or "".
+func (c *Constraint) violation(value interface{}) string {
+	if len(c.Enum) > 0 {
+		allowed := false
+		for _, e := range c.Enum {
+			if sameValue(e, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			options := make([]string, len(c.Enum))
+			for i, e := range c.Enum {
+				options[i] = fmt.Sprint(e)
+			}
+			return "expected one of " + strings.Join(options, ", ")
+		}
+	}
+	if c.Min != nil || c.Max != nil {
+		n, ok := toFloat(value)
+		switch {
+		case !ok:
+			return "expected a number"
+		case c.Min != nil && n < *c.Min:
+			return fmt.Sprintf("less than the minimum of %v", *c.Min)
+		case c.Max != nil && n > *c.Max:
+			return fmt.Sprintf("more than the maximum of %v", *c.Max)
+		}
+	}
+	if c.pattern != nil {
+		s, ok := value.(string)
+		switch {
+		case !ok:
+			return "expected a string"
+		case !c.pattern.MatchString(s):
+			return fmt.Sprintf("which does not match %s", c.Pattern)
+		}
+	}
+	return ""
+}
+
+// sameValue reports whether a and b are equal, comparing numbers by value, since YAML and
+// --set decode them as different types.
+func sameValue(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// CheckConstraints reports every provided value that violates a constraint of its key path,
+// and the required keys without a default or provided value. It returns true if any failing
+// issues were found.
+func CheckConstraints(constraints []*Constraint, defaultValues, providedValues map[string]interface{}, opts Options, r Reporter) bool {
+	if len(constraints) == 0 || !opts.Rules.Enabled(RuleConstraint) {
+		return false
+	}
+	v := &validator{opts: opts, reporter: r}
+	for _, c := range constraints {
+		if (!c.Required && !c.Unused) || shouldIgnore(c.Path, opts.Ignore) || !c.applies(defaultValues, providedValues) {
+			continue
+		}
+		value := effectiveValue(defaultValues, providedValues, c.Path)
+		if c.Required && (value == nil || isEmpty(nil, value)) {
+			v.report(Finding{
+				RuleID:  RuleConstraint,
+				Path:    c.Path,
+				Message: fmt.Sprintf("Constraint violation: '%s' must be set%s", c.Path, c.condition()),
+			})
+		}
+		if provided, ok := lookupPathOK(providedValues, c.Path); c.Unused && ok && provided != nil {
+			v.report(Finding{
+				RuleID:   RuleConstraint,
+				Severity: SeverityWarning,
+				Path:     c.Path,
+				Message:  fmt.Sprintf("Unused value: '%s' is set, but has no effect%s", c.Path, c.condition()),
+				Value:    provided,
+			})
+		}
+	}
+	for _, path := range leafPaths(providedValues, "") {
+		if shouldIgnore(path, opts.Ignore) {
+			continue
+		}
+		value := lookupPath(providedValues, path)
+		for _, c := range constraints {
+			if c.Unused || !c.matches(path) || !c.applies(defaultValues, providedValues) {
+				continue
+			}
+			if items, ok := value.([]interface{}); ok {
+				for i, item := range items {
+					v.checkConstraint(c, fmt.Sprintf("%s[%d]", path, i), item)
+				}
+				continue
+			}
+			v.checkConstraint(c, path, value)
+		}
+	}
+	return v.issuesFound
+}
+
+// checkConstraint reports the value at path if it violates c.
+func (v *validator) checkConstraint(c *Constraint, path string, value interface{}) {
+	reason := c.violation(value)
+	if reason == "" {
+		return
+	}
+	v.report(Finding{
+		RuleID:  RuleConstraint,
+		Path:    path,
+		Message: fmt.Sprintf("Constraint violation: '%s' is %v, %s", path, value, reason),
+		Value:   value,
+	})
+}
@@ -0,0 +1,141 @@
+package kc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckConstraints(t *testing.T) {
+	one, ten := 1.0, 10.0
+	constraints := []*Constraint{
+		{Path: "*.pullPolicy", Enum: []interface{}{"Always", "IfNotPresent", "Never"}},
+		{Path: "replicaCount", Min: &one, Max: &ten},
+		{Path: "image.tag", Pattern: `^v[0-9]+\.[0-9]+\.[0-9]+$`},
+		{Path: "ports", Enum: []interface{}{float64(80), float64(443)}},
+		{Path: "workers", Min: &one},
+	}
+	for _, c := range constraints {
+		if err := c.Compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provided := map[string]interface{}{
+		"image":        map[string]interface{}{"tag": "latest", "pullPolicy": "Sometimes"},
+		"sidecar":      map[string]interface{}{"pullPolicy": "Always"},
+		"replicaCount": int64(20),
+		"ports":        []interface{}{float64(80), float64(8080)},
+		"workers":      "2",
+	}
+	collector := &Collector{}
+	if !CheckConstraints(constraints, nil, provided, Options{}, collector) {
+		t.Error("expected errors")
+	}
+	want := []Finding{
+		{RuleID: RuleConstraint, Severity: SeverityError, Path: "image.pullPolicy", Message: "Constraint violation: 'image.pullPolicy' is Sometimes, expected one of Always, IfNotPresent, Never"},
+		{RuleID: RuleConstraint, Severity: SeverityError, Path: "image.tag", Message: `Constraint violation: 'image.tag' is latest, which does not match ^v[0-9]+\.[0-9]+\.[0-9]+$`},
+		{RuleID: RuleConstraint, Severity: SeverityError, Path: "ports[1]", Message: "Constraint violation: 'ports[1]' is 8080, expected one of 80, 443"},
+		{RuleID: RuleConstraint, Severity: SeverityError, Path: "replicaCount", Message: "Constraint violation: 'replicaCount' is 20, more than the maximum of 10"},
+		{RuleID: RuleConstraint, Severity: SeverityError, Path: "workers", Message: "Constraint violation: 'workers' is 2, expected a number"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestCheckConstraintsConditions verifies that constraints with When only apply if the keys
+// have the given values, provided or by default.
+func TestCheckConstraintsConditions(t *testing.T) {
+	constraints := []*Constraint{
+		{Path: "ingress.hostname", Required: true, When: map[string]interface{}{"ingress.enabled": true}},
+		{Path: "persistence.size", Unused: true, When: map[string]interface{}{"persistence.enabled": false}},
+		{Path: "persistence.storageClass", Enum: []interface{}{"standard"}, When: map[string]interface{}{"persistence.enabled": true}},
+	}
+	for _, c := range constraints {
+		if err := c.Compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaults := map[string]interface{}{
+		"ingress":     map[string]interface{}{"enabled": false, "hostname": ""},
+		"persistence": map[string]interface{}{"enabled": false, "size": "8Gi"},
+	}
+
+	tests := []struct {
+		name     string
+		provided map[string]interface{}
+		want     []string
+	}{
+		{
+			name: "defaults",
+			want: nil,
+		},
+		{
+			name: "enabled",
+			provided: map[string]interface{}{
+				"ingress":     map[string]interface{}{"enabled": true},
+				"persistence": map[string]interface{}{"size": "20Gi", "storageClass": "fast"},
+			},
+			want: []string{
+				"Constraint violation: 'ingress.hostname' must be set when ingress.enabled is true",
+				"Unused value: 'persistence.size' is set, but has no effect when persistence.enabled is false",
+			},
+		},
+		{
+			name: "configured",
+			provided: map[string]interface{}{
+				"ingress":     map[string]interface{}{"enabled": true, "hostname": "example.com"},
+				"persistence": map[string]interface{}{"enabled": true, "size": "20Gi", "storageClass": "fast"},
+			},
+			want: []string{
+				"Constraint violation: 'persistence.storageClass' is fast, expected one of standard",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &Collector{}
+			CheckConstraints(constraints, defaults, tt.provided, Options{}, collector)
+			var got []string
+			for _, f := range collector.Findings {
+				got = append(got, f.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConstraintCompile(t *testing.T) {
+	one, two := 1.0, 2.0
+	tests := []struct {
+		constraint Constraint
+		err        string
+	}{
+		{Constraint{Path: "image.tag", Pattern: "^v"}, ""},
+		{Constraint{Pattern: "^v"}, "without a path"},
+		{Constraint{Path: "image.tag"}, "missing enum"},
+		{Constraint{Path: "replicaCount", Min: &two, Max: &one}, "greater than max"},
+		{Constraint{Path: "image.tag", Pattern: "("}, "missing closing )"},
+		{Constraint{Path: "ingress.hostname", Required: true, Unused: true}, "exclude each other"},
+		{Constraint{Path: "*.hostname", Required: true}, "without globs"},
+	}
+	for _, tt := range tests {
+		err := tt.constraint.Compile()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.constraint.Path, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.constraint.Path, tt.err, err)
+		}
+	}
+}
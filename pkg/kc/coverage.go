@@ -0,0 +1,78 @@
+package kc
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// EnvironmentCoverage describes how much of the chart defaults a values set overrides.
+type EnvironmentCoverage struct {
+	// Environment is the directory of the service file of the set.
+	Environment string   `json:"environment"`
+	Files       []string `json:"files"`
+	// Defaults is the number of keys with a chart default, Overridden the number of them the
+	// set gives another value.
+	Defaults   int     `json:"defaults"`
+	Overridden int     `json:"overridden"`
+	Percent    float64 `json:"percent"`
+	// Customized lists the keys the set gives a value other than the default, including keys
+	// without a default.
+	Customized []string `json:"customized"`
+}
+
+// KeyCoverage lists the environments that customize a key.
+type KeyCoverage struct {
+	Path         string   `json:"path"`
+	Environments []string `json:"environments"`
+}
+
+// EnvironmentName returns the name of the environment of a values set: the directory of its
+// last file, the service file.
+func EnvironmentName(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	last := files[len(files)-1]
+	if dir := filepath.Dir(last); dir != "." {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(last)
+}
+
+// ComputeCoverage compares the merged provided values of a values set with the chart defaults.
+func ComputeCoverage(defaults, provided map[string]interface{}, files []string) EnvironmentCoverage {
+	coverage := EnvironmentCoverage{Environment: EnvironmentName(files), Files: files, Customized: []string{}}
+	for _, path := range leafPaths(defaults, "") {
+		coverage.Defaults++
+		if value, ok := lookupPathOK(provided, path); ok && !reflect.DeepEqual(value, lookupPath(defaults, path)) {
+			coverage.Overridden++
+		}
+	}
+	for _, path := range leafPaths(provided, "") {
+		if value, ok := lookupPathOK(defaults, path); !ok || !reflect.DeepEqual(value, lookupPath(provided, path)) {
+			coverage.Customized = append(coverage.Customized, path)
+		}
+	}
+	if coverage.Defaults > 0 {
+		coverage.Percent = float64(coverage.Overridden) * 100 / float64(coverage.Defaults)
+	}
+	return coverage
+}
+
+// CustomizedKeys returns every key customized by any of the environments, sorted, with the
+// environments that customize it.
+func CustomizedKeys(envs []EnvironmentCoverage) []KeyCoverage {
+	byPath := map[string][]string{}
+	for _, env := range envs {
+		for _, path := range env.Customized {
+			byPath[path] = append(byPath[path], env.Environment)
+		}
+	}
+	keys := make([]KeyCoverage, 0, len(byPath))
+	for path, envs := range byPath {
+		keys = append(keys, KeyCoverage{Path: path, Environments: envs})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Path < keys[j].Path })
+	return keys
+}
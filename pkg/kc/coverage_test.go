@@ -0,0 +1,43 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeCoverage(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"tag": "latest", "pullPolicy": "IfNotPresent"},
+		"args":         []interface{}{"--serve"},
+		"unset":        nil,
+	}
+	provided := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"tag": "1.2.3"},
+		"args":         []interface{}{"--serve", "--verbose"},
+		"podLabels":    map[string]interface{}{"team": "web"},
+	}
+	got := ComputeCoverage(defaults, provided, []string{"overrides.yaml", "prod/web.yaml"})
+	want := EnvironmentCoverage{
+		Environment: "prod",
+		Files:       []string{"overrides.yaml", "prod/web.yaml"},
+		Defaults:    4,
+		Overridden:  2,
+		Percent:     50,
+		Customized:  []string{"args", "image.tag", "podLabels.team"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	keys := CustomizedKeys([]EnvironmentCoverage{got, {Environment: "staging", Customized: []string{"image.tag"}}})
+	wantKeys := []KeyCoverage{
+		{Path: "args", Environments: []string{"prod"}},
+		{Path: "image.tag", Environments: []string{"prod", "staging"}},
+		{Path: "podLabels.team", Environments: []string{"prod"}},
+	}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("expected %+v, got %+v", wantKeys, keys)
+	}
+}
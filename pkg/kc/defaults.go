@@ -0,0 +1,213 @@
+package kc
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// globalKey is the values section Helm shares between a chart and all of its subcharts.
+const globalKey = "global"
+
+// ChartDefaults returns the default values of c as Helm computes them at install time for the
+// given provided values: dependencies are enabled or disabled by their conditions and tags,
+// aliased, and their defaults coalesced under the parent using chartutil.CoalesceValues.
+//
+// Globals defined by any enabled subchart are additionally merged into the top-level "global"
+// section, since provided globals are passed down to every chart in the tree.
+func ChartDefaults(c *chart.Chart, provided map[string]interface{}) (map[string]interface{}, error) {
+	// Dependency processing mutates the chart, so it works on a copy that can be discarded.
+	clone := cloneChart(c)
+	if err := chartutil.ProcessDependenciesWithMerge(clone, provided); err != nil {
+		return nil, err
+	}
+	defaults, err := chartutil.CoalesceValues(clone, nil)
+	if err != nil {
+		return nil, err
+	}
+	collectGlobals(clone, defaults, defaults)
+	return defaults, nil
+}
+
+// DefaultsCache memoizes ChartDefaults of a chart across values sets. The defaults depend on
+// the provided values only through the conditions and tags that enable dependencies, so
+// values sets that agree on those share a single computation. It is safe for concurrent use.
+type DefaultsCache struct {
+	chart      *chart.Chart
+	conditions []string
+
+	mu       sync.Mutex
+	defaults map[string]map[string]interface{}
+}
+
+// NewDefaultsCache returns an empty DefaultsCache for c.
+func NewDefaultsCache(c *chart.Chart) *DefaultsCache {
+	return &DefaultsCache{
+		chart:      c,
+		conditions: conditionPaths(c, ""),
+		defaults:   map[string]map[string]interface{}{},
+	}
+}
+
+// Get returns ChartDefaults of the chart for the provided values. Callers may modify the
+// returned maps.
+func (d *DefaultsCache) Get(provided map[string]interface{}) (map[string]interface{}, error) {
+	key, err := d.key(provided)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if defaults, ok := d.defaults[key]; ok {
+		return copyValues(defaults), nil
+	}
+	defaults, err := ChartDefaults(d.chart, provided)
+	if err != nil {
+		return nil, err
+	}
+	d.defaults[key] = defaults
+	return copyValues(defaults), nil
+}
+
+// key identifies the provided values that enable or disable dependencies.
+func (d *DefaultsCache) key(provided map[string]interface{}) (string, error) {
+	selected := []interface{}{provided["tags"]}
+	for _, path := range d.conditions {
+		selected = append(selected, lookupPath(provided, path))
+	}
+	data, err := json.Marshal(selected)
+	return string(data), err
+}
+
+// conditionPaths returns the value paths of every dependency condition in the tree of c,
+// relative to the values of the top-level chart.
+func conditionPaths(c *chart.Chart, prefix string) []string {
+	if c.Metadata == nil {
+		return nil
+	}
+	var paths []string
+	for _, dep := range c.Metadata.Dependencies {
+		if dep == nil {
+			continue
+		}
+		for _, condition := range strings.Split(dep.Condition, ",") {
+			if condition = strings.TrimSpace(condition); condition != "" {
+				paths = append(paths, prefix+condition)
+			}
+		}
+		name := dep.Name
+		if dep.Alias != "" {
+			name = dep.Alias
+		}
+		for _, sub := range c.Dependencies() {
+			if sub.Name() == dep.Name {
+				paths = append(paths, conditionPaths(sub, prefix+name+".")...)
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// EffectiveValues returns the values the templates of c see at install time for the given
+// provided values: the provided values coalesced with the defaults of c and its enabled
+// subcharts.
+func EffectiveValues(c *chart.Chart, provided map[string]interface{}) (map[string]interface{}, error) {
+	clone := cloneChart(c)
+	if err := chartutil.ProcessDependenciesWithMerge(clone, provided); err != nil {
+		return nil, err
+	}
+	return chartutil.CoalesceValues(clone, provided)
+}
+
+// collectGlobals merges the globals of every subchart of c into root's global section.
+// Globals already set by a parent chart take precedence.
+func collectGlobals(c *chart.Chart, values, root map[string]interface{}) {
+	for _, sub := range c.Dependencies() {
+		subValues, ok := values[sub.Name()].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if subGlobals, ok := subValues[globalKey].(map[string]interface{}); ok {
+			globals, _ := root[globalKey].(map[string]interface{})
+			root[globalKey] = overlayValues(copyValues(subGlobals), globals)
+		}
+		collectGlobals(sub, subValues, root)
+	}
+}
+
+// cloneChart returns a copy of c and its dependencies that can be safely processed by
+// chartutil.ProcessDependencies without affecting c.
+func cloneChart(c *chart.Chart) *chart.Chart {
+	clone := &chart.Chart{
+		Raw:       c.Raw,
+		Lock:      c.Lock,
+		Templates: c.Templates,
+		Values:    copyValues(c.Values),
+		Schema:    c.Schema,
+		Files:     c.Files,
+	}
+	if c.Metadata != nil {
+		metadata := *c.Metadata
+		metadata.Dependencies = make([]*chart.Dependency, 0, len(c.Metadata.Dependencies))
+		for _, dep := range c.Metadata.Dependencies {
+			if dep == nil {
+				continue
+			}
+			d := *dep
+			metadata.Dependencies = append(metadata.Dependencies, &d)
+		}
+		clone.Metadata = &metadata
+	}
+	deps := make([]*chart.Chart, 0, len(c.Dependencies()))
+	for _, sub := range c.Dependencies() {
+		deps = append(deps, cloneChart(sub))
+	}
+	clone.SetDependencies(deps...)
+	return clone
+}
+
+// lookupPath returns the value at a dotted key path, or nil if it does not exist.
+func lookupPath(values map[string]interface{}, path string) interface{} {
+	var current interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+// copyValues returns a deep copy of the nested maps in values.
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if m, ok := v.(map[string]interface{}); ok {
+			out[k] = copyValues(m)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// overlayValues recursively sets every key of src onto dst and returns dst.
+func overlayValues(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[k] = overlayValues(dstMap, srcMap)
+		} else if srcIsMap {
+			dst[k] = copyValues(srcMap)
+		} else {
+			dst[k] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,148 @@
+package kc
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func chartDefaults(t *testing.T, c *chart.Chart, provided map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	defaults, err := ChartDefaults(c, provided)
+	if err != nil {
+		t.Fatalf("ChartDefaults() returned error: %v", err)
+	}
+	return defaults
+}
+
+func TestChartDefaults(t *testing.T) {
+	postgresql := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "postgresql", Version: "12.0.0"},
+		Values: map[string]interface{}{
+			"auth": map[string]interface{}{"username": "postgres", "database": "app"},
+		},
+	}
+	redis := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "redis", Version: "17.0.0"},
+		Values:   map[string]interface{}{"replicas": 3},
+	}
+	parent := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name: "web_service",
+			Dependencies: []*chart.Dependency{
+				{Name: "postgresql", Version: "12.x", Alias: "db", Condition: "db.enabled"},
+				{Name: "redis", Version: "17.x", Tags: []string{"cache"}},
+			},
+		},
+		Values: map[string]interface{}{
+			"db":   map[string]interface{}{"enabled": true, "auth": map[string]interface{}{"database": "web"}},
+			"tags": map[string]interface{}{"cache": false},
+		},
+	}
+	parent.SetDependencies(postgresql, redis)
+
+	defaults := chartDefaults(t, parent, nil)
+	if got := lookupPath(defaults, "db.auth.username"); got != "postgres" {
+		t.Errorf("expected subchart default db.auth.username, got %v", got)
+	}
+	if got := lookupPath(defaults, "db.auth.database"); got != "web" {
+		t.Errorf("expected parent value to win for db.auth.database, got %v", got)
+	}
+	if _, ok := defaults["redis"]; ok {
+		t.Errorf("expected redis defaults to be skipped while its tag is disabled")
+	}
+	if _, ok := postgresql.Values["enabled"]; ok || parent.Metadata.Dependencies[0].Name != "postgresql" {
+		t.Errorf("expected the chart to not be mutated")
+	}
+
+	defaults = chartDefaults(t, parent, map[string]interface{}{
+		"db":   map[string]interface{}{"enabled": false},
+		"tags": map[string]interface{}{"cache": true},
+	})
+	if got := lookupPath(defaults, "db.auth.username"); got != nil {
+		t.Errorf("expected disabled subchart to not contribute defaults, got %v", got)
+	}
+	if got := lookupPath(defaults, "redis.replicas"); got != 3 {
+		t.Errorf("expected redis defaults once its tag is enabled, got %v", got)
+	}
+}
+
+func TestDefaultsCache(t *testing.T) {
+	postgresql := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "postgresql", Version: "12.0.0"},
+		Values:   map[string]interface{}{"auth": map[string]interface{}{"username": "postgres"}},
+	}
+	parent := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:         "web_service",
+			Dependencies: []*chart.Dependency{{Name: "postgresql", Version: "12.x", Alias: "db", Condition: "db.enabled"}},
+		},
+		Values: map[string]interface{}{"db": map[string]interface{}{"enabled": true}},
+	}
+	parent.SetDependencies(postgresql)
+	cache := NewDefaultsCache(parent)
+
+	get := func(provided map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		defaults, err := cache.Get(provided)
+		if err != nil {
+			t.Fatalf("Get() returned error: %v", err)
+		}
+		return defaults
+	}
+	enabled := get(map[string]interface{}{"replicaCount": 3})
+	if got := lookupPath(enabled, "db.auth.username"); got != "postgres" {
+		t.Errorf("expected subchart defaults while enabled, got %v", got)
+	}
+	// Modifying returned defaults must not affect later lookups.
+	delete(enabled["db"].(map[string]interface{}), "auth")
+	if got := lookupPath(get(map[string]interface{}{"replicaCount": 5}), "db.auth.username"); got != "postgres" {
+		t.Errorf("expected cached defaults to be unaffected, got %v", got)
+	}
+	disabled := get(map[string]interface{}{"db": map[string]interface{}{"enabled": false}})
+	if got := lookupPath(disabled, "db.auth.username"); got != nil {
+		t.Errorf("expected no subchart defaults once disabled, got %v", got)
+	}
+	if len(cache.defaults) != 2 {
+		t.Errorf("expected 2 cached defaults, got %d", len(cache.defaults))
+	}
+}
+
+func TestChartDefaultsGlobals(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "postgresql", Version: "12.0.0"},
+		Values: map[string]interface{}{
+			"global": map[string]interface{}{"storageClass": "", "imageRegistry": "docker.io"},
+		},
+	}
+	parent := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:         "web_service",
+			Dependencies: []*chart.Dependency{{Name: "postgresql", Version: "12.x"}},
+		},
+		Values: map[string]interface{}{
+			"global": map[string]interface{}{"imageRegistry": "ghcr.io"},
+		},
+	}
+	parent.SetDependencies(sub)
+
+	defaults := chartDefaults(t, parent, nil)
+	provided := map[string]interface{}{
+		"global": map[string]interface{}{
+			"storageClass":  "fast",
+			"imageRegistry": "ghcr.io",
+			"unknown":       true,
+		},
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+
+	rules := map[string]string{}
+	for _, f := range r.findings {
+		rules[f.Path] = f.RuleID
+	}
+	if len(rules) != 2 || rules["global.imageRegistry"] != RuleRedundant || rules["global.unknown"] != RuleUnknownGlobal {
+		t.Errorf("unexpected findings for globals: %v", rules)
+	}
+}
@@ -0,0 +1,58 @@
+package kc
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ValuesDiff groups the differences between the chart defaults and the provided values.
+type ValuesDiff struct {
+	// Added holds provided values for keys the chart does not define.
+	Added []ValueChange `json:"added"`
+	// Changed holds provided values that differ from the chart default.
+	Changed []ValueChange `json:"changed"`
+	// Redundant holds provided values that match the chart default.
+	Redundant []ValueChange `json:"redundant"`
+	// Removed holds chart defaults deleted by setting them to null.
+	Removed []ValueChange `json:"removed"`
+}
+
+// DiffDefaults compares the provided values against the chart defaults, leaf by leaf.
+// In every change, Old is the chart default and New the provided value.
+func DiffDefaults(defaults, provided map[string]interface{}, opts Options) ValuesDiff {
+	var d ValuesDiff
+	d.walk(defaults, provided, "", opts)
+	for _, group := range []*[]ValueChange{&d.Added, &d.Changed, &d.Redundant, &d.Removed} {
+		sort.Slice(*group, func(i, j int) bool { return (*group)[i].Path < (*group)[j].Path })
+	}
+	return d
+}
+
+func (d *ValuesDiff) walk(defaults, provided map[string]interface{}, prefix string, opts Options) {
+	for key, providedValue := range provided {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		if shouldIgnore(fullKey, opts.Ignore) {
+			continue
+		}
+		defaultValue, exists := defaults[key]
+		defaultMap, defaultIsMap := defaultValue.(map[string]interface{})
+		providedMap, providedIsMap := providedValue.(map[string]interface{})
+
+		switch {
+		case providedValue == nil && defaultValue != nil:
+			d.Removed = append(d.Removed, ValueChange{Path: fullKey, Old: defaultValue, Removed: true})
+		case providedValue == nil:
+		case providedIsMap && (defaultIsMap || !exists):
+			d.walk(defaultMap, providedMap, fullKey, opts)
+		case !exists:
+			d.Added = append(d.Added, ValueChange{Path: fullKey, New: providedValue, Added: true})
+		case reflect.DeepEqual(defaultValue, providedValue):
+			d.Redundant = append(d.Redundant, ValueChange{Path: fullKey, Old: defaultValue, New: providedValue})
+		default:
+			d.Changed = append(d.Changed, ValueChange{Path: fullKey, Old: defaultValue, New: providedValue})
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffDefaults(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": 1,
+		"image":        map[string]interface{}{"tag": "latest", "pullPolicy": "IfNotPresent"},
+		"resources":    map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}},
+		"annotations":  map[string]interface{}{},
+	}
+	provided := map[string]interface{}{
+		"replicaCount": 1,
+		"image":        map[string]interface{}{"tag": "1.25"},
+		"resources":    nil,
+		"annotations":  map[string]interface{}{"team": "web"},
+		"ingress":      map[string]interface{}{"enabled": true},
+		"debug":        map[string]interface{}{"enabled": true},
+	}
+
+	got := DiffDefaults(defaults, provided, Options{Ignore: CompileIgnoreList(IgnoreList{"debug"})})
+	want := ValuesDiff{
+		Added: []ValueChange{
+			{Path: "annotations.team", New: "web", Added: true},
+			{Path: "ingress.enabled", New: true, Added: true},
+		},
+		Changed:   []ValueChange{{Path: "image.tag", Old: "latest", New: "1.25"}},
+		Redundant: []ValueChange{{Path: "replicaCount", Old: 1, New: 1}},
+		Removed:   []ValueChange{{Path: "resources", Old: defaults["resources"], Removed: true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffDefaults() = %+v\nwant %+v", got, want)
+	}
+}
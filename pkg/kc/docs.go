@@ -0,0 +1,39 @@
+package kc
+
+// ValueDoc documents the effective value of a single key.
+type ValueDoc struct {
+	Path string `json:"path"`
+	// Default is the chart default, if the chart defines the key.
+	Default interface{} `json:"default,omitempty"`
+	// Override is the provided value, if the values files or --set flags set the key.
+	Override   interface{} `json:"override,omitempty"`
+	Overridden bool        `json:"overridden"`
+	// Value is the effective value templates see.
+	Value interface{} `json:"value"`
+	// Source and Line locate the layer the effective value comes from.
+	Source string `json:"source,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// DocumentValues documents every leaf of the effective values, sorted by path: its chart
+// default, provided override, and final value, and which of the given layers it comes from.
+func DocumentValues(defaults, provided, effective map[string]interface{}, layers []ValuesLayer, opts Options) ([]ValueDoc, error) {
+	var docs []ValueDoc
+	for _, path := range leafPaths(effective, "") {
+		if shouldIgnore(path, opts.Ignore) {
+			continue
+		}
+		doc := ValueDoc{Path: path, Default: lookupPath(defaults, path), Value: lookupPath(effective, path)}
+		doc.Override, doc.Overridden = lookupPathOK(provided, path)
+		sources, err := Explain(layers, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) > 0 {
+			last := sources[len(sources)-1]
+			doc.Source, doc.Line = last.Source, last.Line
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,39 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocumentValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"repository": "nginx", "tag": "latest"},
+	}
+	provided := map[string]interface{}{
+		"image":   map[string]interface{}{"tag": "1.25"},
+		"ingress": map[string]interface{}{"enabled": true},
+	}
+	effective := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"ingress":      map[string]interface{}{"enabled": true},
+	}
+	layers := []ValuesLayer{
+		{Source: "mychart/values.yaml", Data: []byte("replicaCount: 1\nimage:\n  repository: nginx\n  tag: latest\n")},
+		{Source: "values.yaml", Data: []byte("image:\n  tag: \"1.25\"\ningress:\n  enabled: true\n")},
+	}
+
+	got, err := DocumentValues(defaults, provided, effective, layers, Options{Ignore: CompileIgnoreList(IgnoreList{"replicaCount"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ValueDoc{
+		{Path: "image.repository", Default: "nginx", Value: "nginx", Source: "mychart/values.yaml", Line: 3},
+		{Path: "image.tag", Default: "latest", Override: "1.25", Overridden: true, Value: "1.25", Source: "values.yaml", Line: 2},
+		{Path: "ingress.enabled", Override: true, Overridden: true, Value: true, Source: "values.yaml", Line: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DocumentValues() = %+v\nwant %+v", got, want)
+	}
+}
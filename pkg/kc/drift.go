@@ -0,0 +1,86 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// CheckDrift compares the values a release is expected to run with against the values it was
+// deployed with and reports every key whose effective value differs. Both sides are coalesced
+// with the defaults of c, so a value set to its default on only one side is not drift.
+// It returns true if any failing issues were found.
+func CheckDrift(c *chart.Chart, expected, deployed map[string]interface{}, opts Options, r Reporter) (bool, error) {
+	if !opts.Rules.Enabled(RuleDrift) {
+		return false, nil
+	}
+	expectedValues, err := chartutil.CoalesceValues(c, copyValues(expected))
+	if err != nil {
+		return false, fmt.Errorf("coalescing expected values: %w", err)
+	}
+	deployedValues, err := chartutil.CoalesceValues(c, copyValues(deployed))
+	if err != nil {
+		return false, fmt.Errorf("coalescing deployed values: %w", err)
+	}
+
+	v := &validator{opts: opts, reporter: r}
+	v.drift(expectedValues, deployedValues, "")
+	return v.issuesFound, nil
+}
+
+func (v *validator) drift(expected, deployed map[string]interface{}, prefix string) {
+	keys := make([]string, 0, len(expected)+len(deployed))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	for key := range deployed {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		if shouldIgnore(fullKey, v.opts.Ignore) {
+			continue
+		}
+
+		expectedValue, inExpected := expected[key]
+		deployedValue, inDeployed := deployed[key]
+		expectedMap, expectedIsMap := expectedValue.(map[string]interface{})
+		deployedMap, deployedIsMap := deployedValue.(map[string]interface{})
+		switch {
+		case expectedIsMap && deployedIsMap:
+			v.drift(expectedMap, deployedMap, fullKey)
+		case !inDeployed:
+			v.report(Finding{
+				RuleID:  RuleDrift,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Drift for '%s': set to %v in the values files but not deployed", fullKey, expectedValue),
+				Value:   expectedValue,
+			})
+		case !inExpected:
+			v.report(Finding{
+				RuleID:  RuleDrift,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Drift for '%s': deployed with %v but not set in the values files", fullKey, deployedValue),
+				quoted:  []interface{}{deployedValue},
+			})
+		case !reflect.DeepEqual(expectedValue, deployedValue):
+			v.report(Finding{
+				RuleID:  RuleDrift,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Drift for '%s': values files have %v, deployed has %v", fullKey, expectedValue, deployedValue),
+				Value:   expectedValue,
+				quoted:  []interface{}{deployedValue},
+			})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestCheckDrift(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values: map[string]interface{}{
+			"replicaCount": 1,
+			"image":        map[string]interface{}{"tag": "latest", "pullPolicy": "IfNotPresent"},
+		},
+	}
+	expected := map[string]interface{}{
+		"replicaCount": 1,
+		"image":        map[string]interface{}{"tag": "1.25"},
+		"ingress":      map[string]interface{}{"enabled": true},
+	}
+	deployed := map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "1.24", "pullPolicy": "IfNotPresent"},
+		"debug":     true,
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}},
+	}
+
+	r := &recordingReporter{}
+	issuesFound, err := CheckDrift(c, expected, deployed, Options{Ignore: CompileIgnoreList(IgnoreList{"resources"})}, r)
+	if err != nil {
+		t.Fatalf("CheckDrift() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Errorf("expected drift to be reported as issues")
+	}
+
+	var paths []string
+	for _, f := range r.findings {
+		paths = append(paths, f.Path)
+	}
+	// replicaCount and image.pullPolicy only differ from the defaults on one side.
+	want := []string{"debug", "image.tag", "ingress"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("drifted paths = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,115 @@
+package kc
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// ValuesLayer is a single source of values, such as the chart defaults, a values file, or a
+// --set flag. Layers with a YAML document in Data are searched in the document, so the line
+// of a key can be reported; otherwise Values is used. Prefix is the key path the layer is
+// nested under, e.g. the subchart name for subchart defaults.
+type ValuesLayer struct {
+	Source string
+	Prefix string
+	Data   []byte
+	Values map[string]interface{}
+}
+
+// Provenance records that a layer sets a key.
+type Provenance struct {
+	Source    string      `json:"source"`
+	Line      int         `json:"line,omitempty"`
+	Value     interface{} `json:"value"`
+	Effective bool        `json:"effective"`
+}
+
+// Explain returns every layer that sets the key at an indexed key path, in the given order of
+// increasing precedence. The last of them provides the effective value and is marked as such.
+func Explain(layers []ValuesLayer, path string) ([]Provenance, error) {
+	if _, err := parseIndexedPath(path); err != nil {
+		return nil, err
+	}
+	var sources []Provenance
+	for _, layer := range layers {
+		path := path
+		if layer.Prefix != "" {
+			rest, ok := strings.CutPrefix(path, layer.Prefix+".")
+			if !ok {
+				continue
+			}
+			path = rest
+		}
+		if layer.Data == nil {
+			if value, ok := lookupIndexedPath(layer.Values, path); ok {
+				sources = append(sources, Provenance{Source: layer.Source, Value: value})
+			}
+			continue
+		}
+		line, ok := FindKeyLine(layer.Data, path)
+		if !ok {
+			continue
+		}
+		values := map[string]interface{}{}
+		if err := sigsyaml.Unmarshal(layer.Data, &values); err != nil {
+			return nil, err
+		}
+		// A key set to null has no value but still removes the ones below it.
+		value, _ := lookupIndexedPath(values, path)
+		sources = append(sources, Provenance{Source: layer.Source, Line: line, Value: value})
+	}
+	if len(sources) > 0 {
+		sources[len(sources)-1].Effective = true
+	}
+	return sources, nil
+}
+
+// FindKeyLine returns the 1-based line of the key at an indexed key path in a values
+// document, or of the list item for paths that end in an index.
+func FindKeyLine(data []byte, path string) (int, bool) {
+	pos, ok := FindKeyPosition(data, path)
+	return pos.Line, ok
+}
+
+// KeyPosition is the place of a key in a values document.
+type KeyPosition struct {
+	// Line and Column are 1-based.
+	Line   int
+	Column int
+	// Length is the length of the key, or of the scalar list item for paths that end in an
+	// index, and 1 for other list items.
+	Length int
+}
+
+// FindKeyPosition returns the position of the key at an indexed key path in a values
+// document, or of the list item for paths that end in an index.
+func FindKeyPosition(data []byte, path string) (KeyPosition, bool) {
+	elems, err := parseIndexedPath(path)
+	if err != nil {
+		return KeyPosition{}, false
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return KeyPosition{}, false
+	}
+	node := doc.Content[0]
+	var key *yaml.Node
+	for _, elem := range elems {
+		// Keys merged from an anchored mapping are found where the anchor defines them.
+		k, value, ok := lookupChild(node, elem)
+		if !ok {
+			return KeyPosition{}, false
+		}
+		key, node = k, value
+	}
+	if key == nil {
+		return KeyPosition{}, true
+	}
+	length := 1
+	if key.Kind == yaml.ScalarNode && key.Value != "" {
+		length = len([]rune(key.Value))
+	}
+	return KeyPosition{Line: key.Line, Column: key.Column, Length: length}, true
+}
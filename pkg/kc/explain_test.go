@@ -0,0 +1,102 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	layers := []ValuesLayer{
+		{Source: "mychart/values.yaml", Data: []byte("replicaCount: 1\nimage:\n  repository: nginx\n  tag: latest\n")},
+		{Source: "overrides.yaml", Data: []byte("image:\n  # pinned for the release\n  tag: \"1.24\"\n")},
+		{Source: "production/web_service.yaml", Data: []byte("replicaCount: 3\n")},
+		{Source: "--set image.tag=1.25", Values: map[string]interface{}{"image": map[string]interface{}{"tag": "1.25"}}},
+	}
+
+	got, err := Explain(layers, "image.tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Provenance{
+		{Source: "mychart/values.yaml", Line: 4, Value: "latest"},
+		{Source: "overrides.yaml", Line: 3, Value: "1.24"},
+		{Source: "--set image.tag=1.25", Value: "1.25", Effective: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain(image.tag) = %+v\nwant %+v", got, want)
+	}
+
+	got, err = Explain(layers, "replicaCount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Provenance{
+		{Source: "mychart/values.yaml", Line: 1, Value: float64(1)},
+		{Source: "production/web_service.yaml", Line: 1, Value: float64(3), Effective: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain(replicaCount) = %+v\nwant %+v", got, want)
+	}
+
+	subchart := []ValuesLayer{
+		{Source: "mychart/charts/redis/values.yaml", Prefix: "redis", Data: []byte("auth:\n  enabled: true\n")},
+		{Source: "mychart/values.yaml", Data: []byte("redis:\n  auth:\n    enabled: false\n")},
+	}
+	got, err = Explain(subchart, "redis.auth.enabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Provenance{
+		{Source: "mychart/charts/redis/values.yaml", Line: 2, Value: true},
+		{Source: "mychart/values.yaml", Line: 3, Value: false, Effective: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain(redis.auth.enabled) = %+v\nwant %+v", got, want)
+	}
+
+	if got, _ := Explain(layers, "image.pullPolicy"); len(got) != 0 {
+		t.Errorf("Explain(image.pullPolicy) = %+v, want no sources", got)
+	}
+	if _, err := Explain(layers, "hosts[x]"); err == nil {
+		t.Error("Explain() with an invalid path succeeded")
+	}
+}
+
+func TestFindKeyLine(t *testing.T) {
+	data := []byte("replicaCount: 1\nimage:\n  # pinned\n  tag: latest\nhosts:\n  - a.example.com\n  - b.example.com\n")
+	tests := []struct {
+		path  string
+		line  int
+		found bool
+	}{
+		{"replicaCount", 1, true},
+		{"image.tag", 4, true},
+		{"hosts[1]", 7, true},
+		{"image.pullPolicy", 0, false},
+		{"hosts[2]", 0, false},
+	}
+	for _, tt := range tests {
+		line, found := FindKeyLine(data, tt.path)
+		if line != tt.line || found != tt.found {
+			t.Errorf("FindKeyLine(%q) = %d, %v; want %d, %v", tt.path, line, found, tt.line, tt.found)
+		}
+	}
+}
+
+func TestFindKeyPosition(t *testing.T) {
+	data := []byte("image:\n  tag: latest\nhosts:\n  - a.example.com\n  - name: b\n")
+	tests := []struct {
+		path string
+		want KeyPosition
+	}{
+		{"image.tag", KeyPosition{Line: 2, Column: 3, Length: 3}},
+		{"hosts[0]", KeyPosition{Line: 4, Column: 5, Length: 13}},
+		{"hosts[1]", KeyPosition{Line: 5, Column: 5, Length: 1}},
+		{"hosts[1].name", KeyPosition{Line: 5, Column: 5, Length: 4}},
+	}
+	for _, tt := range tests {
+		if pos, ok := FindKeyPosition(data, tt.path); !ok || pos != tt.want {
+			t.Errorf("FindKeyPosition(%q) = %v, %v; want %v", tt.path, pos, ok, tt.want)
+		}
+	}
+}
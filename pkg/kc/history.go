@@ -0,0 +1,87 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// Revision holds the chart and user-supplied values of a single release revision.
+type Revision struct {
+	Number int
+	Chart  *chart.Chart
+	Values map[string]interface{}
+}
+
+// ValueChange is a difference in a single value between two sets of values.
+type ValueChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+	// Added and Removed report whether the value is missing from the old or new values.
+	Added   bool `json:"added,omitempty"`
+	Removed bool `json:"removed,omitempty"`
+}
+
+// DiffValues returns the changed leaf values between old and new, sorted by path.
+func DiffValues(old, new map[string]interface{}) []ValueChange {
+	seen := map[string]bool{}
+	var paths []string
+	for _, path := range append(leafPaths(old, ""), leafPaths(new, "")...) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []ValueChange
+	for _, path := range paths {
+		oldValue, newValue := lookupPath(old, path), lookupPath(new, path)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, ValueChange{Path: path, Old: oldValue, New: newValue, Added: oldValue == nil, Removed: newValue == nil})
+	}
+	return changes
+}
+
+// CheckHistory compares the user-supplied values of consecutive release revisions, ordered
+// from oldest to newest, and reports values that went back to the chart default of the newer
+// revision or changed type. It returns true if any failing issues were found.
+func CheckHistory(revisions []Revision, opts Options, r Reporter) (bool, error) {
+	v := &validator{opts: opts, reporter: r}
+	for i := 1; i < len(revisions); i++ {
+		prev, cur := revisions[i-1], revisions[i]
+		defaults, err := ChartDefaults(cur.Chart, cur.Values)
+		if err != nil {
+			return false, fmt.Errorf("computing chart defaults of revision %d: %w", cur.Number, err)
+		}
+		for _, change := range DiffValues(prev.Values, cur.Values) {
+			if change.Added || shouldIgnore(change.Path, opts.Ignore) {
+				continue
+			}
+			defaultValue, hasDefault := lookupPathOK(defaults, change.Path)
+			switch {
+			case hasDefault && (change.Removed || reflect.DeepEqual(change.New, defaultValue)) && !reflect.DeepEqual(change.Old, defaultValue):
+				v.report(Finding{
+					RuleID:  RuleRevertedToDefault,
+					Path:    change.Path,
+					Message: fmt.Sprintf("Reverted to default: '%s' was %v in revision %d and is back to the chart default %v in revision %d", change.Path, change.Old, prev.Number, defaultValue, cur.Number),
+					Default: defaultValue,
+					Value:   change.Old,
+				})
+			case !change.Removed && reflect.TypeOf(change.Old) != reflect.TypeOf(change.New):
+				v.report(Finding{
+					RuleID:  RuleTypeChanged,
+					Path:    change.Path,
+					Message: fmt.Sprintf("Type changed for '%s': %T in revision %d, %T in revision %d", change.Path, change.Old, prev.Number, change.New, cur.Number),
+					Value:   change.New,
+				})
+			}
+		}
+	}
+	return v.issuesFound, nil
+}
@@ -0,0 +1,67 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestDiffValues(t *testing.T) {
+	old := map[string]interface{}{
+		"replicaCount": 2,
+		"image":        map[string]interface{}{"tag": "1.24"},
+		"debug":        true,
+	}
+	new := map[string]interface{}{
+		"replicaCount": 2,
+		"image":        map[string]interface{}{"tag": "1.25"},
+		"ingress":      map[string]interface{}{"enabled": true},
+	}
+	want := []ValueChange{
+		{Path: "debug", Old: true, Removed: true},
+		{Path: "image.tag", Old: "1.24", New: "1.25"},
+		{Path: "ingress.enabled", New: true, Added: true},
+	}
+	if got := DiffValues(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffValues() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values: map[string]interface{}{
+			"replicaCount": 1,
+			"image":        map[string]interface{}{"tag": "latest"},
+			"port":         80,
+		},
+	}
+	revisions := []Revision{
+		{Number: 1, Chart: c, Values: map[string]interface{}{"replicaCount": 3, "image": map[string]interface{}{"tag": "1.25"}, "port": 8080}},
+		{Number: 2, Chart: c, Values: map[string]interface{}{"replicaCount": 1, "image": map[string]interface{}{"tag": "1.25"}, "port": "8080"}},
+		{Number: 3, Chart: c, Values: map[string]interface{}{"port": "8080"}},
+	}
+
+	r := &recordingReporter{}
+	issuesFound, err := CheckHistory(revisions, Options{}, r)
+	if err != nil {
+		t.Fatalf("CheckHistory() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Errorf("expected history changes to be reported as issues")
+	}
+
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, f.RuleID+" "+f.Path)
+	}
+	want := []string{
+		RuleTypeChanged + " port",
+		RuleRevertedToDefault + " replicaCount",
+		RuleRevertedToDefault + " image.tag",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
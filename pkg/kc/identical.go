@@ -0,0 +1,52 @@
+package kc
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// ServiceFile is the service file of a values set, for checks across sets.
+type ServiceFile struct {
+	Path   string
+	Data   []byte
+	Values map[string]interface{}
+	// Files are the values files of the set, Suppressions their inline "# kc:ignore" comments.
+	Files        []string
+	Suppressions Suppressions
+}
+
+// CheckIdenticalServiceFiles reports the service files of a chart that are identical to an
+// earlier one, byte for byte or after parsing, which could share an overrides file instead.
+// Empty service files are not compared. It returns true if any failing issues were found.
+func CheckIdenticalServiceFiles(files []ServiceFile, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RuleIdenticalServices) {
+		return false
+	}
+	issuesFound := false
+	for i, file := range files {
+		if len(file.Values) == 0 {
+			continue
+		}
+		for _, earlier := range files[:i] {
+			same := "the same contents"
+			if !bytes.Equal(file.Data, earlier.Data) {
+				if !reflect.DeepEqual(file.Values, earlier.Values) {
+					continue
+				}
+				same = "the same values"
+			}
+			fileOpts := opts
+			fileOpts.Files = file.Files
+			fileOpts.Suppressions = file.Suppressions
+			v := &validator{opts: fileOpts, reporter: r}
+			v.report(Finding{
+				RuleID:  RuleIdenticalServices,
+				Message: fmt.Sprintf("Identical service files: %s has %s as %s; move them to a shared overrides file", file.Path, same, earlier.Path),
+			})
+			issuesFound = issuesFound || v.issuesFound
+			break
+		}
+	}
+	return issuesFound
+}
@@ -0,0 +1,34 @@
+package kc
+
+import (
+	"testing"
+)
+
+// TestCheckIdenticalServiceFiles verifies that service files with the same contents or values
+// as an earlier one are reported once, and that empty service files are not compared.
+func TestCheckIdenticalServiceFiles(t *testing.T) {
+	files := []ServiceFile{
+		{Path: "dev/web.yaml", Data: []byte("replicaCount: 2\n"), Values: map[string]interface{}{"replicaCount": float64(2)}},
+		{Path: "test/web.yaml", Data: []byte("replicaCount: 2\n"), Values: map[string]interface{}{"replicaCount": float64(2)}},
+		{Path: "stage/web.yaml", Data: []byte("# stage\nreplicaCount:   2\n"), Values: map[string]interface{}{"replicaCount": float64(2)}},
+		{Path: "prod/web.yaml", Data: []byte("replicaCount: 3\n"), Values: map[string]interface{}{"replicaCount": float64(3)}},
+		{Path: "a/web.yaml", Data: []byte("{}\n"), Values: map[string]interface{}{}},
+		{Path: "b/web.yaml", Data: []byte("{}\n"), Values: map[string]interface{}{}},
+	}
+	collector := &Collector{}
+	if CheckIdenticalServiceFiles(files, Options{FailOn: SeverityError}, collector) {
+		t.Error("expected no errors")
+	}
+	want := []string{
+		"Identical service files: test/web.yaml has the same contents as dev/web.yaml; move them to a shared overrides file",
+		"Identical service files: stage/web.yaml has the same values as dev/web.yaml; move them to a shared overrides file",
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != RuleIdenticalServices || f.Severity != SeverityWarning || f.Message != want[i] {
+			t.Errorf("finding %d: expected %q, got %+v", i, want[i], f)
+		}
+	}
+}
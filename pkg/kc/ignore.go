@@ -0,0 +1,93 @@
+package kc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// IgnoreList holds fields to be ignored during validation.
+//
+// Plain entries ignore every key path starting with them. Entries containing glob
+// characters are matched segment-wise against the key path and each of its parents:
+// "*" matches a single key, "**" any number of keys, and "?" a single character.
+type IgnoreList []string
+
+func (i *IgnoreList) String() string {
+	return strings.Join(*i, ",")
+}
+
+func (i *IgnoreList) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (i *IgnoreList) Type() string {
+	return "stringArray"
+}
+
+// isGlob reports whether pattern contains glob characters.
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
+
+// globToRegexp converts a dotted glob pattern into an anchored regular expression.
+func globToRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString(`[^.]*`)
+			}
+		case '?':
+			b.WriteString(`[^.]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func shouldIgnore(path string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileIgnoreList compiles the given --ignore entries into regular expressions matching
+// the key paths they ignore, so they are not parsed again for every key.
+func CompileIgnoreList(list IgnoreList) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(list))
+	for _, entry := range list {
+		if isGlob(entry) {
+			// A glob matches the key path or any of its parents.
+			re := globToRegexp(entry).String()
+			res = append(res, regexp.MustCompile(strings.TrimSuffix(re, "$")+`(\..*)?$`))
+		} else {
+			res = append(res, regexp.MustCompile("^"+regexp.QuoteMeta(entry)))
+		}
+	}
+	return res
+}
+
+// CompileIgnoreRegex compiles the given --ignore-regex patterns.
+func CompileIgnoreRegex(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
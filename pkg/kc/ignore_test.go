@@ -0,0 +1,40 @@
+package kc
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestShouldIgnore(t *testing.T) {
+	tests := []struct {
+		name   string
+		ignore IgnoreList
+		regex  string
+		path   string
+		want   bool
+	}{
+		{"prefix", IgnoreList{"resources"}, "", "resources.limits.cpu", true},
+		{"prefix miss", IgnoreList{"resources"}, "", "image.tag", false},
+		{"single segment glob", IgnoreList{"resources.*"}, "", "resources.limits.cpu", true},
+		{"single segment glob miss", IgnoreList{"resources.*"}, "", "resources", false},
+		{"leading glob", IgnoreList{"*.image.tag"}, "", "web.image.tag", true},
+		{"leading glob too deep", IgnoreList{"*.image.tag"}, "", "a.web.image.tag", false},
+		{"double star glob", IgnoreList{"**.tag"}, "", "a.web.image.tag", true},
+		{"question mark", IgnoreList{"replica?"}, "", "replicas", true},
+		{"prefix with dots", IgnoreList{"image.tag"}, "", "imageXtag", false},
+		{"regex", nil, `^.*\.annotations\.`, "ingress.annotations.foo", true},
+		{"regex miss", nil, `^.*\.annotations\.`, "annotations.foo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := CompileIgnoreList(tt.ignore)
+			if tt.regex != "" {
+				res = append(res, regexp.MustCompile(tt.regex))
+			}
+			if got := shouldIgnore(tt.path, res); got != tt.want {
+				t.Errorf("shouldIgnore(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
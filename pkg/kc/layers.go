@@ -0,0 +1,50 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckLayerValues compares the keys every layer of opts.Layers sets with the nearest lower
+// layer that sets them, and reports the values a layer repeats, such as a service file
+// repeating a value of the overrides file, and the values it overrides with another one.
+// sources names the layers. Repeated values that match the chart default are left to the
+// redundant-value rule. It returns true if any failing issues were found.
+func CheckLayerValues(defaultValues map[string]interface{}, sources []string, opts Options, r Reporter) bool {
+	if len(opts.Layers) < 2 {
+		return false
+	}
+	v := &validator{opts: opts, reporter: r}
+	// setBy holds the index of the highest layer seen so far that sets each key.
+	setBy := map[string]int{}
+	for i, layer := range opts.Layers {
+		for _, path := range leafPaths(layer, "") {
+			lower, ok := setBy[path]
+			setBy[path] = i
+			if !ok || shouldIgnore(path, opts.Ignore) {
+				continue
+			}
+			value, lowerValue := lookupPath(layer, path), lookupPath(opts.Layers[lower], path)
+			if !reflect.DeepEqual(value, lowerValue) {
+				v.report(Finding{
+					RuleID:  RuleOverriddenValue,
+					Path:    path,
+					Message: fmt.Sprintf("Overridden value: '%s' is %v in %s, but %v in %s", path, lowerValue, sources[lower], value, sources[i]),
+					Default: lowerValue,
+					Value:   value,
+				})
+				continue
+			}
+			if defaultValue, ok := lookupPathOK(defaultValues, path); ok && reflect.DeepEqual(value, defaultValue) {
+				continue
+			}
+			v.report(Finding{
+				RuleID:  RuleDuplicateValue,
+				Path:    path,
+				Message: fmt.Sprintf("Duplicate value: '%s' is set to %v in both %s and %s", path, value, sources[lower], sources[i]),
+				Value:   value,
+			})
+		}
+	}
+	return v.issuesFound
+}
@@ -0,0 +1,41 @@
+package kc
+
+import (
+	"testing"
+)
+
+// TestCheckLayerValues verifies that values repeating the nearest lower layer are reported,
+// unless they match the chart default, and that values overriding it are reported as info.
+func TestCheckLayerValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"tag": "latest"},
+	}
+	opts := Options{
+		Rules:  RuleSet{RuleOverriddenValue: true},
+		FailOn: SeverityError,
+		Files:  []string{"overrides.yaml", "region.yaml", "prod/web.yaml"},
+		Layers: []map[string]interface{}{
+			{"image": map[string]interface{}{"tag": "1.2.3"}, "replicaCount": float64(1), "tolerations": []interface{}{"spot"}},
+			{"image": map[string]interface{}{"tag": "1.2.4"}},
+			{"image": map[string]interface{}{"tag": "1.2.4"}, "replicaCount": float64(1), "tolerations": []interface{}{"spot"}},
+		},
+	}
+	collector := &Collector{}
+	if CheckLayerValues(defaults, opts.Files, opts, collector) {
+		t.Error("expected no errors")
+	}
+	want := []Finding{
+		{RuleID: RuleOverriddenValue, Severity: SeverityInfo, Path: "image.tag", Message: "Overridden value: 'image.tag' is 1.2.3 in overrides.yaml, but 1.2.4 in region.yaml"},
+		{RuleID: RuleDuplicateValue, Severity: SeverityWarning, Path: "image.tag", Message: "Duplicate value: 'image.tag' is set to 1.2.4 in both region.yaml and prod/web.yaml"},
+		{RuleID: RuleDuplicateValue, Severity: SeverityWarning, Path: "tolerations", Message: "Duplicate value: 'tolerations' is set to [spot] in both overrides.yaml and prod/web.yaml"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Path != want[i].Path || f.Message != want[i].Message || f.Severity != want[i].Severity {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package kc
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/lint/support"
+)
+
+// lintSeverities maps Helm lint severities onto finding severities.
+var lintSeverities = map[int]Severity{
+	support.ErrorSev:   SeverityError,
+	support.WarningSev: SeverityWarning,
+	support.InfoSev:    SeverityInfo,
+}
+
+// CheckLint runs Helm's lint rules on the chart at chartPath with the provided values and
+// reports every lint message, with the template it names as path, or the chart file it is
+// about otherwise. It returns true if any failing issues were found.
+func CheckLint(chartPath string, provided map[string]interface{}, opts Options, r Reporter) bool {
+	client := action.NewLint()
+	client.Namespace = opts.Render.Namespace
+	if client.Namespace == "" {
+		client.Namespace = "default"
+	}
+	if opts.Render.Capabilities != nil {
+		kubeVersion := opts.Render.Capabilities.KubeVersion
+		client.KubeVersion = &kubeVersion
+	}
+
+	v := &validator{opts: opts, reporter: r}
+	result := client.Run([]string{chartPath}, provided)
+	for _, msg := range result.Messages {
+		severity, ok := lintSeverities[msg.Severity]
+		if !ok {
+			severity = SeverityInfo
+		}
+		v.report(Finding{
+			RuleID:   RuleLint,
+			Severity: severity,
+			Path:     templateOr(msg.Err, msg.Path),
+			Message:  fmt.Sprintf("Lint: %s: %v", msg.Path, msg.Err),
+		})
+	}
+	if result.TotalChartsLinted == 0 {
+		for _, err := range result.Errors {
+			v.report(Finding{
+				RuleID:   RuleLint,
+				Severity: SeverityError,
+				Path:     templateOr(err, filepath.Base(chartPath)),
+				Message:  fmt.Sprintf("Lint: %v", err),
+			})
+		}
+	}
+	return v.issuesFound
+}
@@ -0,0 +1,46 @@
+package kc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckLint(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "web_service")
+	if err := os.MkdirAll(filepath.Join(chartDir, "templates"), 0755); err != nil {
+		t.Fatalf("failed to create chart dir: %v", err)
+	}
+	files := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: web_service\nversion: 0.1.0\n",
+		"values.yaml":               "replicaCount: 1\n",
+		"templates/deployment.yaml": "replicas: {{ .Values.replicaCount }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(chartDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	r := &recordingReporter{}
+	if !CheckLint(chartDir, nil, Options{}, r) {
+		t.Errorf("expected broken template to fail lint")
+	}
+	hasError := false
+	for _, f := range r.findings {
+		if f.RuleID != RuleLint {
+			t.Errorf("unexpected rule %s", f.RuleID)
+		}
+		if f.Severity == SeverityError {
+			hasError = true
+			if f.Path != "web_service/templates/deployment.yaml" {
+				t.Errorf("expected the broken template as path, got %+v", f)
+			}
+		} else if f.Path == "" {
+			t.Errorf("expected a path, got %+v", f)
+		}
+	}
+	if !hasError {
+		t.Errorf("expected an error-severity lint finding, got %+v", r.findings)
+	}
+}
@@ -0,0 +1,54 @@
+package kc
+
+import "os"
+
+// Location is the place in a values file where the key of a finding is set.
+type Location struct {
+	File string
+	// Line and Column are 1-based.
+	Line   int
+	Column int
+	// Length is the length of the key on the line.
+	Length int
+}
+
+// Locator finds the values files and lines that set the keys of findings. The files it reads
+// are cached, so a Locator should not outlive the run it reports.
+type Locator struct {
+	files map[string][]byte
+}
+
+// NewLocator returns an empty Locator.
+func NewLocator() *Locator {
+	return &Locator{files: map[string][]byte{}}
+}
+
+// SetFile sets the contents of file, for documents that differ from the file on disk, such
+// as unsaved documents of an editor.
+func (l *Locator) SetFile(file string, data []byte) {
+	l.files[file] = data
+}
+
+// Locate returns the location of the key of f in the last of f.Files that sets it, which is
+// the one that provides the merged value, or the line and column set by the finding. Files that cannot be read, such as Helm releases or
+// documents embedded in other files, are skipped.
+func (l *Locator) Locate(f Finding) (Location, bool) {
+	if f.Line > 0 && len(f.Files) > 0 {
+		return Location{File: f.Files[len(f.Files)-1], Line: f.Line, Column: max(f.Column, 1), Length: 1}, true
+	}
+	for i := len(f.Files) - 1; i >= 0; i-- {
+		file := f.Files[i]
+		data, ok := l.files[file]
+		if !ok {
+			data, _ = os.ReadFile(file)
+			l.files[file] = data
+		}
+		if data == nil {
+			continue
+		}
+		if pos, ok := FindKeyPosition(data, f.Path); ok {
+			return Location{File: file, Line: pos.Line, Column: pos.Column, Length: pos.Length}, true
+		}
+	}
+	return Location{}, false
+}
@@ -0,0 +1,39 @@
+package kc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLocatorLocate verifies that findings are located in the last values file setting their
+// key, and that unreadable files are skipped.
+func TestLocatorLocate(t *testing.T) {
+	dir := t.TempDir()
+	overrides := filepath.Join(dir, "overrides.yaml")
+	service := filepath.Join(dir, "web.yaml")
+	os.WriteFile(overrides, []byte("image:\n  tag: v1\nreplicas: 2\n"), 0o644)
+	os.WriteFile(service, []byte("# web\nimage:\n  repository: web\n  tag: v2\n"), 0o644)
+	files := []string{"release:default/web", overrides, service}
+
+	l := NewLocator()
+	for path, want := range map[string]Location{
+		"image.tag": {File: service, Line: 4, Column: 3, Length: 3},
+		"replicas":  {File: overrides, Line: 3, Column: 1, Length: 8},
+	} {
+		loc, ok := l.Locate(Finding{Path: path, Files: files})
+		if !ok || loc != want {
+			t.Errorf("%s: expected %v, got %v (%v)", path, want, loc, ok)
+		}
+	}
+	if loc, ok := l.Locate(Finding{Path: "missing", Files: files}); ok {
+		t.Errorf("expected no location, got %v", loc)
+	}
+
+	// Contents set on the locator take precedence over the file on disk.
+	l.SetFile(service, []byte("image:\n  repository: web\n"))
+	want := Location{File: overrides, Line: 2, Column: 3, Length: 3}
+	if loc, ok := l.Locate(Finding{Path: "image.tag", Files: files}); !ok || loc != want {
+		t.Errorf("expected %v after SetFile, got %v (%v)", want, loc, ok)
+	}
+}
@@ -0,0 +1,132 @@
+package kc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maskedValue replaces sensitive values in findings.
+const maskedValue = "******"
+
+// sensitive reports whether the value at path is sensitive: its key names a credential, such
+// as password or apiKey, or path matches one of opts.Sensitive, like --ignore does.
+func (o Options) sensitive(path string) bool {
+	key := path
+	for strings.HasSuffix(key, "]") && strings.Contains(key, "[") {
+		key = key[:strings.LastIndex(key, "[")]
+	}
+	key = key[strings.LastIndex(key, ".")+1:]
+	return isCredentialKey(key) || shouldIgnore(path, o.Sensitive)
+}
+
+// mask returns value with maskedValue in place of it, or of the values below it, that are
+// sensitive, so reports can be shared without leaking them.
+func (o Options) mask(path string, value interface{}) interface{} {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			masked[k] = o.mask(childPath, child)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(value))
+		for i, item := range value {
+			masked[i] = o.mask(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return masked
+	}
+	if o.sensitive(path) {
+		return maskedValue
+	}
+	return value
+}
+
+// secrets adds the text of every sensitive value of value at path to found.
+func (o Options) secrets(path string, value interface{}, found map[string]bool) {
+	switch value := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, child := range value {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			o.secrets(childPath, child, found)
+		}
+	case []interface{}:
+		for i, item := range value {
+			o.secrets(fmt.Sprintf("%s[%d]", path, i), item, found)
+		}
+	default:
+		if text := fmt.Sprint(value); text != "" && o.sensitive(path) {
+			found[text] = true
+		}
+	}
+}
+
+// maskMessage returns the message of f with maskedValue in place of the sensitive values it
+// quotes, whether they are values of f or of opts.Values.
+func (v *validator) maskMessage(f Finding) string {
+	if v.secrets == nil {
+		v.secrets = map[string]bool{}
+		v.opts.secrets("", v.opts.Values, v.secrets)
+	}
+	found := make(map[string]bool, len(v.secrets))
+	for text := range v.secrets {
+		found[text] = true
+	}
+	for _, value := range append([]interface{}{f.Default, f.Value}, f.quoted...) {
+		v.opts.secrets(f.Path, value, found)
+	}
+	return maskText(f.Message, found)
+}
+
+// maskText replaces every quote of the given secrets in text with maskedValue. Longer secrets
+// are replaced first, so one containing another is masked as a whole, and secrets are never
+// replaced within a longer word, number or key path.
+func maskText(text string, secrets map[string]bool) string {
+	sorted := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		sorted = append(sorted, secret)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	for _, secret := range sorted {
+		var b strings.Builder
+		rest := text
+		for {
+			i := strings.Index(rest, secret)
+			if i < 0 {
+				break
+			}
+			end := i + len(secret)
+			if (i > 0 && isWordByte(rest[i-1])) || (end < len(rest) && isWordByte(rest[end])) {
+				b.WriteString(rest[:end])
+			} else {
+				b.WriteString(rest[:i])
+				b.WriteString(maskedValue)
+			}
+			rest = rest[end:]
+		}
+		b.WriteString(rest)
+		text = b.String()
+	}
+	return text
+}
+
+// isWordByte reports whether c continues a word, number or key path around a secret.
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,144 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// TestValidateChartValuesMasksSensitiveValues verifies that values of keys named like
+// credentials, and of configured sensitive paths, are masked in messages and findings.
+func TestValidateChartValuesMasksSensitiveValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"db":   map[string]interface{}{"password": "hunter2", "dsn": "postgres://app:hunter2@db/app", "host": "db"},
+		"port": float64(80),
+	}
+	provided := map[string]interface{}{
+		"db":   map[string]interface{}{"password": "hunter2", "dsn": "postgres://app:hunter2@db/app", "host": "db"},
+		"port": float64(80),
+	}
+	collector := &Collector{}
+	ValidateChartValues(defaults, provided, Options{Sensitive: CompileIgnoreList(IgnoreList{"*.dsn"})}, collector)
+	want := map[string]Finding{
+		"db.dsn":      {Message: "Redundant value: 'db.dsn' matches default value: ******", Value: "******"},
+		"db.host":     {Message: "Redundant value: 'db.host' matches default value: db", Value: "db"},
+		"db.password": {Message: "Redundant value: 'db.password' matches default value: ******", Value: "******"},
+		"port":        {Message: "Redundant value: 'port' matches default value: 80", Value: float64(80)},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for _, f := range collector.Findings {
+		if w := want[f.Path]; f.Message != w.Message || !reflect.DeepEqual(f.Value, w.Value) {
+			t.Errorf("expected %+v for %s, got %+v", w, f.Path, f)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	value := map[string]interface{}{
+		"hosts": []interface{}{"a", "b"},
+		"auth":  map[string]interface{}{"apiKey": "abc", "enabled": true},
+	}
+	want := map[string]interface{}{
+		"hosts": []interface{}{"a", "b"},
+		"auth":  map[string]interface{}{"apiKey": maskedValue, "enabled": true},
+	}
+	if got := (Options{}).mask("", value); !reflect.DeepEqual(got, want) {
+		t.Errorf("mask() = %v, want %v", got, want)
+	}
+	if got := (Options{}).mask("auth.token[1]", "b"); got != maskedValue {
+		t.Errorf("mask(auth.token[1]) = %v, want %s", got, maskedValue)
+	}
+}
+
+// TestFindingsMaskSensitiveValues verifies that no rule quotes a sensitive value in the message
+// of a finding, whichever value of the key it quotes.
+func TestFindingsMaskSensitiveValues(t *testing.T) {
+	secret := func(suffix string) map[string]interface{} {
+		return map[string]interface{}{"db": map[string]interface{}{"password": "hunter2-" + suffix}}
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values:   secret("default"),
+		Templates: []*chart.File{{
+			Name: "templates/secret.yaml",
+			Data: []byte(`{{ fail (printf "bad password %s" .Values.db.password) }}`),
+		}},
+	}
+	constraint := &Constraint{Path: "db.password", Pattern: "^[a-z]+$"}
+	if err := constraint.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	resources := map[string]interface{}{
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"memory": "1234Mi"},
+			"limits":   map[string]interface{}{"memory": "1000Mi"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		check func(opts Options, r Reporter)
+	}{
+		{"redundant", func(opts Options, r Reporter) { ValidateChartValues(secret("default"), secret("default"), opts, r) }},
+		{"deleted", func(opts Options, r Reporter) {
+			ValidateChartValues(secret("default"), map[string]interface{}{"db": map[string]interface{}{"password": nil}}, opts, r)
+		}},
+		{"layers", func(opts Options, r Reporter) {
+			opts.Layers = []map[string]interface{}{secret("old"), secret("new")}
+			opts.Rules = RuleSet{RuleOverriddenValue: true}
+			CheckLayerValues(secret("default"), []string{"a.yaml", "b.yaml"}, opts, r)
+		}},
+		{"consistency", func(opts Options, r Reporter) {
+			CheckConsistency([]ValuesSet{{Values: secret("old")}, {Values: secret("old")}, {Values: secret("new")}}, opts, r)
+		}},
+		{"upgrade", func(opts Options, r Reporter) { CheckUpgrade(secret("old"), secret("new"), secret("new"), opts, r) }},
+		{"history", func(opts Options, r Reporter) {
+			CheckHistory([]Revision{{Number: 1, Chart: c, Values: secret("old")}, {Number: 2, Chart: c, Values: secret("default")}}, opts, r)
+		}},
+		{"drift", func(opts Options, r Reporter) { CheckDrift(c, secret("new"), secret("old"), opts, r) }},
+		{"constraint", func(opts Options, r Reporter) {
+			CheckConstraints([]*Constraint{constraint}, secret("default"), secret("new"), opts, r)
+		}},
+		{"resources", func(opts Options, r Reporter) {
+			opts.Sensitive = CompileIgnoreList(IgnoreList{"resources"})
+			opts.Rules = RuleSet{RuleLimitBelowRequest: true}
+			CheckResources(resources, opts, r)
+		}},
+		{"render", func(opts Options, r Reporter) {
+			opts.Values = secret("new")
+			CheckRender(c, secret("new"), opts, r)
+		}},
+		{"secrets", func(opts Options, r Reporter) {
+			CheckSecrets([]map[string]interface{}{secret("new")}, opts, r)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &Collector{}
+			tt.check(Options{}, collector)
+			if len(collector.Findings) == 0 {
+				t.Fatalf("expected findings")
+			}
+			for _, f := range collector.Findings {
+				text := f.Message + fmt.Sprint(f.Default, f.Value)
+				if strings.Contains(text, "hunter2") || strings.Contains(text, "1234Mi") || strings.Contains(text, "1000Mi") {
+					t.Errorf("expected sensitive values to be masked, got %+v", f)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskText(t *testing.T) {
+	secrets := map[string]bool{"db": true, "hunter2": true, "hunter2-old": true}
+	got := maskText("'db.password' is hunter2-old, not hunter2 or db (dbs: xhunter2)", secrets)
+	if want := "'db.password' is ******, not ****** or ****** (dbs: xhunter2)"; got != want {
+		t.Errorf("maskText() = %q, want %q", got, want)
+	}
+}
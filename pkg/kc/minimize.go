@@ -0,0 +1,92 @@
+package kc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Minimize removes every value from a values document that matches the value it would have
+// without it: the chart default, or the value set by earlier values files, as given in
+// baseline. Maps left empty by the removal are removed as well; comments of the remaining
+// keys are kept. It returns the minimized document and the paths of the removed values, in
+// document order. The document is returned unchanged if nothing was removed.
+func Minimize(data []byte, baseline map[string]interface{}, opts Options) ([]byte, []string, error) {
+	values := map[string]interface{}{}
+	if err := sigsyaml.Unmarshal(data, &values); err != nil {
+		return nil, nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+
+	root := doc.Content[0]
+	var first *yaml.Node
+	if len(root.Content) > 0 {
+		first = root.Content[0]
+	}
+	removed := minimizeNode(root, values, baseline, "", opts)
+	if len(removed) == 0 {
+		return data, nil, nil
+	}
+	// A comment at the top of the document is attached to the first key, but usually
+	// describes the whole file, so it is kept when that key is removed.
+	if first != nil && first.HeadComment != "" && (len(root.Content) == 0 || root.Content[0] != first) {
+		if len(root.Content) > 0 {
+			root.Content[0].HeadComment = strings.TrimSpace(first.HeadComment + "\n" + root.Content[0].HeadComment)
+		} else {
+			doc.HeadComment = first.HeadComment
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), removed, nil
+}
+
+// minimizeNode removes the entries of the mapping node whose values match baseline.
+func minimizeNode(node *yaml.Node, values, baseline map[string]interface{}, prefix string, opts Options) []string {
+	var removed []string
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fullKey := key.Value
+		if prefix != "" {
+			fullKey = prefix + "." + key.Value
+		}
+		keep := true
+		if !shouldIgnore(fullKey, opts.Ignore) {
+			providedValue := values[key.Value]
+			baselineValue, exists := baseline[key.Value]
+			providedMap, providedIsMap := providedValue.(map[string]interface{})
+			baselineMap, baselineIsMap := baselineValue.(map[string]interface{})
+			switch {
+			case providedIsMap && baselineIsMap && value.Kind == yaml.MappingNode && len(value.Content) > 0:
+				below := minimizeNode(value, providedMap, baselineMap, fullKey, opts)
+				removed = append(removed, below...)
+				keep = len(value.Content) > 0
+			case exists && reflect.DeepEqual(providedValue, baselineValue):
+				removed = append(removed, fullKey)
+				keep = false
+			}
+		}
+		if keep {
+			content = append(content, key, value)
+		}
+	}
+	node.Content = content
+	return removed
+}
@@ -0,0 +1,61 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimize(t *testing.T) {
+	baseline := map[string]interface{}{
+		"replicaCount":   float64(1),
+		"image":          map[string]interface{}{"repository": "nginx", "tag": "latest"},
+		"resources":      map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}},
+		"podAnnotations": map[string]interface{}{},
+		"debug":          map[string]interface{}{"enabled": false},
+	}
+	data := []byte(`# Production overrides
+replicaCount: 1
+image:
+  repository: nginx
+  # pinned for the release
+  tag: "1.25"
+resources:
+  limits:
+    cpu: "1"
+podAnnotations: {}
+debug:
+  enabled: false
+ingress:
+  enabled: true
+`)
+
+	got, removed, err := Minimize(data, baseline, Options{Ignore: CompileIgnoreList(IgnoreList{"debug"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Production overrides
+image:
+  # pinned for the release
+  tag: "1.25"
+debug:
+  enabled: false
+ingress:
+  enabled: true
+`
+	if string(got) != want {
+		t.Errorf("Minimize() =\n%s\nwant\n%s", got, want)
+	}
+	wantRemoved := []string{"replicaCount", "image.repository", "resources.limits.cpu", "podAnnotations"}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("Minimize() removed %v, want %v", removed, wantRemoved)
+	}
+
+	minimal := []byte("image:\n  tag: \"1.25\"\n")
+	got, removed, err = Minimize(minimal, baseline, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(minimal) || removed != nil {
+		t.Errorf("Minimize() changed a minimal document: %q, removed %v", got, removed)
+	}
+}
@@ -0,0 +1,61 @@
+package kc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OverridesFile is an overrides file found below the searched directories, with the charts
+// of the values sets it was merged into.
+type OverridesFile struct {
+	Path   string
+	Values map[string]interface{}
+	// Charts names the charts of the validated values sets the file was merged into, and Keys
+	// holds the top-level keys those charts define. Both are empty if the file is unused.
+	Charts []string
+	Keys   map[string]bool
+	// Suppressions holds the inline "# kc:ignore" comments of the file.
+	Suppressions Suppressions
+}
+
+// CheckUnusedOverrides reports the overrides files that were not merged into any validated
+// values set, and the top-level keys of the others that none of the charts they were merged
+// for defines. It returns true if any failing issues were found.
+func CheckUnusedOverrides(files []OverridesFile, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RuleUnusedOverrides) {
+		return false
+	}
+	issuesFound := false
+	for _, file := range files {
+		fileOpts := opts
+		fileOpts.Files = []string{file.Path}
+		fileOpts.Suppressions = file.Suppressions
+		v := &validator{opts: fileOpts, reporter: r}
+		if len(file.Charts) == 0 {
+			v.report(Finding{
+				RuleID:  RuleUnusedOverrides,
+				Message: fmt.Sprintf("Unused overrides: %s is not merged into any values set", file.Path),
+			})
+		} else {
+			keys := make([]string, 0, len(file.Values))
+			for key := range file.Values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if key == globalKey || file.Keys[key] || shouldIgnore(key, opts.Ignore) {
+					continue
+				}
+				v.report(Finding{
+					RuleID:  RuleUnusedOverrides,
+					Path:    key,
+					Message: fmt.Sprintf("Unused override: '%s' is not defined by the charts %s is merged for: %s", key, file.Path, strings.Join(file.Charts, ", ")),
+					Value:   file.Values[key],
+				})
+			}
+		}
+		issuesFound = issuesFound || v.issuesFound
+	}
+	return issuesFound
+}
@@ -0,0 +1,221 @@
+package kc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// PluginProtocolVersion is the version of the input plugins receive; it changes on
+// incompatible changes of the input or the expected output.
+const PluginProtocolVersion = "v1"
+
+// Plugin is an external check: an executable, or a WASI module, that reads the input of a
+// values set as JSON on stdin, and writes its findings as JSON on stdout.
+type Plugin struct {
+	// Name identifies the plugin in findings (default the base name of the executable or module).
+	Name string `json:"name,omitempty"`
+	// Command is the executable and its arguments.
+	Command []string `json:"command,omitempty"`
+	// WASM is the path of a WASI module, run sandboxed in-process instead of Command, without
+	// access to files, the network, or the environment.
+	WASM string `json:"wasm,omitempty"`
+	// Args are the arguments of the WASI module.
+	Args []string `json:"args,omitempty"`
+}
+
+// NewPlugin returns the plugin of a --plugin path: a WASI module if it ends with .wasm, or an
+// executable otherwise.
+func NewPlugin(path string) Plugin {
+	if strings.EqualFold(filepath.Ext(path), ".wasm") {
+		return Plugin{WASM: path}
+	}
+	return Plugin{Command: []string{path}}
+}
+
+// wasmTimeout bounds the run of a WASI module, in addition to the context of the run.
+var wasmTimeout = time.Minute
+
+// pluginWaitDelay bounds the wait for the output of an executable plugin killed when the
+// context of the run is done.
+var pluginWaitDelay = time.Second
+
+// wasmCache keeps the WASI modules compiled, so a plugin run for every values set is
+// compiled once.
+var wasmCache = wazero.NewCompilationCache()
+
+// DisplayName returns the name of the plugin in findings.
+func (p Plugin) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	path := p.WASM
+	if path == "" {
+		if len(p.Command) == 0 {
+			return ""
+		}
+		path = p.Command[0]
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// PluginInput is the document plugins read on stdin: the input of policies, with the
+// protocol version.
+type PluginInput struct {
+	Version string `json:"version"`
+	PolicyInput
+}
+
+// PluginOutput is the document plugins write on stdout.
+type PluginOutput struct {
+	Findings []PluginFinding `json:"findings"`
+}
+
+// PluginFinding is a finding of a plugin. Rule is the plugin's own identifier of the check,
+// if any; Severity defaults to error.
+type PluginFinding struct {
+	Rule     string   `json:"rule,omitempty"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// CheckPlugins runs the plugins, executables or WASI modules, with the input of a values set and reports their findings.
+// A plugin that exits with a non-zero status or writes invalid output fails the validation, and
+// a plugin still running once ctx is done is stopped. It returns true if any failing issues were found.
+func CheckPlugins(ctx context.Context, input PolicyInput, plugins []Plugin, opts Options, r Reporter) (bool, error) {
+	if len(plugins) == 0 || !opts.Rules.Enabled(RulePluginFinding) {
+		return false, nil
+	}
+	data, err := json.Marshal(PluginInput{Version: PluginProtocolVersion, PolicyInput: input})
+	if err != nil {
+		return false, err
+	}
+	v := &validator{opts: opts, reporter: r}
+	for _, p := range plugins {
+		output, err := runPlugin(ctx, p, data)
+		if err != nil {
+			return false, fmt.Errorf("plugin %s: %w", p.DisplayName(), err)
+		}
+		for _, f := range output.Findings {
+			if f.Message == "" {
+				return false, fmt.Errorf("plugin %s: finding without a message", p.DisplayName())
+			}
+			if f.Severity != "" {
+				if _, err := ParseSeverity(string(f.Severity)); err != nil {
+					return false, fmt.Errorf("plugin %s: %w", p.DisplayName(), err)
+				}
+			}
+			name := p.DisplayName()
+			if f.Rule != "" {
+				name += "/" + f.Rule
+			}
+			v.report(Finding{
+				RuleID:   RulePluginFinding,
+				Severity: f.Severity,
+				Path:     f.Path,
+				Message:  fmt.Sprintf("Plugin %s: %s", name, f.Message),
+			})
+		}
+	}
+	return v.issuesFound, nil
+}
+
+// runPlugin runs a plugin with the input data and parses its output.
+func runPlugin(ctx context.Context, p Plugin, data []byte) (PluginOutput, error) {
+	var out []byte
+	var err error
+	switch {
+	case p.WASM != "" && len(p.Command) > 0:
+		return PluginOutput{}, errors.New("both command and wasm module set")
+	case p.WASM != "":
+		out, err = runWASM(ctx, p, data)
+	case len(p.Command) > 0:
+		out, err = runCommand(ctx, p.Command, data)
+	default:
+		return PluginOutput{}, errors.New("missing command or wasm module")
+	}
+	if err != nil {
+		return PluginOutput{}, err
+	}
+	var output PluginOutput
+	if err := json.Unmarshal(out, &output); err != nil {
+		return PluginOutput{}, fmt.Errorf("parsing output: %w", err)
+	}
+	return output, nil
+}
+
+// runCommand runs an executable plugin with the input data on stdin and returns its stdout.
+// The plugin is killed once ctx is done.
+func runCommand(ctx context.Context, command []string, data []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	// Children of the plugin that keep its output open do not hold up the run either.
+	cmd.WaitDelay = pluginWaitDelay
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return nil, errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// runWASM runs a WASI module in-process with the input data on stdin and returns its stdout.
+// The module gets no files, environment variables, or clock of the host, and is stopped after
+// wasmTimeout or once ctx is done.
+func runWASM(ctx context.Context, p Plugin, data []byte) ([]byte, error) {
+	binary, err := os.ReadFile(p.WASM)
+	if err != nil {
+		return nil, err
+	}
+	runCtx, cancel := context.WithTimeout(ctx, wasmTimeout)
+	defer cancel()
+	config := wazero.NewRuntimeConfig().WithCompilationCache(wasmCache).WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(runCtx, config)
+	defer runtime.Close(runCtx)
+	wasi_snapshot_preview1.MustInstantiate(runCtx, runtime)
+
+	var stdout, stderr bytes.Buffer
+	module := wazero.NewModuleConfig().
+		WithArgs(append([]string{p.DisplayName()}, p.Args...)...).
+		WithStdin(bytes.NewReader(data)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	if _, err := runtime.InstantiateWithConfig(runCtx, binary, module); err != nil {
+		var exitErr *sys.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		switch code := exitErr.ExitCode(); {
+		case code == 0:
+		case ctx.Err() != nil:
+			return nil, context.Cause(ctx)
+		case code == sys.ExitCodeDeadlineExceeded:
+			return nil, fmt.Errorf("timed out after %s", wasmTimeout)
+		case stderr.Len() > 0:
+			return nil, errors.New(strings.TrimSpace(stderr.String()))
+		default:
+			return nil, fmt.Errorf("exit status %d", code)
+		}
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+package kc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckPlugins verifies that plugins receive the input of the values set with the
+// protocol version, and that their findings are reported with their severity and key path.
+func TestCheckPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin stub is a shell script")
+	}
+	dir := t.TempDir()
+	stub := `#!/bin/sh
+echo "$@" > "$0.args"
+cat > "$0.input"
+cat <<'JSON'
+{"findings": [
+  {"rule": "cost-center", "message": "missing cost center label", "path": "podLabels"},
+  {"message": "large heap", "path": "jvm.heap", "severity": "info"}
+]}
+JSON
+`
+	plugin := filepath.Join(dir, "acme-check.sh")
+	if err := os.WriteFile(plugin, []byte(stub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	input := PolicyInput{Values: map[string]interface{}{"replicaCount": 2}, Files: []string{"envs/prod/web.yaml"}, Environment: "prod"}
+	collector := &Collector{}
+	failed, err := CheckPlugins(context.Background(), input, []Plugin{{Command: []string{plugin, "--strict"}}}, Options{FailOn: SeverityError}, collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !failed {
+		t.Error("expected the error finding to fail the run")
+	}
+
+	args, _ := os.ReadFile(plugin + ".args")
+	if got := strings.TrimSpace(string(args)); got != "--strict" {
+		t.Errorf("unexpected arguments: %s", got)
+	}
+	var sent PluginInput
+	data, _ := os.ReadFile(plugin + ".input")
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Version != PluginProtocolVersion || sent.Environment != "prod" || sent.Values["replicaCount"] != float64(2) {
+		t.Errorf("unexpected input: %s", data)
+	}
+
+	want := []Finding{
+		{RuleID: RulePluginFinding, Severity: SeverityError, Path: "podLabels", Message: "Plugin acme-check/cost-center: missing cost center label"},
+		{RuleID: RulePluginFinding, Severity: SeverityInfo, Path: "jvm.heap", Message: "Plugin acme-check: large heap"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+
+	failing := filepath.Join(dir, "failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'no license' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_, err = CheckPlugins(context.Background(), input, []Plugin{{Name: "licensed", Command: []string{failing}}}, Options{}, &Collector{})
+	if err == nil || err.Error() != "plugin licensed: no license" {
+		t.Errorf("expected the error of the plugin, got %v", err)
+	}
+
+	// A hung plugin, and the child holding its output, are stopped with the run.
+	hung := filepath.Join(dir, "hung")
+	if err := os.WriteFile(hung, []byte("#!/bin/sh\nsleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = CheckPlugins(ctx, input, []Plugin{{Command: []string{hung}}}, Options{}, &Collector{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline of the run, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the plugin to be stopped with the run, took %s", elapsed)
+	}
+}
+
+// TestCheckPluginsWASM verifies that WASI modules are run in-process with the input on stdin,
+// and that a failing or hanging module fails the validation.
+func TestCheckPluginsWASM(t *testing.T) {
+	input := PolicyInput{Values: map[string]interface{}{}}
+	plugin := NewPlugin(filepath.Join("testdata", "naming.wasm"))
+	plugin.Args = []string{"--strict"}
+	collector := &Collector{}
+	if _, err := CheckPlugins(context.Background(), input, []Plugin{plugin}, Options{}, collector); err != nil {
+		t.Fatal(err)
+	}
+	if len(collector.Findings) != 1 || collector.Findings[0].Message != "Plugin naming: bad name" || collector.Findings[0].Path != "nameOverride" {
+		t.Errorf("unexpected findings: %+v", collector.Findings)
+	}
+
+	if _, err := runPlugin(context.Background(), plugin, nil); err == nil || err.Error() != "no input" {
+		t.Errorf("expected the error of the module, got %v", err)
+	}
+
+	loop := NewPlugin(filepath.Join("testdata", "loop.wasm"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := CheckPlugins(ctx, input, []Plugin{loop}, Options{}, &Collector{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline of the run, got %v", err)
+	}
+
+	defer func(timeout time.Duration) { wasmTimeout = timeout }(wasmTimeout)
+	wasmTimeout = 100 * time.Millisecond
+	_, err := CheckPlugins(context.Background(), input, []Plugin{loop}, Options{}, &Collector{})
+	if err == nil || err.Error() != "plugin loop: timed out after 100ms" {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package kc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+// PolicyPackage is the Rego package whose deny and warn rules are reported.
+const PolicyPackage = "kaartcontrole"
+
+// PolicyInput is the input document of the policies of a values set.
+type PolicyInput struct {
+	// Values are the merged provided values, Defaults the chart defaults.
+	Values   map[string]interface{} `json:"values"`
+	Defaults map[string]interface{} `json:"defaults"`
+	// Files are the values files of the set, Environment the directory name of the last one.
+	Files       []string    `json:"files"`
+	Environment string      `json:"environment,omitempty"`
+	Chart       PolicyChart `json:"chart"`
+	// Manifests are the rendered resources, if the chart was rendered.
+	Manifests []map[string]interface{} `json:"manifests,omitempty"`
+}
+
+// PolicyChart identifies the chart in the input of policies.
+type PolicyChart struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// NewPolicyInput returns the input of the policies for a values set. rendered holds the
+// rendered templates, or nil if the chart was not rendered.
+func NewPolicyInput(c *chart.Chart, defaults, provided map[string]interface{}, rendered map[string]string, files []string) (PolicyInput, error) {
+	input := PolicyInput{Values: provided, Defaults: defaults, Files: files}
+	if input.Files == nil {
+		input.Files = []string{}
+	}
+	input.Environment = setEnvironment(files)
+	if c.Metadata != nil {
+		input.Chart = PolicyChart{Name: c.Metadata.Name, Version: c.Metadata.Version}
+	}
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		switch filepath.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		docs := releaseutil.SplitManifests(rendered[name])
+		keys := make([]string, 0, len(docs))
+		for key := range docs {
+			keys = append(keys, key)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+		for _, key := range keys {
+			manifest := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(docs[key]), &manifest); err != nil {
+				return PolicyInput{}, fmt.Errorf("parsing %s: %w", name, err)
+			}
+			if len(manifest) > 0 {
+				input.Manifests = append(input.Manifests, manifest)
+			}
+		}
+	}
+	return input, nil
+}
+
+// policyResult is a violation in the deny or warn set of a policy: a message, or an object
+// with the message, the key path it is about, and optionally its severity.
+type policyResult struct {
+	Msg      string   `json:"msg"`
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+}
+
+func (p *policyResult) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.Msg); err == nil {
+		return nil
+	}
+	type plain policyResult
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// Policies are Rego policies prepared for evaluation by PreparePolicies.
+type Policies struct {
+	query rego.PreparedEvalQuery
+}
+
+// PreparePolicies loads and compiles the Rego policies and data files in paths, files or
+// directories like those of opa eval --data, so they are evaluated against every values set
+// without being compiled again. It returns nil if paths is empty.
+func PreparePolicies(ctx context.Context, paths []string) (*Policies, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	query, err := rego.New(rego.Query("data."+PolicyPackage), rego.Load(paths, nil)).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading policies: %w", err)
+	}
+	return &Policies{query: query}, nil
+}
+
+// CheckPolicies evaluates the prepared policies against input, and reports every result of
+// the deny and warn rules of the kaartcontrole package: deny as errors and warn as warnings,
+// unless a result sets its severity. The evaluation is stopped once ctx is done. It returns
+// true if any failing issues were found.
+func CheckPolicies(ctx context.Context, policies *Policies, input PolicyInput, opts Options, r Reporter) (bool, error) {
+	if policies == nil || !opts.Rules.Enabled(RulePolicyViolation) {
+		return false, nil
+	}
+	rs, err := policies.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return false, fmt.Errorf("evaluating policies: %w", err)
+	}
+
+	v := &validator{opts: opts, reporter: r}
+	for _, res := range rs {
+		for _, expr := range res.Expressions {
+			// The package document holds the deny and warn sets, decoded like JSON.
+			data, err := json.Marshal(expr.Value)
+			if err != nil {
+				return false, err
+			}
+			var value struct {
+				Deny []policyResult `json:"deny"`
+				Warn []policyResult `json:"warn"`
+			}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return false, fmt.Errorf("parsing the result of the policies: %w", err)
+			}
+			for _, set := range []struct {
+				results  []policyResult
+				severity Severity
+			}{{value.Deny, SeverityError}, {value.Warn, SeverityWarning}} {
+				for _, p := range set.results {
+					severity := set.severity
+					if p.Severity != "" {
+						if _, err := ParseSeverity(string(p.Severity)); err != nil {
+							return false, fmt.Errorf("policy result %q: %w", p.Msg, err)
+						}
+						severity = p.Severity
+					}
+					v.report(Finding{
+						RuleID:   RulePolicyViolation,
+						Severity: severity,
+						Path:     p.Path,
+						Message:  "Policy violation: " + p.Msg,
+					})
+				}
+			}
+		}
+	}
+	return v.issuesFound, nil
+}
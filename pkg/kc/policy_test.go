@@ -0,0 +1,125 @@
+package kc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// TestCheckPolicies verifies that policies see the input of a values set and that deny and
+// warn results are reported with their severity and key path.
+func TestCheckPolicies(t *testing.T) {
+	dir := t.TempDir()
+	policy := `package kaartcontrole
+
+import rego.v1
+
+deny contains "prod must set resources.limits" if {
+	input.environment == "prod"
+	not input.values.resources.limits
+}
+
+deny contains {"msg": "replicas below 2", "path": "replicaCount", "severity": "info"} if {
+	input.values.replicaCount < 2
+	count(input.manifests) == 2
+	input.manifests[1].kind == "Service"
+	input.chart.name == "web"
+}
+
+warn contains {"msg": "latest tag", "path": "image.tag"} if {
+	input.values.image.tag == data.tags.floating
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"tags": {"floating": "latest"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := PreparePolicies(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "web", Version: "1.0.0"}}
+	rendered := map[string]string{
+		"web/templates/deployment.yaml": "---\n# Source: web/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\n---\napiVersion: v1\nkind: Service\n",
+		"web/templates/NOTES.txt":       "Thanks",
+	}
+	provided := map[string]interface{}{"replicaCount": 1, "image": map[string]interface{}{"tag": "latest"}}
+	input, err := NewPolicyInput(c, map[string]interface{}{"replicaCount": 1}, provided, rendered, []string{"overrides.yaml", "envs/prod/web.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &Collector{}
+	failed, err := CheckPolicies(context.Background(), policies, input, Options{FailOn: SeverityError}, collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !failed {
+		t.Error("expected the deny result to fail the run")
+	}
+
+	want := []Finding{
+		{RuleID: RulePolicyViolation, Severity: SeverityError, Message: "Policy violation: prod must set resources.limits"},
+		{RuleID: RulePolicyViolation, Severity: SeverityInfo, Path: "replicaCount", Message: "Policy violation: replicas below 2"},
+		{RuleID: RulePolicyViolation, Severity: SeverityWarning, Path: "image.tag", Message: "Policy violation: latest tag"},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestCheckPoliciesCancel verifies that an expensive policy is stopped with the run.
+func TestCheckPoliciesCancel(t *testing.T) {
+	dir := t.TempDir()
+	policy := `package kaartcontrole
+
+import rego.v1
+
+deny contains "slow" if {
+	some i in numbers.range(1, 100000)
+	some j in numbers.range(1, 100000)
+	i == j + 100000
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := PreparePolicies(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := CheckPolicies(ctx, policies, PolicyInput{}, Options{}, &Collector{}); err == nil {
+		t.Error("expected the evaluation to be stopped")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the evaluation to be stopped with the run, took %s", elapsed)
+	}
+}
+
+func TestPreparePoliciesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "policy.rego"), []byte("package kaartcontrole\n\ndeny contains if {"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PreparePolicies(context.Background(), []string{dir}); err == nil || !strings.Contains(err.Error(), "loading policies") {
+		t.Errorf("expected an error loading the policies, got %v", err)
+	}
+	if policies, err := PreparePolicies(context.Background(), nil); policies != nil || err != nil {
+		t.Errorf("expected no policies without paths, got %v, %v", policies, err)
+	}
+}
@@ -0,0 +1,136 @@
+package kc
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryMatch is a value found by Query, with its concrete key path.
+type QueryMatch struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// Query returns the values at a dotted key path. Paths may index into lists ("hosts[0]") and
+// contain wildcards: "*" and "?" in a key match like path.Match within a single key, "[*]" matches
+// every list item, and a "**" key matches any number of keys. Matches are returned in key order.
+func Query(values map[string]interface{}, query string) ([]QueryMatch, error) {
+	elems, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var matches []QueryMatch
+	if err := queryValue(values, elems, "", &matches); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %w", query, err)
+	}
+	return matches, nil
+}
+
+// IsWildcardQuery reports whether a query contains wildcards and may match more than one value.
+func IsWildcardQuery(query string) bool {
+	return strings.ContainsAny(query, "*?")
+}
+
+// queryElem is a single step of a query: a key pattern, or a list index ("*" for all items).
+type queryElem struct {
+	key   string
+	index string
+}
+
+func parseQuery(query string) ([]queryElem, error) {
+	var elems []queryElem
+	for _, segment := range strings.Split(query, ".") {
+		key, rest, _ := strings.Cut(segment, "[")
+		if key != "" {
+			elems = append(elems, queryElem{key: key})
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if _, err := strconv.Atoi(idx); !ok || (err != nil && idx != "*") {
+				return nil, fmt.Errorf("invalid query: %s", query)
+			}
+			elems = append(elems, queryElem{index: idx})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("invalid query: %s", query)
+	}
+	return elems, nil
+}
+
+func queryValue(value interface{}, elems []queryElem, prefix string, matches *[]QueryMatch) error {
+	if len(elems) == 0 {
+		*matches = append(*matches, QueryMatch{Path: prefix, Value: value})
+		return nil
+	}
+	elem := elems[0]
+
+	if elem.index != "" {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			if elem.index == "*" || elem.index == strconv.Itoa(i) {
+				if err := queryValue(item, elems[1:], fmt.Sprintf("%s[%d]", prefix, i), matches); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if elem.key == "**" {
+		// "**" matches zero keys here, or one key and possibly more below it.
+		if err := queryValue(value, elems[1:], prefix, matches); err != nil {
+			return err
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(m) {
+			if err := queryValue(m[key], elems, joinPath(prefix, key), matches); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, key := range sortedKeys(m) {
+		matched, err := path.Match(elem.key, key)
+		if err != nil {
+			return err
+		}
+		if matched {
+			if err := queryValue(m[key], elems[1:], joinPath(prefix, key), matches); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
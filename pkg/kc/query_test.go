@@ -0,0 +1,49 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	values := map[string]interface{}{
+		"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"ingress": map[string]interface{}{
+			"hosts": []interface{}{
+				map[string]interface{}{"host": "a.example.com"},
+				map[string]interface{}{"host": "b.example.com"},
+			},
+		},
+		"redis": map[string]interface{}{"image": map[string]interface{}{"tag": "7.2"}},
+	}
+
+	tests := []struct {
+		query string
+		want  []QueryMatch
+	}{
+		{"image.tag", []QueryMatch{{"image.tag", "1.25"}}},
+		{"image.missing", nil},
+		{"ingress.hosts[1].host", []QueryMatch{{"ingress.hosts[1].host", "b.example.com"}}},
+		{"ingress.hosts[*].host", []QueryMatch{
+			{"ingress.hosts[0].host", "a.example.com"},
+			{"ingress.hosts[1].host", "b.example.com"},
+		}},
+		{"image.*", []QueryMatch{{"image.repository", "nginx"}, {"image.tag", "1.25"}}},
+		{"**.tag", []QueryMatch{{"image.tag", "1.25"}, {"redis.image.tag", "7.2"}}},
+		{"re?is.image", []QueryMatch{{"redis.image", map[string]interface{}{"tag": "7.2"}}}},
+	}
+	for _, tt := range tests {
+		got, err := Query(values, tt.query)
+		if err != nil {
+			t.Errorf("Query(%q) returned error: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Query(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := Query(values, "hosts[x]"); err == nil {
+		t.Error("Query() with an invalid index succeeded")
+	}
+}
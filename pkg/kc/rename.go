@@ -0,0 +1,302 @@
+package kc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// RenamesAnnotation is the Chart.yaml annotation a chart uses to publish its renamed values
+// keys, as a YAML list of renames.
+const RenamesAnnotation = "kaartcontrole.io/renames"
+
+// Rename describes a values key that moved to a new path, for example between chart versions.
+// Paths are dotted key paths and may index into lists, e.g. "ingress.hosts[0]".
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// LoadRenames reads a rename mapping file with a top-level "renames" list.
+func LoadRenames(path string) ([]Rename, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Renames []Rename `json:"renames"`
+	}
+	if err := sigsyaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return file.Renames, nil
+}
+
+// ChartRenames returns the renames published by c and its dependencies through the
+// RenamesAnnotation. Renames of subcharts are prefixed with the subchart name.
+func ChartRenames(c *chart.Chart) ([]Rename, error) {
+	var renames []Rename
+	if c.Metadata != nil {
+		if annotation, ok := c.Metadata.Annotations[RenamesAnnotation]; ok {
+			if err := sigsyaml.UnmarshalStrict([]byte(annotation), &renames); err != nil {
+				return nil, fmt.Errorf("parsing %s annotation of %s: %w", RenamesAnnotation, c.Name(), err)
+			}
+		}
+	}
+	for _, dep := range c.Dependencies() {
+		depRenames, err := ChartRenames(dep)
+		if err != nil {
+			return nil, err
+		}
+		for _, rename := range depRenames {
+			renames = append(renames, Rename{From: dep.Name() + "." + rename.From, To: dep.Name() + "." + rename.To})
+		}
+	}
+	return renames, nil
+}
+
+// CheckRenames reports every provided value that is still set at the old path of a rename.
+// It returns true if any failing issues were found.
+func CheckRenames(renames []Rename, provided map[string]interface{}, opts Options, r Reporter) bool {
+	v := &validator{opts: opts, reporter: r}
+	for _, rename := range renames {
+		if shouldIgnore(rename.From, opts.Ignore) {
+			continue
+		}
+		value, ok := lookupIndexedPath(provided, rename.From)
+		if !ok {
+			continue
+		}
+		v.report(Finding{
+			RuleID:  RuleRenamedKey,
+			Path:    rename.From,
+			Message: fmt.Sprintf("Renamed key: '%s' was renamed to '%s' (run with --fix to migrate)", rename.From, rename.To),
+			Value:   value,
+		})
+	}
+	return v.issuesFound
+}
+
+// ApplyRenames moves every value found at the old path of a rename to its new path in the
+// given values document, keeping comments. It returns the updated document and the renames
+// that were applied; the document is returned unchanged if none were.
+func ApplyRenames(data []byte, renames []Rename) ([]byte, []Rename, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+
+	var applied []Rename
+	for _, rename := range renames {
+		from, err := parseIndexedPath(rename.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		to, err := parseIndexedPath(rename.To)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, value := removeNode(root, from)
+		if value == nil {
+			continue
+		}
+		if err := setNode(root, to, key, value); err != nil {
+			return nil, nil, fmt.Errorf("renaming %s to %s: %w", rename.From, rename.To, err)
+		}
+		applied = append(applied, rename)
+	}
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), applied, nil
+}
+
+// pathElem is a single step of an indexed key path: a map key, or a list index if index >= 0.
+type pathElem struct {
+	key   string
+	index int
+}
+
+// parseIndexedPath splits a path like "ingress.hosts[0].name" into its steps.
+func parseIndexedPath(path string) ([]pathElem, error) {
+	var elems []pathElem
+	for _, segment := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(segment, "[")
+		if key != "" {
+			elems = append(elems, pathElem{key: key, index: -1})
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			n, err := strconv.Atoi(idx)
+			if !ok || err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid key path: %s", path)
+			}
+			elems = append(elems, pathElem{index: n})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("invalid key path: %s", path)
+	}
+	return elems, nil
+}
+
+// lookupIndexedPath returns the non-null value at an indexed key path.
+func lookupIndexedPath(values map[string]interface{}, path string) (interface{}, bool) {
+	elems, err := parseIndexedPath(path)
+	if err != nil {
+		return nil, false
+	}
+	var current interface{} = values
+	for _, elem := range elems {
+		if elem.index >= 0 {
+			list, ok := current.([]interface{})
+			if !ok || elem.index >= len(list) {
+				return nil, false
+			}
+			current = list[elem.index]
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = m[elem.key]
+	}
+	return current, current != nil
+}
+
+// child returns the position of the node for elem in node's content, or -1.
+func child(node *yaml.Node, elem pathElem) int {
+	if elem.index >= 0 {
+		if node.Kind == yaml.SequenceNode && elem.index < len(node.Content) {
+			return elem.index
+		}
+		return -1
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == elem.key {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// removeNode removes the node at path below node and returns it together with its map key
+// node, if it was a map entry. Collections left empty by the removal are removed as well.
+func removeNode(node *yaml.Node, path []pathElem) (*yaml.Node, *yaml.Node) {
+	i := child(node, path[0])
+	if i < 0 {
+		return nil, nil
+	}
+	var key, value *yaml.Node
+	if node.Kind == yaml.MappingNode {
+		key, value = node.Content[i], node.Content[i+1]
+	} else {
+		value = node.Content[i]
+	}
+
+	if len(path) > 1 {
+		removedKey, removed := removeNode(value, path[1:])
+		if removed != nil && len(value.Content) == 0 {
+			deleteChild(node, i)
+		}
+		return removedKey, removed
+	}
+	deleteChild(node, i)
+	return key, value
+}
+
+func deleteChild(node *yaml.Node, i int) {
+	n := 1
+	if node.Kind == yaml.MappingNode {
+		n = 2
+	}
+	node.Content = append(node.Content[:i], node.Content[i+n:]...)
+}
+
+// setNode sets value at path below node, creating intermediate maps and lists as needed.
+// Comments of the original key are carried over to the new key.
+func setNode(node *yaml.Node, path []pathElem, oldKey, value *yaml.Node) error {
+	elem := path[0]
+	i := child(node, elem)
+	last := len(path) == 1
+
+	if elem.index >= 0 {
+		if node.Kind != yaml.SequenceNode {
+			return fmt.Errorf("not a list")
+		}
+		switch {
+		case i >= 0 && last:
+			node.Content[i] = value
+			return nil
+		case i >= 0:
+			return setNode(node.Content[i], path[1:], oldKey, value)
+		case elem.index != len(node.Content):
+			return fmt.Errorf("list index %d out of range", elem.index)
+		}
+		next := value
+		if !last {
+			next = newCollection(path[1])
+		}
+		node.Content = append(node.Content, next)
+		if last {
+			return nil
+		}
+		return setNode(next, path[1:], oldKey, value)
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("not a map")
+	}
+	if i >= 0 {
+		if last {
+			node.Content[i+1] = value
+			return nil
+		}
+		return setNode(node.Content[i+1], path[1:], oldKey, value)
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: elem.key}
+	if last && oldKey != nil {
+		key.HeadComment, key.LineComment, key.FootComment = oldKey.HeadComment, oldKey.LineComment, oldKey.FootComment
+	}
+	next := value
+	if !last {
+		next = newCollection(path[1])
+	}
+	node.Content = append(node.Content, key, next)
+	if last {
+		return nil
+	}
+	return setNode(next, path[1:], oldKey, value)
+}
+
+// newCollection returns an empty map or list node that can hold elem.
+func newCollection(elem pathElem) *yaml.Node {
+	if elem.index >= 0 {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
@@ -0,0 +1,91 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestApplyRenames(t *testing.T) {
+	data := []byte(`# Web service overrides
+ingress:
+  hosts:
+    - web.example.com # primary host
+  enabled: true
+serviceType: NodePort # exposed on every node
+`)
+	renames := []Rename{
+		{From: "ingress.hosts[0]", To: "ingress.hostname"},
+		{From: "serviceType", To: "service.type"},
+		{From: "missing", To: "other"},
+	}
+
+	got, applied, err := ApplyRenames(data, renames)
+	if err != nil {
+		t.Fatalf("ApplyRenames() returned error: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("expected 2 renames to be applied, got %v", applied)
+	}
+	want := `# Web service overrides
+ingress:
+  enabled: true
+  hostname: web.example.com # primary host
+service:
+  type: NodePort # exposed on every node
+`
+	if string(got) != want {
+		t.Errorf("ApplyRenames() =\n%s\nwant:\n%s", got, want)
+	}
+
+	unchanged, applied, err := ApplyRenames(data, []Rename{{From: "missing", To: "other"}})
+	if err != nil || applied != nil || string(unchanged) != string(data) {
+		t.Errorf("expected the document to be unchanged, got %q, %v, %v", unchanged, applied, err)
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	provided := map[string]interface{}{
+		"ingress":     map[string]interface{}{"hosts": []interface{}{"web.example.com"}},
+		"serviceType": "NodePort",
+	}
+	renames := []Rename{
+		{From: "ingress.hosts[0]", To: "ingress.hostname"},
+		{From: "ingress.hosts[1]", To: "ingress.extraHost"},
+		{From: "serviceType", To: "service.type"},
+	}
+
+	r := &recordingReporter{}
+	if !CheckRenames(renames, provided, Options{}, r) {
+		t.Errorf("expected renamed keys to be reported as issues")
+	}
+	var paths []string
+	for _, f := range r.findings {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"ingress.hosts[0]", "serviceType"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("renamed paths = %v, want %v", paths, want)
+	}
+}
+
+func TestChartRenames(t *testing.T) {
+	sub := &chart.Chart{Metadata: &chart.Metadata{
+		Name:        "redis",
+		Annotations: map[string]string{RenamesAnnotation: "- from: password\n  to: auth.password\n"},
+	}}
+	c := &chart.Chart{Metadata: &chart.Metadata{
+		Name:        "web_service",
+		Annotations: map[string]string{RenamesAnnotation: "- from: serviceType\n  to: service.type\n"},
+	}}
+	c.AddDependency(sub)
+
+	renames, err := ChartRenames(c)
+	if err != nil {
+		t.Fatalf("ChartRenames() returned error: %v", err)
+	}
+	want := []Rename{{From: "serviceType", To: "service.type"}, {From: "redis.password", To: "redis.auth.password"}}
+	if !reflect.DeepEqual(renames, want) {
+		t.Errorf("ChartRenames() = %v, want %v", renames, want)
+	}
+}
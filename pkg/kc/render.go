@@ -0,0 +1,109 @@
+package kc
+
+import (
+	"fmt"
+	"regexp"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// RenderOptions controls how templates are rendered.
+type RenderOptions struct {
+	// ReleaseName and Namespace populate .Release. Defaults are used when empty.
+	ReleaseName string
+	Namespace   string
+	// Capabilities populate .Capabilities. chartutil.DefaultCapabilities is used when nil.
+	Capabilities *chartutil.Capabilities
+}
+
+// RenderChart renders the templates of c with the provided values the same way
+// `helm template` does, returning the rendered files keyed by template path.
+func RenderChart(c *chart.Chart, provided map[string]interface{}, opts RenderOptions) (map[string]string, error) {
+	// Dependency processing mutates the chart, so it works on a copy that can be discarded.
+	clone := cloneChart(c)
+	if err := chartutil.ProcessDependenciesWithMerge(clone, provided); err != nil {
+		return nil, err
+	}
+
+	releaseOptions := chartutil.ReleaseOptions{
+		Name:      opts.ReleaseName,
+		Namespace: opts.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	if releaseOptions.Name == "" {
+		releaseOptions.Name = "release-name"
+	}
+	if releaseOptions.Namespace == "" {
+		releaseOptions.Namespace = "default"
+	}
+
+	values, err := chartutil.ToRenderValuesWithSchemaValidation(clone, provided, releaseOptions, opts.Capabilities, true)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Render(clone, values)
+}
+
+// NewCapabilities returns the default capabilities adjusted to the given Kubernetes version
+// and additional API versions, like `helm template --kube-version --api-versions`.
+func NewCapabilities(kubeVersion string, apiVersions []string) (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	if kubeVersion != "" {
+		kv, err := chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %q: %w", kubeVersion, err)
+		}
+		caps.KubeVersion = *kv
+	}
+	caps.APIVersions = append(append(chartutil.VersionSet{}, caps.APIVersions...), apiVersions...)
+	return caps, nil
+}
+
+// templatePattern matches the template named by a render error, such as
+// "execution error at (web_service/templates/deployment.yaml:3:4): ...".
+var templatePattern = regexp.MustCompile(`[^\s:()"]+/templates/[^\s:()"]+`)
+
+// failedTemplate returns the name of the template that err of rendering c names, so the
+// finding has a path baselines and suppressions can target, or the name of c if it names none.
+func failedTemplate(c *chart.Chart, err error) string {
+	return templateOr(err, c.Name())
+}
+
+// templateOr returns the name of the template that err names, or fallback if it names none.
+func templateOr(err error, fallback string) string {
+	if name := templatePattern.FindString(err.Error()); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// CheckRender renders c with the provided values and reports rendering failures, including
+// incompatibilities with the chart's kubeVersion constraint. It returns the rendered files
+// and true if any failing issues were found.
+func CheckRender(c *chart.Chart, provided map[string]interface{}, opts Options, r Reporter) (map[string]string, bool) {
+	v := &validator{opts: opts, reporter: r}
+
+	caps := opts.Render.Capabilities
+	if caps == nil {
+		caps = chartutil.DefaultCapabilities
+	}
+	if c.Metadata != nil && c.Metadata.KubeVersion != "" && !chartutil.IsCompatibleRange(c.Metadata.KubeVersion, caps.KubeVersion.String()) {
+		v.report(Finding{
+			RuleID:  RuleRenderError,
+			Message: fmt.Sprintf("Render error: chart requires kubeVersion: %s which is incompatible with Kubernetes %s", c.Metadata.KubeVersion, caps.KubeVersion.String()),
+		})
+	}
+
+	rendered, err := RenderChart(c, provided, opts.Render)
+	if err != nil {
+		v.report(Finding{
+			RuleID:  RuleRenderError,
+			Path:    failedTemplate(c, err),
+			Message: fmt.Sprintf("Render error: %v", err),
+		})
+	}
+	return rendered, v.issuesFound
+}
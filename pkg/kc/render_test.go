@@ -0,0 +1,70 @@
+package kc
+
+import (
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestCheckRender(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0", KubeVersion: ">=1.25.0-0"},
+		Values:   map[string]interface{}{"replicaCount": 1},
+		Templates: []*chart.File{{
+			Name: "templates/deployment.yaml",
+			Data: []byte(`replicas: {{ .Values.replicaCount | int }}
+{{- if .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" }}
+monitoring: true
+{{- end }}
+`),
+		}},
+	}
+
+	caps, err := NewCapabilities("1.30.0", []string{"monitoring.coreos.com/v1"})
+	if err != nil {
+		t.Fatalf("NewCapabilities() returned error: %v", err)
+	}
+	r := &recordingReporter{}
+	rendered, failed := CheckRender(c, nil, Options{Render: RenderOptions{Capabilities: caps}}, r)
+	if failed || len(r.findings) != 0 {
+		t.Fatalf("expected chart to render, got %+v", r.findings)
+	}
+	if !strings.Contains(rendered["web_service/templates/deployment.yaml"], "monitoring: true") {
+		t.Errorf("expected --api-versions to be honored, got %q", rendered)
+	}
+
+	caps, _ = NewCapabilities("1.20.0", nil)
+	c.Templates[0].Data = []byte(`{{ .Values.missing.key }}`)
+	r = &recordingReporter{}
+	if _, failed := CheckRender(c, nil, Options{Render: RenderOptions{Capabilities: caps}}, r); !failed {
+		t.Fatalf("expected render to fail")
+	}
+	if len(r.findings) != 2 {
+		t.Errorf("expected kubeVersion and template errors, got %+v", r.findings)
+	}
+}
+
+// TestCheckRenderFail verifies that explicit fail calls are reported with the failed template
+// as their path.
+func TestCheckRenderFail(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values:   map[string]interface{}{"mode": "broken"},
+		Templates: []*chart.File{{
+			Name: "templates/check.yaml",
+			Data: []byte(`{{- if eq .Values.mode "broken" }}{{ fail "mode must not be broken" }}{{ end }}`),
+		}},
+	}
+
+	r := &recordingReporter{}
+	if _, failed := CheckRender(c, nil, Options{}, r); !failed {
+		t.Fatalf("expected fail call to be reported")
+	}
+	if len(r.findings) != 1 || r.findings[0].RuleID != RuleRenderError || r.findings[0].Path != "web_service/templates/check.yaml" {
+		t.Errorf("unexpected findings: %+v", r.findings)
+	}
+	if issuesFound, err := CheckRequiredValues(c, nil, Options{}, &recordingReporter{}); err != nil || issuesFound {
+		t.Errorf("expected fail calls to be left to CheckRender, got issuesFound=%v err=%v", issuesFound, err)
+	}
+}
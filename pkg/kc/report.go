@@ -0,0 +1,109 @@
+package kc
+
+import "strings"
+
+// Finding is a single issue detected while validating provided values against chart defaults.
+type Finding struct {
+	RuleID   string      `json:"ruleId"`
+	Severity Severity    `json:"severity"`
+	Path     string      `json:"path"`
+	Message  string      `json:"message"`
+	Default  interface{} `json:"default,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	// Files are the values files that were merged to produce the provided values.
+	Files []string `json:"files,omitempty"`
+	// Line and Column locate findings about the text of the last of Files rather than a key,
+	// such as YAML syntax problems. They are 1-based, and 0 if unset.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// quoted holds the values the message quotes besides Default and Value, which are masked
+	// like them before the finding is reported.
+	quoted []interface{}
+}
+
+// Reporter receives findings as they are discovered and renders them in some output format.
+// Summary is called once after all validations have completed.
+type Reporter interface {
+	Report(f Finding)
+	Summary()
+}
+
+// Collector is a Reporter that keeps every finding in memory.
+type Collector struct {
+	Findings []Finding
+}
+
+func (c *Collector) Report(f Finding) {
+	c.Findings = append(c.Findings, f)
+}
+
+func (c *Collector) Summary() {}
+
+// findingGroup holds the findings of one values set.
+type findingGroup struct {
+	Files    []string
+	Findings []Finding
+	// Counts holds the number of findings per severity.
+	Counts map[string]int
+}
+
+// findingGroups collects findings per values set, in the order the sets were first reported,
+// for reporters that render the whole report at once.
+type findingGroups struct {
+	groups []*findingGroup
+	index  map[string]*findingGroup
+	counts map[string]int
+}
+
+// title names the values set of the group in Markdown.
+func (g *findingGroup) title() string {
+	if len(g.Files) == 0 {
+		return "Values"
+	}
+	return "`" + strings.Join(g.Files, "` + `") + "`"
+}
+
+func (g *findingGroups) add(f Finding) {
+	if g.index == nil {
+		g.index = map[string]*findingGroup{}
+		g.counts = map[string]int{}
+	}
+	key := strings.Join(f.Files, "\x00")
+	group, ok := g.index[key]
+	if !ok {
+		group = &findingGroup{Files: f.Files, Counts: map[string]int{}}
+		g.index[key] = group
+		g.groups = append(g.groups, group)
+	}
+	group.Findings = append(group.Findings, f)
+	group.Counts[string(f.Severity)]++
+	g.counts[string(f.Severity)]++
+}
+
+// MultiReporter forwards findings to several reporters, e.g. to print a report and write
+// another one to a file in the same run.
+type MultiReporter []Reporter
+
+func (m MultiReporter) Report(f Finding) {
+	for _, r := range m {
+		r.Report(f)
+	}
+}
+
+func (m MultiReporter) Summary() {
+	for _, r := range m {
+		r.Summary()
+	}
+}
+
+// SeverityFilter forwards the findings at or above Min to Reporter and drops the others.
+type SeverityFilter struct {
+	Reporter
+	Min Severity
+}
+
+func (r SeverityFilter) Report(f Finding) {
+	if f.Severity.AtLeast(r.Min) {
+		r.Reporter.Report(f)
+	}
+}
@@ -0,0 +1,45 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AzureReporter prints findings as Azure Pipelines logging commands, so errors and warnings
+// are listed on the run summary with the location of the key.
+type AzureReporter struct {
+	w       io.Writer
+	locator *Locator
+}
+
+// NewAzureReporter returns an AzureReporter writing to w.
+func NewAzureReporter(w io.Writer) *AzureReporter {
+	return &AzureReporter{w: w, locator: NewLocator()}
+}
+
+func (r *AzureReporter) Report(f Finding) {
+	// Azure Pipelines only knows errors and warnings; info findings are logged as plain lines.
+	if f.Severity != SeverityError && f.Severity != SeverityWarning {
+		fmt.Fprintf(r.w, "%s: %s\n", f.RuleID, f.Message)
+		return
+	}
+	properties := "type=" + string(f.Severity)
+	if loc, ok := r.locator.Locate(f); ok {
+		properties += fmt.Sprintf(";sourcepath=%s;linenumber=%d", azureEscapeProperty(relativeToWorkDir(loc.File)), loc.Line)
+	}
+	properties += ";code=" + azureEscapeProperty(f.RuleID)
+	fmt.Fprintf(r.w, "##vso[task.logissue %s;]%s\n", properties, azureEscapeMessage(f.Message))
+}
+
+func (r *AzureReporter) Summary() {}
+
+// azureEscapeMessage escapes s for the message of a logging command.
+func azureEscapeMessage(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// azureEscapeProperty escapes s for a property value of a logging command.
+func azureEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", ";", "%3B", "]", "%5D").Replace(s)
+}
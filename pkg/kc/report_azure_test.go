@@ -0,0 +1,29 @@
+package kc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAzureReporter verifies that errors and warnings are printed as located logging commands
+// and that info findings are printed as plain lines.
+func TestAzureReporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "web.yaml")
+	os.WriteFile(file, []byte("replicas: 1\nport: \"80\"\n"), 0o644)
+
+	var out bytes.Buffer
+	r := NewAzureReporter(&out)
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Type mismatch for 'port': expected int, got string", Files: []string{file}})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "missing", Message: "100% redundant\nreally"})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityInfo, Path: "replicas", Message: "Redundant value", Files: []string{file}})
+	r.Summary()
+
+	want := "##vso[task.logissue type=error;sourcepath=" + file + ";linenumber=2;code=KC002;]Type mismatch for 'port': expected int, got string\n" +
+		"##vso[task.logissue type=warning;code=KC001;]100%AZP25 redundant%0Areally\n" +
+		"KC001: Redundant value\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,58 @@
+package kc
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// BuildkiteReporter renders findings as the Markdown of a Buildkite annotation, with a
+// collapsible section per values set.
+type BuildkiteReporter struct {
+	w io.Writer
+	findingGroups
+}
+
+// NewBuildkiteReporter returns a BuildkiteReporter writing to w.
+func NewBuildkiteReporter(w io.Writer) *BuildkiteReporter {
+	return &BuildkiteReporter{w: w}
+}
+
+func (r *BuildkiteReporter) Report(f Finding) {
+	r.add(f)
+}
+
+func (r *BuildkiteReporter) Summary() {
+	if len(r.groups) == 0 {
+		fmt.Fprintf(r.w, "KaartControle: no issues found.\n")
+		return
+	}
+	fmt.Fprintf(r.w, "KaartControle found %d errors, %d warnings, and %d info in %d values sets.\n",
+		r.counts[string(SeverityError)], r.counts[string(SeverityWarning)], r.counts[string(SeverityInfo)], len(r.groups))
+	for _, group := range r.groups {
+		title := "Values"
+		if len(group.Files) > 0 {
+			files := make([]string, len(group.Files))
+			for i, file := range group.Files {
+				files[i] = "<code>" + html.EscapeString(file) + "</code>"
+			}
+			title = strings.Join(files, " + ")
+		}
+		// Markdown is not rendered inside of the summary element.
+		fmt.Fprintf(r.w, "\n<details>\n<summary>%s: %d findings</summary>\n\n", title, len(group.Findings))
+		writeMarkdownTable(r.w, group.Findings)
+		fmt.Fprintf(r.w, "\n</details>\n")
+	}
+}
+
+// Style returns the annotation style matching the most severe finding: error, warning, info,
+// or success without findings.
+func (r *BuildkiteReporter) Style() string {
+	for _, sev := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if r.counts[string(sev)] > 0 {
+			return string(sev)
+		}
+	}
+	return "success"
+}
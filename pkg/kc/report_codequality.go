@@ -0,0 +1,73 @@
+package kc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// CodeQualityReporter collects findings and writes them as a GitLab Code Quality report on
+// Summary, so merge requests show the findings they introduce or resolve.
+type CodeQualityReporter struct {
+	w       io.Writer
+	locator *Locator
+	issues  []codeQualityIssue
+}
+
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// NewCodeQualityReporter returns a CodeQualityReporter writing to w.
+func NewCodeQualityReporter(w io.Writer) *CodeQualityReporter {
+	return &CodeQualityReporter{w: w, locator: NewLocator()}
+}
+
+func (r *CodeQualityReporter) Report(f Finding) {
+	severity := "info"
+	switch f.Severity {
+	case SeverityError:
+		severity = "major"
+	case SeverityWarning:
+		severity = "minor"
+	}
+	// Issues need a file; findings that cannot be located are attached to the last one.
+	var location codeQualityLocation
+	location.Lines.Begin = 1
+	if loc, ok := r.locator.Locate(f); ok {
+		location.Path, location.Lines.Begin = relativeToWorkDir(loc.File), loc.Line
+	} else if len(f.Files) > 0 {
+		location.Path = relativeToWorkDir(f.Files[len(f.Files)-1])
+	}
+	// Like baselines, fingerprints leave out the message, so they survive rewording.
+	sum := sha256.Sum256([]byte(BaselineEntry{RuleID: f.RuleID, Path: f.Path, Files: f.Files}.key()))
+	r.issues = append(r.issues, codeQualityIssue{
+		Description: f.Path + ": " + f.Message,
+		CheckName:   f.RuleID,
+		Fingerprint: hex.EncodeToString(sum[:]),
+		Severity:    severity,
+		Location:    location,
+	})
+}
+
+func (r *CodeQualityReporter) Summary() {
+	issues := r.issues
+	if issues == nil {
+		issues = []codeQualityIssue{}
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(issues)
+}
@@ -0,0 +1,46 @@
+package kc
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCodeQualityReporter verifies that findings are written as located Code Quality issues
+// with fingerprints that do not depend on the message.
+func TestCodeQualityReporter(t *testing.T) {
+	dir := t.TempDir()
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	os.WriteFile(filepath.Join(dir, "web.yaml"), []byte("replicas: 1\nport: \"80\"\n"), 0o644)
+	file := filepath.Join(dir, "web.yaml")
+
+	var out bytes.Buffer
+	r := NewCodeQualityReporter(&out)
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Type mismatch", Files: []string{file}})
+	r.Report(Finding{RuleID: RuleMissingRequired, Severity: SeverityWarning, Path: "image", Message: "Missing", Files: []string{file}})
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Reworded", Files: []string{file}})
+	r.Summary()
+
+	var issues []codeQualityIssue
+	if err := json.Unmarshal(out.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out.String())
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %d", len(issues))
+	}
+	if issues[0].Location.Path != "web.yaml" || issues[0].Location.Lines.Begin != 2 || issues[0].Severity != "major" || issues[0].CheckName != "KC002" {
+		t.Errorf("unexpected issue: %+v", issues[0])
+	}
+	if issues[1].Location.Path != "web.yaml" || issues[1].Location.Lines.Begin != 1 || issues[1].Severity != "minor" {
+		t.Errorf("expected the unlocated issue at the top of the file, got %+v", issues[1])
+	}
+	if issues[0].Fingerprint != issues[2].Fingerprint || issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Errorf("unexpected fingerprints: %s, %s, %s", issues[0].Fingerprint, issues[1].Fingerprint, issues[2].Fingerprint)
+	}
+}
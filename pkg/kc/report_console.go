@@ -0,0 +1,242 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// GroupBy selects how ConsoleReporter groups findings.
+type GroupBy string
+
+const (
+	// GroupByNone prints every finding as soon as it is reported.
+	GroupByNone GroupBy = ""
+	// GroupByEnvironment groups findings per values set.
+	GroupByEnvironment GroupBy = "environment"
+	// GroupByFile groups findings per values file that sets their key.
+	GroupByFile GroupBy = "file"
+	// GroupByRule groups findings per rule.
+	GroupByRule GroupBy = "rule"
+)
+
+// ParseGroupBy converts s into a GroupBy; "none" disables grouping.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch g := GroupBy(s); g {
+	case GroupByEnvironment, GroupByFile, GroupByRule:
+		return g, nil
+	case "none", GroupByNone:
+		return GroupByNone, nil
+	}
+	return "", fmt.Errorf("unknown grouping: %q (expected environment, file, rule, or none)", s)
+}
+
+// ConsoleReporter prints findings as human-readable lines, grouped under a heading per
+// group unless GroupBy is GroupByNone, followed by a summary table.
+type ConsoleReporter struct {
+	// GroupBy selects the grouping; it must be set before the first finding is reported.
+	GroupBy GroupBy
+	// Quiet prints only the line summing up the findings.
+	Quiet bool
+	// Color colors findings and the summary line by severity.
+	Color bool
+	// ASCII prints plain text severity markers instead of emoji, for terminals and log
+	// systems that mangle them.
+	ASCII bool
+
+	w       io.Writer
+	counts  map[Severity]int
+	locator *Locator
+	// groups holds the buffered findings per group heading, in the order they were first seen.
+	groups  []string
+	grouped map[string][]string
+	// rules and envs count findings per severity for the summary table.
+	rules map[string]map[Severity]int
+	envs  map[string]map[Severity]int
+	order []string
+}
+
+// NewConsoleReporter returns a ConsoleReporter writing to w.
+func NewConsoleReporter(w io.Writer) *ConsoleReporter {
+	return &ConsoleReporter{
+		w:       w,
+		counts:  map[Severity]int{},
+		locator: NewLocator(),
+		grouped: map[string][]string{},
+		rules:   map[string]map[Severity]int{},
+		envs:    map[string]map[Severity]int{},
+	}
+}
+
+func (r *ConsoleReporter) Report(f Finding) {
+	r.counts[f.Severity]++
+	env := environmentName(f.Files)
+	if r.envs[env] == nil {
+		r.envs[env] = map[Severity]int{}
+		r.order = append(r.order, env)
+	}
+	r.envs[env][f.Severity]++
+	if r.rules[f.RuleID] == nil {
+		r.rules[f.RuleID] = map[Severity]int{}
+	}
+	r.rules[f.RuleID][f.Severity]++
+	if r.Quiet {
+		return
+	}
+
+	message := f.Message
+	var heading string
+	switch r.GroupBy {
+	case GroupByNone:
+		fmt.Fprintln(r.w, r.line(f.Severity, message))
+		return
+	case GroupByEnvironment:
+		heading = env
+	case GroupByRule:
+		heading = f.RuleID
+		if rule, ok := LookupRule(f.RuleID); ok {
+			heading += " " + rule.Name
+		}
+		message += " (" + env + ")"
+	case GroupByFile:
+		// Files like overrides.yaml are shared between values sets, so the set is named as well.
+		if loc, ok := r.locator.Locate(f); ok {
+			heading = loc.File
+			message = fmt.Sprintf("line %d: %s (%s)", loc.Line, message, env)
+		} else if len(f.Files) > 0 {
+			heading = f.Files[len(f.Files)-1]
+			message += " (" + env + ")"
+		} else {
+			heading = env
+		}
+	}
+	if _, ok := r.grouped[heading]; !ok {
+		r.groups = append(r.groups, heading)
+	}
+	r.grouped[heading] = append(r.grouped[heading], "  "+r.line(f.Severity, message))
+}
+
+func (r *ConsoleReporter) Summary() {
+	if r.Quiet {
+		r.printTotals()
+		return
+	}
+	for i, heading := range r.groups {
+		if i > 0 {
+			fmt.Fprintln(r.w)
+		}
+		fmt.Fprintf(r.w, "%s\n%s\n", heading, strings.Join(r.grouped[heading], "\n"))
+	}
+	if len(r.counts) > 0 {
+		r.printTable()
+	}
+	fmt.Fprintln(r.w)
+	r.printTotals()
+}
+
+// printTable prints the numbers of findings per rule and per environment.
+func (r *ConsoleReporter) printTable() {
+	fmt.Fprintln(r.w)
+	tw := tabwriter.NewWriter(r.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tERRORS\tWARNINGS\tINFO")
+	ids := make([]string, 0, len(r.rules))
+	for id := range r.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		name := id
+		if rule, ok := LookupRule(id); ok {
+			name += " " + rule.Name
+		}
+		writeCounts(tw, name, r.rules[id])
+	}
+	tw.Flush()
+	fmt.Fprintln(r.w)
+	fmt.Fprintln(tw, "ENVIRONMENT\tERRORS\tWARNINGS\tINFO")
+	for _, env := range r.order {
+		writeCounts(tw, env, r.envs[env])
+	}
+	tw.Flush()
+}
+
+// printTotals prints the line summing up the findings.
+func (r *ConsoleReporter) printTotals() {
+	line, color := "Validation completed: No issues found.", ansiGreen
+	if len(r.counts) > 0 {
+		line = fmt.Sprintf("Validation completed: Issues were found (%d errors, %d warnings, %d info).",
+			r.counts[SeverityError], r.counts[SeverityWarning], r.counts[SeverityInfo])
+		color = severityColor(r.worst())
+	}
+	fmt.Fprintln(r.w, r.colored(color, line))
+}
+
+// worst returns the highest severity of the reported findings.
+func (r *ConsoleReporter) worst() Severity {
+	for _, sev := range []Severity{SeverityError, SeverityWarning} {
+		if r.counts[sev] > 0 {
+			return sev
+		}
+	}
+	return SeverityInfo
+}
+
+// writeCounts writes a row of the summary table.
+func writeCounts(w io.Writer, name string, counts map[Severity]int) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", name, counts[SeverityError], counts[SeverityWarning], counts[SeverityInfo])
+}
+
+// ANSI escape sequences used to color findings.
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+// line prefixes message with the marker of sev.
+func (r *ConsoleReporter) line(sev Severity, message string) string {
+	var marker string
+	switch {
+	case r.ASCII:
+		marker = "[" + strings.ToUpper(string(sev)) + "] "
+	case sev == SeverityError:
+		marker = severityIcon(sev) + " "
+	default:
+		// The warning and info emoji are followed by a variation selector and take up one
+		// column less.
+		marker = severityIcon(sev) + "  "
+	}
+	return r.colored(severityColor(sev), marker+message)
+}
+
+// colored wraps s in the ANSI color if colors are enabled.
+func (r *ConsoleReporter) colored(color, s string) string {
+	if !r.Color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// severityColor returns the ANSI color of sev.
+func severityColor(sev Severity) string {
+	switch sev {
+	case SeverityError:
+		return ansiRed
+	case SeverityWarning:
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
+
+// environmentName names the values set of the given files.
+func environmentName(files []string) string {
+	if len(files) == 0 {
+		return "values"
+	}
+	return strings.Join(files, " + ")
+}
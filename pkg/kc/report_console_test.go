@@ -0,0 +1,73 @@
+package kc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConsoleReporterGroupBy verifies the grouping of findings and the summary tables.
+func TestConsoleReporterGroupBy(t *testing.T) {
+	dir := t.TempDir()
+	overrides := filepath.Join(dir, "overrides.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	os.WriteFile(overrides, []byte("image:\n  tag: v1\n"), 0o644)
+	os.WriteFile(prod, []byte("replicas: 1\nport: \"80\"\n"), 0o644)
+	findings := []Finding{
+		{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "image.tag", Message: "tag is redundant", Files: []string{overrides}},
+		{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "image.tag", Message: "tag is redundant", Files: []string{overrides, prod}},
+		{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "port is a string", Files: []string{overrides, prod}},
+	}
+	env := overrides + " + " + prod
+	summary := "\n" +
+		"RULE                   ERRORS  WARNINGS  INFO\n" +
+		"KC001 redundant-value  0       2         0\n" +
+		"KC002 type-mismatch    1       0         0\n" +
+		"\n" +
+		"ENVIRONMENT" + strings.Repeat(" ", len(env)-len("ENVIRONMENT")) + "  ERRORS  WARNINGS  INFO\n" +
+		overrides + strings.Repeat(" ", len(env)-len(overrides)) + "  0       1         0\n" +
+		env + "  1       1         0\n" +
+		"\nValidation completed: Issues were found (1 errors, 2 warnings, 0 info).\n"
+
+	for groupBy, want := range map[GroupBy]string{
+		GroupByNone: "⚠️  tag is redundant\n⚠️  tag is redundant\n❌ port is a string\n",
+		GroupByEnvironment: overrides + "\n  ⚠️  tag is redundant\n\n" +
+			env + "\n  ⚠️  tag is redundant\n  ❌ port is a string\n",
+		GroupByRule: "KC001 redundant-value\n  ⚠️  tag is redundant (" + overrides + ")\n  ⚠️  tag is redundant (" + env + ")\n\n" +
+			"KC002 type-mismatch\n  ❌ port is a string (" + env + ")\n",
+		GroupByFile: overrides + "\n  ⚠️  line 2: tag is redundant (" + overrides + ")\n  ⚠️  line 2: tag is redundant (" + env + ")\n\n" +
+			prod + "\n  ❌ line 2: port is a string (" + env + ")\n",
+	} {
+		var out bytes.Buffer
+		r := NewConsoleReporter(&out)
+		r.GroupBy = groupBy
+		for _, f := range findings {
+			r.Report(f)
+		}
+		r.Summary()
+		if out.String() != want+summary {
+			t.Errorf("group by %q: expected:\n%s\ngot:\n%s", groupBy, want+summary, out.String())
+		}
+	}
+}
+
+// TestConsoleReporterStyle verifies the plain text markers and the colors by severity.
+func TestConsoleReporterStyle(t *testing.T) {
+	var out bytes.Buffer
+	r := NewConsoleReporter(&out)
+	r.GroupBy, r.ASCII, r.Color = GroupByEnvironment, true, true
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "image.tag", Message: "tag is redundant"})
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "port is a string"})
+	r.Summary()
+	for _, want := range []string{
+		"\n  \033[33m[WARNING] tag is redundant\033[0m\n",
+		"\n  \033[31m[ERROR] port is a string\033[0m\n",
+		"\n\033[31mValidation completed: Issues were found (1 errors, 1 warnings, 0 info).\033[0m\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
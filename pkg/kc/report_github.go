@@ -0,0 +1,51 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubReporter prints findings as GitHub Actions workflow commands, so they are shown as
+// annotations on the lines of the pull request and the run summary.
+type GitHubReporter struct {
+	w       io.Writer
+	locator *Locator
+}
+
+// NewGitHubReporter returns a GitHubReporter writing to w.
+func NewGitHubReporter(w io.Writer) *GitHubReporter {
+	return &GitHubReporter{w: w, locator: NewLocator()}
+}
+
+func (r *GitHubReporter) Report(f Finding) {
+	command := "notice"
+	switch f.Severity {
+	case SeverityError:
+		command = "error"
+	case SeverityWarning:
+		command = "warning"
+	}
+	var properties []string
+	if loc, ok := r.locator.Locate(f); ok {
+		properties = append(properties,
+			"file="+gitHubEscapeProperty(relativeToWorkDir(loc.File)),
+			fmt.Sprintf("line=%d", loc.Line),
+			fmt.Sprintf("col=%d", loc.Column),
+		)
+	}
+	properties = append(properties, "title="+gitHubEscapeProperty(f.RuleID))
+	fmt.Fprintf(r.w, "::%s %s::%s\n", command, strings.Join(properties, ","), gitHubEscapeMessage(f.Message))
+}
+
+func (r *GitHubReporter) Summary() {}
+
+// gitHubEscapeMessage escapes s for the message of a workflow command.
+func gitHubEscapeMessage(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// gitHubEscapeProperty escapes s for a property value of a workflow command.
+func gitHubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
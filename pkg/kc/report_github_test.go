@@ -0,0 +1,29 @@
+package kc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGitHubReporter verifies that findings are printed as workflow commands of their severity,
+// located on the key if possible.
+func TestGitHubReporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "web.yaml")
+	os.WriteFile(file, []byte("replicas: 1\nport: \"80\"\n"), 0o644)
+
+	var out bytes.Buffer
+	r := NewGitHubReporter(&out)
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Type mismatch for 'port': expected int, got string", Files: []string{file}})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "missing", Message: "100% redundant\nreally"})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityInfo, Path: "replicas", Message: "Redundant value", Files: []string{file}})
+	r.Summary()
+
+	want := "::error file=" + gitHubEscapeProperty(file) + ",line=2,col=1,title=KC002::Type mismatch for 'port': expected int, got string\n" +
+		"::warning title=KC001::100%25 redundant%0Areally\n" +
+		"::notice file=" + gitHubEscapeProperty(file) + ",line=1,col=1,title=KC001::Redundant value\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
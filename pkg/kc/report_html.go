@@ -0,0 +1,99 @@
+package kc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// HTMLReporter renders findings as a standalone HTML page, with a collapsible section per
+// values set, for publishing as a CI artifact.
+type HTMLReporter struct {
+	w io.Writer
+	findingGroups
+}
+
+// NewHTMLReporter returns an HTMLReporter writing to w.
+func NewHTMLReporter(w io.Writer) *HTMLReporter {
+	return &HTMLReporter{w: w}
+}
+
+func (r *HTMLReporter) Report(f Finding) {
+	r.add(f)
+}
+
+func (r *HTMLReporter) Summary() {
+	_ = htmlTemplate.Execute(r.w, struct {
+		Sections []*findingGroup
+		Counts   map[string]int
+	}{r.groups, r.counts})
+}
+
+// formatValue renders a default or provided value of a finding, or nothing if it is unset.
+func formatValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// linkable reports whether name is a local values file or an http(s) URL that can be linked,
+// rather than e.g. a Helm release ("release:ns/name").
+func linkable(name string) bool {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return true
+	}
+	colon := strings.IndexByte(name, ':')
+	return colon < 0 || strings.ContainsRune(name[:colon], '/')
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"value":    formatValue,
+	"linkable": linkable,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>KaartControle report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.5em; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin-bottom: 1em; }
+summary { cursor: pointer; padding: .6em 1em; background: #f6f8fa; font-weight: 600; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .4em 1em; border-top: 1px solid #d0d7de; vertical-align: top; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .9em; }
+.badge { display: inline-block; border-radius: 1em; padding: 0 .6em; font-size: .85em; font-weight: 600; color: #fff; }
+.error { background: #cf222e; }
+.warning { background: #bf8700; }
+.info { background: #0969da; }
+.files { font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>KaartControle report</h1>
+{{- if .Sections}}
+<p>Issues were found: <span class="badge error">{{index .Counts "error"}} errors</span> <span class="badge warning">{{index .Counts "warning"}} warnings</span> <span class="badge info">{{index .Counts "info"}} info</span></p>
+{{- range .Sections}}
+<details open>
+<summary>
+{{- range $i, $file := .Files}}{{if $i}} + {{end}}{{if linkable $file}}<a class="files" href="{{$file}}">{{$file}}</a>{{else}}{{$file}}{{end}}{{else}}Values{{end}}
+{{- with index .Counts "error"}} <span class="badge error">{{.}}</span>{{end}}
+{{- with index .Counts "warning"}} <span class="badge warning">{{.}}</span>{{end}}
+{{- with index .Counts "info"}} <span class="badge info">{{.}}</span>{{end}}
+</summary>
+<table>
+<tr><th>Severity</th><th>Rule</th><th>Path</th><th>Message</th><th>Default</th><th>Value</th></tr>
+{{- range .Findings}}
+<tr><td><span class="badge {{.Severity}}">{{.Severity}}</span></td><td>{{.RuleID}}</td><td><code>{{.Path}}</code></td><td>{{.Message}}</td><td><code>{{value .Default}}</code></td><td><code>{{value .Value}}</code></td></tr>
+{{- end}}
+</table>
+</details>
+{{- end}}
+{{- else}}
+<p>No issues found.</p>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,47 @@
+package kc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestHTMLReporter verifies that findings are grouped per values set with severity badges,
+// file links, and escaped messages.
+func TestHTMLReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewHTMLReporter(&out)
+	prod := []string{"overrides.yaml", "prod/web.yaml"}
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "replicas", Message: "Redundant value: 'replicas' matches default value: 1", Default: 1, Value: 1, Files: prod})
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Type mismatch for 'port': expected <int>", Files: prod})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "image", Message: "Redundant value", Files: []string{"release:default/web"}})
+	r.Summary()
+
+	html := out.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<a class="files" href="overrides.yaml">overrides.yaml</a> + <a class="files" href="prod/web.yaml">prod/web.yaml</a>`,
+		`<span class="badge error">1 errors</span> <span class="badge warning">2 warnings</span> <span class="badge info">0 info</span>`,
+		`<span class="badge error">1</span> <span class="badge warning">1</span>`,
+		`<summary>release:default/web <span class="badge warning">1</span>`,
+		"expected &lt;int&gt;",
+		"<td>KC002</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, html)
+		}
+	}
+	if strings.Count(html, "<details open>") != 2 {
+		t.Errorf("expected 2 sections, got:\n%s", html)
+	}
+}
+
+// TestHTMLReporterNoFindings verifies the report of a run without findings.
+func TestHTMLReporterNoFindings(t *testing.T) {
+	var out bytes.Buffer
+	r := NewHTMLReporter(&out)
+	r.Summary()
+	if !strings.Contains(out.String(), "<p>No issues found.</p>") {
+		t.Errorf("expected no issues, got:\n%s", out.String())
+	}
+}
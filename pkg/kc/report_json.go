@@ -0,0 +1,33 @@
+package kc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONReporter collects findings and writes them as a single JSON document on Summary.
+type JSONReporter struct {
+	w        io.Writer
+	findings []Finding
+}
+
+// NewJSONReporter returns a JSONReporter writing to w.
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{w: w}
+}
+
+func (r *JSONReporter) Report(f Finding) {
+	r.findings = append(r.findings, f)
+}
+
+func (r *JSONReporter) Summary() {
+	findings := r.findings
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(struct {
+		Findings []Finding `json:"findings"`
+	}{findings})
+}
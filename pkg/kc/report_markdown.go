@@ -0,0 +1,64 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarkdownReporter renders findings as Markdown tables, one per values set, to be posted as a
+// pull request comment or appended to a GitHub Actions job summary ($GITHUB_STEP_SUMMARY).
+type MarkdownReporter struct {
+	w io.Writer
+	findingGroups
+}
+
+// NewMarkdownReporter returns a MarkdownReporter writing to w.
+func NewMarkdownReporter(w io.Writer) *MarkdownReporter {
+	return &MarkdownReporter{w: w}
+}
+
+func (r *MarkdownReporter) Report(f Finding) {
+	r.add(f)
+}
+
+func (r *MarkdownReporter) Summary() {
+	fmt.Fprintf(r.w, "## KaartControle report\n\n")
+	if len(r.groups) == 0 {
+		fmt.Fprintf(r.w, "✅ No issues found.\n")
+		return
+	}
+	fmt.Fprintf(r.w, "Issues were found: %d errors, %d warnings, %d info.\n",
+		r.counts[string(SeverityError)], r.counts[string(SeverityWarning)], r.counts[string(SeverityInfo)])
+	for _, group := range r.groups {
+		fmt.Fprintf(r.w, "\n### %s\n\n", group.title())
+		writeMarkdownTable(r.w, group.Findings)
+	}
+}
+
+// writeMarkdownTable writes findings as a Markdown table.
+func writeMarkdownTable(w io.Writer, findings []Finding) {
+	fmt.Fprintf(w, "| Severity | Rule | Path | Message |\n| --- | --- | --- | --- |\n")
+	for _, f := range findings {
+		fmt.Fprintf(w, "| %s %s | %s | `%s` | %s |\n",
+			severityIcon(f.Severity), f.Severity, f.RuleID, f.Path, markdownCell(f.Message))
+	}
+}
+
+// severityIcon returns the emoji ConsoleReporter prints for sev.
+func severityIcon(sev Severity) string {
+	switch sev {
+	case SeverityError:
+		return "❌"
+	case SeverityWarning:
+		return "⚠️"
+	default:
+		return "ℹ️"
+	}
+}
+
+// markdownCell escapes s for a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,33 @@
+package kc
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestMarkdownReporter verifies that findings are rendered as a table per values set.
+func TestMarkdownReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewMarkdownReporter(&out)
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "replicas", Message: "Redundant value: 'replicas' matches default value: 1", Files: []string{"overrides.yaml", "prod/web.yaml"}})
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "expected a|b", Files: []string{"overrides.yaml", "staging/web.yaml"}})
+	r.Summary()
+
+	want := "## KaartControle report\n\n" +
+		"Issues were found: 1 errors, 1 warnings, 0 info.\n\n" +
+		"### `overrides.yaml` + `prod/web.yaml`\n\n" +
+		"| Severity | Rule | Path | Message |\n| --- | --- | --- | --- |\n" +
+		"| ⚠️ warning | KC001 | `replicas` | Redundant value: 'replicas' matches default value: 1 |\n" +
+		"\n### `overrides.yaml` + `staging/web.yaml`\n\n" +
+		"| Severity | Rule | Path | Message |\n| --- | --- | --- | --- |\n" +
+		"| ❌ error | KC002 | `port` | expected a\\|b |\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+
+	out.Reset()
+	NewMarkdownReporter(&out).Summary()
+	if want := "## KaartControle report\n\n✅ No issues found.\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
@@ -0,0 +1,23 @@
+package kc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NDJSONReporter writes every finding as a JSON document on its own line as soon as it is
+// reported, so long runs can be streamed into log processors.
+type NDJSONReporter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONReporter returns an NDJSONReporter writing to w.
+func NewNDJSONReporter(w io.Writer) *NDJSONReporter {
+	return &NDJSONReporter{enc: json.NewEncoder(w)}
+}
+
+func (r *NDJSONReporter) Report(f Finding) {
+	_ = r.enc.Encode(f)
+}
+
+func (r *NDJSONReporter) Summary() {}
@@ -0,0 +1,21 @@
+package kc
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestNDJSONReporter verifies that every finding is written on its own line when reported.
+func TestNDJSONReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewNDJSONReporter(&out)
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "replicas", Message: "Redundant value", Default: 1, Value: 1, Files: []string{"prod/web.yaml"}})
+	if want := `{"ruleId":"KC001","severity":"warning","path":"replicas","message":"Redundant value","default":1,"value":1,"files":["prod/web.yaml"]}` + "\n"; out.String() != want {
+		t.Errorf("expected %s, got %s", want, out.String())
+	}
+	r.Report(Finding{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "port", Message: "Type mismatch"})
+	r.Summary()
+	if want := `{"ruleId":"KC002","severity":"error","path":"port","message":"Type mismatch"}` + "\n"; out.String()[bytes.IndexByte(out.Bytes(), '\n')+1:] != want {
+		t.Errorf("expected %s, got %s", want, out.String())
+	}
+}
@@ -0,0 +1,121 @@
+package kc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string               `json:"id"`
+	Name             string               `json:"name"`
+	ShortDescription sarifMessage         `json:"shortDescription"`
+	Properties       *sarifRuleProperties `json:"properties,omitempty"`
+}
+
+// sarifRuleProperties marks security rules for code scanning services, which rank their
+// alerts by security-severity, a CVSS-like score.
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// SARIFReporter collects findings and writes them as a SARIF 2.1.0 log on Summary.
+type SARIFReporter struct {
+	w       io.Writer
+	results []sarifResult
+}
+
+// NewSARIFReporter returns a SARIFReporter writing to w.
+func NewSARIFReporter(w io.Writer) *SARIFReporter {
+	return &SARIFReporter{w: w}
+}
+
+func (r *SARIFReporter) Report(f Finding) {
+	level := string(f.Severity)
+	if f.Severity == SeverityInfo {
+		level = "note"
+	}
+	r.results = append(r.results, sarifResult{
+		RuleID:  f.RuleID,
+		Level:   level,
+		Message: sarifMessage{Text: f.Message},
+		Locations: []sarifLocation{{
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: f.Path}},
+		}},
+	})
+}
+
+func (r *SARIFReporter) Summary() {
+	results := r.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+	rules := make([]sarifRule, 0, len(Rules))
+	for _, rule := range Rules {
+		sr := sarifRule{
+			ID:               rule.ID,
+			Name:             rule.Name,
+			ShortDescription: sarifMessage{Text: rule.Description},
+		}
+		if rule.Security {
+			sr.Properties = &sarifRuleProperties{Tags: []string{"security"}, SecuritySeverity: "8.0"}
+		}
+		rules = append(rules, sr)
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kaartcontrole",
+				InformationURI: "https://github.com/tiulpin/kaartcontrole",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
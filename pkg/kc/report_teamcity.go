@@ -0,0 +1,92 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TeamCityReporter prints findings as TeamCity inspection service messages, so they show up
+// on the Inspections tab of a build.
+type TeamCityReporter struct {
+	w       io.Writer
+	locator *Locator
+	// types holds the rules whose inspection type was already declared.
+	types map[string]bool
+}
+
+// NewTeamCityReporter returns a TeamCityReporter writing to w.
+func NewTeamCityReporter(w io.Writer) *TeamCityReporter {
+	return &TeamCityReporter{w: w, locator: NewLocator(), types: map[string]bool{}}
+}
+
+func (r *TeamCityReporter) Report(f Finding) {
+	if !r.types[f.RuleID] {
+		r.types[f.RuleID] = true
+		rule, _ := LookupRule(f.RuleID)
+		name := rule.Name
+		if name == "" {
+			name = f.RuleID
+		}
+		fmt.Fprintf(r.w, "##teamcity[inspectionType id='%s' name='%s' category='KaartControle' description='%s']\n",
+			teamCityEscape(f.RuleID), teamCityEscape(name), teamCityEscape(rule.Description))
+	}
+	// Inspections need a file; findings that cannot be located are attached to the first one.
+	file, line := "", 0
+	if loc, ok := r.locator.Locate(f); ok {
+		file, line = relativeToWorkDir(loc.File), loc.Line
+	} else if len(f.Files) > 0 {
+		file = relativeToWorkDir(f.Files[len(f.Files)-1])
+	}
+	msg := fmt.Sprintf("##teamcity[inspection typeId='%s' message='%s' file='%s'", teamCityEscape(f.RuleID), teamCityEscape(f.Path+": "+f.Message), teamCityEscape(file))
+	if line > 0 {
+		msg += fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Fprintf(r.w, "%s SEVERITY='%s']\n", msg, strings.ToUpper(string(f.Severity)))
+}
+
+func (r *TeamCityReporter) Summary() {}
+
+// teamCityEscape escapes s for a value of a service message.
+func teamCityEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '|', '\'', '[', ']':
+			b.WriteRune('|')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString("|n")
+		case '\r':
+			b.WriteString("|r")
+		case '\u0085':
+			b.WriteString("|x")
+		case '\u2028':
+			b.WriteString("|l")
+		case '\u2029':
+			b.WriteString("|p")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// relativeToWorkDir returns path relative to the working directory if it is below it, so CI
+// servers can resolve it against the checkout.
+func relativeToWorkDir(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
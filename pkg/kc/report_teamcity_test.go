@@ -0,0 +1,28 @@
+package kc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTeamCityReporter verifies that an inspection type is declared once per rule and that
+// inspections are located and escaped.
+func TestTeamCityReporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "web.yaml")
+	os.WriteFile(file, []byte("image:\n  tag: v1\n"), 0o644)
+
+	var out bytes.Buffer
+	r := NewTeamCityReporter(&out)
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "image.tag", Message: "Redundant value: 'image.tag' matches default value: v1", Files: []string{file}})
+	r.Report(Finding{RuleID: RuleRedundant, Severity: SeverityError, Path: "replicas", Message: "[x] | y", Files: []string{file}})
+	r.Summary()
+
+	want := "##teamcity[inspectionType id='KC001' name='redundant-value' category='KaartControle' description='Provided value matches the chart default and can be removed.']\n" +
+		"##teamcity[inspection typeId='KC001' message='image.tag: Redundant value: |'image.tag|' matches default value: v1' file='" + file + "' line='2' SEVERITY='WARNING']\n" +
+		"##teamcity[inspection typeId='KC001' message='replicas: |[x|] || y' file='" + file + "' SEVERITY='ERROR']\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package kc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const valuesPathPattern = `\$?\.Values((?:\.[A-Za-z_][A-Za-z0-9_]*)+)`
+
+var (
+	// valuesRefPattern matches .Values.x.y references in templates.
+	valuesRefPattern = regexp.MustCompile(valuesPathPattern)
+	// requiredPatterns match `required "msg" .Values.x` and `.Values.x | required "msg"`.
+	requiredPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`required\s+(?:"(?:[^"\\]|\\.)*"|` + "`[^`]*`" + `)\s+\(?\s*` + valuesPathPattern),
+		regexp.MustCompile(valuesPathPattern + `\s*\|\s*required\b`),
+	}
+)
+
+// templateRef is a values path referenced by a chart template.
+type templateRef struct {
+	path     string
+	template string
+	required bool
+}
+
+// CheckRequiredValues scans the templates of c and its enabled subcharts for values that are
+// required (via `required`) or referenced but have neither a chart default nor a provided value.
+// Explicit `fail` calls can't be attributed to a key statically; CheckRender reports them. It
+// returns true if any failing issues were found.
+func CheckRequiredValues(c *chart.Chart, provided map[string]interface{}, opts Options, r Reporter) (bool, error) {
+	clone := cloneChart(c)
+	if err := chartutil.ProcessDependenciesWithMerge(clone, provided); err != nil {
+		return false, err
+	}
+	effective, err := chartutil.CoalesceValues(clone, provided)
+	if err != nil {
+		return false, err
+	}
+
+	v := &validator{opts: opts, reporter: r}
+	for _, ref := range templateRefs(clone, "") {
+		if shouldIgnore(ref.path, opts.Ignore) {
+			continue
+		}
+		value, exists := lookupPathOK(effective, ref.path)
+		switch {
+		case ref.required && (value == nil || value == ""):
+			v.report(Finding{
+				RuleID:  RuleMissingRequired,
+				Path:    ref.path,
+				Message: fmt.Sprintf("Missing required value: '%s' is required by %s but has no default or provided value", ref.path, ref.template),
+			})
+		case !ref.required && !exists:
+			v.report(Finding{
+				RuleID:  RuleUndefinedReference,
+				Path:    ref.path,
+				Message: fmt.Sprintf("Undefined reference: '%s' is used by %s but has no default or provided value", ref.path, ref.template),
+			})
+		}
+	}
+	return v.issuesFound, nil
+}
+
+// templateRefs returns the values referenced by the templates of c and its subcharts, with
+// subchart references prefixed by the subchart's key in the parent values. Each path is
+// returned once, preferring required references.
+func templateRefs(c *chart.Chart, prefix string) []templateRef {
+	refs := map[string]templateRef{}
+	add := func(ref templateRef) {
+		if existing, ok := refs[ref.path]; !ok || ref.required && !existing.required {
+			refs[ref.path] = ref
+		}
+	}
+
+	for _, tmpl := range c.Templates {
+		data := string(tmpl.Data)
+		for _, re := range requiredPatterns {
+			for _, m := range re.FindAllStringSubmatch(data, -1) {
+				add(templateRef{path: prefix + strings.TrimPrefix(m[1], "."), template: tmpl.Name, required: true})
+			}
+		}
+		for _, m := range valuesRefPattern.FindAllStringSubmatch(data, -1) {
+			add(templateRef{path: prefix + strings.TrimPrefix(m[1], "."), template: tmpl.Name})
+		}
+	}
+	for _, sub := range c.Dependencies() {
+		for _, ref := range templateRefs(sub, prefix+sub.Name()+".") {
+			add(ref)
+		}
+	}
+
+	result := make([]templateRef, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, ref)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].path < result[j].path })
+	return result
+}
+
+// lookupPathOK returns the value at a dotted key path and whether the key exists.
+func lookupPathOK(values map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
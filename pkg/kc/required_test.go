@@ -0,0 +1,63 @@
+package kc
+
+import (
+	"sort"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestCheckRequiredValues(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values: map[string]interface{}{
+			"image": map[string]interface{}{"repository": "nginx", "tag": ""},
+		},
+		Templates: []*chart.File{{
+			Name: "templates/deployment.yaml",
+			Data: []byte(`image: {{ .Values.image.repository }}:{{ required "image.tag is required" .Values.image.tag }}
+host: {{ .Values.ingress.hostname | required "hostname is required" }}
+{{- if .Values.debug }}
+debug: true
+{{- end }}
+`),
+		}},
+	}
+
+	rs := DefaultRuleSet()
+	_ = rs.Enable(RuleUndefinedReference)
+
+	r := &recordingReporter{}
+	issuesFound, err := CheckRequiredValues(c, map[string]interface{}{}, Options{Rules: rs}, r)
+	if err != nil {
+		t.Fatalf("CheckRequiredValues() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Errorf("expected missing required values to be reported as issues")
+	}
+
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, f.RuleID+" "+f.Path)
+	}
+	sort.Strings(got)
+	want := []string{"KC006 image.tag", "KC006 ingress.hostname", "KC007 debug"}
+	if len(got) != len(want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("findings = %v, want %v", got, want)
+			break
+		}
+	}
+
+	provided := map[string]interface{}{
+		"image":   map[string]interface{}{"tag": "1.25"},
+		"ingress": map[string]interface{}{"hostname": "example.com"},
+	}
+	r = &recordingReporter{}
+	if issuesFound, _ := CheckRequiredValues(c, provided, Options{}, r); issuesFound || len(r.findings) != 0 {
+		t.Errorf("expected no findings once required values are provided, got %+v", r.findings)
+	}
+}
@@ -0,0 +1,216 @@
+package kc
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceProfile holds the thresholds of the resource rules for a group of environments.
+type ResourceProfile struct {
+	// Name identifies the profile in messages.
+	Name string `json:"name"`
+	// Environments are globs of the environment names, the directory names of the service
+	// files, the profile applies to, e.g. "prod-*". A profile without any applies to every
+	// environment.
+	Environments []string `json:"environments,omitempty"`
+	// RequireRequests reports containers without CPU or memory requests.
+	RequireRequests bool `json:"requireRequests,omitempty"`
+	// MaxCPU and MaxMemory are the largest requests and limits not reported, and MinMemory
+	// the smallest memory, which catches quantities missing their unit, like 512 for 512
+	// bytes. Empty thresholds are not checked.
+	MaxCPU    string `json:"maxCPU,omitempty"`
+	MaxMemory string `json:"maxMemory,omitempty"`
+	MinMemory string `json:"minMemory,omitempty"`
+}
+
+// DefaultResourceProfiles are used if no profiles are configured: production environments
+// require requests, and every environment has the same bounds.
+var DefaultResourceProfiles = []ResourceProfile{
+	{
+		Name:            "prod",
+		Environments:    []string{"prod", "prod-*", "prod_*", "*-prod", "production", "*-production"},
+		RequireRequests: true,
+		MaxCPU:          "64",
+		MaxMemory:       "512Gi",
+		MinMemory:       "4Mi",
+	},
+	{Name: "default", MaxCPU: "64", MaxMemory: "512Gi", MinMemory: "4Mi"},
+}
+
+// resourceNames are the resources checked by the requirements and bounds of profiles.
+var resourceNames = []string{"cpu", "memory"}
+
+// matches reports whether the profile applies to the environment.
+func (p ResourceProfile) matches(environment string) bool {
+	if len(p.Environments) == 0 {
+		return true
+	}
+	for _, pattern := range p.Environments {
+		if ok, _ := path.Match(pattern, environment); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bounds returns the smallest and largest quantities of a resource the profile allows; nil
+// bounds are not checked.
+func (p ResourceProfile) bounds(name string) (*resource.Quantity, *resource.Quantity, error) {
+	var min, max string
+	switch name {
+	case "cpu":
+		max = p.MaxCPU
+	case "memory":
+		min, max = p.MinMemory, p.MaxMemory
+	}
+	var bounds [2]*resource.Quantity
+	for i, s := range []string{min, max} {
+		if s == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resource profile %s: invalid %s bound %q: %w", p.Name, name, s, err)
+		}
+		bounds[i] = &q
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// setEnvironment returns the environment of a values set, the directory name of its last
+// file, or "" if it has none.
+func setEnvironment(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	if env := filepath.Base(filepath.Dir(files[len(files)-1])); env != "." && env != string(filepath.Separator) {
+		return env
+	}
+	return ""
+}
+
+// CheckResources reports the container resources below values that Kubernetes rejects or
+// that are likely mistakes: limits lower than requests, requests missing in environments
+// whose profile requires them, and quantities outside the bounds of the profile, such as
+// 100 CPU cores. Resources are the maps of "resources" keys that are empty or hold requests or limits; values
+// should be the effective values, which include the chart defaults. The profile is the first
+// of opts.ResourceProfiles, or DefaultResourceProfiles, that matches the environment of
+// opts.Files. It returns true if any failing issues were found.
+func CheckResources(values map[string]interface{}, opts Options, r Reporter) (bool, error) {
+	if !opts.Rules.Enabled(RuleLimitBelowRequest) && !opts.Rules.Enabled(RuleMissingRequests) && !opts.Rules.Enabled(RuleResourceRange) {
+		return false, nil
+	}
+	profiles := opts.ResourceProfiles
+	if profiles == nil {
+		profiles = DefaultResourceProfiles
+	}
+	var profile *ResourceProfile
+	environment := setEnvironment(opts.Files)
+	for i := range profiles {
+		if profiles[i].matches(environment) {
+			profile = &profiles[i]
+			break
+		}
+	}
+	v := &validator{opts: opts, reporter: r}
+	var err error
+	walkResources(values, "", func(path string, requests, limits map[string]interface{}) {
+		if err == nil && !shouldIgnore(path, opts.Ignore) {
+			err = v.checkResources(path, requests, limits, profile)
+		}
+	})
+	return v.issuesFound, err
+}
+
+// walkResources calls visit for every map of a "resources" key below value that holds
+// requests or limits, or is empty, like the resources defaults of most charts.
+func walkResources(value interface{}, prefix string, visit func(path string, requests, limits map[string]interface{})) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			fullKey := key
+			if prefix != "" {
+				fullKey = prefix + "." + key
+			}
+			if resources, ok := value[key].(map[string]interface{}); ok && key == "resources" {
+				_, hasRequests := resources["requests"]
+				_, hasLimits := resources["limits"]
+				if len(resources) == 0 || hasRequests || hasLimits {
+					requests, _ := resources["requests"].(map[string]interface{})
+					limits, _ := resources["limits"].(map[string]interface{})
+					visit(fullKey, requests, limits)
+					continue
+				}
+			}
+			walkResources(value[key], fullKey, visit)
+		}
+	case []interface{}:
+		for i, item := range value {
+			walkResources(item, fmt.Sprintf("%s[%d]", prefix, i), visit)
+		}
+	}
+}
+
+// checkResources checks the requests and limits of the resources at path against each other
+// and against profile, which may be nil.
+func (v *validator) checkResources(path string, requests, limits map[string]interface{}, profile *ResourceProfile) error {
+	for _, name := range sortedKeys(limits) {
+		limit, okLimit := parseQuantity(limits[name])
+		request, okRequest := parseQuantity(requests[name])
+		if okLimit && okRequest && limit.Cmp(request) < 0 {
+			v.report(Finding{
+				RuleID:  RuleLimitBelowRequest,
+				Path:    path + ".limits." + name,
+				Message: fmt.Sprintf("Limit below request: '%s.limits.%s' is %v, less than the request of %v, which Kubernetes rejects", path, name, limits[name], requests[name]),
+				Value:   limits[name],
+				quoted:  []interface{}{requests[name]},
+			})
+		}
+	}
+	if profile == nil {
+		return nil
+	}
+	for _, name := range resourceNames {
+		if _, ok := requests[name]; !ok && profile.RequireRequests {
+			v.report(Finding{
+				RuleID:  RuleMissingRequests,
+				Path:    path + ".requests." + name,
+				Message: fmt.Sprintf("Missing request: '%s' sets no %s request, which the %s profile requires to schedule pods reliably", path, name, profile.Name),
+			})
+		}
+		min, max, err := profile.bounds(name)
+		if err != nil {
+			return err
+		}
+		for _, set := range []struct {
+			kind   string
+			values map[string]interface{}
+		}{{"requests", requests}, {"limits", limits}} {
+			value := set.values[name]
+			q, ok := parseQuantity(value)
+			if !ok {
+				continue
+			}
+			fullKey := path + "." + set.kind + "." + name
+			var bound string
+			switch {
+			case max != nil && q.Cmp(*max) > 0:
+				bound = "more than the maximum of " + max.String()
+			case min != nil && q.Cmp(*min) < 0:
+				bound = "less than the minimum of " + min.String()
+			default:
+				continue
+			}
+			v.report(Finding{
+				RuleID:  RuleResourceRange,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Resource out of range: '%s' is %v, %s in the %s profile", fullKey, value, bound, profile.Name),
+				Value:   value,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckResources(t *testing.T) {
+	values := map[string]interface{}{
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+			"limits":   map[string]interface{}{"cpu": float64(100), "memory": "256Mi"},
+		},
+		"worker": map[string]interface{}{
+			"resources": map[string]interface{}{
+				"limits": map[string]interface{}{"memory": float64(512)},
+			},
+		},
+		"sidecars": []interface{}{
+			map[string]interface{}{"resources": map[string]interface{}{}},
+		},
+	}
+	rules := RuleSet{RuleLimitBelowRequest: true, RuleMissingRequests: true, RuleResourceRange: true}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "prod",
+			files: []string{"envs/prod/web.yaml"},
+			want: []string{
+				RuleLimitBelowRequest + " resources.limits.memory",
+				RuleResourceRange + " resources.limits.cpu",
+				RuleMissingRequests + " sidecars[0].resources.requests.cpu",
+				RuleMissingRequests + " sidecars[0].resources.requests.memory",
+				RuleMissingRequests + " worker.resources.requests.cpu",
+				RuleMissingRequests + " worker.resources.requests.memory",
+				RuleResourceRange + " worker.resources.limits.memory",
+			},
+		},
+		{
+			name:  "staging",
+			files: []string{"envs/staging/web.yaml"},
+			want: []string{
+				RuleLimitBelowRequest + " resources.limits.memory",
+				RuleResourceRange + " resources.limits.cpu",
+				RuleResourceRange + " worker.resources.limits.memory",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &Collector{}
+			found, err := CheckResources(values, Options{Rules: rules, Files: tt.files}, collector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !found {
+				t.Error("expected issues to be found")
+			}
+			var got []string
+			for _, f := range collector.Findings {
+				got = append(got, f.RuleID+" "+f.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCheckResourcesProfiles verifies that configured profiles replace the defaults, apply
+// to the first environment they match, and that invalid bounds are errors.
+func TestCheckResourcesProfiles(t *testing.T) {
+	values := map[string]interface{}{
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "4"}},
+	}
+	opts := Options{
+		Rules: RuleSet{RuleMissingRequests: true, RuleResourceRange: true},
+		Files: []string{"eu-live/web.yaml"},
+		ResourceProfiles: []ResourceProfile{
+			{Name: "live", Environments: []string{"*-live"}, MaxCPU: "2"},
+			{Name: "default", RequireRequests: true},
+		},
+	}
+	collector := &Collector{}
+	if _, err := CheckResources(values, opts, collector); err != nil {
+		t.Fatal(err)
+	}
+	want := "Resource out of range: 'resources.limits.cpu' is 4, more than the maximum of 2 in the live profile"
+	if len(collector.Findings) != 1 || collector.Findings[0].Message != want {
+		t.Errorf("expected %q, got %+v", want, collector.Findings)
+	}
+
+	opts.ResourceProfiles[0].MaxCPU = "two"
+	if _, err := CheckResources(values, opts, &Collector{}); err == nil {
+		t.Error("expected an error for an invalid bound")
+	}
+
+	if found, _ := CheckResources(values, Options{}, collector); found || len(collector.Findings) != 1 {
+		t.Error("expected the rules to be disabled by default")
+	}
+}
@@ -0,0 +1,389 @@
+package kc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stable identifiers of the built-in rules.
+const (
+	RuleRedundant          = "KC001"
+	RuleTypeMismatch       = "KC002"
+	RuleUnknownKey         = "KC003"
+	RuleUnknownGlobal      = "KC004"
+	RuleUnusedValue        = "KC005"
+	RuleMissingRequired    = "KC006"
+	RuleUndefinedReference = "KC007"
+	RuleRenderError        = "KC008"
+	RuleLint               = "KC009"
+	RuleSchemaViolation    = "KC010"
+	RuleDrift              = "KC011"
+	RuleRevertedToDefault  = "KC012"
+	RuleTypeChanged        = "KC013"
+	RuleUpgradeChange      = "KC014"
+	RuleRenamedKey         = "KC015"
+	RulePolicyViolation    = "KC016"
+	RuleCustom             = "KC017"
+	RulePluginFinding      = "KC018"
+	RuleInconsistentValue  = "KC019"
+	RuleDuplicateValue     = "KC020"
+	RuleOverriddenValue    = "KC021"
+	RuleUnusedOverrides    = "KC022"
+	RuleIdenticalServices  = "KC023"
+	RuleDeletedKey         = "KC024"
+	RuleReplacedList       = "KC025"
+	RuleCoercibleScalar    = "KC026"
+	RuleAnchoredValue      = "KC027"
+	RuleYAMLSyntax         = "KC028"
+	RuleEmptyValue         = "KC029"
+	RulePossibleSecret     = "KC030"
+	RuleLimitBelowRequest  = "KC031"
+	RuleMissingRequests    = "KC032"
+	RuleResourceRange      = "KC033"
+	RuleConstraint         = "KC034"
+)
+
+// Rule describes a single check performed by the validator.
+type Rule struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Remediation describes how to resolve the rule's findings.
+	Remediation string `json:"remediation"`
+	// Severity is the default severity of the rule's findings.
+	Severity Severity `json:"severity"`
+	// Enabled reports whether the rule runs when not explicitly enabled or disabled.
+	Enabled bool `json:"enabled"`
+	// Security marks rules that find security problems, such as leaked credentials.
+	Security bool `json:"security,omitempty"`
+}
+
+// Rules is the registry of built-in rules, ordered by ID.
+var Rules = []Rule{
+	{
+		ID:          RuleRedundant,
+		Name:        "redundant-value",
+		Description: "Provided value matches the chart default and can be removed.",
+		Remediation: "Remove the value from the values file, or run kc minimize to remove every redundant value. If the value is meant to stay pinned when the chart default changes, suppress it with a kc:ignore comment.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleTypeMismatch,
+		Name:        "type-mismatch",
+		Description: "Provided value has a different type than the chart default.",
+		Remediation: "Change the value to the type of the chart default, e.g. quote numbers meant as strings, or use a map or list where the chart expects one.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleUnknownKey,
+		Name:        "unknown-key",
+		Description: "Provided key is not defined in the chart defaults.",
+		Remediation: "Check the key for typos against the chart defaults (kc explain shows them), or remove it if the chart no longer uses it.",
+		Severity:    SeverityWarning,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleUnknownGlobal,
+		Name:        "unknown-global",
+		Description: "Provided global value is not defined by the chart or any of its subcharts.",
+		Remediation: "Check the global key for typos, or remove it if no chart or subchart reads it.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleUnusedValue,
+		Name:        "unused-value",
+		Description: "Provided value is not referenced by any template (checked with --check-unused).",
+		Remediation: "Remove the value, or check the templates for the key it was meant to set.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleMissingRequired,
+		Name:        "missing-required",
+		Description: "Value required by a template has neither a chart default nor a provided value.",
+		Remediation: "Set the value in the values files, or add a default for it to the chart.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleUndefinedReference,
+		Name:        "undefined-reference",
+		Description: "Value referenced by a template has neither a chart default nor a provided value.",
+		Remediation: "Set the value, or add a default to the chart so the template does not render an empty value.",
+		Severity:    SeverityInfo,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleRenderError,
+		Name:        "render-error",
+		Description: "Chart templates fail to render with the provided values (checked with --render).",
+		Remediation: "Fix the values that make the template fail, as shown in the error, or the template itself.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleLint,
+		Name:        "helm-lint",
+		Description: "Helm lint reported a problem with the chart and provided values (checked with --lint). Severity follows the lint message unless overridden.",
+		Remediation: "Follow the message of helm lint, e.g. fix the chart metadata or the rendered resources.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleSchemaViolation,
+		Name:        "schema-violation",
+		Description: "Rendered manifest does not match the Kubernetes OpenAPI schema (checked with --validate-schemas).",
+		Remediation: "Fix the value that renders the invalid field, or the template, so the manifest matches the schema of its Kubernetes API version.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleDrift,
+		Name:        "value-drift",
+		Description: "Deployed release has a different effective value than the values files (checked with kc drift).",
+		Remediation: "Deploy the values files, or update them to match the deployed release if the change was intended.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleRevertedToDefault,
+		Name:        "reverted-to-default",
+		Description: "Value overridden in one release revision went back to the chart default in the next (checked with kc history).",
+		Remediation: "Check whether the override was dropped by mistake, and restore it in the values files if so.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleTypeChanged,
+		Name:        "type-changed",
+		Description: "Value changed type between release revisions (checked with kc history).",
+		Remediation: "Check whether the new type is intended; templates often handle only one type.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleUpgradeChange,
+		Name:        "upgrade-change",
+		Description: "Provided key was removed, renamed, or changed type or default in the newer chart version (checked with kc upgrade-check). Changed defaults are reported as info unless overridden.",
+		Remediation: "Move the value to its new key or type before upgrading, and review changed defaults the values rely on.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleRenamedKey,
+		Name:        "renamed-key",
+		Description: "Provided key was renamed according to a rename mapping or the chart's renames annotation.",
+		Remediation: "Move the value to the new key, or run with --fix to rename it in place.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RulePolicyViolation,
+		Name:        "policy-violation",
+		Description: "Merged values or rendered manifests violate a Rego policy (checked with --policy). Severity follows the deny or warn rule unless overridden.",
+		Remediation: "Change the values to satisfy the policy, as described in its message.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleCustom,
+		Name:        "custom-rule",
+		Description: "CEL expression of a custom rule in the configuration is false for the values. Severity follows the custom rule unless overridden.",
+		Remediation: "Change the values so the expression of the custom rule holds, or narrow the rule with when.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RulePluginFinding,
+		Name:        "plugin-finding",
+		Description: "External rule plugin reported a finding (checked with --plugin). Severity follows the plugin unless overridden.",
+		Remediation: "Follow the message of the plugin, or see its documentation.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleInconsistentValue,
+		Name:        "inconsistent-value",
+		Description: "Values set is the only one to leave out a key every other values set of the chart sets, or to set a different value than all others (checked with --check-consistency). Different values are reported as info unless overridden.",
+		Remediation: "Set the key as the other values sets do, or move values shared by every set to the overrides file. If the difference is intended, suppress it with a kc:ignore comment.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleDuplicateValue,
+		Name:        "duplicate-value",
+		Description: "Provided value repeats the value of a lower layer, such as the overrides file, which it shadows; the two can silently drift apart.",
+		Remediation: "Remove the value from the service file to inherit it from the lower layer, or from the lower layer if no other values set relies on it.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleOverriddenValue,
+		Name:        "overridden-value",
+		Description: "Provided value overrides a different value of a lower layer, such as a cluster-wide setting of the overrides file.",
+		Remediation: "Check that the override is intended. If the lower layer holds a policy every values set must follow, remove the override.",
+		Severity:    SeverityInfo,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleUnusedOverrides,
+		Name:        "unused-overrides",
+		Description: "Overrides file is not merged into any validated values set, or sets a top-level key no chart it is merged for defines (checked with --check-overrides).",
+		Remediation: "Delete the orphaned overrides file or key, or add the service file it was meant for.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleIdenticalServices,
+		Name:        "identical-service-files",
+		Description: "Service file of a chart has the same contents or values as the service file of another environment.",
+		Remediation: "Move the shared values to an overrides file above both environments, or to a --layer between them, and leave the service files empty.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleDeletedKey,
+		Name:        "deleted-key",
+		Description: "Provided value is null, which deletes the key from the chart defaults.",
+		Remediation: "Nothing to do if the key is meant to be removed, e.g. to drop a default annotation or resource limit. Otherwise set a value, or remove the null to keep the default.",
+		Severity:    SeverityInfo,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleReplacedList,
+		Name:        "replaced-list",
+		Description: "Provided list keeps only some of the elements of the default list, which Helm replaces instead of merging.",
+		Remediation: "Add the default elements that should stay to the provided list, or suppress the finding if the others are meant to be removed.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleCoercibleScalar,
+		Name:        "coercible-scalar",
+		Description: "Provided value is a string spelling the bool or number of the chart default, or the other way around, such as \"8080\" for 8080, which templates usually coerce.",
+		Remediation: "Quote or unquote the value to match the type of the chart default. If the chart requires a string, e.g. for an annotation or environment variable, raise the severity of the rule to error in the configuration.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleAnchoredValue,
+		Name:        "anchored-value",
+		Description: "Values file copies a value verbatim from another place of the same file through a YAML alias or merge key, such as the section of another environment.",
+		Remediation: "Move the shared value to an overrides file or --layer both places inherit from, or set the differing values next to the merge key.",
+		Severity:    SeverityInfo,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleYAMLSyntax,
+		Name:        "yaml-syntax",
+		Description: "Values file holds YAML that Helm reads differently than it looks: keys that are not strings, such as 1, true, or on, or documents after the first one, which Helm ignores.",
+		Remediation: "Quote keys such as on or 1 so they stay strings, and split documents separated by --- into their own values files.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RuleEmptyValue,
+		Name:        "empty-value",
+		Description: "Provided value is an empty map, a map of only empty values, or an empty string over a null default, which leaves the defaults unchanged.",
+		Remediation: "Remove the key; it is usually left over from deleted configuration. To remove a default map, set it to null instead.",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+	},
+	{
+		ID:          RulePossibleSecret,
+		Name:        "possible-secret",
+		Description: "Values file holds what looks like a credential in plain text: a private key, a known token format, a literal value of a key named like a password or token, or a random-looking string. Files encrypted with SOPS are not scanned.",
+		Remediation: "Rotate the credential if it was committed, then move it to a Kubernetes Secret referenced by the chart (e.g. existingSecret), or encrypt the values file with SOPS. Suppress false positives with a kc:ignore comment.",
+		Severity:    SeverityError,
+		Enabled:     true,
+		Security:    true,
+	},
+	{
+		ID:          RuleLimitBelowRequest,
+		Name:        "limit-below-request",
+		Description: "Container resources set a limit lower than the request of the same resource, which Kubernetes rejects when creating the pods.",
+		Remediation: "Raise the limit to at least the request, or lower the request.",
+		Severity:    SeverityError,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleMissingRequests,
+		Name:        "missing-requests",
+		Description: "Container resources set no CPU or memory request in an environment whose resource profile requires them, such as production.",
+		Remediation: "Set resources.requests.cpu and resources.requests.memory, or adjust the environments of the resource profile.",
+		Severity:    SeverityWarning,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleResourceRange,
+		Name:        "resource-out-of-range",
+		Description: "Container CPU or memory request or limit is outside the bounds of the environment's resource profile, such as 100 CPU cores, or 512 bytes of memory where 512Mi was meant.",
+		Remediation: "Fix the quantity or its unit, or raise the bounds of the resource profile.",
+		Severity:    SeverityWarning,
+		Enabled:     false,
+	},
+	{
+		ID:          RuleConstraint,
+		Name:        "constraint-violation",
+		Description: "Provided value violates a constraint of its key path in the configuration: it is not one of the allowed values, out of range, or does not match the pattern, or a key is required but not set, or set but unused, for example given the value of another key.",
+		Remediation: "Set one of the allowed values, set the required key, or remove the unused one; update the constraint in the configuration file if the chart accepts the values.",
+		Severity:    SeverityError,
+		Enabled:     true,
+	},
+}
+
+// LookupRule returns the registered rule with the given ID or name.
+func LookupRule(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if strings.EqualFold(rule.ID, id) || rule.Name == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// RuleSet records which rules are enabled.
+type RuleSet map[string]bool
+
+// DefaultRuleSet returns a RuleSet with every rule at its default state.
+func DefaultRuleSet() RuleSet {
+	rs := RuleSet{}
+	for _, rule := range Rules {
+		rs[rule.ID] = rule.Enabled
+	}
+	return rs
+}
+
+// Enabled reports whether the rule with the given ID should run.
+// Rules missing from the set fall back to their registered default.
+func (rs RuleSet) Enabled(id string) bool {
+	if enabled, ok := rs[id]; ok {
+		return enabled
+	}
+	rule, ok := LookupRule(id)
+	return ok && rule.Enabled
+}
+
+// Enable turns on the given rules.
+func (rs RuleSet) Enable(ids ...string) error {
+	return rs.set(ids, true)
+}
+
+// Disable turns off the given rules.
+func (rs RuleSet) Disable(ids ...string) error {
+	return rs.set(ids, false)
+}
+
+func (rs RuleSet) set(ids []string, enabled bool) error {
+	for _, id := range ids {
+		rule, ok := LookupRule(id)
+		if !ok {
+			return fmt.Errorf("unknown rule: %s", id)
+		}
+		rs[rule.ID] = enabled
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package kc
+
+import "testing"
+
+func TestRuleSet(t *testing.T) {
+	rs := DefaultRuleSet()
+	if !rs.Enabled(RuleRedundant) || !rs.Enabled(RuleTypeMismatch) {
+		t.Fatalf("expected KC001 and KC002 to be enabled by default")
+	}
+	if rs.Enabled(RuleUnknownKey) {
+		t.Fatalf("expected KC003 to be disabled by default")
+	}
+
+	if err := rs.Enable("unknown-key"); err != nil {
+		t.Fatalf("Enable() returned error: %v", err)
+	}
+	if err := rs.Disable("kc001"); err != nil {
+		t.Fatalf("Disable() returned error: %v", err)
+	}
+	if !rs.Enabled(RuleUnknownKey) || rs.Enabled(RuleRedundant) {
+		t.Errorf("unexpected rule set after enable/disable: %v", rs)
+	}
+
+	if err := rs.Enable("KC999"); err == nil {
+		t.Errorf("expected error for unknown rule")
+	}
+}
+
+func TestValidateChartValuesRespectsRules(t *testing.T) {
+	defaults := map[string]interface{}{"replicas": 1}
+	provided := map[string]interface{}{"replicas": 1, "extra": true}
+
+	rs := DefaultRuleSet()
+	_ = rs.Disable(RuleRedundant)
+	_ = rs.Enable(RuleUnknownKey)
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{Rules: rs}, r)
+
+	if len(r.findings) != 1 || r.findings[0].RuleID != RuleUnknownKey {
+		t.Errorf("expected a single %s finding, got %+v", RuleUnknownKey, r.findings)
+	}
+}
+
+func TestValidateChartValuesFailOn(t *testing.T) {
+	defaults := map[string]interface{}{"replicas": 1, "name": "web"}
+	redundant := map[string]interface{}{"replicas": 1}
+	mismatch := map[string]interface{}{"name": 42}
+
+	opts := Options{FailOn: SeverityError}
+	if ValidateChartValues(defaults, redundant, opts, &recordingReporter{}) {
+		t.Errorf("expected redundant value to not fail with --fail-on=error")
+	}
+	if !ValidateChartValues(defaults, mismatch, opts, &recordingReporter{}) {
+		t.Errorf("expected type mismatch to fail with --fail-on=error")
+	}
+
+	opts.Severities = map[string]Severity{RuleRedundant: SeverityError}
+	r := &recordingReporter{}
+	if !ValidateChartValues(defaults, redundant, opts, r) {
+		t.Errorf("expected redundant value escalated to error to fail")
+	}
+	if r.findings[0].Severity != SeverityError {
+		t.Errorf("expected severity %q, got %q", SeverityError, r.findings[0].Severity)
+	}
+}
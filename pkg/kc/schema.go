@@ -0,0 +1,99 @@
+package kc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	kubeconform "github.com/yannh/kubeconform/pkg/validator"
+)
+
+// SchemaOptions controls validation of rendered manifests against Kubernetes JSON schemas.
+type SchemaOptions struct {
+	// KubernetesVersion selects the schemas, e.g. "1.30.0". Defaults to the latest schemas.
+	KubernetesVersion string
+	// Locations are kubeconform schema location templates. Defaults to kubeconform's registry.
+	Locations []string
+	// Cache is a directory where downloaded schemas are cached across runs; it is created if
+	// missing.
+	Cache string
+	// Strict rejects fields not documented in the schema.
+	Strict bool
+	// IgnoreMissingSchemas skips resources without a known schema, e.g. custom resources.
+	IgnoreMissingSchemas bool
+}
+
+// Schemas validates rendered manifests against the Kubernetes schemas selected by
+// SchemaOptions.
+type Schemas struct {
+	validator kubeconform.Validator
+}
+
+// PrepareSchemas creates the schema validator once per run, so a schema is downloaded once
+// and reused by every values set.
+func PrepareSchemas(schemaOpts SchemaOptions) (*Schemas, error) {
+	if schemaOpts.Cache != "" {
+		if err := os.MkdirAll(schemaOpts.Cache, 0o755); err != nil {
+			return nil, fmt.Errorf("creating schema cache: %w", err)
+		}
+	}
+	val, err := kubeconform.New(schemaOpts.Locations, kubeconform.Opts{
+		Cache:                schemaOpts.Cache,
+		KubernetesVersion:    strings.TrimPrefix(schemaOpts.KubernetesVersion, "v"),
+		Strict:               schemaOpts.Strict,
+		IgnoreMissingSchemas: schemaOpts.IgnoreMissingSchemas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating schema validator: %w", err)
+	}
+	return &Schemas{validator: val}, nil
+}
+
+// CheckSchemas validates rendered manifests against Kubernetes OpenAPI schemas, like
+// kubeconform, and reports every schema violation. It returns true if any failing issues
+// were found.
+func CheckSchemas(schemas *Schemas, rendered map[string]string, opts Options, r Reporter) bool {
+	if schemas == nil || !opts.Rules.Enabled(RuleSchemaViolation) {
+		return false
+	}
+
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		switch filepath.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	v := &validator{opts: opts, reporter: r}
+	for _, name := range names {
+		for _, res := range schemas.validator.Validate(name, io.NopCloser(strings.NewReader(rendered[name]))) {
+			switch res.Status {
+			case kubeconform.Invalid:
+				for _, ve := range res.ValidationErrors {
+					v.report(Finding{
+						RuleID:  RuleSchemaViolation,
+						Path:    ve.Path,
+						Message: fmt.Sprintf("Schema violation in %s: %s: %s", name, ve.Path, ve.Msg),
+					})
+				}
+				if len(res.ValidationErrors) == 0 && res.Err != nil {
+					v.report(Finding{
+						RuleID:  RuleSchemaViolation,
+						Message: fmt.Sprintf("Schema violation in %s: %v", name, res.Err),
+					})
+				}
+			case kubeconform.Error:
+				v.report(Finding{
+					RuleID:  RuleSchemaViolation,
+					Message: fmt.Sprintf("Schema validation failed for %s: %v", name, res.Err),
+				})
+			}
+		}
+	}
+	return v.issuesFound
+}
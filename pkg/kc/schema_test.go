@@ -0,0 +1,95 @@
+package kc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckSchemas(t *testing.T) {
+	schemaDir := t.TempDir()
+	schema := `{
+  "type": "object",
+  "properties": {
+    "apiVersion": {"type": "string"},
+    "kind": {"type": "string"},
+    "metadata": {"type": "object"},
+    "data": {"type": "object", "additionalProperties": {"type": "string"}}
+  }
+}`
+	if err := os.WriteFile(filepath.Join(schemaDir, "configmap-v1.json"), []byte(schema), 0644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+
+	rendered := map[string]string{
+		"web_service/templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  replicas: 3
+`,
+		"web_service/templates/NOTES.txt": "Thanks for installing!",
+	}
+	schemaOpts := SchemaOptions{
+		Locations: []string{filepath.Join(schemaDir, "{{ .ResourceKind }}{{ .KindSuffix }}.json")},
+	}
+
+	schemas, err := PrepareSchemas(schemaOpts)
+	if err != nil {
+		t.Fatalf("PrepareSchemas() returned error: %v", err)
+	}
+	r := &recordingReporter{}
+	failed := CheckSchemas(schemas, rendered, Options{}, r)
+	if !failed || len(r.findings) != 1 || r.findings[0].Path != "/data/replicas" {
+		t.Errorf("expected a single violation for /data/replicas, got %+v", r.findings)
+	}
+
+	rendered["web_service/templates/configmap.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  replicas: \"3\"\n"
+	r = &recordingReporter{}
+	if CheckSchemas(schemas, rendered, Options{}, r) || len(r.findings) != 0 {
+		t.Errorf("expected valid manifest to pass, got %+v", r.findings)
+	}
+}
+
+// TestCheckSchemasCache verifies that a schema is downloaded once per run, and not at all by
+// a later run that finds it in the cache.
+func TestCheckSchemasCache(t *testing.T) {
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write([]byte(`{"type": "object", "properties": {"data": {"type": "object", "additionalProperties": {"type": "string"}}}}`))
+	}))
+	defer server.Close()
+
+	rendered := map[string]string{"web/templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\ndata:\n  replicas: 3\n"}
+	schemaOpts := SchemaOptions{
+		Locations: []string{server.URL + "/{{ .ResourceKind }}{{ .KindSuffix }}.json"},
+		Cache:     filepath.Join(t.TempDir(), "schemas"),
+	}
+	schemas, err := PrepareSchemas(schemaOpts)
+	if err != nil {
+		t.Fatalf("PrepareSchemas() returned error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		r := &recordingReporter{}
+		if !CheckSchemas(schemas, rendered, Options{}, r) || len(r.findings) != 1 {
+			t.Errorf("expected a single violation, got %+v", r.findings)
+		}
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("expected the schema to be downloaded once, got %d downloads", got)
+	}
+
+	server.Close()
+	if schemas, err = PrepareSchemas(schemaOpts); err != nil {
+		t.Fatalf("PrepareSchemas() returned error: %v", err)
+	}
+	r := &recordingReporter{}
+	if !CheckSchemas(schemas, rendered, Options{}, r) || len(r.findings) != 1 {
+		t.Errorf("expected the cached schema to be used offline, got %+v", r.findings)
+	}
+}
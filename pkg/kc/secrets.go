@@ -0,0 +1,199 @@
+package kc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// secretPatterns match well-known credential formats, by the kind of secret they find.
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"a private key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"an AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"a GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+}
+
+// secretReference matches values that refer to a secret kept elsewhere instead of holding
+// it: template expressions, environment variables, SOPS-encrypted values, and references
+// resolved by tools like vals or helm-secrets.
+var secretReference = regexp.MustCompile(`\{\{|\$\{|^ENC\[|^ref\+|^(?:vault|secret|secretref|awssm|gcpsm|sops)://`)
+
+// credentialWords are the last words of key names that hold credentials, such as
+// adminPassword or api_key; ignoredWords mark keys that name or locate a credential instead,
+// such as existingSecret or passwordFile.
+var (
+	credentialWords = map[string]bool{
+		"password": true, "passwd": true, "pwd": true, "secret": true, "token": true,
+		"apikey": true, "credentials": true, "passphrase": true,
+	}
+	credentialPairs = map[string]bool{
+		"api key": true, "access key": true, "secret key": true, "private key": true,
+		"client secret": true, "auth token": true, "access token": true,
+	}
+	ignoredWords = map[string]bool{
+		"existing": true, "name": true, "ref": true, "file": true, "path": true, "env": true,
+		"length": true, "policy": true, "enabled": true, "create": true, "mount": true,
+	}
+)
+
+// highEntropy matches strings that may be random tokens: long, without spaces, and of the
+// alphabet of base64 and URL-safe tokens.
+var highEntropy = regexp.MustCompile(`^[A-Za-z0-9+/=_-]{24,}$`)
+
+// minEntropy is the Shannon entropy, in bits per character, of strings reported as
+// possible secrets; names and identifiers stay below it.
+const minEntropy = 4.0
+
+// CheckSecrets reports the values of the given layers that look like credentials: private
+// keys, well-known token formats, literal values of keys named like passwords or tokens, and
+// random-looking strings. layers holds the values of the files to scan, leaving out those
+// kept encrypted at rest. Every path is reported once, without its value. It returns true
+// if any failing issues were found.
+func CheckSecrets(layers []map[string]interface{}, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RulePossibleSecret) {
+		return false
+	}
+	v := &validator{opts: opts, reporter: r}
+	reported := map[string]bool{}
+	for _, layer := range layers {
+		scanSecrets(layer, "", "", func(path, key string, value interface{}) {
+			if reported[path] || shouldIgnore(path, opts.Ignore) {
+				return
+			}
+			kind := secretKind(key, value)
+			if kind == "" {
+				return
+			}
+			reported[path] = true
+			v.report(Finding{
+				RuleID:  RulePossibleSecret,
+				Path:    path,
+				Message: fmt.Sprintf("Possible secret: '%s' holds %s; keep it in a Kubernetes Secret or encrypt the values file", path, kind),
+			})
+		})
+	}
+	return v.issuesFound
+}
+
+// scanSecrets calls visit for every scalar below value, with its path and the key of its
+// nearest map.
+func scanSecrets(value interface{}, path, key string, visit func(path, key string, value interface{})) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(value) {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			scanSecrets(value[k], child, k, visit)
+		}
+	case []interface{}:
+		for i, item := range value {
+			scanSecrets(item, fmt.Sprintf("%s[%d]", path, i), key, visit)
+		}
+	default:
+		visit(path, key, value)
+	}
+}
+
+// secretKind returns what kind of secret the value of key looks like, or "".
+func secretKind(key string, value interface{}) string {
+	var s string
+	switch value := value.(type) {
+	case string:
+		s = strings.TrimSpace(value)
+	case float64, int, int64:
+		s = fmt.Sprint(value)
+	default:
+		return ""
+	}
+	if s == "" || secretReference.MatchString(s) {
+		return ""
+	}
+	for _, p := range secretPatterns {
+		if p.pattern.MatchString(s) {
+			return p.kind
+		}
+	}
+	if isCredentialKey(key) {
+		return "a literal credential"
+	}
+	if highEntropy.MatchString(s) && isMixed(s) && entropy(s) >= minEntropy {
+		return "a random-looking string"
+	}
+	return ""
+}
+
+// isCredentialKey reports whether a key name, in camel, snake, or kebab case, names a
+// credential rather than its name or location.
+func isCredentialKey(key string) bool {
+	words := keyWords(key)
+	if len(words) == 0 {
+		return false
+	}
+	for _, word := range words {
+		if ignoredWords[word] {
+			return false
+		}
+	}
+	last := words[len(words)-1]
+	if credentialWords[last] {
+		return true
+	}
+	return len(words) > 1 && credentialPairs[words[len(words)-2]+" "+last]
+}
+
+// keyWords splits a key name into lower-case words at case changes, underscores, dashes,
+// and dots.
+func keyWords(key string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(key)
+	for i, c := range runes {
+		boundary := c == '_' || c == '-' || c == '.'
+		if !boundary && unicode.IsUpper(c) && len(word) > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+		if boundary {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+			}
+			word = nil
+			continue
+		}
+		word = append(word, c)
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	return words
+}
+
+// isMixed reports whether s mixes digits, lower-case, and upper-case letters, like random
+// tokens do. Digests and commit hashes used as image tags are lower-case hex, and names
+// rarely mix all three.
+func isMixed(s string) bool {
+	return strings.ContainsFunc(s, unicode.IsDigit) && strings.ContainsFunc(s, unicode.IsLower) && strings.ContainsFunc(s, unicode.IsUpper)
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	n := float64(len([]rune(s)))
+	var h float64
+	for _, count := range counts {
+		p := float64(count) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
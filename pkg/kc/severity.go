@@ -0,0 +1,33 @@
+package kc
+
+import "fmt"
+
+// Severity classifies how serious a finding is.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+var severityRank = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// ParseSeverity converts s into a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(s)
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("unknown severity: %q (expected error, warning, or info)", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+// An empty threshold matches every severity.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRank[s] >= severityRank[threshold]
+}
@@ -0,0 +1,100 @@
+package kc
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// suppressionPattern matches "kc:ignore" and "kc:ignore=KC001,KC002" markers in comments.
+// Anything after the marker is treated as the justification and ignored.
+var suppressionPattern = regexp.MustCompile(`kc:ignore(?:=([A-Za-z0-9,-]+))?`)
+
+// Suppressions maps key paths to the rule IDs suppressed for them by inline comments.
+// An empty rule list suppresses every rule.
+type Suppressions map[string][]string
+
+// ParseSuppressions collects inline suppressions from already loaded values documents.
+func ParseSuppressions(docs ...[]byte) (Suppressions, error) {
+	s := Suppressions{}
+	for _, data := range docs {
+		if err := s.parse(data); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s Suppressions) parse(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	s.walk(doc.Content[0], "")
+	return nil
+}
+
+func (s Suppressions) walk(node *yaml.Node, prefix string) {
+	// Comments in anchored values apply wherever an alias or merge key copies them.
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for _, merged := range mergedMappings(node) {
+		s.walk(merged, prefix)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if isMergeKey(keyNode) {
+			continue
+		}
+		path := keyNode.Value
+		if prefix != "" {
+			path = prefix + "." + keyNode.Value
+		}
+		for _, comment := range []string{keyNode.HeadComment, keyNode.LineComment, valueNode.LineComment} {
+			s.add(path, comment)
+		}
+		s.walk(valueNode, path)
+	}
+}
+
+func (s Suppressions) add(path, comment string) {
+	for _, m := range suppressionPattern.FindAllStringSubmatch(comment, -1) {
+		if m[1] == "" {
+			s[path] = []string{}
+			continue
+		}
+		if rules, ok := s[path]; ok && len(rules) == 0 {
+			continue
+		}
+		s[path] = append(s[path], strings.Split(m[1], ",")...)
+	}
+}
+
+// Suppressed reports whether f is suppressed by a comment on its key or any parent key.
+func (s Suppressions) Suppressed(f Finding) bool {
+	path := f.Path
+	for {
+		if rules, ok := s[path]; ok {
+			if len(rules) == 0 {
+				return true
+			}
+			for _, id := range rules {
+				if rule, ok := LookupRule(id); ok && rule.ID == f.RuleID {
+					return true
+				}
+			}
+		}
+		// List elements, such as ports[1], are suppressed with their list.
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
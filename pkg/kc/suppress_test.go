@@ -0,0 +1,41 @@
+package kc
+
+import "testing"
+
+func TestParseSuppressions(t *testing.T) {
+	data := []byte(`
+replicaCount: 1 # kc:ignore=KC001 kept explicit for the on-call runbook
+image:
+  # kc:ignore
+  tag: 3
+resources: # kc:ignore=KC002,KC003
+  limits:
+    cpu: 1
+name: web
+ports: # kc:ignore=KC002
+  - 80
+  - "8443"
+`)
+	s, err := ParseSuppressions(data)
+	if err != nil {
+		t.Fatalf("ParseSuppressions() returned error: %v", err)
+	}
+
+	tests := []struct {
+		finding Finding
+		want    bool
+	}{
+		{Finding{RuleID: RuleRedundant, Path: "replicaCount"}, true},
+		{Finding{RuleID: RuleTypeMismatch, Path: "replicaCount"}, false},
+		{Finding{RuleID: RuleTypeMismatch, Path: "image.tag"}, true},
+		{Finding{RuleID: RuleTypeMismatch, Path: "resources.limits.cpu"}, true},
+		{Finding{RuleID: RuleRedundant, Path: "resources.limits.cpu"}, false},
+		{Finding{RuleID: RuleRedundant, Path: "name"}, false},
+		{Finding{RuleID: RuleTypeMismatch, Path: "ports[1]"}, true},
+	}
+	for _, tt := range tests {
+		if got := s.Suppressed(tt.finding); got != tt.want {
+			t.Errorf("Suppressed(%s %s) = %v, want %v", tt.finding.RuleID, tt.finding.Path, got, tt.want)
+		}
+	}
+}
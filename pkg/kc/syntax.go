@@ -0,0 +1,213 @@
+package kc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SyntaxError is an error in a values document, at a position.
+type SyntaxError struct {
+	// Line and Column are 1-based; Column is 0 if unknown.
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+var (
+	// yamlErrorLine matches the line of errors of the YAML parser.
+	yamlErrorLine = regexp.MustCompile(`^(?:error converting YAML to JSON: )?yaml: line (\d+): (.*)$`)
+	// blockScalarStart matches lines that start a literal or folded block scalar, whose
+	// content may be indented with tabs.
+	blockScalarStart = regexp.MustCompile(`(?:^|:|-)\s*[|>][-+0-9]*\s*(?:#.*)?$`)
+)
+
+// DiagnoseYAML returns the position and cause of err, the error of parsing the values
+// document data, as a *SyntaxError: a tab in the indentation, or else the first line the
+// document cannot be parsed up to, such as one with trailing content after a quoted value,
+// or the line given by the parser. It returns err if there is no better position.
+func DiagnoseYAML(data []byte, err error) error {
+	lines := strings.Split(string(data), "\n")
+	if line, column, ok := findIndentationTab(lines); ok {
+		return &SyntaxError{Line: line, Column: column, Message: "tab in indentation; YAML indents with spaces only"}
+	}
+	message := strings.TrimPrefix(strings.TrimPrefix(err.Error(), "error converting YAML to JSON: "), "yaml: ")
+	parserLine := 0
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		parserLine, _ = strconv.Atoi(m[1])
+		message = m[2]
+	}
+	// The parser reports the line it noticed the error on, often the one of the enclosing
+	// key. The first line the document fails on is more precise, unless it only cuts a
+	// multi-line value short.
+	for n := 1; n <= len(lines); n++ {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(strings.Join(lines[:n], "\n")), &doc); err != nil && !strings.Contains(err.Error(), "end of stream") {
+			return &SyntaxError{Line: n, Message: message}
+		}
+	}
+	if parserLine > 0 {
+		return &SyntaxError{Line: parserLine, Message: message}
+	}
+	return err
+}
+
+// findIndentationTab returns the position of the first tab in the indentation of a line,
+// other than in the content of block scalars.
+func findIndentationTab(lines []string) (int, int, bool) {
+	blockIndent := -1
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if tab := strings.IndexByte(line[:indent], '\t'); tab >= 0 {
+			return i + 1, tab + 1, true
+		}
+		if blockScalarStart.MatchString(line) {
+			blockIndent = indent
+		}
+	}
+	return 0, 0, false
+}
+
+// yaml11Bools are the plain scalars YAML 1.1, which Helm follows, reads as bools, in
+// addition to true and false, in lower case; they are also read in title and upper case.
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "on": true,
+	"n": false, "no": false, "off": false,
+}
+
+// yaml11Bool returns the bool YAML 1.1 reads a plain scalar as, if it is one.
+func yaml11Bool(value string) (bool, bool) {
+	lower := strings.ToLower(value)
+	b, ok := yaml11Bools[lower]
+	if !ok || (value != lower && value != strings.ToUpper(value) && value != strings.ToUpper(lower[:1])+lower[1:]) {
+		return false, false
+	}
+	return b, true
+}
+
+// CheckYAMLSyntax reports the parts of the values files that Helm reads differently than
+// they look: keys that are not strings, such as 1, true, or on, which Helm converts to
+// strings and which may collide with other keys, and documents after the first one, which
+// Helm ignores. docs holds the contents of files; documents that cannot be parsed are
+// skipped, since loading them fails. It returns true if any failing issues were found.
+func CheckYAMLSyntax(files []string, docs [][]byte, opts Options, r Reporter) bool {
+	if !opts.Rules.Enabled(RuleYAMLSyntax) {
+		return false
+	}
+	issuesFound := false
+	for i, data := range docs {
+		fileOpts := opts
+		fileOpts.Files = []string{files[i]}
+		v := &validator{opts: fileOpts, reporter: r}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for n := 0; ; n++ {
+			var doc yaml.Node
+			if err := dec.Decode(&doc); err != nil {
+				// Documents that cannot be parsed fail to load instead.
+				break
+			}
+			if len(doc.Content) == 0 {
+				continue
+			}
+			if n == 0 {
+				checkKeys(v, doc.Content[0], "")
+				continue
+			}
+			v.report(Finding{
+				RuleID:  RuleYAMLSyntax,
+				Message: fmt.Sprintf("Multiple documents: %s holds more than one YAML document, but Helm only reads the first one", files[i]),
+				Line:    doc.Content[0].Line,
+				Column:  doc.Content[0].Column,
+			})
+			break
+		}
+		issuesFound = issuesFound || v.issuesFound
+	}
+	return issuesFound
+}
+
+// checkKeys reports the keys below node that are not strings, and whether they collide with
+// another key of their mapping once Helm converts them to strings.
+func checkKeys(v *validator, node *yaml.Node, prefix string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		// seen maps the keys Helm reads to the keys as written.
+		seen := map[string]string{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if isMergeKey(key) {
+				continue
+			}
+			path := key.Value
+			if prefix != "" {
+				path = prefix + "." + key.Value
+			}
+			converted, kind := helmKey(key)
+			if kind != "" {
+				message := fmt.Sprintf("Non-string key: '%s' is read as the %s %s; quote it to keep it a string", path, kind, converted)
+				if written, ok := seen[converted]; ok {
+					message = fmt.Sprintf("Non-string key: '%s' is read as the %s %s, the same key as '%s', which it overwrites; quote it", path, kind, converted, written)
+				}
+				v.report(Finding{
+					RuleID:  RuleYAMLSyntax,
+					Path:    path,
+					Message: message,
+					Line:    key.Line,
+					Column:  key.Column,
+				})
+			}
+			seen[converted] = key.Value
+			checkKeys(v, value, path)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			checkKeys(v, item, fmt.Sprintf("%s[%d]", prefix, i))
+		}
+	}
+}
+
+// helmKey returns the key Helm reads for a mapping key, and the kind of scalar it is read
+// as, or "" for strings.
+func helmKey(key *yaml.Node) (string, string) {
+	if key.Kind != yaml.ScalarNode {
+		return key.Value, "complex value"
+	}
+	switch key.ShortTag() {
+	case "!!str":
+		if b, ok := yaml11Bool(key.Value); ok && key.Style == 0 {
+			return strconv.FormatBool(b), "bool"
+		}
+		return key.Value, ""
+	case "!!bool":
+		b, _ := strconv.ParseBool(strings.ToLower(key.Value))
+		return strconv.FormatBool(b), "bool"
+	case "!!int":
+		return key.Value, "integer"
+	case "!!float":
+		return key.Value, "float"
+	case "!!null":
+		return "null", "null"
+	}
+	return key.Value, strings.TrimPrefix(key.ShortTag(), "!!")
+}
@@ -0,0 +1,66 @@
+package kc
+
+import (
+	"testing"
+
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// TestDiagnoseYAML verifies that errors of parsing values documents are given the position of
+// their cause.
+func TestDiagnoseYAML(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"image:\n  tag: v1\n\tpullPolicy: Always\n", "line 3, column 1: tab in indentation; YAML indents with spaces only"},
+		{"script: |\n\techo hello\nimage:\n\ttag: v1\n", "line 4, column 1: tab in indentation; YAML indents with spaces only"},
+		{"replicaCount: 1\nname: \"web\" service\n", "line 2: did not find expected key"},
+		{"replicaCount: 1\n  port: 80\n", "line 2: mapping values are not allowed in this context"},
+		{"description: \"a long\n  text\"\nports: [80, 443\n", "line 3: did not find expected ',' or ']'"},
+	}
+	for _, tt := range tests {
+		values := map[string]interface{}{}
+		err := sigsyaml.Unmarshal([]byte(tt.data), &values)
+		if err == nil {
+			t.Fatalf("expected %q not to parse", tt.data)
+		}
+		if got := DiagnoseYAML([]byte(tt.data), err).Error(); got != tt.want {
+			t.Errorf("DiagnoseYAML(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+// TestCheckYAMLSyntax verifies that keys Helm reads as other scalars than strings, and
+// documents after the first one, are reported at their position.
+func TestCheckYAMLSyntax(t *testing.T) {
+	files := []string{"keys.yaml", "docs.yaml", "invalid.yaml"}
+	docs := [][]byte{
+		[]byte("nodeSelector:\n  \"true\": a\n  on: b\nports:\n  1: http\n  \"2\": https\nyes: 1\n"),
+		[]byte("replicaCount: 1\n---\nreplicaCount: 2\n"),
+		[]byte("a: [b\n"),
+	}
+	collector := &Collector{}
+	if CheckYAMLSyntax(files, docs, Options{FailOn: SeverityError}, collector) {
+		t.Error("expected no errors")
+	}
+	want := []Finding{
+		{Path: "nodeSelector.on", Message: "Non-string key: 'nodeSelector.on' is read as the bool true, the same key as 'true', which it overwrites; quote it", Line: 3, Column: 3, Files: []string{"keys.yaml"}},
+		{Path: "ports.1", Message: "Non-string key: 'ports.1' is read as the integer 1; quote it to keep it a string", Line: 5, Column: 3, Files: []string{"keys.yaml"}},
+		{Path: "yes", Message: "Non-string key: 'yes' is read as the bool true; quote it to keep it a string", Line: 7, Column: 1, Files: []string{"keys.yaml"}},
+		{Message: "Multiple documents: docs.yaml holds more than one YAML document, but Helm only reads the first one", Line: 3, Column: 1, Files: []string{"docs.yaml"}},
+	}
+	if len(collector.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), collector.Findings)
+	}
+	for i, f := range collector.Findings {
+		if f.RuleID != RuleYAMLSyntax || f.Path != want[i].Path || f.Message != want[i].Message || f.Line != want[i].Line || f.Column != want[i].Column || f.Files[0] != want[i].Files[0] {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+
+	loc, ok := NewLocator().Locate(collector.Findings[3])
+	if !ok || loc != (Location{File: "docs.yaml", Line: 3, Column: 1, Length: 1}) {
+		t.Errorf("expected the finding to be located at docs.yaml:3:1, got %+v, %v", loc, ok)
+	}
+}
@@ -0,0 +1,4 @@
+;; loop.wasm is this module assembled with wat2wasm: it never returns.
+(module
+  (func (export "_start")
+    (loop $forever (br $forever))))
@@ -0,0 +1,23 @@
+;; naming.wasm is this module assembled with wat2wasm: it reads the input on stdin and
+;; prints a fixed finding, or fails with "no input" if stdin is empty.
+(module
+  (import "wasi_snapshot_preview1" "fd_read" (func $fd_read (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+  (memory (export "memory") 1)
+  (data (i32.const 256) "{\"findings\": [{\"message\": \"bad name\", \"path\": \"nameOverride\"}]}")
+  (data (i32.const 512) "no input")
+  (func (export "_start")
+    ;; The iovec at 0 points to a buffer at 1024; the number of bytes is stored at 16.
+    (i32.store (i32.const 0) (i32.const 1024))
+    (i32.store (i32.const 4) (i32.const 4096))
+    (drop (call $fd_read (i32.const 0) (i32.const 0) (i32.const 1) (i32.const 16)))
+    (if (i32.eqz (i32.load (i32.const 16)))
+      (then
+        (i32.store (i32.const 0) (i32.const 512))
+        (i32.store (i32.const 4) (i32.const 8))
+        (drop (call $fd_write (i32.const 2) (i32.const 0) (i32.const 1) (i32.const 16)))
+        (call $proc_exit (i32.const 1))))
+    (i32.store (i32.const 0) (i32.const 256))
+    (i32.store (i32.const 4) (i32.const 63))
+    (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 16)))))
@@ -0,0 +1,113 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// unusedProbeSuffix is appended to string values to check whether they reach the templates.
+const unusedProbeSuffix = "-kc-unused-probe"
+
+// CheckUnusedValues renders c with the provided values and reports every provided value that
+// has no effect on the rendered output. Each value is probed by replacing it with different
+// values and re-rendering; if no probe changes the output, no template reads the value.
+// It returns true if any failing issues were found.
+func CheckUnusedValues(c *chart.Chart, provided map[string]interface{}, opts Options, r Reporter) (bool, error) {
+	if !opts.Rules.Enabled(RuleUnusedValue) {
+		return false, nil
+	}
+	baseline, err := RenderChart(c, provided, opts.Render)
+	if err != nil {
+		return false, fmt.Errorf("rendering chart: %w", err)
+	}
+
+	v := &validator{opts: opts, reporter: r}
+	for _, path := range leafPaths(provided, "") {
+		if shouldIgnore(path, opts.Ignore) {
+			continue
+		}
+		value := lookupPath(provided, path)
+		used := false
+		for _, probe := range unusedProbes(value) {
+			probed := copyValues(provided)
+			setPath(probed, path, probe)
+			rendered, err := RenderChart(c, probed, opts.Render)
+			// A probe that breaks rendering proves that the value is read.
+			if err != nil || !reflect.DeepEqual(rendered, baseline) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			v.report(Finding{
+				RuleID:  RuleUnusedValue,
+				Path:    path,
+				Message: fmt.Sprintf("Unused value: '%s' is not referenced by any template", path),
+				Value:   value,
+			})
+		}
+	}
+	return v.issuesFound, nil
+}
+
+// unusedProbes returns replacement values used to detect whether value reaches the templates.
+func unusedProbes(value interface{}) []interface{} {
+	switch val := value.(type) {
+	case string:
+		return []interface{}{val + unusedProbeSuffix, ""}
+	case bool:
+		return []interface{}{!val}
+	case float64:
+		return []interface{}{val + 1, float64(0)}
+	case int:
+		return []interface{}{val + 1, 0}
+	case int64:
+		return []interface{}{val + 1, int64(0)}
+	case []interface{}:
+		if len(val) == 0 {
+			return []interface{}{[]interface{}{unusedProbeSuffix}}
+		}
+		return []interface{}{[]interface{}{}}
+	default:
+		return nil
+	}
+}
+
+// leafPaths returns the sorted dotted paths of every non-map, non-null value in values.
+func leafPaths(values map[string]interface{}, prefix string) []string {
+	var paths []string
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch val := value.(type) {
+		case map[string]interface{}:
+			paths = append(paths, leafPaths(val, path)...)
+		case nil:
+		default:
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// setPath sets the value at a dotted key path, creating intermediate maps as needed.
+func setPath(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	current := values
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,46 @@
+package kc
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestCheckUnusedValues(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "web_service", Version: "0.1.0"},
+		Values: map[string]interface{}{
+			"replicaCount": 1,
+			"image":        map[string]interface{}{"tag": "latest"},
+		},
+		Templates: []*chart.File{{
+			Name: "templates/deployment.yaml",
+			Data: []byte("replicas: {{ .Values.replicaCount }}\nimage: nginx:{{ .Values.image.tag }}\n" +
+				"{{- if .Values.debug }}\ndebug: true\n{{- end }}\n"),
+		}},
+	}
+	provided := map[string]interface{}{
+		"replicaCount": 3,
+		"image":        map[string]interface{}{"tag": "1.25", "pullPolicy": "Always"},
+		"debug":        false,
+		"legacy":       map[string]interface{}{"enabled": true},
+	}
+
+	r := &recordingReporter{}
+	issuesFound, err := CheckUnusedValues(c, provided, Options{}, r)
+	if err != nil {
+		t.Fatalf("CheckUnusedValues() returned error: %v", err)
+	}
+	if !issuesFound {
+		t.Errorf("expected unused values to be reported as issues")
+	}
+
+	var unused []string
+	for _, f := range r.findings {
+		unused = append(unused, f.Path)
+	}
+	want := []string{"image.pullPolicy", "legacy.enabled"}
+	if len(unused) != len(want) || unused[0] != want[0] || unused[1] != want[1] {
+		t.Errorf("unused values = %v, want %v", unused, want)
+	}
+}
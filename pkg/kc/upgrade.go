@@ -0,0 +1,130 @@
+package kc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CheckUpgrade compares the provided values against the defaults of the current and the newer
+// chart version, and reports provided keys that were removed, renamed, or changed type or
+// default in the newer version. It returns true if any failing issues were found.
+func CheckUpgrade(oldDefaults, newDefaults, provided map[string]interface{}, opts Options, r Reporter) bool {
+	v := &validator{opts: opts, reporter: r}
+	added := addedPaths(oldDefaults, newDefaults, "")
+	v.upgrade(oldDefaults, newDefaults, provided, "", added)
+	return v.issuesFound
+}
+
+func (v *validator) upgrade(oldDefaults, newDefaults, provided map[string]interface{}, prefix string, added map[string]interface{}) {
+	for key, providedValue := range provided {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		if shouldIgnore(fullKey, v.opts.Ignore) {
+			continue
+		}
+		oldValue, inOld := oldDefaults[key]
+		if !inOld {
+			// Keys unknown to the current version are covered by the unknown-key rule.
+			continue
+		}
+		newValue, inNew := newDefaults[key]
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		providedMap, providedIsMap := providedValue.(map[string]interface{})
+		if oldIsMap && newIsMap && providedIsMap {
+			v.upgrade(oldMap, newMap, providedMap, fullKey, added)
+			continue
+		}
+
+		switch {
+		case !inNew:
+			if renamed := renamedPath(fullKey, oldValue, added); renamed != "" {
+				v.report(Finding{
+					RuleID:   RuleUpgradeChange,
+					Severity: SeverityError,
+					Path:     fullKey,
+					Message:  fmt.Sprintf("Renamed key: '%s' appears to have been renamed to '%s' in the newer chart version", fullKey, renamed),
+					Default:  oldValue,
+					Value:    providedValue,
+				})
+				continue
+			}
+			v.report(Finding{
+				RuleID:   RuleUpgradeChange,
+				Severity: SeverityError,
+				Path:     fullKey,
+				Message:  fmt.Sprintf("Removed key: '%s' is no longer defined in the newer chart version", fullKey),
+				Default:  oldValue,
+				Value:    providedValue,
+			})
+		case oldValue != nil && newValue != nil && reflect.TypeOf(oldValue) != reflect.TypeOf(newValue):
+			v.report(Finding{
+				RuleID:   RuleUpgradeChange,
+				Severity: SeverityError,
+				Path:     fullKey,
+				Message:  fmt.Sprintf("Changed type: '%s' default changed from %T to %T in the newer chart version", fullKey, oldValue, newValue),
+				Default:  newValue,
+				Value:    providedValue,
+			})
+		case !reflect.DeepEqual(oldValue, newValue):
+			v.report(Finding{
+				RuleID:   RuleUpgradeChange,
+				Severity: SeverityInfo,
+				Path:     fullKey,
+				Message:  fmt.Sprintf("Changed default: '%s' default changed from %v to %v in the newer chart version", fullKey, oldValue, newValue),
+				Default:  newValue,
+				Value:    providedValue,
+				quoted:   []interface{}{oldValue},
+			})
+		}
+	}
+}
+
+// addedPaths returns the values of every key path in newDefaults that is missing from oldDefaults.
+func addedPaths(oldDefaults, newDefaults map[string]interface{}, prefix string) map[string]interface{} {
+	added := map[string]interface{}{}
+	for key, newValue := range newDefaults {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		oldValue, inOld := oldDefaults[key]
+		if !inOld {
+			added[fullKey] = newValue
+		}
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if newIsMap && (!inOld || oldIsMap) {
+			for k, v := range addedPaths(oldMap, newMap, fullKey) {
+				added[k] = v
+			}
+		}
+	}
+	return added
+}
+
+// renamedPath guesses the new path of a removed key: the single added key with the same name
+// or, failing that, the single added key with the same default value.
+func renamedPath(path string, value interface{}, added map[string]interface{}) string {
+	name := path[strings.LastIndex(path, ".")+1:]
+	var sameName, sameValue []string
+	for candidate, candidateValue := range added {
+		if candidate[strings.LastIndex(candidate, ".")+1:] == name && reflect.TypeOf(candidateValue) == reflect.TypeOf(value) {
+			sameName = append(sameName, candidate)
+		}
+		if value != nil && reflect.DeepEqual(candidateValue, value) {
+			sameValue = append(sameValue, candidate)
+		}
+	}
+	if len(sameName) == 1 {
+		return sameName[0]
+	}
+	if len(sameValue) == 1 {
+		return sameValue[0]
+	}
+	return ""
+}
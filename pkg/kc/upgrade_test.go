@@ -0,0 +1,56 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckUpgrade(t *testing.T) {
+	oldDefaults := map[string]interface{}{
+		"replicaCount": 1,
+		"image":        map[string]interface{}{"tag": "1.0", "pullPolicy": "IfNotPresent"},
+		"port":         80,
+		"timeout":      "30s",
+		"legacy":       true,
+		"serviceType":  "ClusterIP",
+		"apiKey":       1234,
+	}
+	newDefaults := map[string]interface{}{
+		"replicaCount": 2,
+		"image":        map[string]interface{}{"tag": "2.0", "pullPolicy": "IfNotPresent"},
+		"port":         "http",
+		"timeout":      "30s",
+		"service":      map[string]interface{}{"type": "ClusterIP"},
+		"apiKey":       "changeme",
+	}
+	provided := map[string]interface{}{
+		"replicaCount": 3,
+		"image":        map[string]interface{}{"pullPolicy": "Always"},
+		"port":         8080,
+		"timeout":      "60s",
+		"legacy":       false,
+		"serviceType":  "NodePort",
+		"unknown":      true,
+		"apiKey":       "s3cr3t",
+	}
+
+	r := &recordingReporter{}
+	if !CheckUpgrade(oldDefaults, newDefaults, provided, Options{}, r) {
+		t.Errorf("expected upgrade changes to be reported as issues")
+	}
+
+	got := map[string]string{}
+	for _, f := range r.findings {
+		got[f.Path] = string(f.Severity) + " " + f.Message
+	}
+	want := map[string]string{
+		"replicaCount": "info Changed default: 'replicaCount' default changed from 1 to 2 in the newer chart version",
+		"port":         "error Changed type: 'port' default changed from int to string in the newer chart version",
+		"apiKey":       "error Changed type: 'apiKey' default changed from int to string in the newer chart version",
+		"legacy":       "error Removed key: 'legacy' is no longer defined in the newer chart version",
+		"serviceType":  "error Renamed key: 'serviceType' appears to have been renamed to 'service.type' in the newer chart version",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,507 @@
+package kc
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Options controls what the validator checks.
+type Options struct {
+	// Ignore holds the regular expressions of key paths to skip, compiled from --ignore with
+	// CompileIgnoreList and from --ignore-regex with CompileIgnoreRegex.
+	Ignore []*regexp.Regexp
+	// Rules selects the enabled rules. A nil RuleSet runs the default rules.
+	Rules RuleSet
+	// Severities overrides the default severity per rule ID.
+	Severities map[string]Severity
+	// FailOn is the minimum severity counted as an issue. Empty counts every finding.
+	FailOn Severity
+	// Files are the values files being validated; they are attached to every finding.
+	Files []string
+	// Layers holds the values of every layer that was merged into the provided values, from
+	// the lowest to the highest precedence. A value that matches its default is not redundant
+	// if it restores the default over a different value of a lower layer.
+	Layers []map[string]interface{}
+	// Baseline suppresses findings that were already known when it was recorded.
+	Baseline *Baseline
+	// Render controls how templates are rendered by template-based checks.
+	Render RenderOptions
+	// Suppressions holds inline "# kc:ignore" comments collected from the values files.
+	Suppressions Suppressions
+	// Renames lists renamed values keys; values still set at an old path are reported.
+	Renames []Rename
+	// Policies holds the Rego policies evaluated by CheckPolicies, prepared once per run.
+	Policies *Policies
+	// Schemas validates rendered manifests in CheckSchemas, created once per run.
+	Schemas *Schemas
+	// CustomRules holds the compiled custom rules evaluated by CheckCustomRules.
+	CustomRules []*CustomRule
+	// Plugins lists the external rule plugins run by CheckPlugins.
+	Plugins []Plugin
+	// NumericTolerant compares numbers by value, so an int and a float64 of the same number,
+	// which YAML and --set decode inconsistently, are neither type mismatches nor different.
+	NumericTolerant bool
+	// Constraints restrict the provided values of key paths, checked by CheckConstraints.
+	Constraints []*Constraint
+	// ResourceProfiles holds the thresholds of the resource rules per environment; nil uses
+	// DefaultResourceProfiles.
+	ResourceProfiles []ResourceProfile
+	// Values holds the provided values; findings quote none of their sensitive values, not
+	// even in the text of render or schema errors.
+	Values map[string]interface{}
+	// Sensitive holds the key paths, compiled with CompileIgnoreList, whose values are masked
+	// in findings, in addition to those of keys named like credentials.
+	Sensitive []*regexp.Regexp
+	// Logger, if set, receives a debug record for every compared key.
+	Logger *slog.Logger
+}
+
+// severity returns the effective severity of f: a configured override for its rule,
+// otherwise the severity set by the check, otherwise the rule's default.
+func (o Options) severity(f Finding) Severity {
+	if sev, ok := o.Severities[f.RuleID]; ok {
+		return sev
+	}
+	if f.Severity != "" {
+		return f.Severity
+	}
+	rule, _ := LookupRule(f.RuleID)
+	return rule.Severity
+}
+
+// ValidateChartValues compares providedValues against the chart's defaultValues and reports
+// every finding to r. Values are compared as Helm coalesces them with chartutil.CoalesceTables,
+// so a null deletes a default, and a map never merges with a scalar, as at install
+// time. It returns true if any finding at or above opts.FailOn was found.
+func ValidateChartValues(defaultValues, providedValues map[string]interface{}, opts Options, r Reporter) bool {
+	v := &validator{opts: opts, reporter: r}
+	layers := make([]interface{}, len(opts.Layers))
+	for i, layer := range opts.Layers {
+		layers[i] = layer
+	}
+	// CoalesceTables modifies the provided values it coalesces the defaults into.
+	effectiveValues := chartutil.CoalesceTables(copyValues(providedValues), defaultValues)
+	v.validate(defaultValues, providedValues, effectiveValues, layers, "")
+	return v.issuesFound
+}
+
+type validator struct {
+	opts        Options
+	reporter    Reporter
+	issuesFound bool
+	// secrets holds the text of the sensitive values of opts.Values, once a finding is reported.
+	secrets map[string]bool
+}
+
+// report forwards a finding to the reporter if its rule is enabled.
+func (v *validator) report(f Finding) {
+	if !v.opts.Rules.Enabled(f.RuleID) {
+		return
+	}
+	f.Files = v.opts.Files
+	if v.opts.Baseline.Contains(f) || v.opts.Suppressions.Suppressed(f) {
+		return
+	}
+	f.Severity = v.opts.severity(f)
+	f.Message = v.maskMessage(f)
+	f.Default, f.Value, f.quoted = v.opts.mask(f.Path, f.Default), v.opts.mask(f.Path, f.Value), nil
+	v.reporter.Report(f)
+	if f.Severity.AtLeast(v.opts.FailOn) {
+		v.issuesFound = true
+	}
+}
+
+// restoresDefault reports whether any layer sets a value different from defaultValue, so the
+// upper layer that provides the default value restores it and is not redundant.
+func restoresDefault(layers []interface{}, defaultValue interface{}) bool {
+	for _, layer := range layers {
+		if layer != nil && !reflect.DeepEqual(layer, defaultValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// layerValues returns the values of key in every layer, nil where a layer does not set it.
+func layerValues(layers []interface{}, key string) []interface{} {
+	values := make([]interface{}, len(layers))
+	for i, layer := range layers {
+		if m, ok := layer.(map[string]interface{}); ok {
+			values[i] = m[key]
+		}
+	}
+	return values
+}
+
+// traceValue formats v for Options.Logger: strings are quoted to tell them from numbers, and
+// the keys of maps are logged on their own.
+func traceValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		return "map"
+	}
+	return v
+}
+
+// validate compares providedValues with defaultValues below prefix, where Helm coalesces them
+// into effectiveValues. layers holds the values of every layer at the same prefix.
+func (v *validator) validate(defaultValues, providedValues, effectiveValues map[string]interface{}, layers []interface{}, prefix string) {
+	// Keys are visited in order, so findings are reported deterministically.
+	for _, key := range sortedKeys(providedValues) {
+		providedValue := providedValues[key]
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		if shouldIgnore(fullKey, v.opts.Ignore) {
+			continue
+		}
+
+		defaultValue, exists := defaultValues[key]
+		effectiveValue, kept := effectiveValues[key]
+		if v.opts.Logger != nil {
+			if exists {
+				v.opts.Logger.Debug("comparing key", "key", fullKey, "default", traceValue(v.opts.mask(fullKey, defaultValue)), "provided", traceValue(v.opts.mask(fullKey, providedValue)))
+			} else {
+				v.opts.Logger.Debug("comparing key", "key", fullKey, "provided", traceValue(v.opts.mask(fullKey, providedValue)))
+			}
+		}
+		if !exists && prefix == "" && key == globalKey {
+			// Helm always provides an empty global section, so each provided global is
+			// checked against the globals defined in the dependency tree.
+			defaultValue, exists = map[string]interface{}{}, true
+		}
+		if !exists && prefix == globalKey {
+			v.report(Finding{
+				RuleID:  RuleUnknownGlobal,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Unknown global: '%s' is not defined by the chart or any of its subcharts", fullKey),
+				Value:   providedValue,
+			})
+			continue
+		}
+		if !exists {
+			v.report(Finding{
+				RuleID:  RuleUnknownKey,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Unexpected key: '%s' is not defined in chart defaults", fullKey),
+				Value:   providedValue,
+			})
+			continue
+		}
+
+		if !kept && defaultValue != nil {
+			// Helm deleted the key set to null from the chart defaults, whatever its type.
+			v.report(Finding{
+				RuleID:  RuleDeletedKey,
+				Path:    fullKey,
+				Message: fmt.Sprintf("Deleted key: '%s' is set to null, which removes its default value: %v", fullKey, defaultValue),
+				Default: defaultValue,
+			})
+			continue
+		}
+
+		if message, ok := emptyValue(fullKey, defaultValue, providedValue); ok {
+			v.report(Finding{
+				RuleID:  RuleEmptyValue,
+				Path:    fullKey,
+				Message: message,
+				Default: defaultValue,
+				Value:   providedValue,
+			})
+			continue
+		}
+
+		if defaultMap, isDefaultMap := defaultValue.(map[string]interface{}); isDefaultMap {
+			if providedMap, isProvidedMap := providedValue.(map[string]interface{}); isProvidedMap {
+				effectiveMap, _ := effectiveValue.(map[string]interface{})
+				v.validate(defaultMap, providedMap, effectiveMap, layerValues(layers, key), fullKey)
+			} else {
+				v.report(Finding{
+					RuleID:  RuleTypeMismatch,
+					Path:    fullKey,
+					Message: fmt.Sprintf("Type mismatch for '%s': expected map, got %T", fullKey, providedValue),
+					Default: defaultValue,
+					Value:   providedValue,
+				})
+			}
+			continue
+		}
+
+		// Scalars and lists of the provided values replace their defaults.
+		equal := v.equal(defaultValue, effectiveValue)
+		defaultQuantity, providedQuantity, isQuantity := quantities(defaultValue, providedValue)
+		if equal || (isQuantity && defaultQuantity.Cmp(providedQuantity) == 0) {
+			if restoresDefault(layerValues(layers, key), defaultValue) {
+				continue
+			}
+			message := fmt.Sprintf("Redundant value: '%s' matches default value: %v", fullKey, providedValue)
+			if !equal {
+				message = fmt.Sprintf("Redundant value: '%s' is %v, the same quantity as the default value: %v", fullKey, providedValue, defaultValue)
+			}
+			v.report(Finding{
+				RuleID:  RuleRedundant,
+				Path:    fullKey,
+				Message: message,
+				Default: defaultValue,
+				Value:   providedValue,
+			})
+			continue
+		}
+		if isQuantity {
+			// Quantities such as 100m and 0.1 are written as strings or numbers alike.
+			continue
+		}
+
+		if defaultList, isDefaultList := defaultValue.([]interface{}); isDefaultList {
+			if providedList, isProvidedList := providedValue.([]interface{}); isProvidedList {
+				v.validateList(defaultList, providedList, fullKey)
+				continue
+			}
+		}
+
+		if defaultValue != nil && providedValue != nil {
+			defaultType := reflect.TypeOf(defaultValue)
+			providedType := reflect.TypeOf(providedValue)
+
+			switch {
+			case defaultType == providedType || v.numeric(defaultValue, providedValue):
+			case coercible(defaultValue, providedValue):
+				v.report(Finding{
+					RuleID:  RuleCoercibleScalar,
+					Path:    fullKey,
+					Message: fmt.Sprintf("Coercible scalar for '%s': expected %T, got %T %s, which templates usually coerce", fullKey, defaultValue, providedValue, formatScalar(providedValue)),
+					Default: defaultValue,
+					Value:   providedValue,
+				})
+			default:
+				v.report(Finding{
+					RuleID:  RuleTypeMismatch,
+					Path:    fullKey,
+					Message: fmt.Sprintf("Type mismatch for '%s': expected %T, got %T", fullKey, defaultValue, providedValue),
+					Default: defaultValue,
+					Value:   providedValue,
+				})
+			}
+		}
+	}
+}
+
+// emptyValue returns the message of a provided value that leaves the defaults as they are
+// although it looks like it sets something, typically a leftover of deleted configuration:
+// an empty map, which Helm merges without changing anything, a map of only such values,
+// or an empty string over a null default.
+func emptyValue(fullKey string, defaultValue, providedValue interface{}) (string, bool) {
+	if _, isDefaultMap := defaultValue.(map[string]interface{}); !isDefaultMap && defaultValue != nil {
+		return "", false
+	}
+	if !isEmpty(defaultValue, providedValue) {
+		return "", false
+	}
+	switch providedValue := providedValue.(type) {
+	case string:
+		return fmt.Sprintf("Empty value: '%s' is an empty string, which templates treat like the null default", fullKey), true
+	case map[string]interface{}:
+		if len(providedValue) == 0 {
+			return fmt.Sprintf("Empty value: '%s' is an empty map, which leaves the defaults unchanged", fullKey), true
+		}
+	}
+	return fmt.Sprintf("Empty value: every value below '%s' is empty, which leaves the defaults unchanged", fullKey), true
+}
+
+// isEmpty reports whether providedValue is an empty map, a map of empty values, or an empty
+// string over a null or empty default.
+func isEmpty(defaultValue, providedValue interface{}) bool {
+	switch providedValue := providedValue.(type) {
+	case string:
+		return providedValue == "" && (defaultValue == nil || defaultValue == "")
+	case map[string]interface{}:
+		defaultMap, _ := defaultValue.(map[string]interface{})
+		if defaultMap == nil && defaultValue != nil {
+			return false
+		}
+		for key, value := range providedValue {
+			if !isEmpty(defaultMap[key], value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// validateList compares the elements of a provided list with those of its default list, which
+// Helm replaces instead of merging: elements of another type than the default elements are
+// type mismatches, and a list keeping only some of the default elements drops the others.
+func (v *validator) validateList(defaultList, providedList []interface{}, fullKey string) {
+	if elemType := elementType(defaultList); elemType != nil {
+		for i, elem := range providedList {
+			if elem != nil && reflect.TypeOf(elem) != elemType && !v.numeric(reflect.Zero(elemType).Interface(), elem) {
+				v.report(Finding{
+					RuleID:  RuleTypeMismatch,
+					Path:    fmt.Sprintf("%s[%d]", fullKey, i),
+					Message: fmt.Sprintf("Type mismatch for '%s[%d]': expected %v, got %T", fullKey, i, elemType, elem),
+					Default: defaultList,
+					Value:   elem,
+				})
+			}
+		}
+	}
+
+	// An empty list clears the default on purpose.
+	if len(providedList) == 0 || len(providedList) >= len(defaultList) {
+		return
+	}
+	for _, elem := range providedList {
+		if !containsValue(defaultList, elem) {
+			return
+		}
+	}
+	v.report(Finding{
+		RuleID:  RuleReplacedList,
+		Path:    fullKey,
+		Message: fmt.Sprintf("Replaced list: '%s' keeps %d of the %d default elements; Helm replaces lists instead of merging them, so the others are removed", fullKey, len(providedList), len(defaultList)),
+		Default: defaultList,
+		Value:   providedList,
+	})
+}
+
+// coercible reports whether one of a and b is a string spelling the bool or number the other
+// one is, such as "true" for true or "8080" for 8080, which templates render the same way.
+func coercible(a, b interface{}) bool {
+	s, ok := a.(string)
+	other := b
+	if !ok {
+		if s, ok = b.(string); !ok {
+			return false
+		}
+		other = a
+	}
+	if _, isBool := other.(bool); isBool {
+		return strings.EqualFold(s, "true") || strings.EqualFold(s, "false")
+	}
+	if _, isNumber := toFloat(other); isNumber {
+		_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return err == nil
+	}
+	return false
+}
+
+// formatScalar formats a scalar for messages, quoting strings to tell them from the values
+// they spell.
+func formatScalar(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// quantities parses a and b as Kubernetes resource quantities, such as 100m, 0.1, 1Gi, and
+// 1024Mi, if both are quantities and one of them is a string with a unit suffix.
+func quantities(a, b interface{}) (resource.Quantity, resource.Quantity, bool) {
+	if !hasUnit(a) && !hasUnit(b) {
+		return resource.Quantity{}, resource.Quantity{}, false
+	}
+	qa, okA := parseQuantity(a)
+	qb, okB := parseQuantity(b)
+	return qa, qb, okA && okB
+}
+
+// hasUnit reports whether v is a string ending in a unit suffix, such as m or Gi.
+func hasUnit(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return false
+	}
+	last := s[len(s)-1]
+	return (last >= 'a' && last <= 'z') || (last >= 'A' && last <= 'Z')
+}
+
+// parseQuantity parses a string or number as a resource quantity.
+func parseQuantity(v interface{}) (resource.Quantity, bool) {
+	s, ok := v.(string)
+	if !ok {
+		f, isNumber := toFloat(v)
+		if !isNumber {
+			return resource.Quantity{}, false
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	q, err := resource.ParseQuantity(s)
+	return q, err == nil
+}
+
+// equal reports whether the provided value equals the default value, comparing numbers by
+// value with NumericTolerant.
+func (v *validator) equal(defaultValue, providedValue interface{}) bool {
+	if reflect.DeepEqual(defaultValue, providedValue) {
+		return true
+	}
+	if !v.numeric(defaultValue, providedValue) {
+		return false
+	}
+	d, _ := toFloat(defaultValue)
+	p, _ := toFloat(providedValue)
+	return d == p
+}
+
+// numeric reports whether a and b are both numbers compared by value with NumericTolerant.
+func (v *validator) numeric(a, b interface{}) bool {
+	if !v.opts.NumericTolerant {
+		return false
+	}
+	_, aIsNumber := toFloat(a)
+	_, bIsNumber := toFloat(b)
+	return aIsNumber && bIsNumber
+}
+
+// toFloat returns the value of a number of any numeric type.
+func toFloat(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// elementType returns the type shared by the non-null elements of list, or nil if they have
+// different types or there are none.
+func elementType(list []interface{}) reflect.Type {
+	var elemType reflect.Type
+	for _, elem := range list {
+		if elem == nil {
+			continue
+		}
+		if t := reflect.TypeOf(elem); elemType == nil {
+			elemType = t
+		} else if t != elemType {
+			return nil
+		}
+	}
+	return elemType
+}
+
+// containsValue reports whether list holds an element equal to value.
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, elem := range list {
+		if reflect.DeepEqual(elem, value) {
+			return true
+		}
+	}
+	return false
+}
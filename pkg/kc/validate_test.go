@@ -0,0 +1,393 @@
+package kc
+
+import (
+	"reflect"
+	"testing"
+)
+
+// recordingReporter keeps every reported finding in memory for assertions.
+type recordingReporter struct {
+	findings []Finding
+}
+
+func (r *recordingReporter) Report(f Finding) {
+	r.findings = append(r.findings, f)
+}
+
+func (r *recordingReporter) Summary() {}
+
+func TestValidateChartValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultValues  map[string]interface{}
+		providedValues map[string]interface{}
+		ignoreList     IgnoreList
+		wantIssues     bool
+	}{
+		{
+			name: "no issues",
+			defaultValues: map[string]interface{}{
+				"key1": "value1",
+			},
+			providedValues: map[string]interface{}{
+				"key1": "different",
+			},
+			ignoreList: IgnoreList{},
+			wantIssues: false,
+		},
+		{
+			name: "redundant value",
+			defaultValues: map[string]interface{}{
+				"key1": "value1",
+			},
+			providedValues: map[string]interface{}{
+				"key1": "value1",
+			},
+			ignoreList: IgnoreList{},
+			wantIssues: true,
+		},
+		{
+			name: "type mismatch",
+			defaultValues: map[string]interface{}{
+				"key1": "value1",
+			},
+			providedValues: map[string]interface{}{
+				"key1": 123,
+			},
+			ignoreList: IgnoreList{},
+			wantIssues: true,
+		},
+		{
+			name: "ignored field",
+			defaultValues: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{
+						"cpu": "100m",
+					},
+				},
+			},
+			providedValues: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{
+						"cpu": 1,
+					},
+				},
+			},
+			ignoreList: IgnoreList{"resources"},
+			wantIssues: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuesFound := ValidateChartValues(tt.defaultValues, tt.providedValues, Options{Ignore: CompileIgnoreList(tt.ignoreList)}, &recordingReporter{})
+			if issuesFound != tt.wantIssues {
+				t.Errorf("ValidateChartValues() issuesFound = %v, want %v", issuesFound, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestValidateChartValuesReportsFindings(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"tag": "latest"},
+	}
+	provided := map[string]interface{}{
+		"replicas": 1,
+		"image":    "nginx:latest",
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+
+	kinds := map[string]string{}
+	for _, f := range r.findings {
+		kinds[f.Path] = f.RuleID
+	}
+	if kinds["replicas"] != RuleRedundant {
+		t.Errorf("expected replicas to be reported as %q, got %q", RuleRedundant, kinds["replicas"])
+	}
+	if kinds["image"] != RuleTypeMismatch {
+		t.Errorf("expected image to be reported as %q, got %q", RuleTypeMismatch, kinds["image"])
+	}
+}
+
+// TestValidateChartValuesRestoredDefaults verifies that a value restoring the default over a
+// lower layer is not redundant, while a default repeated by every layer still is.
+func TestValidateChartValuesRestoredDefaults(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"tag": "latest", "pullPolicy": "IfNotPresent"},
+		"debug":    false,
+	}
+	global := map[string]interface{}{
+		"replicas": 3,
+		"image":    map[string]interface{}{"tag": "1.25"},
+		"debug":    false,
+	}
+	region := map[string]interface{}{
+		"image": map[string]interface{}{"pullPolicy": "IfNotPresent"},
+	}
+	service := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"tag": "latest"},
+	}
+	provided := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"tag": "latest", "pullPolicy": "IfNotPresent"},
+		"debug":    false,
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{Layers: []map[string]interface{}{global, region, service}}, r)
+
+	var paths []string
+	for _, f := range r.findings {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"debug", "image.pullPolicy"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected redundant values %v, got %v", want, paths)
+	}
+}
+
+// TestValidateChartValuesNull verifies that a null deleting a default is reported as such,
+// not as a type mismatch or a redundant value, and that a null over a null default is redundant.
+func TestValidateChartValuesNull(t *testing.T) {
+	defaults := map[string]interface{}{
+		"resources":   map[string]interface{}{"limits": map[string]interface{}{"cpu": "100m"}},
+		"annotations": map[string]interface{}{"team": "web", "owner": "ops"},
+		"nodeName":    nil,
+	}
+	provided := map[string]interface{}{
+		"resources":   nil,
+		"annotations": map[string]interface{}{"owner": nil},
+		"nodeName":    nil,
+	}
+
+	r := &recordingReporter{}
+	if !ValidateChartValues(defaults, provided, Options{FailOn: SeverityWarning}, r) {
+		t.Error("expected the redundant null to be an issue")
+	}
+	want := []Finding{
+		{RuleID: RuleDeletedKey, Severity: SeverityInfo, Path: "annotations.owner", Message: "Deleted key: 'annotations.owner' is set to null, which removes its default value: ops"},
+		{RuleID: RuleRedundant, Severity: SeverityWarning, Path: "nodeName", Message: "Redundant value: 'nodeName' matches default value: <nil>"},
+		{RuleID: RuleDeletedKey, Severity: SeverityInfo, Path: "resources", Message: "Deleted key: 'resources' is set to null, which removes its default value: map[limits:map[cpu:100m]]"},
+	}
+	if len(r.findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), r.findings)
+	}
+	for i, f := range r.findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestValidateChartValuesLists verifies that lists equal to their default are redundant, that
+// elements of another type than the default elements are type mismatches, and that a list
+// keeping only some of the default elements is reported as replacing the others.
+func TestValidateChartValuesLists(t *testing.T) {
+	defaults := map[string]interface{}{
+		"args":        []interface{}{"--verbose", "--port=80"},
+		"ports":       []interface{}{float64(80), float64(443)},
+		"tolerations": []interface{}{"spot", "arm64", "gpu"},
+		"extraEnv":    []interface{}{},
+		"mixed":       []interface{}{"a", float64(1)},
+	}
+	provided := map[string]interface{}{
+		"args":        []interface{}{"--verbose", "--port=80"},
+		"ports":       []interface{}{float64(80), "8443"},
+		"tolerations": []interface{}{"arm64"},
+		"extraEnv":    []interface{}{map[string]interface{}{"name": "A"}},
+		"mixed":       []interface{}{true},
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+	want := []Finding{
+		{RuleID: RuleRedundant, Path: "args", Message: "Redundant value: 'args' matches default value: [--verbose --port=80]"},
+		{RuleID: RuleTypeMismatch, Path: "ports[1]", Message: "Type mismatch for 'ports[1]': expected float64, got string"},
+		{RuleID: RuleReplacedList, Path: "tolerations", Message: "Replaced list: 'tolerations' keeps 1 of the 3 default elements; Helm replaces lists instead of merging them, so the others are removed"},
+	}
+	if len(r.findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), r.findings)
+	}
+	for i, f := range r.findings {
+		if f.RuleID != want[i].RuleID || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestValidateChartValuesNumericTolerant verifies that numbers of different types are compared
+// by value with NumericTolerant, and are type mismatches without it.
+func TestValidateChartValuesNumericTolerant(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicas": float64(3),
+		"port":     float64(80),
+		"ports":    []interface{}{float64(80)},
+		"name":     "web",
+	}
+	provided := map[string]interface{}{
+		"replicas": int64(3),
+		"port":     8080,
+		"ports":    []interface{}{int64(443)},
+		"name":     int64(1),
+	}
+
+	tests := []struct {
+		tolerant bool
+		want     map[string]string
+	}{
+		{false, map[string]string{"replicas": RuleTypeMismatch, "port": RuleTypeMismatch, "ports[0]": RuleTypeMismatch, "name": RuleTypeMismatch}},
+		{true, map[string]string{"replicas": RuleRedundant, "name": RuleTypeMismatch}},
+	}
+	for _, tt := range tests {
+		r := &recordingReporter{}
+		ValidateChartValues(defaults, provided, Options{NumericTolerant: tt.tolerant}, r)
+		got := map[string]string{}
+		for _, f := range r.findings {
+			got[f.Path] = f.RuleID
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NumericTolerant = %v: expected %v, got %v", tt.tolerant, tt.want, got)
+		}
+	}
+}
+
+// TestValidateChartValuesCoercibleScalars verifies that strings spelling the bool or number of
+// the default, and the other way around, are reported apart from type mismatches.
+func TestValidateChartValuesCoercibleScalars(t *testing.T) {
+	defaults := map[string]interface{}{
+		"enabled": true,
+		"port":    float64(80),
+		"version": "1.25",
+		"image":   "nginx",
+		"name":    "web",
+	}
+	provided := map[string]interface{}{
+		"enabled": "False",
+		"port":    "8080",
+		"version": float64(1.26),
+		"image":   float64(1),
+		"name":    true,
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+	want := []Finding{
+		{RuleID: RuleCoercibleScalar, Severity: SeverityWarning, Path: "enabled", Message: `Coercible scalar for 'enabled': expected bool, got string "False", which templates usually coerce`},
+		{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "image", Message: "Type mismatch for 'image': expected string, got float64"},
+		{RuleID: RuleTypeMismatch, Severity: SeverityError, Path: "name", Message: "Type mismatch for 'name': expected string, got bool"},
+		{RuleID: RuleCoercibleScalar, Severity: SeverityWarning, Path: "port", Message: `Coercible scalar for 'port': expected float64, got string "8080", which templates usually coerce`},
+		{RuleID: RuleCoercibleScalar, Severity: SeverityWarning, Path: "version", Message: "Coercible scalar for 'version': expected string, got float64 1.26, which templates usually coerce"},
+	}
+	if len(r.findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), r.findings)
+	}
+	for i, f := range r.findings {
+		if f.RuleID != want[i].RuleID || f.Severity != want[i].Severity || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestValidateChartValuesQuantities verifies that resource quantities are compared by amount,
+// whether written as strings or numbers, and are neither type mismatches nor coercible scalars.
+func TestValidateChartValuesQuantities(t *testing.T) {
+	defaults := map[string]interface{}{
+		"cpu":     "100m",
+		"memory":  "1Gi",
+		"storage": "10Gi",
+		"gpu":     "1",
+		"timeout": "30s",
+	}
+	provided := map[string]interface{}{
+		"cpu":     float64(0.1),
+		"memory":  "1024Mi",
+		"storage": float64(20),
+		"gpu":     float64(2),
+		"timeout": float64(30),
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+	want := []Finding{
+		{RuleID: RuleRedundant, Path: "cpu", Message: "Redundant value: 'cpu' is 0.1, the same quantity as the default value: 100m"},
+		{RuleID: RuleCoercibleScalar, Path: "gpu", Message: "Coercible scalar for 'gpu': expected string, got float64 2, which templates usually coerce"},
+		{RuleID: RuleRedundant, Path: "memory", Message: "Redundant value: 'memory' is 1024Mi, the same quantity as the default value: 1Gi"},
+		{RuleID: RuleTypeMismatch, Path: "timeout", Message: "Type mismatch for 'timeout': expected string, got float64"},
+	}
+	if len(r.findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), r.findings)
+	}
+	for i, f := range r.findings {
+		if f.RuleID != want[i].RuleID || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestValidateChartValuesEmptyValues verifies that empty maps, maps of only empty values, and
+// empty strings over null defaults are reported once at their topmost key.
+func TestValidateChartValuesEmptyValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"podAnnotations": map[string]interface{}{},
+		"ingress":        map[string]interface{}{"annotations": map[string]interface{}{}, "className": nil},
+		"image":          map[string]interface{}{"tag": "latest"},
+		"nodeName":       nil,
+		"name":           "",
+	}
+	provided := map[string]interface{}{
+		"podAnnotations": map[string]interface{}{},
+		"ingress":        map[string]interface{}{"annotations": map[string]interface{}{}, "className": ""},
+		"image":          map[string]interface{}{"tag": ""},
+		"nodeName":       "",
+		"name":           "",
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+	want := []Finding{
+		{RuleID: RuleEmptyValue, Path: "ingress", Message: "Empty value: every value below 'ingress' is empty, which leaves the defaults unchanged"},
+		{RuleID: RuleRedundant, Path: "name", Message: "Redundant value: 'name' matches default value: "},
+		{RuleID: RuleEmptyValue, Path: "nodeName", Message: "Empty value: 'nodeName' is an empty string, which templates treat like the null default"},
+		{RuleID: RuleEmptyValue, Path: "podAnnotations", Message: "Empty value: 'podAnnotations' is an empty map, which leaves the defaults unchanged"},
+	}
+	if len(r.findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), r.findings)
+	}
+	for i, f := range r.findings {
+		if f.RuleID != want[i].RuleID || f.Path != want[i].Path || f.Message != want[i].Message {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], f)
+		}
+	}
+}
+
+// TestValidateChartValuesKeepsProvidedValues verifies that coalescing the values to compare
+// them leaves the provided values, which later checks read, as they are.
+func TestValidateChartValuesKeepsProvidedValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "latest", "pullPolicy": "Always"},
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "100m"}},
+	}
+	provided := map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "1.25", "pullPolicy": nil},
+		"resources": nil,
+	}
+	want := map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "1.25", "pullPolicy": nil},
+		"resources": nil,
+	}
+
+	r := &recordingReporter{}
+	ValidateChartValues(defaults, provided, Options{}, r)
+	if !reflect.DeepEqual(provided, want) {
+		t.Errorf("provided values = %v, want %v", provided, want)
+	}
+	if len(r.findings) != 2 || r.findings[0].RuleID != RuleDeletedKey || r.findings[1].RuleID != RuleDeletedKey {
+		t.Errorf("expected both nulls to be reported as deleted keys, got %+v", r.findings)
+	}
+}